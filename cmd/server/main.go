@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"net/http"
@@ -16,7 +18,11 @@ import (
 	"github.com/yourusername/dataweaver/internal/database"
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
 	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/metrics"
+	"github.com/yourusername/dataweaver/pkg/tracing"
+	"github.com/yourusername/dataweaver/pkg/validator"
 	"go.uber.org/zap"
 )
 
@@ -69,32 +75,83 @@ func main() {
 		logger.Fatal("Failed to initialize encryption", zap.Error(err))
 	}
 
+	// Restrict SQLite datasources to files in the allowed directory
+	dbconnector.ConfigureSQLite(dbconnector.SQLiteConfig{
+		AllowedDir:  cfg.DataSource.SQLite.AllowedDir,
+		AllowWrites: cfg.DataSource.SQLite.AllowWrites,
+	})
+
+	// Restrict file datasources and uploads to the data directory
+	dbconnector.ConfigureFiles(dbconnector.FileConfig{
+		Dir:         cfg.DataSource.Files.Dir,
+		MaxFileSize: int64(cfg.DataSource.Files.MaxFileSizeMB) << 20,
+	})
+
+	// Bound table previews and profiles and hide sensitive columns from them
+	dbconnector.ConfigureProfiling(dbconnector.ProfileConfig{
+		SensitiveColumns: cfg.DataSource.Profiling.SensitiveColumns,
+		PreviewMaxRows:   cfg.DataSource.Profiling.PreviewMaxRows,
+		SampleRows:       cfg.DataSource.Profiling.SampleRows,
+		TopValues:        cfg.DataSource.Profiling.TopValues,
+		HistogramBuckets: cfg.DataSource.Profiling.HistogramBuckets,
+	})
+
+	// Register the custom binding validators, such as the datasource type check
+	if err := validator.Init(); err != nil {
+		logger.Fatal("Failed to initialize validator", zap.Error(err))
+	}
+
 	logger.Info("Starting DataWeaver server",
 		zap.String("version", "1.0.0"),
 		zap.String("mode", cfg.Server.Mode),
 	)
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(&tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	// Initialize database
 	if err := database.Init(&cfg.Database); err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 	defer database.Close()
 
+	if cfg.Tracing.Enabled {
+		if err := database.DB.Use(tracing.NewGormPlugin()); err != nil {
+			logger.Fatal("Failed to register database tracing", zap.Error(err))
+		}
+	}
+
 	// Auto migrate models
 	if err := database.AutoMigrate(
 		&model.User{},
 		&model.DataSource{},
+		&model.DataSourceHealthCheck{},
+		&model.SchemaSnapshot{},
+		&model.TableProfile{},
 		&model.Query{},
 		&model.QueryExecution{},
 		&model.Tool{},
 		&model.McpServer{},
 		&model.McpLog{},
+		&model.McpUsageCounter{},
+		&model.McpLogAggregate{},
+		&model.AlertRule{},
+		&model.AlertDelivery{},
 	); err != nil {
 		logger.Fatal("Failed to migrate database", zap.Error(err))
 	}
 
 	// Setup router
-	router := api.SetupRouter(cfg.Server.Mode)
+	router, closeStreams, cleanup := api.SetupRouter(cfg)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -104,6 +161,15 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	srv.RegisterOnShutdown(closeStreams)
+
+	if cfg.Server.TLS.Enabled {
+		tlsConfig, err := buildTLSConfig(&cfg.Server.TLS)
+		if err != nil {
+			logger.Fatal("Failed to configure TLS", zap.Error(err))
+		}
+		srv.TLSConfig = tlsConfig
+	}
 
 	// Start server in goroutine
 	go func() {
@@ -112,11 +178,37 @@ func main() {
 			zap.String("swagger", fmt.Sprintf("http://localhost:%d/swagger/index.html", cfg.Server.Port)),
 			zap.String("health", fmt.Sprintf("http://localhost:%d/health", cfg.Server.Port)),
 		)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.Server.TLS.Enabled {
+			err = srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
 
+	// Serve metrics on a separate address so they are not exposed publicly
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled && !cfg.Metrics.Public {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.Path, metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:         cfg.Metrics.ListenAddr,
+			Handler:      mux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Metrics server is running", zap.String("addr", cfg.Metrics.ListenAddr))
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -125,12 +217,60 @@ func main() {
 	logger.Info("Shutting down server...")
 
 	// Create context with timeout for graceful shutdown
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+	cancelShutdown()
+
+	// Stop background workers and flush queued MCP logs before the database
+	// closes, even when requests kept the server from shutting down in time
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	cleanup(ctx)
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("Metrics server forced to shutdown", zap.Error(err))
+		}
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
 	}
 
 	logger.Info("Server exited gracefully")
 }
+
+// buildTLSConfig builds the server TLS configuration, enabling client
+// certificate verification when a client CA bundle is configured
+func buildTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile == "" || cfg.ClientAuth == "none" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+
+	switch cfg.ClientAuth {
+	case "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unsupported client_auth mode: %s", cfg.ClientAuth)
+	}
+
+	return tlsConfig, nil
+}
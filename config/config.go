@@ -13,11 +13,26 @@ type Config struct {
 	JWT        JWTConfig        `mapstructure:"jwt"`
 	Encryption EncryptionConfig `mapstructure:"encryption"`
 	Log        LogConfig        `mapstructure:"log"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Tracing    TracingConfig    `mapstructure:"tracing"`
+	Mcp        McpConfig        `mapstructure:"mcp"`
+	DataSource DataSourceConfig `mapstructure:"datasource"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port           int       `mapstructure:"port"`
+	Mode           string    `mapstructure:"mode"`
+	TrustedProxies []string  `mapstructure:"trusted_proxies"`
+	TLS            TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig configures HTTPS and optional mutual TLS for the HTTP server
+type TLSConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	ClientAuth   string `mapstructure:"client_auth"` // none, optional, require
 }
 
 type DatabaseConfig struct {
@@ -50,6 +65,72 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Path       string `mapstructure:"path"`
+	ListenAddr string `mapstructure:"listen_addr"` // separate address metrics are served on; defaults to 127.0.0.1:9090
+	Public     bool   `mapstructure:"public"`      // serve metrics on the API router instead, without authentication
+}
+
+// TracingConfig configures OpenTelemetry trace export over OTLP/HTTP
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"` // host:port of the OTLP/HTTP collector
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// McpConfig configures background maintenance of MCP servers
+type McpConfig struct {
+	LogRetentionIntervalMinutes    int    `mapstructure:"log_retention_interval_minutes"`
+	AlertEvaluationIntervalSeconds int    `mapstructure:"alert_evaluation_interval_seconds"`
+	LogQueueSize                   int    `mapstructure:"log_queue_size"`
+	LogBatchSize                   int    `mapstructure:"log_batch_size"`
+	LogFlushIntervalMs             int    `mapstructure:"log_flush_interval_ms"`
+	LogSpillFile                   string `mapstructure:"log_spill_file"` // optional; logs the database cannot take are kept here and replayed
+}
+
+// DataSourceConfig configures health tracking of target databases
+type DataSourceConfig struct {
+	HealthCheckIntervalSeconds   int                  `mapstructure:"health_check_interval_seconds"`
+	HealthHistoryDays            int                  `mapstructure:"health_history_days"`
+	SchemaRefreshIntervalMinutes int                  `mapstructure:"schema_refresh_interval_minutes"` // 0 refreshes schema snapshots on demand only
+	CircuitBreaker               CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	SQLite                       SQLiteConfig         `mapstructure:"sqlite"`
+	Files                        FilesConfig          `mapstructure:"files"`
+	Profiling                    ProfilingConfig      `mapstructure:"profiling"`
+}
+
+// SQLiteConfig sandboxes the database files SQLite datasources may open
+type SQLiteConfig struct {
+	AllowedDir  string `mapstructure:"allowed_dir"`  // SQLite datasources are disabled when empty
+	AllowWrites bool   `mapstructure:"allow_writes"` // open database files read-write instead of read-only
+}
+
+// FilesConfig sandboxes the CSV, NDJSON and Parquet files file datasources read
+type FilesConfig struct {
+	Dir           string `mapstructure:"dir"`              // data files and uploads live here; file datasources are disabled when empty
+	MaxFileSizeMB int    `mapstructure:"max_file_size_mb"` // largest data file or upload; 0 means no limit
+}
+
+// ProfilingConfig bounds table previews and column profiles
+type ProfilingConfig struct {
+	SensitiveColumns []string `mapstructure:"sensitive_columns"` // column name patterns never previewed or profiled, e.g. "*password*"
+	PreviewMaxRows   int      `mapstructure:"preview_max_rows"`  // most rows a table preview returns
+	SampleRows       int      `mapstructure:"sample_rows"`       // rows a profile reads; -1 profiles the whole table
+	TopValues        int      `mapstructure:"top_values"`        // most frequent values reported per column
+	HistogramBuckets int      `mapstructure:"histogram_buckets"` // buckets of the histogram of numeric columns
+}
+
+// CircuitBreakerConfig configures the circuit breaker kept per datasource
+type CircuitBreakerConfig struct {
+	FailureThreshold int `mapstructure:"failure_threshold"`   // consecutive connection failures that open the circuit
+	OpenSeconds      int `mapstructure:"open_seconds"`        // time calls are rejected before a trial call
+	HalfOpenMaxCalls int `mapstructure:"half_open_max_calls"` // concurrent trial calls while half-open
+}
+
 func (d *DatabaseConfig) DSN() string {
 	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=Asia/Shanghai",
@@ -83,6 +164,9 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Server.Mode == "" {
 		config.Server.Mode = "debug"
 	}
+	if config.Server.TLS.ClientAuth == "" {
+		config.Server.TLS.ClientAuth = "optional"
+	}
 	if config.Database.MaxIdleConns == 0 {
 		config.Database.MaxIdleConns = 10
 	}
@@ -108,6 +192,62 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Log.MaxAge = 28
 	}
 
+	if config.Metrics.Path == "" {
+		config.Metrics.Path = "/metrics"
+	}
+	if config.Metrics.ListenAddr == "" {
+		config.Metrics.ListenAddr = "127.0.0.1:9090"
+	}
+	if config.Tracing.ServiceName == "" {
+		config.Tracing.ServiceName = "dataweaver"
+	}
+	if config.Tracing.SampleRatio == 0 {
+		config.Tracing.SampleRatio = 1
+	}
+	if config.Mcp.LogRetentionIntervalMinutes == 0 {
+		config.Mcp.LogRetentionIntervalMinutes = 60
+	}
+	if config.Mcp.AlertEvaluationIntervalSeconds == 0 {
+		config.Mcp.AlertEvaluationIntervalSeconds = 60
+	}
+	if config.Mcp.LogQueueSize == 0 {
+		config.Mcp.LogQueueSize = 1000
+	}
+	if config.Mcp.LogBatchSize == 0 {
+		config.Mcp.LogBatchSize = 100
+	}
+	if config.Mcp.LogFlushIntervalMs == 0 {
+		config.Mcp.LogFlushIntervalMs = 1000
+	}
+
+	if config.DataSource.HealthCheckIntervalSeconds == 0 {
+		config.DataSource.HealthCheckIntervalSeconds = 60
+	}
+	if config.DataSource.HealthHistoryDays == 0 {
+		config.DataSource.HealthHistoryDays = 7
+	}
+	if config.DataSource.CircuitBreaker.FailureThreshold == 0 {
+		config.DataSource.CircuitBreaker.FailureThreshold = 5
+	}
+	if config.DataSource.CircuitBreaker.OpenSeconds == 0 {
+		config.DataSource.CircuitBreaker.OpenSeconds = 30
+	}
+	if config.DataSource.CircuitBreaker.HalfOpenMaxCalls == 0 {
+		config.DataSource.CircuitBreaker.HalfOpenMaxCalls = 1
+	}
+	if config.DataSource.Profiling.PreviewMaxRows == 0 {
+		config.DataSource.Profiling.PreviewMaxRows = 100
+	}
+	if config.DataSource.Profiling.SampleRows == 0 {
+		config.DataSource.Profiling.SampleRows = 100000
+	}
+	if config.DataSource.Profiling.TopValues == 0 {
+		config.DataSource.Profiling.TopValues = 5
+	}
+	if config.DataSource.Profiling.HistogramBuckets == 0 {
+		config.DataSource.Profiling.HistogramBuckets = 10
+	}
+
 	AppConfig = &config
 	return &config, nil
 }
@@ -0,0 +1,264 @@
+package alert
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/internal/response"
+	"github.com/yourusername/dataweaver/internal/service"
+)
+
+// Handler handles alert rule API requests
+type Handler struct {
+	alertService service.AlertService
+}
+
+// NewHandler creates a new alert rule handler
+func NewHandler(alertService service.AlertService) *Handler {
+	return &Handler{alertService: alertService}
+}
+
+// getUserID extracts user ID from context
+func getUserID(c *gin.Context) uint {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0
+	}
+	if id, ok := userID.(uint); ok {
+		return id
+	}
+	if id, ok := userID.(float64); ok {
+		return uint(id)
+	}
+	return 0
+}
+
+// Create creates a new alert rule
+// @Summary Create alert rule
+// @Description Create an alert rule on an MCP server or one of its tools
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body model.CreateAlertRuleRequest true "Create alert rule request"
+// @Success 201 {object} response.Response{data=model.AlertRuleResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules [post]
+func (h *Handler) Create(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	var req model.CreateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.alertService.Create(userID, &req)
+	if err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.Created(c, rule)
+}
+
+// List returns all alert rules for the current user
+// @Summary List alert rules
+// @Description Get all alert rules for the current user with pagination
+// @Tags alert-rules
+// @Produce json
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param size query int false "Page size" default(20)
+// @Success 200 {object} response.PagedResponse{data=[]model.AlertRuleResponse}
+// @Failure 401 {object} response.Response
+// @Router /alert-rules [get]
+func (h *Handler) List(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	rules, total, err := h.alertService.List(userID, page, size)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessPaged(c, rules, total, page, size)
+}
+
+// Get returns an alert rule by ID
+// @Summary Get alert rule
+// @Description Get an alert rule by ID
+// @Tags alert-rules
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} response.Response{data=model.AlertRuleResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules/{id} [get]
+func (h *Handler) Get(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	rule, err := h.alertService.Get(c.Param("id"), userID)
+	if err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// Update updates an alert rule
+// @Summary Update alert rule
+// @Description Update an alert rule by ID
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Alert rule ID"
+// @Param request body model.UpdateAlertRuleRequest true "Update alert rule request"
+// @Success 200 {object} response.Response{data=model.AlertRuleResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules/{id} [put]
+func (h *Handler) Update(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	var req model.UpdateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.alertService.Update(c.Param("id"), userID, &req)
+	if err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// Delete deletes an alert rule
+// @Summary Delete alert rule
+// @Description Delete an alert rule by ID
+// @Tags alert-rules
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules/{id} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	if err := h.alertService.Delete(c.Param("id"), userID); err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Test sends a test notification to the webhook of an alert rule
+// @Summary Test alert rule webhook
+// @Description Send a signed test notification to the webhook of an alert rule
+// @Tags alert-rules
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} response.Response{data=model.AlertDelivery}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules/{id}/test [post]
+func (h *Handler) Test(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	delivery, err := h.alertService.TestRule(c.Param("id"), userID)
+	if err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+// GetDeliveries returns the webhook delivery log of an alert rule
+// @Summary Get alert rule deliveries
+// @Description Get the webhook deliveries of an alert rule with pagination
+// @Tags alert-rules
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Alert rule ID"
+// @Param page query int false "Page number" default(1)
+// @Param size query int false "Page size" default(20)
+// @Success 200 {object} response.PagedResponse{data=[]model.AlertDelivery}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alert-rules/{id}/deliveries [get]
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	deliveries, total, err := h.alertService.GetDeliveries(c.Param("id"), userID, page, size)
+	if err != nil {
+		handleAlertError(c, err)
+		return
+	}
+
+	response.SuccessPaged(c, deliveries, total, page, size)
+}
+
+// handleAlertError maps service errors to HTTP responses
+func handleAlertError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAlertRuleNotFound):
+		response.NotFound(c, "Alert rule not found")
+	case errors.Is(err, repository.ErrMcpServerNotFound):
+		response.NotFound(c, "MCP server not found")
+	case errors.Is(err, service.ErrInvalidAlertRule):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -1,6 +1,8 @@
 package datasource
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +10,7 @@ import (
 	"github.com/yourusername/dataweaver/internal/repository"
 	"github.com/yourusername/dataweaver/internal/response"
 	"github.com/yourusername/dataweaver/internal/service"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
 )
 
 // Handler handles datasource API requests
@@ -20,6 +23,10 @@ func NewHandler(svc service.DataSourceService) *Handler {
 	return &Handler{service: svc}
 }
 
+// multipartOverhead is the room an upload body gets on top of the file size
+// limit for the multipart boundaries and part headers
+const multipartOverhead = 1 << 20
+
 // getUserID extracts user ID from context (set by JWT middleware)
 func getUserID(c *gin.Context) uint {
 	userID, exists := c.Get("user_id")
@@ -97,7 +104,11 @@ func (h *Handler) Create(c *gin.Context) {
 
 	ds, err := h.service.Create(userID, &req)
 	if err != nil {
-		if err == service.ErrInvalidDataSourceType {
+		if err == service.ErrInvalidDataSourceType || errors.Is(err, service.ErrInvalidDataSourcePath) ||
+			errors.Is(err, service.ErrInvalidDataSourceOptions) || errors.Is(err, service.ErrInvalidConcurrencyConfig) ||
+			errors.Is(err, service.ErrInvalidSSHTunnel) || errors.Is(err, service.ErrInvalidTLSConfig) ||
+			errors.Is(err, service.ErrInvalidConnectionURL) || errors.Is(err, service.ErrInvalidSessionInit) ||
+			errors.Is(err, service.ErrInvalidReplicas) {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -108,6 +119,64 @@ func (h *Handler) Create(c *gin.Context) {
 	response.Created(c, ds)
 }
 
+// UploadDataFile godoc
+// @Summary Upload data file
+// @Description Upload a CSV, NDJSON or Parquet file to query through a file datasource
+// @Tags DataSources
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Data file"
+// @Security BearerAuth
+// @Success 201 {object} response.Response{data=model.UploadDataFileResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 413 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/upload [post]
+func (h *Handler) UploadDataFile(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	// Stop reading the body past the file size limit, before the multipart
+	// form is spooled to memory or temporary files
+	if maxSize := dbconnector.MaxFileSize(); maxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Error(c, http.StatusRequestEntityTooLarge, dbconnector.ErrFileTooLarge.Error())
+			return
+		}
+		response.BadRequest(c, "file is required")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.UploadDataFile(header.Filename, file)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDataFileUpload) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, result)
+}
+
 // Get godoc
 // @Summary Get datasource
 // @Description Get a datasource by ID
@@ -188,7 +257,10 @@ func (h *Handler) Update(c *gin.Context) {
 			response.NotFound(c, "datasource not found")
 			return
 		}
-		if err == service.ErrInvalidDataSourceType {
+		if err == service.ErrInvalidDataSourceType || errors.Is(err, service.ErrInvalidDataSourcePath) ||
+			errors.Is(err, service.ErrInvalidDataSourceOptions) || errors.Is(err, service.ErrInvalidConcurrencyConfig) ||
+			errors.Is(err, service.ErrInvalidSSHTunnel) || errors.Is(err, service.ErrInvalidTLSConfig) ||
+			errors.Is(err, service.ErrInvalidSessionInit) || errors.Is(err, service.ErrInvalidReplicas) {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -233,8 +305,8 @@ func (h *Handler) Delete(c *gin.Context) {
 			response.NotFound(c, "datasource not found")
 			return
 		}
-		if err == service.ErrDataSourceInUse {
-			response.Error(c, 409, "datasource is in use by queries")
+		if errors.Is(err, service.ErrDataSourceInUse) {
+			response.Error(c, 409, err.Error())
 			return
 		}
 		response.InternalError(c, err.Error())
@@ -246,7 +318,7 @@ func (h *Handler) Delete(c *gin.Context) {
 
 // TestConnection godoc
 // @Summary Test datasource connection
-// @Description Test the connection to a datasource
+// @Description Test the connection to a datasource stage by stage: DNS, TCP or SSH tunnel, TLS, authentication, database, server version and privileges
 // @Tags DataSources
 // @Accept json
 // @Produce json
@@ -319,6 +391,45 @@ func (h *Handler) TestConnectionDirect(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// ParseDSN godoc
+// @Summary Parse connection string
+// @Description Split a connection string (postgres://, mysql://, sqlserver://, JDBC URL or key=value DSN) into datasource fields
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param request body model.ParseDSNRequest true "Connection string"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.ParseDSNResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/parse-dsn [post]
+func (h *Handler) ParseDSN(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	var req model.ParseDSNRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.ParseDSN(&req)
+	if err != nil {
+		if err == service.ErrInvalidDataSourceType || errors.Is(err, service.ErrInvalidConnectionURL) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
 // GetTables godoc
 // @Summary Get datasource tables
 // @Description Get the list of tables in a datasource
@@ -331,7 +442,9 @@ func (h *Handler) TestConnectionDirect(c *gin.Context) {
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
 // @Router /api/v1/datasources/{id}/tables [get]
 func (h *Handler) GetTables(c *gin.Context) {
 	userID := getUserID(c)
@@ -347,6 +460,164 @@ func (h *Handler) GetTables(c *gin.Context) {
 	}
 
 	tables, err := h.service.GetTables(id, userID)
+	if err != nil {
+		handleTableError(c, err)
+		return
+	}
+
+	response.Success(c, tables)
+}
+
+// PreviewTable godoc
+// @Summary Preview table rows
+// @Description Get a sample of the rows of a table, leaving out the configured sensitive columns
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param schema path string true "Schema name"
+// @Param table path string true "Table name"
+// @Param limit query int false "Number of rows, capped by the server" default(20)
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.TablePreviewResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /api/v1/datasources/{id}/tables/{schema}/{table}/preview [get]
+func (h *Handler) PreviewTable(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	preview, err := h.service.PreviewTable(c.Param("id"), userID, c.Param("schema"), c.Param("table"), limit)
+	if err != nil {
+		handleTableError(c, err)
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// ProfileTable godoc
+// @Summary Profile table columns
+// @Description Compute the null ratio, distinct count, range, top values and histogram of every column of a table that is not sensitive, and store the result
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param schema path string true "Schema name"
+// @Param table path string true "Table name"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.TableProfile}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /api/v1/datasources/{id}/tables/{schema}/{table}/profile [post]
+func (h *Handler) ProfileTable(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	profile, err := h.service.ProfileTable(c.Param("id"), userID, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		handleTableError(c, err)
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// GetTableProfile godoc
+// @Summary Get table profile
+// @Description Get the stored profile of a table
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param schema path string true "Schema name"
+// @Param table path string true "Table name"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.TableProfile}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/tables/{schema}/{table}/profile [get]
+func (h *Handler) GetTableProfile(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	profile, err := h.service.GetTableProfile(c.Param("id"), userID, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		handleTableError(c, err)
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// handleTableError maps errors of table listings, previews and profiles to
+// HTTP responses
+func handleTableError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrDataSourceNotFound):
+		response.NotFound(c, "datasource not found")
+	case errors.Is(err, dbconnector.ErrTableNotFound):
+		response.NotFound(c, "table not found")
+	case errors.Is(err, repository.ErrTableProfileNotFound):
+		response.NotFound(c, "table has not been profiled")
+	case errors.Is(err, service.ErrDataSourceBusy):
+		response.Error(c, 429, err.Error())
+	case errors.Is(err, service.ErrDataSourceUnavailable):
+		response.Error(c, 503, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+// GetHealth godoc
+// @Summary Get datasource health
+// @Description Get the current health of a datasource and its recent health checks
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param limit query int false "Number of health checks to return" default(100)
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.DataSourceHealthHistoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/health [get]
+func (h *Handler) GetHealth(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "datasource id is required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	health, err := h.service.GetHealthHistory(id, userID, limit)
 	if err != nil {
 		if err == repository.ErrDataSourceNotFound {
 			response.NotFound(c, "datasource not found")
@@ -356,5 +627,104 @@ func (h *Handler) GetTables(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, tables)
+	response.Success(c, health)
+}
+
+// GetDependents godoc
+// @Summary Get datasource dependents
+// @Description Get the queries of a datasource with the tables they read, the tools built on them and the MCP servers exposing those tools
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.DataSourceDependentsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/dependents [get]
+func (h *Handler) GetDependents(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "datasource id is required")
+		return
+	}
+
+	deps, err := h.service.GetDependents(id, userID)
+	if err != nil {
+		if err == repository.ErrDataSourceNotFound {
+			response.NotFound(c, "datasource not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, deps)
+}
+
+// RepointDependents godoc
+// @Summary Repoint datasource dependents
+// @Description Move the queries of a datasource, and so their tools and MCP servers, to another datasource of the same type in one transaction. Nothing is moved unless every table the queries read exists on the target; dry_run only checks the target. The review flags the old datasource's schema raised on the moved tools are cleared.
+// @Tags DataSources
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param request body model.RepointDependentsRequest true "Target datasource"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.RepointDependentsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /api/v1/datasources/{id}/repoint [post]
+func (h *Handler) RepointDependents(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "datasource id is required")
+		return
+	}
+
+	var req model.RepointDependentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.RepointDependents(id, userID, &req)
+	if err != nil {
+		switch {
+		case err == repository.ErrDataSourceNotFound:
+			response.NotFound(c, "datasource not found")
+		case errors.Is(err, service.ErrInvalidRepointTarget):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrDependentsChanged):
+			response.Error(c, 409, err.Error())
+		case errors.Is(err, service.ErrDataSourceBusy):
+			response.Error(c, 429, err.Error())
+		case errors.Is(err, service.ErrDataSourceUnavailable):
+			response.Error(c, 503, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, result)
 }
@@ -1,7 +1,10 @@
 package mcp
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
@@ -11,13 +14,20 @@ import (
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/service"
 	"github.com/yourusername/dataweaver/pkg/analytics"
+	"github.com/yourusername/dataweaver/pkg/metrics"
 )
 
+// apiKeyPrefixKey is the context key holding the caller's API key prefix
+const apiKeyPrefixKey = "mcp_api_key_prefix"
+
 // RuntimeHandler handles MCP protocol requests
 type RuntimeHandler struct {
 	mcpService   service.McpServerService
 	rateLimiters map[string]*analytics.RateLimiter
 	mu           sync.RWMutex
+
+	closing   chan struct{} // closed to end open SSE streams
+	closeOnce sync.Once
 }
 
 // NewRuntimeHandler creates a new MCP runtime handler
@@ -25,9 +35,16 @@ func NewRuntimeHandler(mcpService service.McpServerService) *RuntimeHandler {
 	return &RuntimeHandler{
 		mcpService:   mcpService,
 		rateLimiters: make(map[string]*analytics.RateLimiter),
+		closing:      make(chan struct{}),
 	}
 }
 
+// CloseStreams ends every open SSE stream, which would otherwise keep a
+// graceful server shutdown waiting until their clients disconnect
+func (h *RuntimeHandler) CloseStreams() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
 // HandleMcpRequest handles incoming MCP protocol requests
 // @Summary Handle MCP request
 // @Description Process MCP protocol requests (tools/list, tools/call)
@@ -35,7 +52,7 @@ func NewRuntimeHandler(mcpService service.McpServerService) *RuntimeHandler {
 // @Accept json
 // @Produce json
 // @Param serverId path string true "Server ID"
-// @Param X-API-Key header string true "API Key"
+// @Param X-API-Key header string false "API Key (optional when authenticating with a client certificate)"
 // @Param request body model.McpRequest true "MCP Request"
 // @Success 200 {object} model.McpResponse
 // @Failure 400 {object} model.McpResponse
@@ -46,36 +63,20 @@ func NewRuntimeHandler(mcpService service.McpServerService) *RuntimeHandler {
 func (h *RuntimeHandler) HandleMcpRequest(c *gin.Context) {
 	serverID := c.Param("serverId")
 
-	// Get API key from header
-	apiKey := c.GetHeader("X-API-Key")
-	if apiKey == "" {
-		// Also check Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			apiKey = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-	}
-
-	if apiKey == "" {
-		h.sendError(c, nil, model.McpErrorCodeInvalidRequest, "Missing API key")
-		return
-	}
-
-	// Validate API key and get server
-	server, err := h.mcpService.GetServerByApiKey(apiKey)
+	// Validate credentials and network access
+	apiKey := requestAPIKey(c)
+	server, err := h.authenticate(c, serverID, apiKey)
 	if err != nil {
-		h.sendError(c, nil, model.McpErrorCodeInvalidRequest, "Invalid API key")
+		h.sendError(c, nil, model.McpErrorCodeInvalidRequest, err.Error())
 		return
 	}
-
-	// Verify server ID matches
-	if server.ID != serverID {
-		h.sendError(c, nil, model.McpErrorCodeInvalidRequest, "Server ID mismatch")
-		return
+	if apiKey != "" {
+		c.Set(apiKeyPrefixKey, model.ApiKeyPrefix(apiKey))
 	}
 
 	// Check rate limit
 	if !h.checkRateLimit(serverID, server.Config.RateLimitPerMin) {
+		metrics.IncRateLimitRejections(serverID)
 		h.sendError(c, nil, model.McpErrorCodeInternalError, "Rate limit exceeded")
 		c.Status(http.StatusTooManyRequests)
 		return
@@ -176,20 +177,94 @@ func (h *RuntimeHandler) handleToolsCall(c *gin.Context, server *model.McpServer
 	}
 
 	// Execute tool
-	result, log, err := h.mcpService.ExecuteTool(server.ID, callParams.Name, callParams.Arguments)
-	if err != nil {
+	result, log, err := h.mcpService.ExecuteTool(c.Request.Context(), server.ID, callParams.Name, callParams.Arguments)
+
+	// Log the call asynchronously, including calls rejected as busy
+	if log != nil {
+		log.ApiKeyPrefix = c.GetString(apiKeyPrefixKey)
+		metrics.ObserveToolCall(server.ID, log.ToolName, log.Status, time.Duration(log.ResponseTimeMs)*time.Millisecond)
+		_ = h.mcpService.LogToolCall(log)
+	}
+
+	switch {
+	case errors.Is(err, service.ErrQuotaExceeded):
+		h.sendError(c, req.ID, model.McpErrorCodeQuotaExceeded, err.Error())
+		return
+	case errors.Is(err, service.ErrServerBusy):
+		h.sendError(c, req.ID, model.McpErrorCodeBusy, err.Error())
+		return
+	case errors.Is(err, service.ErrCallCancelled):
+		h.sendError(c, req.ID, model.McpErrorCodeCancelled, err.Error())
+		return
+	case err != nil:
 		h.sendError(c, req.ID, model.McpErrorCodeInternalError, err.Error())
 		return
 	}
 
-	// Log the call asynchronously
-	if log != nil {
-		go func() {
-			_ = h.mcpService.LogToolCall(log)
-		}()
+	h.sendResult(c, req.ID, result)
+}
+
+// requestAPIKey returns the API key of a request, from the X-API-Key header or
+// a bearer token
+func requestAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
 	}
+	return ""
+}
 
-	h.sendResult(c, req.ID, result)
+// authenticate resolves the server for a request from its API key or, when no
+// key is presented, from a verified TLS client certificate mapped to the server.
+// It then enforces the server's client certificate requirement and IP allowlist.
+func (h *RuntimeHandler) authenticate(c *gin.Context, serverID, apiKey string) (*model.McpServer, error) {
+	clientCert := verifiedClientCert(c)
+
+	var server *model.McpServer
+	switch {
+	case apiKey != "":
+		found, err := h.mcpService.GetServerByApiKey(apiKey)
+		if err != nil {
+			return nil, errors.New("Invalid API key")
+		}
+		if found.ID != serverID {
+			return nil, errors.New("Server ID mismatch")
+		}
+		server = found
+	case clientCert != nil:
+		found, err := h.mcpService.GetPublishedServer(serverID)
+		if err != nil || !found.Config.Access.AcceptsClientCert(clientCert) {
+			return nil, errors.New("Client certificate not authorized")
+		}
+		server = found
+	default:
+		return nil, errors.New("Missing API key")
+	}
+
+	access := server.Config.Access
+	if access.RequireClientCert && !access.AcceptsClientCert(clientCert) {
+		return nil, fmt.Errorf("%w: client certificate required", service.ErrAccessDenied)
+	}
+	if !access.AllowsIP(c.ClientIP()) {
+		return nil, fmt.Errorf("%w: client IP not allowed", service.ErrAccessDenied)
+	}
+
+	return server, nil
+}
+
+// verifiedClientCert returns the leaf client certificate verified during the
+// TLS handshake, or nil if the connection has none
+func verifiedClientCert(c *gin.Context) *x509.Certificate {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	chain := c.Request.TLS.VerifiedChains[0]
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain[0]
 }
 
 // checkRateLimit checks if the request is within rate limit
@@ -239,7 +314,7 @@ func (h *RuntimeHandler) sendError(c *gin.Context, id interface{}, code int, mes
 // @Tags mcp-runtime
 // @Produce text/event-stream
 // @Param serverId path string true "Server ID"
-// @Param X-API-Key header string true "API Key"
+// @Param X-API-Key header string false "API Key (optional when authenticating with a client certificate)"
 // @Router /mcp/{serverId}/sse [get]
 func (h *RuntimeHandler) HandleMcpSSE(c *gin.Context) {
 	serverID := c.Param("serverId")
@@ -250,15 +325,14 @@ func (h *RuntimeHandler) HandleMcpSSE(c *gin.Context) {
 		apiKey = c.Query("api_key")
 	}
 
-	if apiKey == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
-		return
-	}
-
-	// Validate API key
-	server, err := h.mcpService.GetServerByApiKey(apiKey)
-	if err != nil || server.ID != serverID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+	// Validate credentials and network access
+	server, err := h.authenticate(c, serverID, apiKey)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, service.ErrAccessDenied) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -285,6 +359,8 @@ func (h *RuntimeHandler) HandleMcpSSE(c *gin.Context) {
 		select {
 		case <-clientGone:
 			return
+		case <-h.closing:
+			return
 		case <-ticker.C:
 			c.SSEvent("heartbeat", map[string]int64{"timestamp": time.Now().Unix()})
 			c.Writer.Flush()
@@ -294,18 +370,35 @@ func (h *RuntimeHandler) HandleMcpSSE(c *gin.Context) {
 
 // Health check endpoint for MCP server
 // @Summary MCP Server health check
-// @Description Check if MCP server is healthy
+// @Description Report the overall health of the MCP server. Callers authenticated like MCP requests also get the health of every datasource its tools depend on.
 // @Tags mcp-runtime
 // @Produce json
 // @Param serverId path string true "Server ID"
-// @Success 200 {object} map[string]interface{}
+// @Param X-API-Key header string false "API Key, for the datasource details"
+// @Success 200 {object} model.McpServerHealthResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 503 {object} model.McpServerHealthResponse
 // @Router /mcp/{serverId}/health [get]
 func (h *RuntimeHandler) HandleHealthCheck(c *gin.Context) {
 	serverID := c.Param("serverId")
 
-	// Try to get server (without auth for health check)
-	tools, err := h.mcpService.GetServerTools(serverID)
+	// Datasource names, types and errors are only shown to authenticated callers
+	apiKey := requestAPIKey(c)
+	detailed := apiKey != "" || verifiedClientCert(c) != nil
+	if detailed {
+		if _, err := h.authenticate(c, serverID, apiKey); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, service.ErrAccessDenied) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"status": "error", "error": err.Error()})
+			return
+		}
+	}
+
+	health, err := h.mcpService.GetServerHealth(serverID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status": "error",
@@ -313,11 +406,14 @@ func (h *RuntimeHandler) HandleHealthCheck(c *gin.Context) {
 		})
 		return
 	}
+	if !detailed {
+		health = &model.McpServerHealthResponse{Status: health.Status, ServerID: health.ServerID, Timestamp: health.Timestamp}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":      "healthy",
-		"server_id":   serverID,
-		"tools_count": len(tools),
-		"timestamp":   time.Now().Unix(),
-	})
+	// Degraded servers can still answer some tools, so only a fully unhealthy server reports 503
+	status := http.StatusOK
+	if health.Status == string(model.McpServerUnhealthy) {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, health)
 }
@@ -0,0 +1,409 @@
+package mcp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/service"
+	"github.com/yourusername/dataweaver/pkg/analytics"
+)
+
+// MockMcpServerService is a mock implementation of McpServerService
+type MockMcpServerService struct {
+	mock.Mock
+}
+
+func (m *MockMcpServerService) Create(userID uint, req *model.CreateMcpServerRequest) (*model.McpServerResponse, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServerResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) List(userID uint, page, size int, keyword string) ([]model.McpServerResponse, int64, error) {
+	args := m.Called(userID, page, size, keyword)
+	return args.Get(0).([]model.McpServerResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMcpServerService) Get(id string, userID uint) (*model.McpServerResponse, error) {
+	args := m.Called(id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServerResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) Update(id string, userID uint, req *model.UpdateMcpServerRequest) (*model.McpServerResponse, error) {
+	args := m.Called(id, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServerResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) Delete(id string, userID uint) error {
+	return m.Called(id, userID).Error(0)
+}
+
+func (m *MockMcpServerService) Publish(id string, userID uint, baseURL string) (*model.PublishMcpServerResponse, error) {
+	args := m.Called(id, userID, baseURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PublishMcpServerResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) Unpublish(id string, userID uint) error {
+	return m.Called(id, userID).Error(0)
+}
+
+func (m *MockMcpServerService) GenerateMcpConfig(id string, userID uint, baseURL string) (*model.McpConfigOutput, error) {
+	args := m.Called(id, userID, baseURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpConfigOutput), args.Error(1)
+}
+
+func (m *MockMcpServerService) LogToolCall(log *model.McpLog) error {
+	return m.Called(log).Error(0)
+}
+
+func (m *MockMcpServerService) GetLogs(serverID string, userID uint, filter *model.McpLogFilter, page, size int) ([]model.McpLogResponse, int64, error) {
+	args := m.Called(serverID, userID, filter, page, size)
+	return args.Get(0).([]model.McpLogResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMcpServerService) ExportLogs(serverID string, userID uint, filter *model.McpLogFilter, fn func([]model.McpLog) error) error {
+	return m.Called(serverID, userID, filter, fn).Error(0)
+}
+
+func (m *MockMcpServerService) GetLogAggregates(serverID string, userID uint, timeRange analytics.TimeRange) ([]model.McpLogAggregateResponse, error) {
+	args := m.Called(serverID, userID, timeRange)
+	return args.Get(0).([]model.McpLogAggregateResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) EnforceRetention() ([]model.RetentionResult, error) {
+	args := m.Called()
+	return args.Get(0).([]model.RetentionResult), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetStatistics(serverID string, userID uint, timeRange analytics.TimeRange) (*analytics.Statistics, error) {
+	args := m.Called(serverID, userID, timeRange)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*analytics.Statistics), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetUsage(serverID string, userID uint) (*model.McpUsageResponse, error) {
+	args := m.Called(serverID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpUsageResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetServerByApiKey(apiKey string) (*model.McpServer, error) {
+	args := m.Called(apiKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServer), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetPublishedServer(serverID string) (*model.McpServer, error) {
+	args := m.Called(serverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServer), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetServerTools(serverID string) ([]model.Tool, error) {
+	args := m.Called(serverID)
+	return args.Get(0).([]model.Tool), args.Error(1)
+}
+
+func (m *MockMcpServerService) GetServerHealth(serverID string) (*model.McpServerHealthResponse, error) {
+	args := m.Called(serverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.McpServerHealthResponse), args.Error(1)
+}
+
+func (m *MockMcpServerService) ExecuteTool(ctx context.Context, serverID, toolName string, params map[string]interface{}) (*model.McpToolCallResult, *model.McpLog, error) {
+	args := m.Called(ctx, serverID, toolName, params)
+	var result *model.McpToolCallResult
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.McpToolCallResult)
+	}
+	var log *model.McpLog
+	if args.Get(1) != nil {
+		log = args.Get(1).(*model.McpLog)
+	}
+	return result, log, args.Error(2)
+}
+
+func (m *MockMcpServerService) Shutdown(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func setupRouter(handler *RuntimeHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	r.POST("/mcp/:serverId", handler.HandleMcpRequest)
+	r.GET("/mcp/:serverId/health", handler.HandleHealthCheck)
+
+	return r
+}
+
+func serverHealth() *model.McpServerHealthResponse {
+	return &model.McpServerHealthResponse{
+		Status:     string(model.McpServerDegraded),
+		ServerID:   "server-1",
+		ToolsCount: 1,
+		DataSources: []model.DataSourceHealthResponse{
+			{ID: "ds-1", Name: "orders-db", Type: "postgresql", Status: "down", Error: "dial tcp 10.0.0.5:5432: connection refused", Tools: []string{"list_orders"}},
+		},
+	}
+}
+
+func TestRuntimeHandler_HealthCheck_Anonymous(t *testing.T) {
+	mockSvc := new(MockMcpServerService)
+	router := setupRouter(NewRuntimeHandler(mockSvc))
+
+	mockSvc.On("GetServerHealth", "server-1").Return(serverHealth(), nil)
+
+	req, _ := http.NewRequest("GET", "/mcp/server-1/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "degraded", body["status"])
+	assert.NotContains(t, body, "data_sources")
+	assert.NotContains(t, w.Body.String(), "orders-db")
+	assert.NotContains(t, w.Body.String(), "list_orders")
+}
+
+func TestRuntimeHandler_HealthCheck_Authenticated(t *testing.T) {
+	mockSvc := new(MockMcpServerService)
+	router := setupRouter(NewRuntimeHandler(mockSvc))
+
+	mockSvc.On("GetServerByApiKey", "key-1").Return(&model.McpServer{ID: "server-1"}, nil)
+	mockSvc.On("GetServerHealth", "server-1").Return(serverHealth(), nil)
+
+	req, _ := http.NewRequest("GET", "/mcp/server-1/health", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "orders-db")
+}
+
+func TestRuntimeHandler_HealthCheck_InvalidKey(t *testing.T) {
+	mockSvc := new(MockMcpServerService)
+	router := setupRouter(NewRuntimeHandler(mockSvc))
+
+	mockSvc.On("GetServerByApiKey", "key-2").Return(&model.McpServer{ID: "server-2"}, nil)
+
+	req, _ := http.NewRequest("GET", "/mcp/server-1/health", nil)
+	req.Header.Set("X-API-Key", "key-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "GetServerHealth", mock.Anything)
+}
+
+// postMcp sends a JSON-RPC request to server-1, letting prepare adjust the
+// HTTP request, and decodes the response
+func postMcp(t *testing.T, router *gin.Engine, body string, prepare func(req *http.Request)) model.McpResponse {
+	req, _ := http.NewRequest("POST", "/mcp/server-1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if prepare != nil {
+		prepare(req)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.McpResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestRuntimeHandler_ToolsCall_QuotaExceeded(t *testing.T) {
+	mockSvc := new(MockMcpServerService)
+	router := setupRouter(NewRuntimeHandler(mockSvc))
+
+	mockSvc.On("GetServerByApiKey", "key-1").Return(&model.McpServer{ID: "server-1"}, nil)
+	mockSvc.On("ExecuteTool", mock.Anything, "server-1", "list_orders", mock.Anything).
+		Return(nil, nil, fmt.Errorf("%w: daily calls limit reached, resets at 2024-05-02T00:00:00Z", service.ErrQuotaExceeded))
+
+	resp := postMcp(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_orders"}}`, func(req *http.Request) {
+		req.Header.Set("X-API-Key", "key-1")
+	})
+
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, model.McpErrorCodeQuotaExceeded, resp.Error.Code)
+	assert.Contains(t, resp.Error.Message, "daily calls limit reached")
+	mockSvc.AssertNotCalled(t, "LogToolCall", mock.Anything)
+}
+
+// withClientCert makes a request arrive over TLS with a verified client
+// certificate for commonName
+func withClientCert(commonName string) func(req *http.Request) {
+	return func(req *http.Request) {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: []string{"Acme"}}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+}
+
+func TestRuntimeHandler_Access(t *testing.T) {
+	const ping = `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	access := model.AccessConfig{
+		AllowedCIDRs:       []string{"10.0.0.0/8", "192.168.1.7", "2001:db8::/32"},
+		ClientCertSubjects: []string{"CN=agent-1"},
+	}
+
+	tests := []struct {
+		name    string
+		access  model.AccessConfig
+		prepare func(req *http.Request)
+		denied  string
+	}{
+		{
+			name:    "allowed network",
+			access:  access,
+			prepare: func(req *http.Request) { req.RemoteAddr = "10.20.30.40:5000" },
+		},
+		{
+			name:    "allowed host",
+			access:  access,
+			prepare: func(req *http.Request) { req.RemoteAddr = "192.168.1.7:5000" },
+		},
+		{
+			name:    "allowed IPv6 network",
+			access:  access,
+			prepare: func(req *http.Request) { req.RemoteAddr = "[2001:db8::1]:5000" },
+		},
+		{
+			name:    "address outside the allowlist",
+			access:  access,
+			prepare: func(req *http.Request) { req.RemoteAddr = "192.168.1.8:5000" },
+			denied:  "client IP not allowed",
+		},
+		{
+			name:    "empty allowlist",
+			prepare: func(req *http.Request) { req.RemoteAddr = "203.0.113.9:5000" },
+		},
+		{
+			name:    "client certificate required but missing",
+			access:  model.AccessConfig{RequireClientCert: true, ClientCertSubjects: []string{"agent-1"}},
+			prepare: func(req *http.Request) { req.RemoteAddr = "10.0.0.1:5000" },
+			denied:  "client certificate required",
+		},
+		{
+			name:   "client certificate required and mapped",
+			access: model.AccessConfig{RequireClientCert: true, ClientCertSubjects: []string{"CN=agent-1,O=Acme"}},
+			prepare: func(req *http.Request) {
+				withClientCert("agent-1")(req)
+				req.RemoteAddr = "10.0.0.1:5000"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockMcpServerService)
+			router := setupRouter(NewRuntimeHandler(mockSvc))
+			server := &model.McpServer{ID: "server-1"}
+			server.Config.Access = tt.access
+			mockSvc.On("GetServerByApiKey", "key-1").Return(server, nil)
+
+			resp := postMcp(t, router, ping, func(req *http.Request) {
+				req.Header.Set("X-API-Key", "key-1")
+				tt.prepare(req)
+			})
+
+			if tt.denied == "" {
+				assert.Nil(t, resp.Error)
+				return
+			}
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, model.McpErrorCodeInvalidRequest, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, tt.denied)
+		})
+	}
+}
+
+func TestRuntimeHandler_ClientCertWithoutApiKey(t *testing.T) {
+	const ping = `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	server := &model.McpServer{ID: "server-1"}
+	server.Config.Access = model.AccessConfig{RequireClientCert: true, ClientCertSubjects: []string{"agent-1"}}
+
+	mockSvc := new(MockMcpServerService)
+	router := setupRouter(NewRuntimeHandler(mockSvc))
+	mockSvc.On("GetPublishedServer", "server-1").Return(server, nil)
+
+	resp := postMcp(t, router, ping, withClientCert("agent-1"))
+	assert.Nil(t, resp.Error, "a mapped certificate authenticates without an API key")
+
+	resp = postMcp(t, router, ping, withClientCert("agent-2"))
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "Client certificate not authorized")
+
+	resp = postMcp(t, router, ping, nil)
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "Missing API key")
+	mockSvc.AssertNotCalled(t, "GetServerByApiKey", mock.Anything)
+}
+
+func TestRuntimeHandler_SSE_EndsOnCloseStreams(t *testing.T) {
+	mockSvc := new(MockMcpServerService)
+	handler := NewRuntimeHandler(mockSvc)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/mcp/:serverId/sse", handler.HandleMcpSSE)
+
+	mockSvc.On("GetServerByApiKey", "key-1").Return(&model.McpServer{ID: "server-1"}, nil)
+
+	req, _ := http.NewRequest("GET", "/mcp/server-1/sse", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(w, req)
+	}()
+
+	handler.CloseStreams()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SSE stream kept the request open after CloseStreams")
+	}
+	assert.Contains(t, w.Body.String(), "event:connected")
+	handler.CloseStreams()
+}
@@ -0,0 +1,117 @@
+package mcpserver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/dataweaver/internal/model"
+)
+
+// logCSVHeader lists the columns of a CSV log export
+var logCSVHeader = []string{
+	"id", "timestamp", "tool_id", "tool_name", "status", "response_time_ms", "queue_wait_ms", "row_count",
+	"error_class", "error_message", "api_key_prefix", "trace_id", "parameters",
+}
+
+// logExporter streams log batches to the response in the requested format.
+// Headers are written with the first batch so errors before any output can
+// still be reported as a regular JSON error response.
+type logExporter struct {
+	c        *gin.Context
+	serverID string
+	format   model.LogExportFormat
+	csv      *csv.Writer
+	started  bool
+}
+
+func newLogExporter(c *gin.Context, serverID string, format model.LogExportFormat) *logExporter {
+	return &logExporter{c: c, serverID: serverID, format: format}
+}
+
+// start writes the response headers and, for CSV, the header row
+func (e *logExporter) start() error {
+	e.started = true
+
+	filename := fmt.Sprintf("mcp-logs-%s-%s.%s", e.serverID, time.Now().UTC().Format("20060102T150405Z"), e.format)
+	e.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if e.format == model.LogExportFormatNDJSON {
+		e.c.Header("Content-Type", "application/x-ndjson")
+		e.c.Status(http.StatusOK)
+		return nil
+	}
+
+	e.c.Header("Content-Type", "text/csv; charset=utf-8")
+	e.c.Status(http.StatusOK)
+	e.csv = csv.NewWriter(e.c.Writer)
+	return e.csv.Write(logCSVHeader)
+}
+
+// write writes a batch of logs and flushes it to the client
+func (e *logExporter) write(logs []model.McpLog) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	for i := range logs {
+		if err := e.writeLog(&logs[i]); err != nil {
+			return err
+		}
+	}
+
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	e.c.Writer.Flush()
+	return nil
+}
+
+func (e *logExporter) writeLog(log *model.McpLog) error {
+	resp := log.ToResponse()
+
+	if e.format == model.LogExportFormatNDJSON {
+		line, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		_, err = e.c.Writer.Write(append(line, '\n'))
+		return err
+	}
+
+	params, err := json.Marshal(resp.Parameters)
+	if err != nil {
+		return err
+	}
+	return e.csv.Write([]string{
+		resp.ID,
+		resp.Timestamp.UTC().Format(time.RFC3339Nano),
+		resp.ToolID,
+		resp.ToolName,
+		resp.Status,
+		strconv.FormatInt(resp.ResponseTimeMs, 10),
+		strconv.FormatInt(resp.QueueWaitMs, 10),
+		strconv.Itoa(resp.RowCount),
+		resp.ErrorClass,
+		resp.ErrorMessage,
+		resp.ApiKeyPrefix,
+		resp.TraceID,
+		string(params),
+	})
+}
+
+// finish completes the export, writing headers for an empty result
+func (e *logExporter) finish() {
+	if !e.started {
+		_ = e.write(nil)
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
 	"github.com/yourusername/dataweaver/internal/response"
 	"github.com/yourusername/dataweaver/internal/service"
+	"github.com/yourusername/dataweaver/pkg/analytics"
 )
 
 // Handler handles MCP server API requests
@@ -293,14 +295,22 @@ func (h *Handler) GetConfig(c *gin.Context) {
 
 // GetLogs returns logs for an MCP server
 // @Summary Get MCP server logs
-// @Description Get logs for an MCP server with pagination
+// @Description Get logs for an MCP server with filtering and pagination
 // @Tags mcp-servers
 // @Produce json
 // @Security Bearer
 // @Param id path string true "MCP Server ID"
 // @Param page query int false "Page number" default(1)
 // @Param size query int false "Page size" default(20)
+// @Param start query string false "Only logs at or after this time (RFC3339)"
+// @Param end query string false "Only logs before this time (RFC3339)"
+// @Param tool query string false "Tool name"
+// @Param status query string false "Call status" Enums(success, error)
+// @Param min_latency_ms query int false "Minimum response time in milliseconds"
+// @Param error query string false "Text contained in the error message"
+// @Param api_key query string false "API key or API key prefix of the caller"
 // @Success 200 {object} response.PagedResponse{data=[]model.McpLogResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /mcp-servers/{id}/logs [get]
@@ -315,7 +325,13 @@ func (h *Handler) GetLogs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
 
-	logs, total, err := h.mcpService.GetLogs(id, userID, page, size)
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	logs, total, err := h.mcpService.GetLogs(id, userID, filter, page, size)
 	if err != nil {
 		handleMcpServerError(c, err)
 		return
@@ -324,15 +340,153 @@ func (h *Handler) GetLogs(c *gin.Context) {
 	response.SuccessPaged(c, logs, total, page, size)
 }
 
+// ExportLogs exports the filtered logs of an MCP server
+// @Summary Export MCP server logs
+// @Description Export all logs matching the filter as CSV or newline-delimited JSON
+// @Tags mcp-servers
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security Bearer
+// @Param id path string true "MCP Server ID"
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Param start query string false "Only logs at or after this time (RFC3339)"
+// @Param end query string false "Only logs before this time (RFC3339)"
+// @Param tool query string false "Tool name"
+// @Param status query string false "Call status" Enums(success, error)
+// @Param min_latency_ms query int false "Minimum response time in milliseconds"
+// @Param error query string false "Text contained in the error message"
+// @Param api_key query string false "API key or API key prefix of the caller"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /mcp-servers/{id}/logs/export [get]
+func (h *Handler) ExportLogs(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+
+	format := model.LogExportFormat(c.DefaultQuery("format", string(model.LogExportFormatCSV)))
+	if format != model.LogExportFormatCSV && format != model.LogExportFormatNDJSON {
+		response.BadRequest(c, "format must be csv or ndjson")
+		return
+	}
+
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	exporter := newLogExporter(c, id, format)
+	if err := h.mcpService.ExportLogs(id, userID, filter, exporter.write); err != nil {
+		if !exporter.started {
+			handleMcpServerError(c, err)
+			return
+		}
+		// Headers are already sent; abort the stream so the client sees a truncated download
+		_ = c.Error(err)
+		return
+	}
+
+	exporter.finish()
+}
+
+// GetLogAggregates returns hourly log aggregates for an MCP server
+// @Summary Get MCP server log aggregates
+// @Description Get hourly aggregates of logs removed by the retention policy
+// @Tags mcp-servers
+// @Produce json
+// @Security Bearer
+// @Param id path string true "MCP Server ID"
+// @Param days query int false "Number of days" default(30)
+// @Param start query string false "Range start (RFC3339)"
+// @Param end query string false "Range end (RFC3339), defaults to now"
+// @Success 200 {object} response.Response{data=[]model.McpLogAggregateResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /mcp-servers/{id}/logs/aggregates [get]
+func (h *Handler) GetLogAggregates(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+
+	timeRange, err := parseTimeRange(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	aggregates, err := h.mcpService.GetLogAggregates(id, userID, timeRange)
+	if err != nil {
+		handleMcpServerError(c, err)
+		return
+	}
+
+	response.Success(c, aggregates)
+}
+
+// parseLogFilter reads the log filter query parameters
+func parseLogFilter(c *gin.Context) (*model.McpLogFilter, error) {
+	filter := &model.McpLogFilter{
+		ToolName:  c.Query("tool"),
+		Status:    c.Query("status"),
+		ErrorText: c.Query("error"),
+		ApiKey:    c.Query("api_key"),
+	}
+
+	if filter.Status != "" &&
+		filter.Status != string(model.McpLogStatusSuccess) &&
+		filter.Status != string(model.McpLogStatusError) {
+		return nil, errors.New("status must be success or error")
+	}
+
+	if v := c.Query("start"); v != "" {
+		start, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("invalid start time, expected RFC3339")
+		}
+		filter.Start = &start
+	}
+	if v := c.Query("end"); v != "" {
+		end, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("invalid end time, expected RFC3339")
+		}
+		filter.End = &end
+	}
+	if v := c.Query("min_latency_ms"); v != "" {
+		minLatency, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || minLatency < 0 {
+			return nil, errors.New("min_latency_ms must be a non-negative integer")
+		}
+		filter.MinResponseMs = minLatency
+	}
+
+	return filter, nil
+}
+
 // GetStatistics returns statistics for an MCP server
 // @Summary Get MCP server statistics
-// @Description Get statistics for an MCP server
+// @Description Get statistics for an MCP server within a time range. Use start/end (RFC3339) for an arbitrary range, or days for the most recent days.
 // @Tags mcp-servers
 // @Produce json
 // @Security Bearer
 // @Param id path string true "MCP Server ID"
 // @Param days query int false "Number of days" default(30)
+// @Param start query string false "Range start (RFC3339)"
+// @Param end query string false "Range end (RFC3339), defaults to now"
 // @Success 200 {object} response.Response{data=analytics.Statistics}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /mcp-servers/{id}/statistics [get]
@@ -344,9 +498,14 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 	}
 
 	id := c.Param("id")
-	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
 
-	stats, err := h.mcpService.GetStatistics(id, userID, days)
+	timeRange, err := parseTimeRange(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	stats, err := h.mcpService.GetStatistics(id, userID, timeRange)
 	if err != nil {
 		handleMcpServerError(c, err)
 		return
@@ -355,6 +514,68 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 	response.Success(c, stats)
 }
 
+// parseTimeRange reads the start/end query parameters, falling back to the last N days
+func parseTimeRange(c *gin.Context) (analytics.TimeRange, error) {
+	startParam := c.Query("start")
+	endParam := c.Query("end")
+
+	if startParam == "" && endParam == "" {
+		days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+		if days <= 0 {
+			days = 30
+		}
+		return analytics.LastDays(days), nil
+	}
+
+	if startParam == "" {
+		return analytics.TimeRange{}, errors.New("start is required when end is provided")
+	}
+
+	start, err := time.Parse(time.RFC3339, startParam)
+	if err != nil {
+		return analytics.TimeRange{}, errors.New("invalid start time, expected RFC3339")
+	}
+
+	end := time.Now()
+	if endParam != "" {
+		end, err = time.Parse(time.RFC3339, endParam)
+		if err != nil {
+			return analytics.TimeRange{}, errors.New("invalid end time, expected RFC3339")
+		}
+	}
+
+	return analytics.NewTimeRange(start, end), nil
+}
+
+// GetUsage returns quota usage for an MCP server
+// @Summary Get MCP server usage
+// @Description Get daily and monthly usage of an MCP server against its quotas
+// @Tags mcp-servers
+// @Produce json
+// @Security Bearer
+// @Param id path string true "MCP Server ID"
+// @Success 200 {object} response.Response{data=model.McpUsageResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /mcp-servers/{id}/usage [get]
+func (h *Handler) GetUsage(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	id := c.Param("id")
+
+	usage, err := h.mcpService.GetUsage(id, userID)
+	if err != nil {
+		handleMcpServerError(c, err)
+		return
+	}
+
+	response.Success(c, usage)
+}
+
 // handleMcpServerError handles MCP server-specific errors
 func handleMcpServerError(c *gin.Context, err error) {
 	switch {
@@ -368,6 +589,14 @@ func handleMcpServerError(c *gin.Context, err error) {
 		response.BadRequest(c, "Server is not published")
 	case errors.Is(err, service.ErrNoToolsToPublish):
 		response.BadRequest(c, "At least one tool is required to publish")
+	case errors.Is(err, service.ErrToolNeedsReview):
+		response.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, analytics.ErrInvalidTimeRange):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrInvalidAccessConfig):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrInvalidConcurrencyConfig):
+		response.BadRequest(c, err.Error())
 	case errors.Is(err, service.ErrInvalidApiKey):
 		response.Unauthorized(c, "Invalid API key")
 	default:
@@ -261,7 +261,9 @@ func (h *Handler) Delete(c *gin.Context) {
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
 // @Router /api/v1/queries/{id}/execute [post]
 func (h *Handler) Execute(c *gin.Context) {
 	userID := getUserID(c)
@@ -282,7 +284,7 @@ func (h *Handler) Execute(c *gin.Context) {
 		req = model.ExecuteQueryRequest{Parameters: make(map[string]interface{})}
 	}
 
-	result, err := h.service.Execute(id, userID, &req)
+	result, err := h.service.Execute(c.Request.Context(), id, userID, &req)
 	if err != nil {
 		if errors.Is(err, repository.ErrQueryNotFound) {
 			response.NotFound(c, "query not found")
@@ -296,6 +298,14 @@ func (h *Handler) Execute(c *gin.Context) {
 			response.BadRequest(c, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrDataSourceBusy) {
+			response.Error(c, 429, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDataSourceUnavailable) {
+			response.Error(c, 503, err.Error())
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
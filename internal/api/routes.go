@@ -1,39 +1,68 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/yourusername/dataweaver/config"
+	"github.com/yourusername/dataweaver/internal/api/alert"
 	"github.com/yourusername/dataweaver/internal/api/auth"
 	"github.com/yourusername/dataweaver/internal/api/datasource"
 	"github.com/yourusername/dataweaver/internal/api/mcp"
 	"github.com/yourusername/dataweaver/internal/api/mcpserver"
 	"github.com/yourusername/dataweaver/internal/api/query"
+	"github.com/yourusername/dataweaver/internal/api/schema"
 	"github.com/yourusername/dataweaver/internal/api/tool"
 	"github.com/yourusername/dataweaver/internal/database"
 	"github.com/yourusername/dataweaver/internal/middleware"
 	"github.com/yourusername/dataweaver/internal/repository"
 	"github.com/yourusername/dataweaver/internal/response"
 	"github.com/yourusername/dataweaver/internal/service"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/metrics"
+	"go.uber.org/zap"
 )
 
-func SetupRouter(mode string) *gin.Engine {
-	gin.SetMode(mode)
+// SetupRouter builds the HTTP router and starts background workers.
+// The returned closeStreams function ends long-lived streams once the server
+// shuts down; cleanup stops the workers and releases shared resources.
+func SetupRouter(cfg *config.Config) (*gin.Engine, func(), func(ctx context.Context)) {
+	gin.SetMode(cfg.Server.Mode)
 
 	r := gin.New()
 
+	// Only trust X-Forwarded-For from configured proxies so MCP server
+	// IP allowlists cannot be bypassed with a forged header
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Warn("Invalid trusted proxies configuration", zap.Error(err))
+	}
+
 	// Global middleware
 	r.Use(middleware.Recovery())
+	r.Use(middleware.Tracing())
 	r.Use(middleware.Logger())
 	r.Use(corsMiddleware())
+	if cfg.Metrics.Enabled {
+		r.Use(middleware.Metrics())
+	}
 
 	// Health check endpoint
 	r.GET("/health", healthCheck)
 
+	// Metrics endpoint, when not served on a separate listen address
+	if cfg.Metrics.Enabled && cfg.Metrics.Public {
+		r.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
+	}
+
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -49,21 +78,86 @@ func SetupRouter(mode string) *gin.Engine {
 	queryRepo := repository.NewQueryRepository(database.DB)
 	toolRepo := repository.NewToolRepository(database.DB)
 	mcpRepo := repository.NewMcpServerRepository(database.DB)
+	alertRepo := repository.NewAlertRepository(database.DB)
+	snapshotRepo := repository.NewSchemaSnapshotRepository(database.DB)
+
+	// Initialize datasource connection pool
+	dsPool := dbconnector.NewPool(dbconnector.DefaultPoolOptions())
+	if err := metrics.RegisterPoolStats(dsPool.Stats); err != nil {
+		logger.Warn("Failed to register datasource pool metrics", zap.Error(err))
+	}
+
+	// Tools and queries read from the replicas of datasources that have them
+	dsRouter := dbconnector.NewRouter(dsPool, dbconnector.DefaultRouterOptions())
+
+	// Circuit breakers shared by tool execution, query runs, table reads and the health prober
+	breakers := circuitbreaker.NewSet(circuitbreaker.Config{
+		FailureThreshold: cfg.DataSource.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      time.Duration(cfg.DataSource.CircuitBreaker.OpenSeconds) * time.Second,
+		HalfOpenMaxCalls: cfg.DataSource.CircuitBreaker.HalfOpenMaxCalls,
+	})
+
+	// Concurrency limits of servers and datasources, shared by tool execution,
+	// query runs and table previews and profiles
+	limiters := concurrency.NewSet()
 
 	// Initialize services
 	authSvc := service.NewAuthService(userRepo)
-	dsSvc := service.NewDataSourceService(dsRepo)
-	querySvc := service.NewQueryService(queryRepo, dsRepo)
-	toolSvc := service.NewToolService(toolRepo, queryRepo, dsRepo)
-	mcpSvc := service.NewMcpServerService(mcpRepo, toolRepo, queryRepo, dsRepo)
+	dsSvc := service.NewDataSourceService(dsRepo, dsRouter, breakers, limiters)
+	querySvc := service.NewQueryService(queryRepo, dsRepo, dsRouter, breakers, limiters)
+	toolSvc := service.NewToolService(toolRepo, queryRepo, dsRepo, dsRouter)
+	mcpSvc := service.NewMcpServerService(mcpRepo, toolRepo, queryRepo, dsRepo, dsRouter, breakers, limiters, service.McpLogWriterOptions{
+		QueueSize:     cfg.Mcp.LogQueueSize,
+		BatchSize:     cfg.Mcp.LogBatchSize,
+		FlushInterval: time.Duration(cfg.Mcp.LogFlushIntervalMs) * time.Millisecond,
+		SpillFile:     cfg.Mcp.LogSpillFile,
+	})
+	schemaSvc := service.NewSchemaService(snapshotRepo, dsRepo, queryRepo, toolRepo, dsRouter, breakers, limiters)
+	alertSvc := service.NewAlertService(alertRepo, mcpRepo, toolRepo, queryRepo, dsRepo, mcpSvc, dsPool)
 
 	// Initialize handlers
 	authHandler := auth.NewHandler(authSvc)
 	dsHandler := datasource.NewHandler(dsSvc)
+	schemaHandler := schema.NewHandler(schemaSvc)
 	queryHandler := query.NewHandler(querySvc)
 	toolHandler := tool.NewHandler(toolSvc)
 	mcpServerHandler := mcpserver.NewHandler(mcpSvc, baseURL)
 	mcpRuntimeHandler := mcp.NewRuntimeHandler(mcpSvc)
+	alertHandler := alert.NewHandler(alertSvc)
+
+	// Start background workers
+	retentionWorker := service.NewRetentionWorker(mcpSvc, time.Duration(cfg.Mcp.LogRetentionIntervalMinutes)*time.Minute)
+	retentionWorker.Start()
+	alertWorker := service.NewAlertWorker(alertSvc, time.Duration(cfg.Mcp.AlertEvaluationIntervalSeconds)*time.Second)
+	alertWorker.Start()
+	healthProber := service.NewHealthProber(dsRepo, dsPool, breakers,
+		time.Duration(cfg.DataSource.HealthCheckIntervalSeconds)*time.Second,
+		time.Duration(cfg.DataSource.HealthHistoryDays)*24*time.Hour,
+	)
+	healthProber.Start()
+	var schemaRefresher *service.SchemaRefresher
+	if cfg.DataSource.SchemaRefreshIntervalMinutes > 0 {
+		schemaRefresher = service.NewSchemaRefresher(schemaSvc, time.Duration(cfg.DataSource.SchemaRefreshIntervalMinutes)*time.Minute)
+		schemaRefresher.Start()
+	}
+
+	cleanup := func(ctx context.Context) {
+		if schemaRefresher != nil {
+			schemaRefresher.Stop()
+		}
+		healthProber.Stop()
+		alertWorker.Stop()
+		if err := alertSvc.Shutdown(ctx); err != nil {
+			logger.Error("Failed to deliver queued alert notifications", zap.Error(err))
+		}
+		retentionWorker.Stop()
+		if err := mcpSvc.Shutdown(ctx); err != nil {
+			logger.Error("Failed to flush MCP logs", zap.Error(err))
+		}
+		if err := dsPool.Close(); err != nil {
+			logger.Warn("Failed to close datasource connections", zap.Error(err))
+		}
+	}
 
 	// MCP Runtime routes (no authentication - uses API key)
 	mcpRuntime := r.Group("/mcp")
@@ -101,11 +195,23 @@ func SetupRouter(mode string) *gin.Engine {
 				datasources.GET("", dsHandler.List)
 				datasources.POST("", dsHandler.Create)
 				datasources.POST("/test", dsHandler.TestConnectionDirect)
+				datasources.POST("/parse-dsn", dsHandler.ParseDSN)
+				datasources.POST("/upload", dsHandler.UploadDataFile)
 				datasources.GET("/:id", dsHandler.Get)
 				datasources.PUT("/:id", dsHandler.Update)
 				datasources.DELETE("/:id", dsHandler.Delete)
 				datasources.POST("/:id/test", dsHandler.TestConnection)
 				datasources.GET("/:id/tables", dsHandler.GetTables)
+				datasources.GET("/:id/tables/:schema/:table/preview", dsHandler.PreviewTable)
+				datasources.GET("/:id/tables/:schema/:table/profile", dsHandler.GetTableProfile)
+				datasources.POST("/:id/tables/:schema/:table/profile", dsHandler.ProfileTable)
+				datasources.GET("/:id/health", dsHandler.GetHealth)
+				datasources.GET("/:id/dependents", dsHandler.GetDependents)
+				datasources.POST("/:id/repoint", dsHandler.RepointDependents)
+				datasources.GET("/:id/schema", schemaHandler.Get)
+				datasources.POST("/:id/schema/refresh", schemaHandler.Refresh)
+				datasources.GET("/:id/schema/snapshots", schemaHandler.ListSnapshots)
+				datasources.GET("/:id/schema/snapshots/:version", schemaHandler.GetSnapshot)
 			}
 
 			// Query routes
@@ -134,6 +240,7 @@ func SetupRouter(mode string) *gin.Engine {
 				tools.PUT("/:id", toolHandler.Update)
 				tools.DELETE("/:id", toolHandler.Delete)
 				tools.POST("/:id/test", toolHandler.TestTool)
+				tools.POST("/:id/review", toolHandler.MarkReviewed)
 				tools.GET("/:id/export", toolHandler.Export)
 				tools.POST("/:id/generate-description", toolHandler.GenerateDescription)
 			}
@@ -150,20 +257,35 @@ func SetupRouter(mode string) *gin.Engine {
 				mcpServers.POST("/:id/unpublish", mcpServerHandler.Unpublish)
 				mcpServers.GET("/:id/config", mcpServerHandler.GetConfig)
 				mcpServers.GET("/:id/logs", mcpServerHandler.GetLogs)
+				mcpServers.GET("/:id/logs/export", mcpServerHandler.ExportLogs)
+				mcpServers.GET("/:id/logs/aggregates", mcpServerHandler.GetLogAggregates)
 				mcpServers.GET("/:id/statistics", mcpServerHandler.GetStatistics)
+				mcpServers.GET("/:id/usage", mcpServerHandler.GetUsage)
+			}
+
+			// Alert rule routes
+			alertRules := protected.Group("/alert-rules")
+			{
+				alertRules.GET("", alertHandler.List)
+				alertRules.POST("", alertHandler.Create)
+				alertRules.GET("/:id", alertHandler.Get)
+				alertRules.PUT("/:id", alertHandler.Update)
+				alertRules.DELETE("/:id", alertHandler.Delete)
+				alertRules.POST("/:id/test", alertHandler.Test)
+				alertRules.GET("/:id/deliveries", alertHandler.GetDeliveries)
 			}
 		}
 	}
 
-	return r
+	return r, mcpRuntimeHandler.CloseStreams, cleanup
 }
 
 func corsMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-API-Key"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-API-Key", "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Trace-Id"},
 		AllowCredentials: true,
 		MaxAge:           86400,
 	}
@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/internal/response"
+	"github.com/yourusername/dataweaver/internal/service"
+)
+
+// Handler handles datasource schema snapshot API requests
+type Handler struct {
+	service service.SchemaService
+}
+
+// NewHandler creates a new Handler
+func NewHandler(svc service.SchemaService) *Handler {
+	return &Handler{service: svc}
+}
+
+// getUserID extracts user ID from context (set by JWT middleware)
+func getUserID(c *gin.Context) uint {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0
+	}
+	if id, ok := userID.(uint); ok {
+		return id
+	}
+	if id, ok := userID.(float64); ok {
+		return uint(id)
+	}
+	return 0
+}
+
+// Get godoc
+// @Summary Get datasource schema
+// @Description Get the latest schema snapshot of a datasource, taking the first one if none exists
+// @Tags Schemas
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.SchemaSnapshotResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/schema [get]
+func (h *Handler) Get(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	snapshot, err := h.service.GetLatest(c.Param("id"), userID)
+	if err != nil {
+		handleSchemaError(c, err)
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// Refresh godoc
+// @Summary Refresh datasource schema
+// @Description Introspect a datasource and store a new schema snapshot if the schema changed, flagging affected tools for review
+// @Tags Schemas
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.RefreshSchemaResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/schema/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	result, err := h.service.Refresh(c.Param("id"), userID)
+	if err != nil {
+		handleSchemaError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ListSnapshots godoc
+// @Summary List datasource schema snapshots
+// @Description Get a paginated list of the schema snapshots of a datasource, newest first and without their tables
+// @Tags Schemas
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param page query int false "Page number" default(1)
+// @Param size query int false "Page size" default(20)
+// @Security BearerAuth
+// @Success 200 {object} response.PagedResponse{data=[]model.SchemaSnapshotResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/schema/snapshots [get]
+func (h *Handler) ListSnapshots(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	snapshots, total, err := h.service.ListSnapshots(c.Param("id"), userID, page, size)
+	if err != nil {
+		handleSchemaError(c, err)
+		return
+	}
+
+	response.SuccessPaged(c, snapshots, total, page, size)
+}
+
+// GetSnapshot godoc
+// @Summary Get datasource schema snapshot
+// @Description Get a specific schema snapshot version of a datasource
+// @Tags Schemas
+// @Accept json
+// @Produce json
+// @Param id path string true "Datasource ID"
+// @Param version path int true "Snapshot version"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.SchemaSnapshotResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/datasources/{id}/schema/snapshots/{version} [get]
+func (h *Handler) GetSnapshot(c *gin.Context) {
+	userID := getUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "unauthorized")
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		response.BadRequest(c, "invalid snapshot version")
+		return
+	}
+
+	snapshot, err := h.service.GetSnapshot(c.Param("id"), userID, version)
+	if err != nil {
+		handleSchemaError(c, err)
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// handleSchemaError maps service errors to HTTP responses
+func handleSchemaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrDataSourceNotFound):
+		response.NotFound(c, "datasource not found")
+	case errors.Is(err, repository.ErrSchemaSnapshotNotFound):
+		response.NotFound(c, "schema snapshot not found")
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
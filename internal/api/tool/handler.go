@@ -212,6 +212,30 @@ func (h *Handler) Delete(c *gin.Context) {
 	response.Success(c, nil)
 }
 
+// MarkReviewed clears the needs review flag of a tool
+// @Summary Mark tool reviewed
+// @Description Clear the needs review flag set when a schema change touched the objects the tool's query uses
+// @Tags tools
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Tool ID"
+// @Success 200 {object} response.Response{data=model.ToolResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tools/{id}/review [post]
+func (h *Handler) MarkReviewed(c *gin.Context) {
+	userID := getUserID(c)
+	id := c.Param("id")
+
+	tool, err := h.toolService.MarkReviewed(id, userID)
+	if err != nil {
+		handleToolError(c, err)
+		return
+	}
+
+	response.Success(c, tool)
+}
+
 // TestTool tests a tool by executing its associated query
 // @Summary Test tool
 // @Description Test a tool by executing its associated query with provided parameters
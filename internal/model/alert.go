@@ -0,0 +1,168 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertRuleType represents the condition an alert rule watches
+type AlertRuleType string
+
+const (
+	// AlertRuleErrorRate fires when the error rate (%) over the window reaches the threshold
+	AlertRuleErrorRate AlertRuleType = "error_rate"
+	// AlertRuleP95Latency fires when the p95 response time (ms) over the window reaches the threshold
+	AlertRuleP95Latency AlertRuleType = "p95_latency"
+	// AlertRuleQuotaUsage fires when any quota metric reaches the threshold (% of its limit)
+	AlertRuleQuotaUsage AlertRuleType = "quota_usage"
+	// AlertRuleDataSourceUnreachable fires when at least threshold data sources cannot be reached
+	AlertRuleDataSourceUnreachable AlertRuleType = "datasource_unreachable"
+)
+
+// AlertState represents the current state of an alert rule
+type AlertState string
+
+const (
+	AlertStateOK     AlertState = "ok"
+	AlertStateFiring AlertState = "firing"
+)
+
+// AlertEvent represents the kind of notification sent for an alert rule
+type AlertEvent string
+
+const (
+	AlertEventFiring   AlertEvent = "firing"
+	AlertEventResolved AlertEvent = "resolved"
+	AlertEventTest     AlertEvent = "test"
+)
+
+// AlertRule represents a health condition on an MCP server or one of its tools
+type AlertRule struct {
+	ID              string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uint           `gorm:"index;not null" json:"user_id"`
+	McpServerID     string         `gorm:"type:uuid;not null;index" json:"mcp_server_id"`
+	ToolName        string         `gorm:"size:100" json:"tool_name"`
+	Name            string         `gorm:"size:100;not null" json:"name"`
+	Type            string         `gorm:"size:30;not null" json:"type"`
+	Threshold       float64        `gorm:"not null" json:"threshold"`
+	WindowMinutes   int            `gorm:"default:5" json:"window_minutes"`
+	MinCalls        int64          `gorm:"default:1" json:"min_calls"`
+	WebhookURL      string         `gorm:"size:500;not null" json:"webhook_url"`
+	WebhookSecret   string         `gorm:"size:500" json:"-"` // Encrypted
+	Enabled         bool           `gorm:"default:true" json:"enabled"`
+	State           string         `gorm:"size:20;default:'ok'" json:"state"`
+	LastValue       float64        `json:"last_value"`
+	LastEvaluatedAt *time.Time     `json:"last_evaluated_at"`
+	LastTriggeredAt *time.Time     `json:"last_triggered_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// AlertDelivery records a webhook notification attempt for an alert rule
+type AlertDelivery struct {
+	ID           string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AlertRuleID  string    `gorm:"type:uuid;not null;index" json:"alert_rule_id"`
+	Event        string    `gorm:"size:20;not null" json:"event"`
+	Payload      string    `gorm:"type:jsonb" json:"payload"`
+	Attempts     int       `gorm:"default:0" json:"attempts"`
+	StatusCode   int       `json:"status_code"`
+	Success      bool      `gorm:"default:false" json:"success"`
+	ErrorMessage string    `gorm:"type:text" json:"error_message,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+func (AlertDelivery) TableName() string {
+	return "alert_deliveries"
+}
+
+// CreateAlertRuleRequest represents the request body for creating an alert rule
+type CreateAlertRuleRequest struct {
+	Name          string  `json:"name" binding:"required,min=1,max=100"`
+	McpServerID   string  `json:"mcp_server_id" binding:"required,uuid"`
+	ToolName      string  `json:"tool_name" binding:"max=100"`
+	Type          string  `json:"type" binding:"required,oneof=error_rate p95_latency quota_usage datasource_unreachable"`
+	Threshold     float64 `json:"threshold" binding:"required,gt=0"`
+	WindowMinutes int     `json:"window_minutes" binding:"omitempty,min=1,max=1440"`
+	MinCalls      int64   `json:"min_calls" binding:"omitempty,min=1"`
+	WebhookURL    string  `json:"webhook_url" binding:"required,url,max=500"`
+	WebhookSecret string  `json:"webhook_secret" binding:"max=200"`
+	Enabled       *bool   `json:"enabled"`
+}
+
+// UpdateAlertRuleRequest represents the request body for updating an alert rule
+type UpdateAlertRuleRequest struct {
+	Name          *string  `json:"name" binding:"omitempty,min=1,max=100"`
+	ToolName      *string  `json:"tool_name" binding:"omitempty,max=100"`
+	Threshold     *float64 `json:"threshold" binding:"omitempty,gt=0"`
+	WindowMinutes *int     `json:"window_minutes" binding:"omitempty,min=1,max=1440"`
+	MinCalls      *int64   `json:"min_calls" binding:"omitempty,min=1"`
+	WebhookURL    *string  `json:"webhook_url" binding:"omitempty,url,max=500"`
+	WebhookSecret *string  `json:"webhook_secret" binding:"omitempty,max=200"`
+	Enabled       *bool    `json:"enabled"`
+}
+
+// AlertRuleResponse represents the response body for an alert rule
+type AlertRuleResponse struct {
+	ID              string     `json:"id"`
+	McpServerID     string     `json:"mcp_server_id"`
+	ToolName        string     `json:"tool_name,omitempty"`
+	Name            string     `json:"name"`
+	Type            string     `json:"type"`
+	Threshold       float64    `json:"threshold"`
+	WindowMinutes   int        `json:"window_minutes"`
+	MinCalls        int64      `json:"min_calls"`
+	WebhookURL      string     `json:"webhook_url"`
+	HasSecret       bool       `json:"has_secret"`
+	Enabled         bool       `json:"enabled"`
+	State           string     `json:"state"`
+	LastValue       float64    `json:"last_value"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts AlertRule to AlertRuleResponse
+func (r *AlertRule) ToResponse() *AlertRuleResponse {
+	return &AlertRuleResponse{
+		ID:              r.ID,
+		McpServerID:     r.McpServerID,
+		ToolName:        r.ToolName,
+		Name:            r.Name,
+		Type:            r.Type,
+		Threshold:       r.Threshold,
+		WindowMinutes:   r.WindowMinutes,
+		MinCalls:        r.MinCalls,
+		WebhookURL:      r.WebhookURL,
+		HasSecret:       r.WebhookSecret != "",
+		Enabled:         r.Enabled,
+		State:           r.State,
+		LastValue:       r.LastValue,
+		LastEvaluatedAt: r.LastEvaluatedAt,
+		LastTriggeredAt: r.LastTriggeredAt,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+// AlertPayload is the JSON body delivered to alert webhooks
+type AlertPayload struct {
+	Event       string    `json:"event"`
+	RuleID      string    `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	RuleType    string    `json:"rule_type"`
+	McpServerID string    `json:"mcp_server_id"`
+	ToolName    string    `json:"tool_name,omitempty"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"`
+	Window      int       `json:"window_minutes"`
+	Message     string    `json:"message"`
+	Timestamp   time.Time `json:"timestamp"`
+}
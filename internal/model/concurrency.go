@@ -0,0 +1,26 @@
+package model
+
+import "errors"
+
+// maxQueueTimeoutMs caps how long a queued tool execution may wait for a slot
+const maxQueueTimeoutMs = 60000
+
+// ConcurrencyConfig caps the executions running at once against an MCP server
+// or a datasource; a datasource's cap also covers query runs and table reads.
+// Executions beyond the cap wait in a bounded queue.
+type ConcurrencyConfig struct {
+	MaxConcurrent  int `json:"max_concurrent"`   // 0 means unlimited
+	MaxQueued      int `json:"max_queued"`       // executions allowed to wait; 0 rejects immediately when busy
+	QueueTimeoutMs int `json:"queue_timeout_ms"` // how long a queued execution waits; defaults to 5000
+}
+
+// Validate checks that the limits are usable
+func (c ConcurrencyConfig) Validate() error {
+	if c.MaxConcurrent < 0 || c.MaxQueued < 0 || c.QueueTimeoutMs < 0 {
+		return errors.New("concurrency limits must not be negative")
+	}
+	if c.QueueTimeoutMs > maxQueueTimeoutMs {
+		return errors.New("queue_timeout_ms must not exceed 60000")
+	}
+	return nil
+}
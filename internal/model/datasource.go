@@ -1,6 +1,9 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,25 +17,85 @@ const (
 	DataSourceTypePostgreSQL DataSourceType = "postgresql"
 	DataSourceTypeSQLServer  DataSourceType = "sqlserver"
 	DataSourceTypeOracle     DataSourceType = "oracle"
+	DataSourceTypeSQLite     DataSourceType = "sqlite"
+	DataSourceTypeClickHouse DataSourceType = "clickhouse"
+	DataSourceTypeFile       DataSourceType = "file"
 )
 
+// DataSourceOptions holds driver-specific connection settings, such as how an
+// Oracle datasource identifies its database
+type DataSourceOptions map[string]string
+
+// Value implements driver.Valuer interface
+func (o DataSourceOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return json.Marshal(o)
+}
+
+// Scan implements sql.Scanner interface
+func (o *DataSourceOptions) Scan(value interface{}) error {
+	if value == nil {
+		*o = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("failed to scan DataSourceOptions")
+	}
+
+	if len(bytes) == 0 {
+		*o = nil
+		return nil
+	}
+
+	return json.Unmarshal(bytes, o)
+}
+
 // DataSource is the main DataSource model with UUID primary key
 type DataSource struct {
-	ID          string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID      uint           `gorm:"index;not null" json:"user_id"`
-	Name        string         `gorm:"size:100;not null" json:"name" binding:"required,min=1,max=100"`
-	Description string         `gorm:"size:500" json:"description"`
-	Type        string         `gorm:"size:20;not null" json:"type" binding:"required,oneof=mysql postgresql sqlserver oracle"`
-	Host        string         `gorm:"size:255;not null" json:"host" binding:"required"`
-	Port        int            `gorm:"not null" json:"port" binding:"required,min=1,max=65535"`
-	Database    string         `gorm:"size:100;not null" json:"database" binding:"required"`
-	Username    string         `gorm:"size:100;not null" json:"username" binding:"required"`
-	Password    string         `gorm:"size:500;not null" json:"-"` // encrypted, not returned in JSON
-	SSLMode     string         `gorm:"size:20;default:'disable'" json:"ssl_mode"`
-	Status      string         `gorm:"size:20;default:'active'" json:"status"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          string            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uint              `gorm:"index;not null" json:"user_id"`
+	Name        string            `gorm:"size:100;not null" json:"name" binding:"required,min=1,max=100"`
+	Description string            `gorm:"size:500" json:"description"`
+	Type        string            `gorm:"size:20;not null" json:"type" binding:"required"`
+	Host        string            `gorm:"size:255;not null" json:"host" binding:"required_for_server"`
+	Port        int               `gorm:"not null" json:"port" binding:"required_for_server,min=0,max=65535"`
+	Database    string            `gorm:"size:500;not null" json:"database" binding:"required"` // file path for sqlite, file or directory path for file
+	Username    string            `gorm:"size:100;not null" json:"username" binding:"required_for_server"`
+	Password    string            `gorm:"size:500;not null" json:"-"` // encrypted, not returned in JSON
+	SSLMode     string            `gorm:"size:20;default:'disable'" json:"ssl_mode"`
+	Options     DataSourceOptions `gorm:"type:jsonb" json:"options"`
+	SessionInit StringArray       `gorm:"type:jsonb" json:"session_init"` // statements run on every new connection, such as SET search_path
+	Status      string            `gorm:"size:20;default:'active'" json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	// Concurrency caps tool executions across all MCP servers, query runs and table reads against this datasource
+	Concurrency ConcurrencyConfig `gorm:"embedded;embeddedPrefix:concurrency_" json:"concurrency"`
+
+	// TLS holds the certificates used when SSLMode enables TLS
+	TLS DataSourceTLS `gorm:"embedded;embeddedPrefix:tls_" json:"tls"`
+
+	// SSHTunnel reaches Host:Port through a bastion
+	SSHTunnel SSHTunnelConfig `gorm:"embedded;embeddedPrefix:ssh_" json:"ssh_tunnel"`
+
+	// Replicas take the reads of tools and queries, routed as ReplicaRouting says
+	Replicas       DataSourceReplicas `gorm:"type:jsonb" json:"-"`
+	ReplicaRouting ReplicaRouting     `gorm:"embedded;embeddedPrefix:replica_" json:"replica_routing"`
+
+	// Health as last observed by the background prober
+	HealthStatus      string     `gorm:"size:20;default:'unknown'" json:"health_status"`
+	HealthError       string     `gorm:"type:text" json:"health_error,omitempty"`
+	LastHealthCheckAt *time.Time `json:"last_health_check_at"`
 
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
@@ -45,20 +108,53 @@ func (DataSource) TableName() string {
 type CreateDataSourceRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=100"`
 	Description string `json:"description" binding:"max=500"`
-	Type        string `json:"type" binding:"required,oneof=mysql postgresql sqlserver oracle"`
-	Host        string `json:"host" binding:"required"`
-	Port        int    `json:"port" binding:"required,min=1,max=65535"`
-	Database    string `json:"database" binding:"required"`
-	Username    string `json:"username" binding:"required"`
-	Password    string `json:"password" binding:"required"`
-	SSLMode     string `json:"ssl_mode"`
+	Type        string `json:"type" binding:"required_without=ConnectionURL"` // a registered dbconnector dialect, checked by the service
+	Host        string `json:"host" binding:"required_for_server"`
+	Port        int    `json:"port" binding:"required_for_server,min=0,max=65535"`
+	Database    string `json:"database" binding:"required_without=ConnectionURL"` // file path for sqlite, file or directory path for file, inside the allowed directory
+	Username    string `json:"username" binding:"required_for_server"`
+	Password    string `json:"password" binding:"required_for_server"`
+	SSLMode     string `json:"ssl_mode"` // disable, require, verify-ca or verify-full
+
+	// ConnectionURL fills the connection fields left empty from a connection
+	// string, such as a postgres:// or JDBC URL or a key=value DSN
+	ConnectionURL string `json:"connection_url"`
+
+	Options     DataSourceOptions     `json:"options"`
+	SessionInit []string              `json:"session_init"` // SET statements from the type's allowlist, run on every new connection
+	Concurrency ConcurrencyConfig     `json:"concurrency"`
+	TLS         *DataSourceTLSRequest `json:"tls"`
+	SSHTunnel   *SSHTunnelRequest     `json:"ssh_tunnel"`
+
+	Replicas       []DataSourceReplicaRequest `json:"replicas"`
+	ReplicaRouting ReplicaRouting             `json:"replica_routing"`
+}
+
+// ParseDSNRequest represents the request body for splitting a connection string
+type ParseDSNRequest struct {
+	ConnectionURL string `json:"connection_url" binding:"required"`
+	Type          string `json:"type"` // settles key=value strings that fit several database types
+}
+
+// ParseDSNResponse represents the datasource fields of a connection string
+type ParseDSNResponse struct {
+	Type          string            `json:"type"`
+	Host          string            `json:"host"`
+	Port          int               `json:"port"`
+	Database      string            `json:"database"`
+	Username      string            `json:"username"`
+	Password      string            `json:"password"`
+	SSLMode       string            `json:"ssl_mode"`
+	TLSServerName string            `json:"tls_server_name,omitempty"`
+	Options       DataSourceOptions `json:"options,omitempty"`
+	Warnings      []string          `json:"warnings,omitempty"` // parts of the string that were dropped or options the type does not support
 }
 
 // UpdateDataSourceRequest represents the request body for updating a datasource
 type UpdateDataSourceRequest struct {
 	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
 	Description *string `json:"description" binding:"omitempty,max=500"`
-	Type        *string `json:"type" binding:"omitempty,oneof=mysql postgresql sqlserver oracle"`
+	Type        *string `json:"type"`
 	Host        *string `json:"host"`
 	Port        *int    `json:"port" binding:"omitempty,min=1,max=65535"`
 	Database    *string `json:"database"`
@@ -66,6 +162,15 @@ type UpdateDataSourceRequest struct {
 	Password    *string `json:"password"`
 	SSLMode     *string `json:"ssl_mode"`
 	Status      *string `json:"status" binding:"omitempty,oneof=active inactive"`
+
+	Options     *DataSourceOptions    `json:"options"`
+	SessionInit []string              `json:"session_init"` // replaces the statements when set; an empty list removes them
+	Concurrency *ConcurrencyConfig    `json:"concurrency"`
+	TLS         *DataSourceTLSRequest `json:"tls"`
+	SSHTunnel   *SSHTunnelRequest     `json:"ssh_tunnel"` // replaces the tunnel settings; enabled false removes the tunnel
+
+	Replicas       []DataSourceReplicaRequest `json:"replicas"` // replaces the replicas when set; an empty list removes them
+	ReplicaRouting *ReplicaRouting            `json:"replica_routing"`
 }
 
 // DataSourceResponse represents the response body for a datasource (without password)
@@ -83,6 +188,19 @@ type DataSourceResponse struct {
 	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Options     DataSourceOptions      `json:"options,omitempty"`
+	SessionInit []string               `json:"session_init,omitempty"`
+	Concurrency ConcurrencyConfig      `json:"concurrency"`
+	TLS         *DataSourceTLSResponse `json:"tls,omitempty"`
+	SSHTunnel   *SSHTunnelResponse     `json:"ssh_tunnel,omitempty"`
+
+	Replicas       []DataSourceReplicaResponse `json:"replicas,omitempty"`
+	ReplicaRouting ReplicaRouting              `json:"replica_routing"`
+
+	HealthStatus      string     `json:"health_status"`
+	HealthError       string     `json:"health_error,omitempty"`
+	LastHealthCheckAt *time.Time `json:"last_health_check_at"`
 }
 
 // ToResponse converts DataSource to DataSourceResponse
@@ -101,28 +219,84 @@ func (ds *DataSource) ToResponse() *DataSourceResponse {
 		Status:      ds.Status,
 		CreatedAt:   ds.CreatedAt,
 		UpdatedAt:   ds.UpdatedAt,
+
+		Options:     ds.Options,
+		SessionInit: ds.SessionInit,
+		Concurrency: ds.Concurrency,
+		TLS:         ds.TLS.ToResponse(),
+		SSHTunnel:   ds.SSHTunnel.ToResponse(),
+
+		Replicas:       ds.Replicas.ToResponse(),
+		ReplicaRouting: ds.ReplicaRouting,
+
+		HealthStatus:      ds.HealthStatus,
+		HealthError:       ds.HealthError,
+		LastHealthCheckAt: ds.LastHealthCheckAt,
 	}
 }
 
 // TestConnectionResult represents the result of a connection test
 type TestConnectionResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Latency int64  `json:"latency_ms"`
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Latency int64                   `json:"latency_ms"`
+	Hop     string                  `json:"hop,omitempty"` // bastion, target or database when an SSH tunneled connection failed
+	Stages  []ConnectionStageResult `json:"stages,omitempty"`
+}
+
+// ConnectionStageResult represents one check of a connection test: dns, tcp,
+// ssh_tunnel, tls, authentication, database, server_version or privileges
+type ConnectionStageResult struct {
+	Stage   string            `json:"stage"`
+	Status  string            `json:"status"` // passed, failed, warning or skipped
+	Latency int64             `json:"latency_ms"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"` // e.g. resolved addresses or the server certificate
+}
+
+// UploadDataFileResponse represents the stored location of an uploaded data file
+type UploadDataFileResponse struct {
+	Path string `json:"path"` // database of a file datasource reading the upload
+	Size int64  `json:"size"`
 }
 
 // TableInfoResponse represents table information from a datasource
 type TableInfoResponse struct {
-	Name     string               `json:"name"`
-	Schema   string               `json:"schema"`
-	RowCount int64                `json:"row_count,omitempty"`
-	Columns  []ColumnInfoResponse `json:"columns,omitempty"`
+	Name        string                   `json:"name"`
+	Schema      string                   `json:"schema"`
+	Kind        string                   `json:"kind"` // table, view or materialized_view
+	Comment     string                   `json:"comment,omitempty"`
+	RowCount    int64                    `json:"row_count,omitempty"`
+	RowEstimate *int64                   `json:"row_estimate,omitempty"` // from the database's statistics, absent when it has none
+	Columns     []ColumnInfoResponse     `json:"columns,omitempty"`
+	ForeignKeys []ForeignKeyInfoResponse `json:"foreign_keys,omitempty"`
+	Indexes     []IndexInfoResponse      `json:"indexes,omitempty"`
 }
 
 // ColumnInfoResponse represents column information
 type ColumnInfoResponse struct {
-	Name       string `json:"name"`
-	Type       string `json:"type"`
-	Nullable   bool   `json:"nullable"`
-	PrimaryKey bool   `json:"primary_key"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Nullable   bool    `json:"nullable"`
+	PrimaryKey bool    `json:"primary_key"`
+	Default    *string `json:"default,omitempty"`
+	Comment    string  `json:"comment,omitempty"`
+	ParamType  string  `json:"param_type"` // suggested parameter type for filtering on the column
+}
+
+// ForeignKeyInfoResponse represents a foreign key of a table
+type ForeignKeyInfoResponse struct {
+	Name              string   `json:"name,omitempty"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referenced_schema"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+// IndexInfoResponse represents an index of a table
+type IndexInfoResponse struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
 }
@@ -0,0 +1,59 @@
+package model
+
+// DataSourceDependentsResponse represents what depends on a datasource: its
+// queries, the tools built on them and the MCP servers exposing the tools
+type DataSourceDependentsResponse struct {
+	DataSourceID string           `json:"datasource_id"`
+	Queries      []DependentQuery `json:"queries"`
+
+	QueryCount           int `json:"query_count"`
+	ToolCount            int `json:"tool_count"`
+	ServerCount          int `json:"server_count"`
+	PublishedServerCount int `json:"published_server_count"`
+}
+
+// DependentQuery represents a query of a datasource and its tools
+type DependentQuery struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Tables []string        `json:"tables"` // read by the query's SQL template
+	Tools  []DependentTool `json:"tools"`
+}
+
+// DependentTool represents a tool built on a dependent query and the servers exposing it
+type DependentTool struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Status  string            `json:"status"`
+	Servers []DependentServer `json:"servers"`
+}
+
+// DependentServer represents an MCP server exposing a dependent tool
+type DependentServer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // draft, published or archived
+}
+
+// RepointDependentsRequest represents the request body for moving the queries
+// of a datasource, and so their tools, to another datasource
+type RepointDependentsRequest struct {
+	TargetDataSourceID string `json:"target_datasource_id" binding:"required"`
+	DryRun             bool   `json:"dry_run"` // check the target without repointing
+}
+
+// RepointDependentsResponse represents the outcome of repointing. Nothing is
+// repointed unless every table the queries read exists on the target.
+type RepointDependentsResponse struct {
+	Repointed bool             `json:"repointed"`
+	Message   string           `json:"message"`
+	Queries   []RepointedQuery `json:"queries"`
+}
+
+// RepointedQuery represents a query checked against the target datasource
+type RepointedQuery struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Tables        []string `json:"tables"`
+	MissingTables []string `json:"missing_tables,omitempty"` // tables the target does not have
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// DataSourceHealthStatus represents the reachability of a datasource
+type DataSourceHealthStatus string
+
+const (
+	DataSourceHealthUnknown   DataSourceHealthStatus = "unknown"
+	DataSourceHealthHealthy   DataSourceHealthStatus = "healthy"
+	DataSourceHealthUnhealthy DataSourceHealthStatus = "unhealthy"
+)
+
+// McpServerHealthStatus summarizes the health of the datasources behind an MCP server
+type McpServerHealthStatus string
+
+const (
+	McpServerHealthy   McpServerHealthStatus = "healthy"
+	McpServerDegraded  McpServerHealthStatus = "degraded"
+	McpServerUnhealthy McpServerHealthStatus = "unhealthy"
+)
+
+// DataSourceHealthCheck records the outcome of a single health probe
+type DataSourceHealthCheck struct {
+	ID           string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DataSourceID string    `gorm:"type:uuid;not null;index:idx_ds_health_checked" json:"data_source_id"`
+	Status       string    `gorm:"size:20;not null" json:"status"`
+	LatencyMs    int64     `json:"latency_ms"`
+	ErrorMessage string    `gorm:"type:text" json:"error_message,omitempty"`
+	CheckedAt    time.Time `gorm:"not null;index:idx_ds_health_checked" json:"checked_at"`
+}
+
+func (DataSourceHealthCheck) TableName() string {
+	return "data_source_health_checks"
+}
+
+// DataSourceHealthResponse describes the health of a datasource a server depends on
+type DataSourceHealthResponse struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	CircuitState  string     `json:"circuit_state"`
+	Tools         []string   `json:"tools"`
+}
+
+// McpServerHealthResponse describes the health of an MCP server and its datasources
+type McpServerHealthResponse struct {
+	Status      string                     `json:"status"`
+	ServerID    string                     `json:"server_id"`
+	ToolsCount  int                        `json:"tools_count,omitempty"`  // authenticated callers only
+	DataSources []DataSourceHealthResponse `json:"data_sources,omitempty"` // authenticated callers only
+	Timestamp   int64                      `json:"timestamp"`
+}
+
+// DataSourceHealthHistoryResponse describes the current health of a datasource and its recent checks
+type DataSourceHealthHistoryResponse struct {
+	Status        string                  `json:"status"`
+	Error         string                  `json:"error,omitempty"`
+	LastCheckedAt *time.Time              `json:"last_checked_at"`
+	Checks        []DataSourceHealthCheck `json:"checks"`
+}
@@ -0,0 +1,43 @@
+package model
+
+// DataSourceTLS holds the certificates of TLS connections to a datasource,
+// stored encrypted. Whether and how the server is verified is set by SSLMode.
+type DataSourceTLS struct {
+	CACert     string `gorm:"type:text" json:"-"`
+	ClientCert string `gorm:"type:text" json:"-"`
+	ClientKey  string `gorm:"type:text" json:"-"`
+	ServerName string `gorm:"size:255" json:"server_name"` // verify the server certificate against this name instead of the host
+}
+
+// IsEmpty reports whether no TLS settings are stored
+func (t DataSourceTLS) IsEmpty() bool {
+	return t == DataSourceTLS{}
+}
+
+// DataSourceTLSRequest represents the TLS settings of a datasource request.
+// Fields left out keep their stored value and empty strings clear it.
+type DataSourceTLSRequest struct {
+	CACert     *string `json:"ca_cert"`     // PEM encoded CA bundle; the system roots are trusted when empty
+	ClientCert *string `json:"client_cert"` // PEM encoded client certificate for mutual TLS
+	ClientKey  *string `json:"client_key"`  // PEM encoded key of the client certificate
+	ServerName *string `json:"server_name"`
+}
+
+// DataSourceTLSResponse represents the TLS settings of a datasource without its certificates
+type DataSourceTLSResponse struct {
+	HasCACert     bool   `json:"has_ca_cert"`
+	HasClientCert bool   `json:"has_client_cert"`
+	ServerName    string `json:"server_name,omitempty"`
+}
+
+// ToResponse converts DataSourceTLS to DataSourceTLSResponse, nil when no settings are stored
+func (t DataSourceTLS) ToResponse() *DataSourceTLSResponse {
+	if t.IsEmpty() {
+		return nil
+	}
+	return &DataSourceTLSResponse{
+		HasCACert:     t.CACert != "",
+		HasClientCert: t.ClientCert != "",
+		ServerName:    t.ServerName,
+	}
+}
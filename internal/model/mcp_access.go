@@ -0,0 +1,99 @@
+package model
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AccessConfig restricts which network clients may reach a published MCP server.
+// An empty allowlist accepts every address; client certificate subjects are
+// only considered when the server listens with TLS client authentication.
+type AccessConfig struct {
+	AllowedCIDRs       []string `json:"allowed_cidrs"`
+	ClientCertSubjects []string `json:"client_cert_subjects"`
+	RequireClientCert  bool     `json:"require_client_cert"`
+}
+
+// Validate checks that every allowlist entry is a valid CIDR or IP address
+func (a AccessConfig) Validate() error {
+	for _, entry := range a.AllowedCIDRs {
+		if _, err := parseCIDR(entry); err != nil {
+			return err
+		}
+	}
+	if a.RequireClientCert && len(a.ClientCertSubjects) == 0 {
+		return fmt.Errorf("client_cert_subjects is required when require_client_cert is enabled")
+	}
+	return nil
+}
+
+// AllowsIP reports whether the client IP is permitted by the allowlist
+func (a AccessConfig) AllowsIP(clientIP string) bool {
+	if len(a.AllowedCIDRs) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range a.AllowedCIDRs {
+		network, err := parseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsClientCert reports whether the certificate subject is mapped to this server.
+// Entries may be the full distinguished name, "CN=<name>" or the bare common name.
+func (a AccessConfig) AcceptsClientCert(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+
+	subject := cert.Subject.String()
+	commonName := cert.Subject.CommonName
+	for _, entry := range a.ClientCertSubjects {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.EqualFold(entry, subject) ||
+			strings.EqualFold(entry, "CN="+commonName) ||
+			strings.EqualFold(entry, commonName) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDR parses a CIDR block, treating a bare IP address as a single host
+func parseCIDR(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address in allowlist: %s", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR in allowlist: %s", entry)
+	}
+	return network, nil
+}
@@ -0,0 +1,92 @@
+package model
+
+import "time"
+
+// McpLogFilter narrows down MCP call logs. Zero values do not filter.
+type McpLogFilter struct {
+	Start         *time.Time
+	End           *time.Time
+	ToolName      string
+	Status        string
+	MinResponseMs int64
+	ErrorText     string
+	ApiKey        string // full key or its prefix
+}
+
+// LogExportFormat represents a file format for exported logs
+type LogExportFormat string
+
+const (
+	LogExportFormatCSV    LogExportFormat = "csv"
+	LogExportFormatNDJSON LogExportFormat = "ndjson"
+)
+
+// RetentionConfig limits how long MCP call logs are kept for a server.
+// Logs older than MaxAgeDays or beyond the newest MaxRows are deleted;
+// zero disables the respective limit. When AggregateDays is set, deleted
+// logs are first rolled up into hourly aggregates kept for that many days.
+type RetentionConfig struct {
+	MaxAgeDays    int   `json:"max_age_days" binding:"min=0"`
+	MaxRows       int64 `json:"max_rows" binding:"min=0"`
+	AggregateDays int   `json:"aggregate_days" binding:"min=0"`
+}
+
+// IsUnlimited reports whether logs are kept forever
+func (r RetentionConfig) IsUnlimited() bool {
+	return r.MaxAgeDays == 0 && r.MaxRows == 0
+}
+
+// McpLogAggregate is an hourly rollup of MCP call logs kept after the raw
+// logs have been removed by the retention policy
+type McpLogAggregate struct {
+	ID              string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	McpServerID     string    `gorm:"type:uuid;not null;uniqueIndex:idx_mcp_log_aggregate_bucket" json:"mcp_server_id"`
+	ToolName        string    `gorm:"size:100;not null;uniqueIndex:idx_mcp_log_aggregate_bucket" json:"tool_name"`
+	Hour            time.Time `gorm:"not null;uniqueIndex:idx_mcp_log_aggregate_bucket" json:"hour"`
+	CallCount       int64     `gorm:"default:0" json:"call_count"`
+	SuccessCount    int64     `gorm:"default:0" json:"success_count"`
+	ErrorCount      int64     `gorm:"default:0" json:"error_count"`
+	TotalResponseMs int64     `gorm:"default:0" json:"total_response_ms"`
+	MaxResponseMs   int64     `gorm:"default:0" json:"max_response_ms"`
+	TotalRows       int64     `gorm:"default:0" json:"total_rows"`
+}
+
+func (McpLogAggregate) TableName() string {
+	return "mcp_log_aggregates"
+}
+
+// McpLogAggregateResponse represents the response body for an hourly log aggregate
+type McpLogAggregateResponse struct {
+	ToolName      string    `json:"tool_name"`
+	Hour          time.Time `json:"hour"`
+	CallCount     int64     `json:"call_count"`
+	SuccessCount  int64     `json:"success_count"`
+	ErrorCount    int64     `json:"error_count"`
+	AvgResponseMs float64   `json:"avg_response_ms"`
+	MaxResponseMs int64     `json:"max_response_ms"`
+	TotalRows     int64     `json:"total_rows"`
+}
+
+// ToResponse converts McpLogAggregate to McpLogAggregateResponse
+func (a *McpLogAggregate) ToResponse() *McpLogAggregateResponse {
+	resp := &McpLogAggregateResponse{
+		ToolName:      a.ToolName,
+		Hour:          a.Hour,
+		CallCount:     a.CallCount,
+		SuccessCount:  a.SuccessCount,
+		ErrorCount:    a.ErrorCount,
+		MaxResponseMs: a.MaxResponseMs,
+		TotalRows:     a.TotalRows,
+	}
+	if a.CallCount > 0 {
+		resp.AvgResponseMs = float64(a.TotalResponseMs) / float64(a.CallCount)
+	}
+	return resp
+}
+
+// RetentionResult reports what a retention run removed for a server
+type RetentionResult struct {
+	ServerID          string `json:"server_id"`
+	DeletedLogs       int64  `json:"deleted_logs"`
+	DeletedAggregates int64  `json:"deleted_aggregates"`
+}
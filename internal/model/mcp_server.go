@@ -50,10 +50,15 @@ func (s *StringArray) Scan(value interface{}) error {
 
 // ServerConfig represents MCP server configuration
 type ServerConfig struct {
-	TimeoutSeconds  int    `json:"timeout_seconds"`
-	RateLimitPerMin int    `json:"rate_limit_per_min"`
-	LogLevel        string `json:"log_level"`
-	EnableCaching   bool   `json:"enable_caching"`
+	TimeoutSeconds       int               `json:"timeout_seconds"`
+	RateLimitPerMin      int               `json:"rate_limit_per_min"`
+	LogLevel             string            `json:"log_level"`
+	EnableCaching        bool              `json:"enable_caching"`
+	BlockPublishOnReview bool              `json:"block_publish_on_review"` // refuse to publish while a tool needs review after a schema change
+	Quota                QuotaConfig       `json:"quota"`
+	Access               AccessConfig      `json:"access"`
+	Retention            RetentionConfig   `json:"retention"`
+	Concurrency          ConcurrencyConfig `json:"concurrency"`
 }
 
 // ServerConfigJSON is a custom type for storing ServerConfig in the database
@@ -133,6 +138,18 @@ func GenerateApiKey() (string, error) {
 	return "sk_live_" + hex.EncodeToString(bytes), nil
 }
 
+// apiKeyPrefixLength covers the "sk_live_" marker plus 8 random characters,
+// enough to tell keys apart in logs without storing the secret
+const apiKeyPrefixLength = 16
+
+// ApiKeyPrefix returns the non-secret prefix of an API key recorded in call logs
+func ApiKeyPrefix(apiKey string) string {
+	if len(apiKey) <= apiKeyPrefixLength {
+		return apiKey
+	}
+	return apiKey[:apiKeyPrefixLength]
+}
+
 // GenerateEndpoint generates the endpoint URL for the MCP server
 func GenerateEndpoint(serverID, baseURL string) string {
 	return fmt.Sprintf("%s/mcp/%s", baseURL, serverID)
@@ -146,6 +163,22 @@ const (
 	McpLogStatusError   McpLogStatus = "error"
 )
 
+// McpErrorClass categorizes why a tool call failed
+type McpErrorClass string
+
+const (
+	McpErrorClassQueryNotFound      McpErrorClass = "query_not_found"
+	McpErrorClassInvalidParameters  McpErrorClass = "invalid_parameters"
+	McpErrorClassDataSourceNotFound McpErrorClass = "datasource_not_found"
+	McpErrorClassCredentials        McpErrorClass = "credentials"
+	McpErrorClassConnection         McpErrorClass = "connection"
+	McpErrorClassExecution          McpErrorClass = "execution"
+	McpErrorClassCircuitOpen        McpErrorClass = "circuit_open"
+	McpErrorClassBusy               McpErrorClass = "busy"
+	McpErrorClassCancelled          McpErrorClass = "cancelled"
+	McpErrorClassNoReplica          McpErrorClass = "no_replica"
+)
+
 // McpLogParameters is a custom type for storing log parameters
 type McpLogParameters map[string]interface{}
 
@@ -190,9 +223,14 @@ type McpLog struct {
 	ToolName       string           `gorm:"size:100" json:"tool_name"`
 	Parameters     McpLogParameters `gorm:"type:jsonb" json:"parameters"`
 	ResponseTimeMs int64            `gorm:"default:0" json:"response_time_ms"`
+	QueueWaitMs    int64            `gorm:"default:0" json:"queue_wait_ms"` // time spent waiting for a server or datasource slot
 	Status         string           `gorm:"size:20" json:"status"`
 	ErrorMessage   string           `gorm:"type:text" json:"error_message"`
+	ErrorClass     string           `gorm:"size:50" json:"error_class"`
 	RowCount       int              `gorm:"default:0" json:"row_count"`
+	RoutedTo       string           `gorm:"size:100" json:"routed_to,omitempty"` // primary or the replica the query ran on
+	TraceID        string           `gorm:"size:32;index" json:"trace_id"`
+	ApiKeyPrefix   string           `gorm:"size:16" json:"api_key_prefix"`
 	Timestamp      time.Time        `gorm:"index" json:"timestamp"`
 }
 
@@ -312,9 +350,13 @@ type McpLogResponse struct {
 	ToolName       string                 `json:"tool_name"`
 	Parameters     map[string]interface{} `json:"parameters"`
 	ResponseTimeMs int64                  `json:"response_time_ms"`
+	QueueWaitMs    int64                  `json:"queue_wait_ms"`
 	Status         string                 `json:"status"`
 	ErrorMessage   string                 `json:"error_message,omitempty"`
+	ErrorClass     string                 `json:"error_class,omitempty"`
 	RowCount       int                    `json:"row_count"`
+	TraceID        string                 `json:"trace_id,omitempty"`
+	ApiKeyPrefix   string                 `json:"api_key_prefix,omitempty"`
 	Timestamp      time.Time              `json:"timestamp"`
 }
 
@@ -332,9 +374,13 @@ func (l *McpLog) ToResponse() *McpLogResponse {
 		ToolName:       l.ToolName,
 		Parameters:     params,
 		ResponseTimeMs: l.ResponseTimeMs,
+		QueueWaitMs:    l.QueueWaitMs,
 		Status:         l.Status,
 		ErrorMessage:   l.ErrorMessage,
+		ErrorClass:     l.ErrorClass,
 		RowCount:       l.RowCount,
+		TraceID:        l.TraceID,
+		ApiKeyPrefix:   l.ApiKeyPrefix,
 		Timestamp:      l.Timestamp,
 	}
 }
@@ -371,6 +417,11 @@ const (
 	McpErrorCodeMethodNotFound = -32601
 	McpErrorCodeInvalidParams  = -32602
 	McpErrorCodeInternalError  = -32603
+
+	// Implementation-defined server errors (-32000 to -32099)
+	McpErrorCodeQuotaExceeded = -32001
+	McpErrorCodeBusy          = -32002
+	McpErrorCodeCancelled     = -32003
 )
 
 // McpToolCallParams represents parameters for tools/call method
@@ -0,0 +1,129 @@
+package model
+
+import "time"
+
+// QuotaLimits caps the usage of an MCP server within a single quota period.
+// A zero value means the metric is unlimited.
+type QuotaLimits struct {
+	Calls       int64 `json:"calls" binding:"min=0"`
+	Rows        int64 `json:"rows" binding:"min=0"`
+	ExecutionMs int64 `json:"execution_ms" binding:"min=0"`
+}
+
+// IsUnlimited reports whether no limit is configured
+func (l QuotaLimits) IsUnlimited() bool {
+	return l.Calls == 0 && l.Rows == 0 && l.ExecutionMs == 0
+}
+
+// Exceeded returns the name of the first limit reached by the given usage,
+// or an empty string if the usage is still within all limits
+func (l QuotaLimits) Exceeded(usage *McpUsageCounter) string {
+	switch {
+	case l.Calls > 0 && usage.Calls >= l.Calls:
+		return "calls"
+	case l.Rows > 0 && usage.Rows >= l.Rows:
+		return "rows"
+	case l.ExecutionMs > 0 && usage.ExecutionMs >= l.ExecutionMs:
+		return "execution_ms"
+	default:
+		return ""
+	}
+}
+
+// QuotaConfig represents the daily and monthly usage quotas of an MCP server.
+// Since every server has exactly one API key, quotas apply per API key as well.
+type QuotaConfig struct {
+	Daily   QuotaLimits `json:"daily"`
+	Monthly QuotaLimits `json:"monthly"`
+}
+
+// UsagePeriod represents the granularity of a usage counter
+type UsagePeriod string
+
+const (
+	UsagePeriodDay   UsagePeriod = "day"
+	UsagePeriodMonth UsagePeriod = "month"
+)
+
+// PeriodBounds returns the UTC start and end of the period containing t
+func (p UsagePeriod) PeriodBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	switch p {
+	case UsagePeriodMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// McpUsageCounter accumulates the usage of an MCP server within a quota period.
+// Counters are maintained alongside McpLog so quota checks never scan the logs.
+type McpUsageCounter struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	McpServerID string    `gorm:"type:uuid;not null;uniqueIndex:idx_mcp_usage_period" json:"mcp_server_id"`
+	Period      string    `gorm:"size:10;not null;uniqueIndex:idx_mcp_usage_period" json:"period"`
+	PeriodStart time.Time `gorm:"not null;uniqueIndex:idx_mcp_usage_period" json:"period_start"`
+	Calls       int64     `gorm:"default:0" json:"calls"`
+	Rows        int64     `gorm:"default:0" json:"rows"`
+	ExecutionMs int64     `gorm:"default:0" json:"execution_ms"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (McpUsageCounter) TableName() string {
+	return "mcp_usage_counters"
+}
+
+// UsageMetric represents consumption of a single metric against its limit
+type UsageMetric struct {
+	Used        int64   `json:"used"`
+	Limit       int64   `json:"limit"`
+	Remaining   int64   `json:"remaining"`
+	PercentUsed float64 `json:"percent_used"`
+}
+
+// NewUsageMetric builds a UsageMetric; a zero limit means unlimited
+func NewUsageMetric(used, limit int64) UsageMetric {
+	metric := UsageMetric{Used: used, Limit: limit, Remaining: -1}
+	if limit > 0 {
+		metric.Remaining = limit - used
+		if metric.Remaining < 0 {
+			metric.Remaining = 0
+		}
+		metric.PercentUsed = float64(used) / float64(limit) * 100
+	}
+	return metric
+}
+
+// PeriodUsageResponse represents usage within a quota period
+type PeriodUsageResponse struct {
+	Period      string      `json:"period"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	Exceeded    bool        `json:"exceeded"`
+	Calls       UsageMetric `json:"calls"`
+	Rows        UsageMetric `json:"rows"`
+	ExecutionMs UsageMetric `json:"execution_ms"`
+}
+
+// NewPeriodUsageResponse builds a PeriodUsageResponse from a counter and its limits
+func NewPeriodUsageResponse(period UsagePeriod, usage *McpUsageCounter, limits QuotaLimits) PeriodUsageResponse {
+	start, end := period.PeriodBounds(usage.PeriodStart)
+	return PeriodUsageResponse{
+		Period:      string(period),
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Exceeded:    limits.Exceeded(usage) != "",
+		Calls:       NewUsageMetric(usage.Calls, limits.Calls),
+		Rows:        NewUsageMetric(usage.Rows, limits.Rows),
+		ExecutionMs: NewUsageMetric(usage.ExecutionMs, limits.ExecutionMs),
+	}
+}
+
+// McpUsageResponse represents the usage of an MCP server against its quotas
+type McpUsageResponse struct {
+	ServerID string              `json:"server_id"`
+	Daily    PeriodUsageResponse `json:"daily"`
+	Monthly  PeriodUsageResponse `json:"monthly"`
+}
@@ -198,6 +198,7 @@ type QueryExecution struct {
 	ExecutionTimeMs int64     `json:"execution_time_ms"`
 	Status          string    `gorm:"size:20;not null" json:"status"` // success, error
 	ErrorMessage    string    `gorm:"type:text" json:"error_message,omitempty"`
+	TraceID         string    `gorm:"size:32;index" json:"trace_id,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 
 	Query Query `gorm:"foreignKey:QueryID" json:"query,omitempty"`
@@ -217,5 +218,6 @@ type QueryExecutionResponse struct {
 	ExecutionTimeMs int64                  `json:"execution_time_ms"`
 	Status          string                 `json:"status"`
 	ErrorMessage    string                 `json:"error_message,omitempty"`
+	TraceID         string                 `json:"trace_id,omitempty"`
 	CreatedAt       time.Time              `json:"created_at"`
 }
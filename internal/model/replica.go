@@ -0,0 +1,101 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// DataSourceReplica is a read replica of a datasource. It shares the
+// datasource's database, SSL mode, TLS and SSH tunnel settings, and its
+// credentials unless it sets a username. Password is stored encrypted.
+type DataSourceReplica struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Weight   int    `json:"weight"` // share of the reads among healthy replicas; 0 takes none
+}
+
+// DataSourceReplicas is a custom type for storing the replicas of a datasource in the database
+type DataSourceReplicas []DataSourceReplica
+
+// Value implements driver.Valuer interface
+func (r DataSourceReplicas) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner interface
+func (r *DataSourceReplicas) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("failed to scan DataSourceReplicas")
+	}
+
+	if len(bytes) == 0 {
+		*r = nil
+		return nil
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// ReplicaRouting says how reads of a datasource with replicas are routed
+type ReplicaRouting struct {
+	MaxLagSeconds     int  `gorm:"default:0" json:"max_lag_seconds"`         // replicas lagging further behind take no reads; 0 does not check lag
+	FallbackToPrimary bool `gorm:"default:false" json:"fallback_to_primary"` // read from the primary when no replica is healthy
+}
+
+// DataSourceReplicaRequest represents a replica of a datasource request.
+// On update, a password left empty keeps the stored password of the replica
+// with the same name.
+type DataSourceReplicaRequest struct {
+	Name     string `json:"name"` // defaults to host:port
+	Host     string `json:"host"`
+	Port     int    `json:"port"` // defaults to the datasource's port
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Weight   *int   `json:"weight"` // defaults to 1
+}
+
+// DataSourceReplicaResponse represents a replica of a datasource without its password
+type DataSourceReplicaResponse struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Weight   int    `json:"weight"`
+}
+
+// ToResponse converts DataSourceReplicas to their responses, nil when there are none
+func (r DataSourceReplicas) ToResponse() []DataSourceReplicaResponse {
+	if len(r) == 0 {
+		return nil
+	}
+
+	replicas := make([]DataSourceReplicaResponse, len(r))
+	for i, replica := range r {
+		replicas[i] = DataSourceReplicaResponse{
+			Name:     replica.Name,
+			Host:     replica.Host,
+			Port:     replica.Port,
+			Username: replica.Username,
+			Weight:   replica.Weight,
+		}
+	}
+	return replicas
+}
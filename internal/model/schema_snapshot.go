@@ -0,0 +1,173 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SchemaChangeKind describes how a table or column changed between two schema snapshots
+type SchemaChangeKind string
+
+const (
+	SchemaChangeTableAdded        SchemaChangeKind = "table_added"
+	SchemaChangeTableDropped      SchemaChangeKind = "table_dropped"
+	SchemaChangeColumnAdded       SchemaChangeKind = "column_added"
+	SchemaChangeColumnDropped     SchemaChangeKind = "column_dropped"
+	SchemaChangeColumnTypeChanged SchemaChangeKind = "column_type_changed"
+	SchemaChangeColumnRenamed     SchemaChangeKind = "column_renamed"
+)
+
+// Breaking reports whether the change can break a query that uses the changed object
+func (k SchemaChangeKind) Breaking() bool {
+	return k != SchemaChangeTableAdded && k != SchemaChangeColumnAdded
+}
+
+// SchemaChange describes a single difference between two schema snapshots
+type SchemaChange struct {
+	Kind      SchemaChangeKind `json:"kind"`
+	Schema    string           `json:"schema"`
+	Table     string           `json:"table"`
+	Column    string           `json:"column,omitempty"`
+	NewColumn string           `json:"new_column,omitempty"` // set for renamed columns
+	OldType   string           `json:"old_type,omitempty"`
+	NewType   string           `json:"new_type,omitempty"`
+}
+
+// SchemaTables is a custom type for storing introspected tables in the database
+type SchemaTables []TableInfoResponse
+
+// Value implements driver.Valuer interface
+func (t SchemaTables) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan implements sql.Scanner interface
+func (t *SchemaTables) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("failed to scan SchemaTables")
+	}
+
+	if len(bytes) == 0 {
+		*t = nil
+		return nil
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+// SchemaChanges is a custom type for storing schema changes in the database
+type SchemaChanges []SchemaChange
+
+// Value implements driver.Valuer interface
+func (c SchemaChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner interface
+func (c *SchemaChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("failed to scan SchemaChanges")
+	}
+
+	if len(bytes) == 0 {
+		*c = nil
+		return nil
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// SchemaSnapshot is a versioned copy of the schema of a datasource. A new
+// version is only stored when the schema differs from the previous one.
+type SchemaSnapshot struct {
+	ID               string        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DataSourceID     string        `gorm:"type:uuid;not null;uniqueIndex:idx_schema_snapshot_version" json:"data_source_id"`
+	Version          int           `gorm:"not null;uniqueIndex:idx_schema_snapshot_version" json:"version"`
+	Tables           SchemaTables  `gorm:"type:jsonb" json:"tables"`
+	Checksum         string        `gorm:"size:64;not null" json:"checksum"`
+	Changes          SchemaChanges `gorm:"type:jsonb" json:"changes"`            // differences from the previous version
+	AffectedQueryIDs StringArray   `gorm:"type:jsonb" json:"affected_query_ids"` // queries whose SQL references a changed object
+	AffectedToolIDs  StringArray   `gorm:"type:jsonb" json:"affected_tool_ids"`  // tools flagged for review because of the changes
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+func (SchemaSnapshot) TableName() string {
+	return "schema_snapshots"
+}
+
+// SchemaSnapshotResponse represents the response body for a schema snapshot
+type SchemaSnapshotResponse struct {
+	ID               string              `json:"id"`
+	DataSourceID     string              `json:"data_source_id"`
+	Version          int                 `json:"version"`
+	Checksum         string              `json:"checksum"`
+	Tables           []TableInfoResponse `json:"tables,omitempty"`
+	Changes          []SchemaChange      `json:"changes"`
+	AffectedQueryIDs []string            `json:"affected_query_ids"`
+	AffectedToolIDs  []string            `json:"affected_tool_ids"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+// ToResponse converts SchemaSnapshot to SchemaSnapshotResponse
+func (s *SchemaSnapshot) ToResponse() *SchemaSnapshotResponse {
+	changes := []SchemaChange(s.Changes)
+	if changes == nil {
+		changes = []SchemaChange{}
+	}
+	queryIDs := []string(s.AffectedQueryIDs)
+	if queryIDs == nil {
+		queryIDs = []string{}
+	}
+	toolIDs := []string(s.AffectedToolIDs)
+	if toolIDs == nil {
+		toolIDs = []string{}
+	}
+
+	return &SchemaSnapshotResponse{
+		ID:               s.ID,
+		DataSourceID:     s.DataSourceID,
+		Version:          s.Version,
+		Checksum:         s.Checksum,
+		Tables:           s.Tables,
+		Changes:          changes,
+		AffectedQueryIDs: queryIDs,
+		AffectedToolIDs:  toolIDs,
+		CreatedAt:        s.CreatedAt,
+	}
+}
+
+// RefreshSchemaResponse represents the outcome of refreshing the schema of a datasource
+type RefreshSchemaResponse struct {
+	Changed  bool                    `json:"changed"` // false when the schema matches the latest snapshot
+	Snapshot *SchemaSnapshotResponse `json:"snapshot"`
+}
@@ -0,0 +1,55 @@
+package model
+
+// SSHTunnelConfig routes connections to a datasource through an SSH bastion.
+// Password, PrivateKey and Passphrase are stored encrypted.
+type SSHTunnelConfig struct {
+	Enabled    bool   `gorm:"default:false" json:"enabled"`
+	Host       string `gorm:"size:255" json:"host"`
+	Port       int    `json:"port"`
+	User       string `gorm:"size:100" json:"user"`
+	Password   string `gorm:"size:500" json:"-"`
+	PrivateKey string `gorm:"type:text" json:"-"`
+	Passphrase string `gorm:"size:500" json:"-"`
+	KnownHosts string `gorm:"type:text" json:"known_hosts"` // known_hosts lines the bastion's host key must match
+}
+
+// SSHTunnelRequest represents the SSH tunnel settings of a datasource request.
+// On update, secrets left empty keep their stored values.
+type SSHTunnelRequest struct {
+	Enabled    bool   `json:"enabled"`
+	Host       string `json:"host"`
+	Port       int    `json:"port"` // defaults to 22
+	User       string `json:"user"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key"` // PEM encoded
+	Passphrase string `json:"passphrase"`  // of an encrypted private key
+	KnownHosts string `json:"known_hosts"`
+}
+
+// SSHTunnelResponse represents the SSH tunnel of a datasource without its secrets
+type SSHTunnelResponse struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	User       string `json:"user"`
+	AuthMethod string `json:"auth_method"` // private_key or password
+	KnownHosts string `json:"known_hosts"`
+}
+
+// ToResponse converts SSHTunnelConfig to SSHTunnelResponse, nil when the tunnel is disabled
+func (t SSHTunnelConfig) ToResponse() *SSHTunnelResponse {
+	if !t.Enabled {
+		return nil
+	}
+
+	authMethod := "password"
+	if t.PrivateKey != "" {
+		authMethod = "private_key"
+	}
+	return &SSHTunnelResponse{
+		Host:       t.Host,
+		Port:       t.Port,
+		User:       t.User,
+		AuthMethod: authMethod,
+		KnownHosts: t.KnownHosts,
+	}
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ColumnProfile summarizes the values of one column of a profiled table
+type ColumnProfile struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	NullCount     int64             `json:"null_count"`
+	NullRatio     float64           `json:"null_ratio"`
+	DistinctCount int64             `json:"distinct_count"`
+	Min           interface{}       `json:"min,omitempty"`
+	Max           interface{}       `json:"max,omitempty"`
+	TopValues     []ValueCount      `json:"top_values,omitempty"`
+	Histogram     []HistogramBucket `json:"histogram,omitempty"` // numeric columns only
+	Error         string            `json:"error,omitempty"`     // the first aggregate the database rejected for this column
+}
+
+// ValueCount is a value and the number of rows holding it
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+// HistogramBucket counts the values in [lower, upper), or [lower, upper] for the last bucket
+type HistogramBucket struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int64   `json:"count"`
+}
+
+// ColumnProfiles is a custom type for storing column profiles in the database
+type ColumnProfiles []ColumnProfile
+
+// Value implements driver.Valuer interface
+func (p ColumnProfiles) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements sql.Scanner interface
+func (p *ColumnProfiles) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("failed to scan ColumnProfiles")
+	}
+
+	if len(bytes) == 0 {
+		*p = nil
+		return nil
+	}
+
+	return json.Unmarshal(bytes, p)
+}
+
+// TableProfile stores the latest profile of a datasource table, so tool
+// descriptions can mention the values its columns hold
+type TableProfile struct {
+	ID              string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DataSourceID    string         `gorm:"type:uuid;not null;uniqueIndex:idx_table_profile" json:"data_source_id"`
+	Schema          string         `gorm:"column:schema_name;size:255;not null;uniqueIndex:idx_table_profile" json:"schema"`
+	Table           string         `gorm:"column:table_name;size:255;not null;uniqueIndex:idx_table_profile" json:"table"`
+	RowCount        int64          `json:"row_count"` // rows profiled
+	Sampled         bool           `json:"sampled"`   // the profile read a sample, the table may hold more rows
+	Columns         ColumnProfiles `gorm:"type:jsonb" json:"columns"`
+	ExcludedColumns StringArray    `gorm:"type:jsonb" json:"excluded_columns"` // sensitive columns left out
+	ProfiledAt      time.Time      `gorm:"not null" json:"profiled_at"`
+}
+
+func (TableProfile) TableName() string {
+	return "table_profiles"
+}
+
+// TablePreviewResponse represents a sample of the rows of a table
+type TablePreviewResponse struct {
+	Columns         []string                 `json:"columns"`
+	Rows            []map[string]interface{} `json:"rows"`
+	RowCount        int                      `json:"row_count"`
+	ExcludedColumns []string                 `json:"excluded_columns"` // sensitive columns left out
+}
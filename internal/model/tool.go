@@ -94,6 +94,8 @@ type Tool struct {
 	Version      int            `gorm:"default:1" json:"version"`
 	McpServerID  *string        `gorm:"type:uuid" json:"mcp_server_id,omitempty"`
 	Status       string         `gorm:"size:20;default:'active'" json:"status"`
+	NeedsReview  bool           `gorm:"default:false" json:"needs_review"` // set when a schema change touches the objects its query uses
+	ReviewReason string         `gorm:"type:text" json:"review_reason,omitempty"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -156,6 +158,8 @@ type ToolResponse struct {
 	Version      int                    `json:"version"`
 	McpServerID  *string                `json:"mcp_server_id,omitempty"`
 	Status       string                 `json:"status"`
+	NeedsReview  bool                   `json:"needs_review"`
+	ReviewReason string                 `json:"review_reason,omitempty"`
 	CreatedAt    time.Time              `json:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at"`
 	Query        *QueryInfo             `json:"query,omitempty"`
@@ -192,6 +196,8 @@ func (t *Tool) ToResponse() *ToolResponse {
 		Version:      t.Version,
 		McpServerID:  t.McpServerID,
 		Status:       t.Status,
+		NeedsReview:  t.NeedsReview,
+		ReviewReason: t.ReviewReason,
 		CreatedAt:    t.CreatedAt,
 		UpdatedAt:    t.UpdatedAt,
 	}
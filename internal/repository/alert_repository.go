@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+)
+
+// AlertRepository handles database operations for alert rules and deliveries
+type AlertRepository interface {
+	WithContext(ctx context.Context) AlertRepository
+	Create(rule *model.AlertRule) error
+	FindAll(userID uint, page, size int) ([]model.AlertRule, int64, error)
+	FindByIDAndUserID(id string, userID uint) (*model.AlertRule, error)
+	FindEnabled() ([]model.AlertRule, error)
+	Update(rule *model.AlertRule) error
+	UpdateState(id string, state model.AlertState, value float64, evaluatedAt time.Time, triggeredAt *time.Time) error
+	Delete(id string, userID uint) error
+
+	// Deliveries
+	CreateDelivery(delivery *model.AlertDelivery) error
+	FindDeliveries(ruleID string, page, size int) ([]model.AlertDelivery, int64, error)
+}
+
+type alertRepository struct {
+	db *gorm.DB
+}
+
+// NewAlertRepository creates a new AlertRepository
+func NewAlertRepository(db *gorm.DB) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+// WithContext returns a repository whose queries are bound to ctx
+func (r *alertRepository) WithContext(ctx context.Context) AlertRepository {
+	return &alertRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create creates a new alert rule
+func (r *alertRepository) Create(rule *model.AlertRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+// FindAll returns all alert rules for a user with pagination
+func (r *alertRepository) FindAll(userID uint, page, size int) ([]model.AlertRule, int64, error) {
+	var rules []model.AlertRule
+	var total int64
+
+	offset := (page - 1) * size
+
+	// Count total records
+	if err := r.db.Model(&model.AlertRule{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count alert rules: %w", err)
+	}
+
+	// Get paginated records
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&rules).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to find alert rules: %w", err)
+	}
+
+	return rules, total, nil
+}
+
+// FindByIDAndUserID returns an alert rule by ID and user ID
+func (r *alertRepository) FindByIDAndUserID(id string, userID uint) (*model.AlertRule, error) {
+	var rule model.AlertRule
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlertRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to find alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// FindEnabled returns every enabled alert rule (for the evaluator)
+func (r *alertRepository) FindEnabled() ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	if err := r.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find enabled alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Update updates an alert rule
+func (r *alertRepository) Update(rule *model.AlertRule) error {
+	if err := r.db.Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateState records the outcome of an evaluation without touching user-editable fields
+func (r *alertRepository) UpdateState(id string, state model.AlertState, value float64, evaluatedAt time.Time, triggeredAt *time.Time) error {
+	updates := map[string]interface{}{
+		"state":             string(state),
+		"last_value":        value,
+		"last_evaluated_at": evaluatedAt,
+	}
+	if triggeredAt != nil {
+		updates["last_triggered_at"] = *triggeredAt
+	}
+
+	if err := r.db.Model(&model.AlertRule{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update alert rule state: %w", err)
+	}
+	return nil
+}
+
+// Delete soft deletes an alert rule
+func (r *alertRepository) Delete(id string, userID uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.AlertRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// CreateDelivery records a webhook delivery
+func (r *alertRepository) CreateDelivery(delivery *model.AlertDelivery) error {
+	if err := r.db.Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create alert delivery: %w", err)
+	}
+	return nil
+}
+
+// FindDeliveries returns the deliveries of an alert rule with pagination
+func (r *alertRepository) FindDeliveries(ruleID string, page, size int) ([]model.AlertDelivery, int64, error) {
+	var deliveries []model.AlertDelivery
+	var total int64
+
+	offset := (page - 1) * size
+
+	// Count total records
+	if err := r.db.Model(&model.AlertDelivery{}).Where("alert_rule_id = ?", ruleID).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count alert deliveries: %w", err)
+	}
+
+	// Get paginated records
+	if err := r.db.Where("alert_rule_id = ?", ruleID).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to find alert deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
@@ -1,20 +1,26 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrDataSourceNotFound   = errors.New("datasource not found")
 	ErrDataSourceHasQueries = errors.New("datasource has associated queries")
+	ErrTableProfileNotFound = errors.New("table profile not found")
+	ErrDependentsChanged    = errors.New("datasource dependents changed")
 )
 
 // DataSourceRepository handles database operations for datasources
 type DataSourceRepository interface {
+	WithContext(ctx context.Context) DataSourceRepository
 	Create(ds *model.DataSource) error
 	FindAll(userID uint, page, size int) ([]model.DataSource, int64, error)
 	FindByID(id string) (*model.DataSource, error)
@@ -23,6 +29,22 @@ type DataSourceRepository interface {
 	Delete(id string, userID uint) error
 	Search(userID uint, keyword string, page, size int) ([]model.DataSource, int64, error)
 	HasAssociatedQueries(id string) (bool, error)
+
+	// Dependents
+	FindDependents(id string, userID uint) (*DataSourceDependents, error)
+	RepointQueries(fromID, toID string, userID uint, queries []model.Query, baseline *model.SchemaSnapshot) error
+
+	// Health
+	FindAllActive() ([]model.DataSource, error)
+	UpdateHealth(id string, status model.DataSourceHealthStatus, errMsg string, checkedAt time.Time) error
+	CreateHealthCheck(check *model.DataSourceHealthCheck) error
+	FindHealthChecks(id string, limit int) ([]model.DataSourceHealthCheck, error)
+	DeleteHealthChecksBefore(before time.Time) (int64, error)
+
+	// Table profiles
+	SaveTableProfile(p *model.TableProfile) error
+	FindTableProfile(id, schema, table string) (*model.TableProfile, error)
+	FindTableProfiles(id string) ([]model.TableProfile, error)
 }
 
 type dataSourceRepository struct {
@@ -34,6 +56,11 @@ func NewDataSourceRepository(db *gorm.DB) DataSourceRepository {
 	return &dataSourceRepository{db: db}
 }
 
+// WithContext returns a repository whose queries are bound to ctx
+func (r *dataSourceRepository) WithContext(ctx context.Context) DataSourceRepository {
+	return &dataSourceRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new datasource
 func (r *dataSourceRepository) Create(ds *model.DataSource) error {
 	if err := r.db.Create(ds).Error; err != nil {
@@ -151,3 +178,229 @@ func (r *dataSourceRepository) HasAssociatedQueries(id string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// DataSourceDependents are the queries reading a datasource, the tools built
+// on them and the MCP servers exposing those tools
+type DataSourceDependents struct {
+	Queries []model.Query
+	Tools   []model.Tool
+	Servers []model.McpServer
+}
+
+// FindDependents finds the queries of a datasource, their tools and the MCP
+// servers of the user that expose the tools
+func (r *dataSourceRepository) FindDependents(id string, userID uint) (*DataSourceDependents, error) {
+	deps := &DataSourceDependents{}
+	if err := r.db.Where("data_source_id = ?", id).Order("name").Find(&deps.Queries).Error; err != nil {
+		return nil, fmt.Errorf("failed to find dependent queries: %w", err)
+	}
+	if len(deps.Queries) == 0 {
+		return deps, nil
+	}
+
+	queryIDs := make([]string, len(deps.Queries))
+	for i, q := range deps.Queries {
+		queryIDs[i] = q.ID
+	}
+	if err := r.db.Where("query_id IN ?", queryIDs).Order("name").Find(&deps.Tools).Error; err != nil {
+		return nil, fmt.Errorf("failed to find dependent tools: %w", err)
+	}
+	if len(deps.Tools) == 0 {
+		return deps, nil
+	}
+
+	// Servers list their tools in a JSON column, so they are matched here.
+	// Tools may also name their server themselves.
+	toolIDs := make(map[string]bool, len(deps.Tools))
+	serverIDs := make(map[string]bool)
+	for _, t := range deps.Tools {
+		toolIDs[t.ID] = true
+		if t.McpServerID != nil {
+			serverIDs[*t.McpServerID] = true
+		}
+	}
+	var servers []model.McpServer
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&servers).Error; err != nil {
+		return nil, fmt.Errorf("failed to find dependent mcp servers: %w", err)
+	}
+	for _, server := range servers {
+		exposes := serverIDs[server.ID]
+		for _, toolID := range server.ToolIDs {
+			exposes = exposes || toolIDs[toolID]
+		}
+		if exposes {
+			deps.Servers = append(deps.Servers, server)
+		}
+	}
+	return deps, nil
+}
+
+// RepointQueries moves the queries of datasource fromID to datasource toID
+// of the user in one transaction. Unless queries are exactly the queries of
+// fromID, with the SQL they were checked with, nothing is moved and
+// ErrDependentsChanged is returned. The review flags of the moved queries'
+// tools, raised by the schema of fromID, are cleared, and baseline is stored
+// as the first schema snapshot of toID if it has none, so later drift of toID
+// is flagged against the schema the queries were checked with.
+func (r *dataSourceRepository) RepointQueries(fromID, toID string, userID uint, queries []model.Query, baseline *model.SchemaSnapshot) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var target model.DataSource
+		if err := tx.Where("id = ? AND user_id = ?", toID, userID).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDataSourceNotFound
+			}
+			return fmt.Errorf("failed to find target datasource: %w", err)
+		}
+
+		var current []model.Query
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "sql_template").
+			Where("data_source_id = ?", fromID).
+			Find(&current).Error; err != nil {
+			return fmt.Errorf("failed to find dependent queries: %w", err)
+		}
+		if !sameQueries(current, queries) {
+			return ErrDependentsChanged
+		}
+
+		queryIDs := make([]string, len(queries))
+		for i, q := range queries {
+			queryIDs[i] = q.ID
+		}
+		if err := tx.Model(&model.Query{}).
+			Where("id IN ? AND data_source_id = ?", queryIDs, fromID).
+			Update("data_source_id", toID).Error; err != nil {
+			return fmt.Errorf("failed to repoint queries: %w", err)
+		}
+		if err := tx.Model(&model.Tool{}).
+			Where("query_id IN ? AND needs_review = ?", queryIDs, true).
+			UpdateColumns(map[string]interface{}{
+				"needs_review":  false,
+				"review_reason": "",
+			}).Error; err != nil {
+			return fmt.Errorf("failed to clear tool reviews: %w", err)
+		}
+
+		if baseline == nil {
+			return nil
+		}
+		var snapshots int64
+		if err := tx.Model(&model.SchemaSnapshot{}).Where("data_source_id = ?", toID).Count(&snapshots).Error; err != nil {
+			return fmt.Errorf("failed to count schema snapshots: %w", err)
+		}
+		if snapshots > 0 {
+			return nil
+		}
+		baseline.DataSourceID = toID
+		baseline.Version = 1
+		if err := tx.Create(baseline).Error; err != nil {
+			return fmt.Errorf("failed to create schema snapshot: %w", err)
+		}
+		return nil
+	})
+}
+
+// sameQueries reports whether current and checked are the same queries with
+// the same SQL, in any order
+func sameQueries(current, checked []model.Query) bool {
+	if len(current) != len(checked) {
+		return false
+	}
+	sqlByID := make(map[string]string, len(checked))
+	for _, q := range checked {
+		sqlByID[q.ID] = q.SQLTemplate
+	}
+	for _, q := range current {
+		sql, ok := sqlByID[q.ID]
+		if !ok || sql != q.SQLTemplate {
+			return false
+		}
+	}
+	return true
+}
+
+// FindAllActive returns every active datasource across all users (for the health prober)
+func (r *dataSourceRepository) FindAllActive() ([]model.DataSource, error) {
+	var datasources []model.DataSource
+	if err := r.db.Where("status = ?", "active").Find(&datasources).Error; err != nil {
+		return nil, fmt.Errorf("failed to find active datasources: %w", err)
+	}
+	return datasources, nil
+}
+
+// UpdateHealth records the latest health of a datasource without touching user-editable fields
+func (r *dataSourceRepository) UpdateHealth(id string, status model.DataSourceHealthStatus, errMsg string, checkedAt time.Time) error {
+	updates := map[string]interface{}{
+		"health_status":        string(status),
+		"health_error":         errMsg,
+		"last_health_check_at": checkedAt,
+	}
+	if err := r.db.Model(&model.DataSource{}).Where("id = ?", id).UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("failed to update datasource health: %w", err)
+	}
+	return nil
+}
+
+// CreateHealthCheck records a health probe result
+func (r *dataSourceRepository) CreateHealthCheck(check *model.DataSourceHealthCheck) error {
+	if err := r.db.Create(check).Error; err != nil {
+		return fmt.Errorf("failed to create datasource health check: %w", err)
+	}
+	return nil
+}
+
+// FindHealthChecks returns the most recent health probe results of a datasource
+func (r *dataSourceRepository) FindHealthChecks(id string, limit int) ([]model.DataSourceHealthCheck, error) {
+	var checks []model.DataSourceHealthCheck
+	if err := r.db.Where("data_source_id = ?", id).
+		Order("checked_at DESC").
+		Limit(limit).
+		Find(&checks).Error; err != nil {
+		return nil, fmt.Errorf("failed to find datasource health checks: %w", err)
+	}
+	return checks, nil
+}
+
+// DeleteHealthChecksBefore deletes health probe results older than before
+func (r *dataSourceRepository) DeleteHealthChecksBefore(before time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", before).Delete(&model.DataSourceHealthCheck{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete datasource health checks: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// SaveTableProfile stores the profile of a table, replacing its previous profile
+func (r *dataSourceRepository) SaveTableProfile(p *model.TableProfile) error {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "data_source_id"}, {Name: "schema_name"}, {Name: "table_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"row_count", "sampled", "columns", "excluded_columns", "profiled_at"}),
+	}).Create(p).Error; err != nil {
+		return fmt.Errorf("failed to save table profile: %w", err)
+	}
+	return nil
+}
+
+// FindTableProfile returns the stored profile of a table of a datasource
+func (r *dataSourceRepository) FindTableProfile(id, schema, table string) (*model.TableProfile, error) {
+	var p model.TableProfile
+	if err := r.db.Where("data_source_id = ? AND schema_name = ? AND table_name = ?", id, schema, table).
+		First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTableProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to find table profile: %w", err)
+	}
+	return &p, nil
+}
+
+// FindTableProfiles returns the stored profiles of every profiled table of a datasource
+func (r *dataSourceRepository) FindTableProfiles(id string) ([]model.TableProfile, error) {
+	var profiles []model.TableProfile
+	if err := r.db.Where("data_source_id = ?", id).
+		Order("schema_name, table_name").
+		Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to find table profiles: %w", err)
+	}
+	return profiles, nil
+}
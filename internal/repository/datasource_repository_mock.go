@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/yourusername/dataweaver/internal/model"
 )
@@ -10,6 +13,10 @@ type MockDataSourceRepository struct {
 	mock.Mock
 }
 
+func (m *MockDataSourceRepository) WithContext(ctx context.Context) DataSourceRepository {
+	return m
+}
+
 func (m *MockDataSourceRepository) Create(ds *model.DataSource) error {
 	args := m.Called(ds)
 	return args.Error(0)
@@ -61,3 +68,68 @@ func (m *MockDataSourceRepository) HasAssociatedQueries(id string) (bool, error)
 	args := m.Called(id)
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *MockDataSourceRepository) FindAllActive() ([]model.DataSource, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DataSource), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) UpdateHealth(id string, status model.DataSourceHealthStatus, errMsg string, checkedAt time.Time) error {
+	args := m.Called(id, status, errMsg, checkedAt)
+	return args.Error(0)
+}
+
+func (m *MockDataSourceRepository) CreateHealthCheck(check *model.DataSourceHealthCheck) error {
+	args := m.Called(check)
+	return args.Error(0)
+}
+
+func (m *MockDataSourceRepository) FindHealthChecks(id string, limit int) ([]model.DataSourceHealthCheck, error) {
+	args := m.Called(id, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DataSourceHealthCheck), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) DeleteHealthChecksBefore(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) SaveTableProfile(p *model.TableProfile) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockDataSourceRepository) FindTableProfile(id, schema, table string) (*model.TableProfile, error) {
+	args := m.Called(id, schema, table)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TableProfile), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) FindTableProfiles(id string) ([]model.TableProfile, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.TableProfile), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) FindDependents(id string, userID uint) (*DataSourceDependents, error) {
+	args := m.Called(id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DataSourceDependents), args.Error(1)
+}
+
+func (m *MockDataSourceRepository) RepointQueries(fromID, toID string, userID uint, queries []model.Query, baseline *model.SchemaSnapshot) error {
+	args := m.Called(fromID, toID, userID, queries, baseline)
+	return args.Error(0)
+}
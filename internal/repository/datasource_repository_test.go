@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/dataweaver/internal/model"
+)
+
+func TestSameQueries(t *testing.T) {
+	checked := []model.Query{
+		{ID: "q1", SQLTemplate: "SELECT * FROM orders"},
+		{ID: "q2", SQLTemplate: "SELECT * FROM refunds"},
+	}
+
+	assert.True(t, sameQueries([]model.Query{checked[1], checked[0]}, checked))
+	assert.False(t, sameQueries(checked[:1], checked), "a query was moved away")
+	assert.False(t, sameQueries(append(checked, model.Query{ID: "q3"}), checked), "a query was added")
+	assert.False(t, sameQueries([]model.Query{
+		checked[0],
+		{ID: "q2", SQLTemplate: "SELECT * FROM refunds JOIN payouts USING (id)"},
+	}, checked), "a query was edited after it was checked")
+}
@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -16,6 +19,7 @@ var (
 
 // McpServerRepository handles database operations for MCP servers
 type McpServerRepository interface {
+	WithContext(ctx context.Context) McpServerRepository
 	Create(server *model.McpServer) error
 	FindAll(userID uint, page, size int) ([]model.McpServer, int64, error)
 	FindByID(id string) (*model.McpServer, error)
@@ -28,25 +32,94 @@ type McpServerRepository interface {
 
 	// Log operations
 	CreateLog(log *model.McpLog) error
+	CreateLogs(logs []*model.McpLog) error
 	FindLogsByServerID(serverID string, page, size int) ([]model.McpLog, int64, error)
-	FindLogsByTimeRange(serverID string, start, end time.Time, page, size int) ([]model.McpLog, int64, error)
+	FindLogs(serverID string, filter *model.McpLogFilter, page, size int) ([]model.McpLog, int64, error)
+	FindLogsInBatches(serverID string, filter *model.McpLogFilter, batchSize int, fn func([]model.McpLog) error) error
+
+	// Retention
+	FindAllServers() ([]model.McpServer, error)
+	FindLogCutoffByRowCount(serverID string, maxRows int64) (*LogPosition, error)
+	DeleteLogsBefore(serverID string, cutoff LogCutoff, aggregate bool) (int64, error)
+	DeleteLogAggregatesBefore(serverID string, before time.Time) (int64, error)
+	FindLogAggregates(serverID string, start, end time.Time) ([]model.McpLogAggregate, error)
+
+	// Statistics (all aggregates cover logs with start <= timestamp < end)
+	GetLogSummary(serverID string, start, end time.Time) (*LogSummary, error)
+	GetLogStatsByTool(serverID string, start, end time.Time) ([]ToolLogStats, error)
+	GetLogStatsByDay(serverID string, start, end time.Time) ([]DayLogStats, error)
+	GetLogStatsByHour(serverID string, start, end time.Time) ([]HourLogStats, error)
+	GetErrorStatsByClass(serverID string, start, end time.Time) ([]ErrorClassStats, error)
+	GetWindowStats(serverID, toolName string, start, end time.Time) (*WindowStats, error)
+
+	// Usage counters
+	IncrementUsage(serverID string, period model.UsagePeriod, periodStart time.Time, calls, rows, executionMs int64) error
+	GetUsage(serverID string, period model.UsagePeriod, periodStart time.Time) (*model.McpUsageCounter, error)
+}
+
+// LogPosition is the place of a log in the (timestamp, id) order logs are
+// paged and retained in, which is unique even among logs sharing a timestamp
+type LogPosition struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// LogCutoff selects the logs retention deletes: those older than Before and
+// those at or before the position Through. A nil field selects no logs.
+type LogCutoff struct {
+	Before  *time.Time
+	Through *LogPosition
+}
 
-	// Statistics
-	CountLogsByServerID(serverID string) (int64, error)
-	CountLogsByStatus(serverID string, status string) (int64, error)
-	GetAvgResponseTime(serverID string) (float64, error)
-	GetLogStatsByTool(serverID string) ([]ToolLogStats, error)
-	GetLogStatsByDay(serverID string, days int) ([]DayLogStats, error)
+// IsZero reports whether the cutoff selects no logs
+func (c LogCutoff) IsZero() bool {
+	return c.Before == nil && c.Through == nil
+}
+
+// condition returns the WHERE condition matching the logs the cutoff selects
+func (c LogCutoff) condition() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if c.Before != nil {
+		conds = append(conds, "timestamp < ?")
+		args = append(args, *c.Before)
+	}
+	if c.Through != nil {
+		conds = append(conds, "(timestamp, id) <= (?, ?)")
+		args = append(args, c.Through.Timestamp, c.Through.ID)
+	}
+	if len(conds) == 0 {
+		return "false", nil
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// LogSummary represents aggregate call statistics for a server
+type LogSummary struct {
+	TotalCalls     int64   `json:"total_calls"`
+	SuccessCount   int64   `json:"success_count"`
+	ErrorCount     int64   `json:"error_count"`
+	AvgResponseMs  float64 `json:"avg_response_ms"`
+	P50ResponseMs  float64 `json:"p50_response_ms"`
+	P90ResponseMs  float64 `json:"p90_response_ms"`
+	P99ResponseMs  float64 `json:"p99_response_ms"`
+	TotalRows      int64   `json:"total_rows"`
+	AvgQueueWaitMs float64 `json:"avg_queue_wait_ms"`
+	MaxQueueWaitMs int64   `json:"max_queue_wait_ms"`
+	BusyCount      int64   `json:"busy_count"`
 }
 
 // ToolLogStats represents statistics for a specific tool
 type ToolLogStats struct {
-	ToolID        string  `json:"tool_id"`
-	ToolName      string  `json:"tool_name"`
-	CallCount     int64   `json:"call_count"`
-	SuccessCount  int64   `json:"success_count"`
-	ErrorCount    int64   `json:"error_count"`
-	AvgResponseMs float64 `json:"avg_response_ms"`
+	ToolID         string  `json:"tool_id"`
+	ToolName       string  `json:"tool_name"`
+	CallCount      int64   `json:"call_count"`
+	SuccessCount   int64   `json:"success_count"`
+	ErrorCount     int64   `json:"error_count"`
+	AvgResponseMs  float64 `json:"avg_response_ms"`
+	P90ResponseMs  float64 `json:"p90_response_ms"`
+	TotalRows      int64   `json:"total_rows"`
+	AvgQueueWaitMs float64 `json:"avg_queue_wait_ms"`
 }
 
 // DayLogStats represents statistics for a specific day
@@ -57,6 +130,28 @@ type DayLogStats struct {
 	ErrorCount   int64  `json:"error_count"`
 }
 
+// HourLogStats represents statistics for a specific hour
+type HourLogStats struct {
+	Hour          string  `json:"hour"`
+	CallCount     int64   `json:"call_count"`
+	SuccessCount  int64   `json:"success_count"`
+	ErrorCount    int64   `json:"error_count"`
+	AvgResponseMs float64 `json:"avg_response_ms"`
+}
+
+// WindowStats represents call health of a server or tool within a short window
+type WindowStats struct {
+	CallCount     int64   `json:"call_count"`
+	ErrorCount    int64   `json:"error_count"`
+	P95ResponseMs float64 `json:"p95_response_ms"`
+}
+
+// ErrorClassStats represents the number of failed calls of an error class
+type ErrorClassStats struct {
+	ErrorClass string `json:"error_class"`
+	Count      int64  `json:"count"`
+}
+
 type mcpServerRepository struct {
 	db *gorm.DB
 }
@@ -66,6 +161,11 @@ func NewMcpServerRepository(db *gorm.DB) McpServerRepository {
 	return &mcpServerRepository{db: db}
 }
 
+// WithContext returns a repository whose queries are bound to ctx
+func (r *mcpServerRepository) WithContext(ctx context.Context) McpServerRepository {
+	return &mcpServerRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new MCP server
 func (r *mcpServerRepository) Create(server *model.McpServer) error {
 	// Check if name already exists for this user
@@ -219,6 +319,99 @@ func (r *mcpServerRepository) CreateLog(log *model.McpLog) error {
 	return nil
 }
 
+// CreateLogs stores a batch of logs and adds them to their servers' daily and
+// monthly usage counters in one transaction, so a batch either counts fully or
+// can be retried. Logs whose ID is already stored are skipped, so writing a
+// batch again, such as when replaying spilled logs, neither duplicates them
+// nor counts them twice.
+func (r *mcpServerRepository) CreateLogs(logs []*model.McpLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]string, 0, len(logs))
+		for _, log := range logs {
+			if log.ID != "" {
+				ids = append(ids, log.ID)
+			}
+		}
+		stored := make(map[string]bool, len(ids))
+		if len(ids) > 0 {
+			var existing []string
+			if err := tx.Model(&model.McpLog{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+				return fmt.Errorf("failed to find stored mcp logs: %w", err)
+			}
+			for _, id := range existing {
+				stored[id] = true
+			}
+		}
+
+		fresh := make([]*model.McpLog, 0, len(logs))
+		for _, log := range logs {
+			if log.ID != "" {
+				if stored[log.ID] {
+					continue
+				}
+				stored[log.ID] = true
+			}
+			fresh = append(fresh, log)
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		if err := tx.CreateInBatches(fresh, len(fresh)).Error; err != nil {
+			return fmt.Errorf("failed to create mcp logs: %w", err)
+		}
+		repo := &mcpServerRepository{db: tx}
+		for _, usage := range usageIncrements(fresh) {
+			if err := repo.IncrementUsage(usage.serverID, usage.period, usage.periodStart, usage.calls, usage.rows, usage.executionMs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// usageIncrement is the usage a batch of logs adds to one counter
+type usageIncrement struct {
+	serverID                 string
+	period                   model.UsagePeriod
+	periodStart              time.Time
+	calls, rows, executionMs int64
+}
+
+// usageIncrements sums logs by usage counter, in the order the counters are
+// first met, so a batch issues one update per counter
+func usageIncrements(logs []*model.McpLog) []*usageIncrement {
+	type counterKey struct {
+		serverID    string
+		period      model.UsagePeriod
+		periodStart time.Time
+	}
+	byKey := make(map[counterKey]*usageIncrement)
+	var increments []*usageIncrement
+
+	for _, log := range logs {
+		for _, period := range []model.UsagePeriod{model.UsagePeriodDay, model.UsagePeriodMonth} {
+			periodStart, _ := period.PeriodBounds(log.Timestamp)
+			key := counterKey{serverID: log.McpServerID, period: period, periodStart: periodStart}
+
+			increment, ok := byKey[key]
+			if !ok {
+				increment = &usageIncrement{serverID: key.serverID, period: period, periodStart: periodStart}
+				byKey[key] = increment
+				increments = append(increments, increment)
+			}
+			increment.calls++
+			increment.rows += int64(log.RowCount)
+			increment.executionMs += log.ResponseTimeMs
+		}
+	}
+	return increments
+}
+
 // FindLogsByServerID returns logs for an MCP server with pagination
 func (r *mcpServerRepository) FindLogsByServerID(serverID string, page, size int) ([]model.McpLog, int64, error) {
 	var logs []model.McpLog
@@ -243,25 +436,51 @@ func (r *mcpServerRepository) FindLogsByServerID(serverID string, page, size int
 	return logs, total, nil
 }
 
-// FindLogsByTimeRange returns logs within a time range
-func (r *mcpServerRepository) FindLogsByTimeRange(serverID string, start, end time.Time, page, size int) ([]model.McpLog, int64, error) {
+// filteredLogs scopes a query to a server's logs matching the filter
+func (r *mcpServerRepository) filteredLogs(serverID string, filter *model.McpLogFilter) *gorm.DB {
+	query := r.db.Model(&model.McpLog{}).Where("mcp_server_id = ?", serverID)
+	if filter == nil {
+		return query
+	}
+
+	if filter.Start != nil {
+		query = query.Where("timestamp >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("timestamp < ?", *filter.End)
+	}
+	if filter.ToolName != "" {
+		query = query.Where("tool_name = ?", filter.ToolName)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MinResponseMs > 0 {
+		query = query.Where("response_time_ms >= ?", filter.MinResponseMs)
+	}
+	if filter.ErrorText != "" {
+		query = query.Where("error_message ILIKE ?", "%"+escapeLike(filter.ErrorText)+"%")
+	}
+	if filter.ApiKey != "" {
+		query = query.Where("api_key_prefix = ?", model.ApiKeyPrefix(filter.ApiKey))
+	}
+	return query
+}
+
+// FindLogs returns logs matching a filter with pagination
+func (r *mcpServerRepository) FindLogs(serverID string, filter *model.McpLogFilter, page, size int) ([]model.McpLog, int64, error) {
 	var logs []model.McpLog
 	var total int64
 
 	offset := (page - 1) * size
 
-	query := r.db.Model(&model.McpLog{}).
-		Where("mcp_server_id = ?", serverID).
-		Where("timestamp >= ? AND timestamp <= ?", start, end)
-
 	// Count total records
-	if err := query.Count(&total).Error; err != nil {
+	if err := r.filteredLogs(serverID, filter).Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count mcp logs: %w", err)
 	}
 
 	// Get paginated records
-	if err := r.db.Where("mcp_server_id = ?", serverID).
-		Where("timestamp >= ? AND timestamp <= ?", start, end).
+	if err := r.filteredLogs(serverID, filter).
 		Order("timestamp DESC").
 		Offset(offset).
 		Limit(size).
@@ -272,54 +491,193 @@ func (r *mcpServerRepository) FindLogsByTimeRange(serverID string, start, end ti
 	return logs, total, nil
 }
 
-// CountLogsByServerID counts all logs for a server
-func (r *mcpServerRepository) CountLogsByServerID(serverID string) (int64, error) {
-	var count int64
-	if err := r.db.Model(&model.McpLog{}).Where("mcp_server_id = ?", serverID).Count(&count).Error; err != nil {
-		return 0, fmt.Errorf("failed to count mcp logs: %w", err)
+// FindLogsInBatches passes all logs matching a filter to fn, newest first,
+// in batches of batchSize so large exports are never held in memory
+func (r *mcpServerRepository) FindLogsInBatches(serverID string, filter *model.McpLogFilter, batchSize int, fn func([]model.McpLog) error) error {
+	var last *model.McpLog
+	for {
+		var logs []model.McpLog
+		if err := r.logsPage(serverID, filter, last, batchSize).Find(&logs).Error; err != nil {
+			return fmt.Errorf("failed to find mcp logs: %w", err)
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		if len(logs) < batchSize {
+			return nil
+		}
+		last = &logs[len(logs)-1]
 	}
-	return count, nil
 }
 
-// CountLogsByStatus counts logs by status for a server
-func (r *mcpServerRepository) CountLogsByStatus(serverID string, status string) (int64, error) {
-	var count int64
-	if err := r.db.Model(&model.McpLog{}).
-		Where("mcp_server_id = ? AND status = ?", serverID, status).
-		Count(&count).Error; err != nil {
-		return 0, fmt.Errorf("failed to count mcp logs: %w", err)
+// logsPage selects the batch of logs following last, keyed on timestamp and
+// id rather than an offset so logs written during an export neither shift
+// rows into the next batch twice nor push them past it
+func (r *mcpServerRepository) logsPage(serverID string, filter *model.McpLogFilter, last *model.McpLog, size int) *gorm.DB {
+	query := r.filteredLogs(serverID, filter)
+	if last != nil {
+		query = query.Where("(timestamp, id) < (?, ?)", last.Timestamp, last.ID)
 	}
-	return count, nil
+	return query.Order("timestamp DESC, id DESC").Limit(size)
 }
 
-// GetAvgResponseTime returns the average response time for a server
-func (r *mcpServerRepository) GetAvgResponseTime(serverID string) (float64, error) {
-	var result struct {
-		Avg float64
+// escapeLike escapes the wildcards of a LIKE pattern, so user input only
+// matches literally
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FindAllServers returns every MCP server regardless of owner
+func (r *mcpServerRepository) FindAllServers() ([]model.McpServer, error) {
+	var servers []model.McpServer
+	if err := r.db.Find(&servers).Error; err != nil {
+		return nil, fmt.Errorf("failed to find mcp servers: %w", err)
 	}
-	if err := r.db.Model(&model.McpLog{}).
-		Select("COALESCE(AVG(response_time_ms), 0) as avg").
+	return servers, nil
+}
+
+// FindLogCutoffByRowCount returns the position of the newest log beyond the
+// newest maxRows logs of a server, or nil if the server has no more than maxRows
+// logs. Logs are ordered on timestamp and id, so a log sharing the timestamp
+// of the cutoff stays when it is among the newest maxRows.
+func (r *mcpServerRepository) FindLogCutoffByRowCount(serverID string, maxRows int64) (*LogPosition, error) {
+	var logs []model.McpLog
+	if err := r.db.Select("timestamp, id").
 		Where("mcp_server_id = ?", serverID).
-		Scan(&result).Error; err != nil {
-		return 0, fmt.Errorf("failed to get avg response time: %w", err)
+		Order("timestamp DESC, id DESC").
+		Offset(int(maxRows)).
+		Limit(1).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to find log cutoff: %w", err)
+	}
+	if len(logs) == 0 {
+		return nil, nil
 	}
-	return result.Avg, nil
+	return &LogPosition{Timestamp: logs[0].Timestamp, ID: logs[0].ID}, nil
+}
+
+// DeleteLogsBefore deletes the logs of a server the cutoff selects. When
+// aggregate is set, the logs are rolled up into hourly aggregates in the same
+// transaction.
+func (r *mcpServerRepository) DeleteLogsBefore(serverID string, cutoff LogCutoff, aggregate bool) (int64, error) {
+	var deleted int64
+	cond, condArgs := cutoff.condition()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if aggregate {
+			if err := tx.Exec(`
+				INSERT INTO mcp_log_aggregates
+					(mcp_server_id, tool_name, hour, call_count, success_count, error_count,
+					 total_response_ms, max_response_ms, total_rows)
+				SELECT
+					mcp_server_id,
+					COALESCE(tool_name, ''),
+					date_trunc('hour', timestamp),
+					COUNT(*),
+					SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END),
+					SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END),
+					COALESCE(SUM(response_time_ms), 0),
+					COALESCE(MAX(response_time_ms), 0),
+					COALESCE(SUM(row_count), 0)
+				FROM mcp_logs
+				WHERE mcp_server_id = ? AND `+cond+`
+				GROUP BY mcp_server_id, COALESCE(tool_name, ''), date_trunc('hour', timestamp)
+				ON CONFLICT (mcp_server_id, tool_name, hour) DO UPDATE SET
+					call_count = mcp_log_aggregates.call_count + EXCLUDED.call_count,
+					success_count = mcp_log_aggregates.success_count + EXCLUDED.success_count,
+					error_count = mcp_log_aggregates.error_count + EXCLUDED.error_count,
+					total_response_ms = mcp_log_aggregates.total_response_ms + EXCLUDED.total_response_ms,
+					max_response_ms = GREATEST(mcp_log_aggregates.max_response_ms, EXCLUDED.max_response_ms),
+					total_rows = mcp_log_aggregates.total_rows + EXCLUDED.total_rows
+			`, append([]interface{}{serverID}, condArgs...)...).Error; err != nil {
+				return fmt.Errorf("failed to aggregate mcp logs: %w", err)
+			}
+		}
+
+		result := tx.Where("mcp_server_id = ?", serverID).Where(cond, condArgs...).Delete(&model.McpLog{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete mcp logs: %w", result.Error)
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+
+	return deleted, err
+}
+
+// DeleteLogAggregatesBefore deletes a server's hourly aggregates older than before
+func (r *mcpServerRepository) DeleteLogAggregatesBefore(serverID string, before time.Time) (int64, error) {
+	result := r.db.Where("mcp_server_id = ? AND hour < ?", serverID, before).Delete(&model.McpLogAggregate{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete mcp log aggregates: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// FindLogAggregates returns a server's hourly aggregates within [start, end)
+func (r *mcpServerRepository) FindLogAggregates(serverID string, start, end time.Time) ([]model.McpLogAggregate, error) {
+	var aggregates []model.McpLogAggregate
+	if err := r.db.Where("mcp_server_id = ? AND hour >= ? AND hour < ?", serverID, start, end).
+		Order("hour DESC, tool_name").
+		Find(&aggregates).Error; err != nil {
+		return nil, fmt.Errorf("failed to find mcp log aggregates: %w", err)
+	}
+	return aggregates, nil
+}
+
+// logsInRange scopes a query to a server's logs within [start, end)
+func (r *mcpServerRepository) logsInRange(serverID string, start, end time.Time) *gorm.DB {
+	return r.db.Model(&model.McpLog{}).
+		Where("mcp_server_id = ?", serverID).
+		Where("timestamp >= ? AND timestamp < ?", start, end)
+}
+
+// GetLogSummary returns call counts, latency percentiles, queue waits and row totals for a server
+func (r *mcpServerRepository) GetLogSummary(serverID string, start, end time.Time) (*LogSummary, error) {
+	var summary LogSummary
+
+	if err := r.logsInRange(serverID, start, end).
+		Select(`
+			COUNT(*) as total_calls,
+			COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0) as success_count,
+			COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0) as error_count,
+			COALESCE(AVG(response_time_ms), 0) as avg_response_ms,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY response_time_ms), 0) as p50_response_ms,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY response_time_ms), 0) as p90_response_ms,
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY response_time_ms), 0) as p99_response_ms,
+			COALESCE(SUM(row_count), 0) as total_rows,
+			COALESCE(AVG(queue_wait_ms), 0) as avg_queue_wait_ms,
+			COALESCE(MAX(queue_wait_ms), 0) as max_queue_wait_ms,
+			COALESCE(SUM(CASE WHEN error_class = 'busy' THEN 1 ELSE 0 END), 0) as busy_count
+		`).
+		Scan(&summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to get log summary: %w", err)
+	}
+
+	return &summary, nil
 }
 
 // GetLogStatsByTool returns statistics grouped by tool
-func (r *mcpServerRepository) GetLogStatsByTool(serverID string) ([]ToolLogStats, error) {
+func (r *mcpServerRepository) GetLogStatsByTool(serverID string, start, end time.Time) ([]ToolLogStats, error) {
 	var stats []ToolLogStats
 
-	if err := r.db.Model(&model.McpLog{}).
+	if err := r.logsInRange(serverID, start, end).
 		Select(`
 			tool_id,
 			tool_name,
 			COUNT(*) as call_count,
 			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
 			SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as error_count,
-			COALESCE(AVG(response_time_ms), 0) as avg_response_ms
+			COALESCE(AVG(response_time_ms), 0) as avg_response_ms,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY response_time_ms), 0) as p90_response_ms,
+			COALESCE(SUM(row_count), 0) as total_rows,
+			COALESCE(AVG(queue_wait_ms), 0) as avg_queue_wait_ms
 		`).
-		Where("mcp_server_id = ?", serverID).
 		Group("tool_id, tool_name").
 		Order("call_count DESC").
 		Scan(&stats).Error; err != nil {
@@ -330,17 +688,16 @@ func (r *mcpServerRepository) GetLogStatsByTool(serverID string) ([]ToolLogStats
 }
 
 // GetLogStatsByDay returns statistics grouped by day
-func (r *mcpServerRepository) GetLogStatsByDay(serverID string, days int) ([]DayLogStats, error) {
+func (r *mcpServerRepository) GetLogStatsByDay(serverID string, start, end time.Time) ([]DayLogStats, error) {
 	var stats []DayLogStats
 
-	if err := r.db.Model(&model.McpLog{}).
+	if err := r.logsInRange(serverID, start, end).
 		Select(`
 			TO_CHAR(timestamp, 'YYYY-MM-DD') as date,
 			COUNT(*) as call_count,
 			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
 			SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as error_count
 		`).
-		Where("mcp_server_id = ? AND timestamp >= NOW() - INTERVAL '1 day' * ?", serverID, days).
 		Group("TO_CHAR(timestamp, 'YYYY-MM-DD')").
 		Order("date DESC").
 		Scan(&stats).Error; err != nil {
@@ -349,3 +706,110 @@ func (r *mcpServerRepository) GetLogStatsByDay(serverID string, days int) ([]Day
 
 	return stats, nil
 }
+
+// GetLogStatsByHour returns statistics grouped by hour
+func (r *mcpServerRepository) GetLogStatsByHour(serverID string, start, end time.Time) ([]HourLogStats, error) {
+	var stats []HourLogStats
+
+	if err := r.logsInRange(serverID, start, end).
+		Select(`
+			TO_CHAR(timestamp, 'YYYY-MM-DD HH24:00') as hour,
+			COUNT(*) as call_count,
+			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
+			SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as error_count,
+			COALESCE(AVG(response_time_ms), 0) as avg_response_ms
+		`).
+		Group("TO_CHAR(timestamp, 'YYYY-MM-DD HH24:00')").
+		Order("hour DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get hourly stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetErrorStatsByClass returns failed call counts grouped by error class
+func (r *mcpServerRepository) GetErrorStatsByClass(serverID string, start, end time.Time) ([]ErrorClassStats, error) {
+	var stats []ErrorClassStats
+
+	if err := r.logsInRange(serverID, start, end).
+		Select(`
+			COALESCE(NULLIF(error_class, ''), 'unknown') as error_class,
+			COUNT(*) as count
+		`).
+		Where("status = ?", model.McpLogStatusError).
+		Group("COALESCE(NULLIF(error_class, ''), 'unknown')").
+		Order("count DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get error stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetWindowStats returns call and error counts and p95 latency within [start, end),
+// optionally restricted to a single tool
+func (r *mcpServerRepository) GetWindowStats(serverID, toolName string, start, end time.Time) (*WindowStats, error) {
+	var stats WindowStats
+
+	query := r.logsInRange(serverID, start, end)
+	if toolName != "" {
+		query = query.Where("tool_name = ?", toolName)
+	}
+
+	if err := query.
+		Select(`
+			COUNT(*) as call_count,
+			COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0) as error_count,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY response_time_ms), 0) as p95_response_ms
+		`).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get window stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// IncrementUsage atomically adds to the usage counter of a quota period,
+// creating the counter if it does not exist yet
+func (r *mcpServerRepository) IncrementUsage(serverID string, period model.UsagePeriod, periodStart time.Time, calls, rows, executionMs int64) error {
+	counter := &model.McpUsageCounter{
+		McpServerID: serverID,
+		Period:      string(period),
+		PeriodStart: periodStart,
+		Calls:       calls,
+		Rows:        rows,
+		ExecutionMs: executionMs,
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "mcp_server_id"}, {Name: "period"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":        gorm.Expr("mcp_usage_counters.calls + ?", calls),
+			"rows":         gorm.Expr("mcp_usage_counters.rows + ?", rows),
+			"execution_ms": gorm.Expr("mcp_usage_counters.execution_ms + ?", executionMs),
+			"updated_at":   time.Now(),
+		}),
+	}).Create(counter).Error; err != nil {
+		return fmt.Errorf("failed to increment usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsage returns the usage counter of a quota period; an empty counter is
+// returned when nothing has been recorded yet
+func (r *mcpServerRepository) GetUsage(serverID string, period model.UsagePeriod, periodStart time.Time) (*model.McpUsageCounter, error) {
+	var counter model.McpUsageCounter
+	if err := r.db.Where("mcp_server_id = ? AND period = ? AND period_start = ?", serverID, string(period), periodStart).
+		First(&counter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.McpUsageCounter{
+				McpServerID: serverID,
+				Period:      string(period),
+				PeriodStart: periodStart,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return &counter, nil
+}
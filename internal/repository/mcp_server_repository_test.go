@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunRepository returns a repository whose statements are built but
+// never sent to a database
+func newDryRunRepository(t *testing.T) *mcpServerRepository {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	return &mcpServerRepository{db: db}
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% done`, escapeLike("100% done"))
+	assert.Equal(t, `no\_such\_table`, escapeLike("no_such_table"))
+	assert.Equal(t, `C:\\temp`, escapeLike(`C:\temp`))
+	assert.Equal(t, "timeout", escapeLike("timeout"))
+}
+
+func TestLogsPage_KeysetOrder(t *testing.T) {
+	repo := newDryRunRepository(t)
+
+	first := repo.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		r := &mcpServerRepository{db: tx}
+		var logs []model.McpLog
+		return r.logsPage("server-1", nil, nil, 100).Find(&logs)
+	})
+	assert.Contains(t, first, "ORDER BY timestamp DESC, id DESC")
+	assert.NotContains(t, first, "OFFSET")
+
+	last := &model.McpLog{ID: "log-9", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	next := repo.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		r := &mcpServerRepository{db: tx}
+		var logs []model.McpLog
+		return r.logsPage("server-1", nil, last, 100).Find(&logs)
+	})
+	assert.Contains(t, next, "(timestamp, id) < ('2024-05-01 12:00:00", "the next batch starts after the last exported log")
+	assert.Contains(t, next, "'log-9')")
+	assert.Contains(t, next, "ORDER BY timestamp DESC, id DESC")
+	assert.NotContains(t, next, "OFFSET")
+}
+
+func TestFindLogCutoffByRowCount_KeysetOrder(t *testing.T) {
+	repo := newDryRunRepository(t)
+
+	var sql string
+	require.NoError(t, repo.db.Callback().Query().After("gorm:query").Register("test:capture_sql", func(db *gorm.DB) {
+		sql = db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
+	}))
+	_, err := repo.FindLogCutoffByRowCount("server-1", 100)
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "SELECT timestamp, id FROM")
+	assert.Contains(t, sql, "ORDER BY timestamp DESC, id DESC", "logs sharing a timestamp are told apart by id")
+	assert.Contains(t, sql, "OFFSET 100")
+}
+
+func TestLogCutoff_Condition(t *testing.T) {
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	through := &LogPosition{Timestamp: before.Add(time.Hour), ID: "log-9"}
+
+	cond, args := LogCutoff{Before: &before}.condition()
+	assert.Equal(t, "(timestamp < ?)", cond)
+	assert.Equal(t, []interface{}{before}, args)
+
+	cond, args = LogCutoff{Through: through}.condition()
+	assert.Equal(t, "((timestamp, id) <= (?, ?))", cond, "the log at the cutoff is deleted, later ones sharing its timestamp are not")
+	assert.Equal(t, []interface{}{through.Timestamp, "log-9"}, args)
+
+	cond, args = LogCutoff{Before: &before, Through: through}.condition()
+	assert.Equal(t, "(timestamp < ? OR (timestamp, id) <= (?, ?))", cond, "logs beyond either limit are deleted")
+	assert.Len(t, args, 3)
+
+	cond, args = LogCutoff{}.condition()
+	assert.Equal(t, "false", cond)
+	assert.Empty(t, args)
+}
+
+func TestFilteredLogs_EscapesErrorText(t *testing.T) {
+	repo := newDryRunRepository(t)
+
+	sql := repo.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		r := &mcpServerRepository{db: tx}
+		var logs []model.McpLog
+		return r.filteredLogs("server-1", &model.McpLogFilter{ErrorText: "50%_"}).Find(&logs)
+	})
+	assert.Contains(t, sql, `error_message ILIKE '%50\%\_%'`)
+}
+
+func TestGetLogSummary_RangeAndPercentiles(t *testing.T) {
+	repo := newDryRunRepository(t)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	sql := repo.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		r := &mcpServerRepository{db: tx}
+		var summary LogSummary
+		return r.logsInRange("server-1", start, start.AddDate(0, 0, 1)).Select("COUNT(*)").Scan(&summary)
+	})
+	assert.Contains(t, sql, "timestamp >= '2024-05-01 00:00:00")
+	assert.Contains(t, sql, "AND timestamp < '2024-05-02 00:00:00", "the end of the range is exclusive")
+
+	// A dry run cannot scan the summary, but its statement is still built
+	var summarySQL string
+	require.NoError(t, repo.db.Callback().Row().After("gorm:row").Register("test:capture_sql", func(db *gorm.DB) {
+		summarySQL = db.Statement.SQL.String()
+	}))
+	_, _ = repo.GetLogSummary("server-1", start, start.AddDate(0, 0, 1))
+	assert.Contains(t, summarySQL, "percentile_cont(0.5) WITHIN GROUP (ORDER BY response_time_ms)")
+	assert.Contains(t, summarySQL, "percentile_cont(0.9) WITHIN GROUP (ORDER BY response_time_ms)")
+	assert.Contains(t, summarySQL, "percentile_cont(0.99) WITHIN GROUP (ORDER BY response_time_ms)")
+}
+
+func TestIncrementUsage_Upserts(t *testing.T) {
+	repo := newDryRunRepository(t)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var sql string
+	require.NoError(t, repo.db.Callback().Create().After("gorm:create").Register("test:capture_sql", func(db *gorm.DB) {
+		sql = db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
+	}))
+	r := &mcpServerRepository{db: repo.db.Session(&gorm.Session{SkipDefaultTransaction: true})}
+	require.NoError(t, r.IncrementUsage("server-1", model.UsagePeriodDay, start, 1, 20, 300))
+
+	assert.Contains(t, sql, `ON CONFLICT ("mcp_server_id","period","period_start") DO UPDATE SET`)
+	assert.Contains(t, sql, `"calls"=mcp_usage_counters.calls + 1`, "calls are added to the stored counter")
+	assert.Contains(t, sql, `"rows"=mcp_usage_counters.rows + 20`)
+	assert.Contains(t, sql, `"execution_ms"=mcp_usage_counters.execution_ms + 300`)
+}
+
+func TestUsageIncrements(t *testing.T) {
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	logs := []*model.McpLog{
+		{McpServerID: "s1", RowCount: 10, ResponseTimeMs: 100, Timestamp: day},
+		{McpServerID: "s1", RowCount: 5, ResponseTimeMs: 50, Timestamp: day.Add(time.Hour)},
+		{McpServerID: "s1", RowCount: 1, ResponseTimeMs: 10, Timestamp: day.AddDate(0, 0, 1)},
+		{McpServerID: "s2", RowCount: 2, ResponseTimeMs: 20, Timestamp: day},
+	}
+
+	increments := usageIncrements(logs)
+	require.Len(t, increments, 5, "two days and a month of s1, a day and a month of s2")
+
+	byCounter := make(map[string]usageIncrement)
+	for _, inc := range increments {
+		byCounter[inc.serverID+"|"+string(inc.period)+"|"+inc.periodStart.Format("2006-01-02")] = *inc
+	}
+	first := byCounter["s1|"+string(model.UsagePeriodDay)+"|2024-05-01"]
+	assert.Equal(t, int64(2), first.calls)
+	assert.Equal(t, int64(15), first.rows)
+	assert.Equal(t, int64(150), first.executionMs)
+	month := byCounter["s1|"+string(model.UsagePeriodMonth)+"|2024-05-01"]
+	assert.Equal(t, int64(3), month.calls)
+	assert.Equal(t, int64(16), month.rows)
+}
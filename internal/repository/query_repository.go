@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -14,6 +15,7 @@ var (
 
 // QueryRepository handles database operations for queries
 type QueryRepository interface {
+	WithContext(ctx context.Context) QueryRepository
 	Create(q *model.Query) error
 	FindAll(userID uint, page, size int) ([]model.Query, int64, error)
 	FindByID(id string) (*model.Query, error)
@@ -39,6 +41,11 @@ func NewQueryRepository(db *gorm.DB) QueryRepository {
 	return &queryRepository{db: db}
 }
 
+// WithContext returns a repository whose queries are bound to ctx
+func (r *queryRepository) WithContext(ctx context.Context) QueryRepository {
+	return &queryRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new query
 func (r *queryRepository) Create(q *model.Query) error {
 	if err := r.db.Create(q).Error; err != nil {
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSchemaSnapshotNotFound = errors.New("schema snapshot not found")
+)
+
+// SchemaSnapshotRepository handles database operations for schema snapshots
+type SchemaSnapshotRepository interface {
+	WithContext(ctx context.Context) SchemaSnapshotRepository
+	Create(s *model.SchemaSnapshot) error
+	CreateWithReviews(s *model.SchemaSnapshot, toolsByReason map[string][]string) error
+	FindLatest(dataSourceID string) (*model.SchemaSnapshot, error)
+	FindByVersion(dataSourceID string, version int) (*model.SchemaSnapshot, error)
+	FindAll(dataSourceID string, page, size int) ([]model.SchemaSnapshot, int64, error)
+}
+
+type schemaSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewSchemaSnapshotRepository creates a new SchemaSnapshotRepository
+func NewSchemaSnapshotRepository(db *gorm.DB) SchemaSnapshotRepository {
+	return &schemaSnapshotRepository{db: db}
+}
+
+// WithContext returns a repository whose queries are bound to ctx
+func (r *schemaSnapshotRepository) WithContext(ctx context.Context) SchemaSnapshotRepository {
+	return &schemaSnapshotRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create stores a new schema snapshot
+func (r *schemaSnapshotRepository) Create(s *model.SchemaSnapshot) error {
+	if err := r.db.Create(s).Error; err != nil {
+		return fmt.Errorf("failed to create schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// CreateWithReviews stores a new schema snapshot and flags the tools its
+// changes affect for review, grouped by reason, in one transaction. A
+// snapshot is never stored without its flags, which a later refresh finding
+// the same checksum could not add anymore.
+func (r *schemaSnapshotRepository) CreateWithReviews(s *model.SchemaSnapshot, toolsByReason map[string][]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(s).Error; err != nil {
+			return fmt.Errorf("failed to create schema snapshot: %w", err)
+		}
+		tools := &toolRepository{db: tx}
+		for reason, ids := range toolsByReason {
+			if err := tools.MarkNeedsReview(ids, reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindLatest returns the most recent schema snapshot of a datasource
+func (r *schemaSnapshotRepository) FindLatest(dataSourceID string) (*model.SchemaSnapshot, error) {
+	var s model.SchemaSnapshot
+	if err := r.db.Where("data_source_id = ?", dataSourceID).
+		Order("version DESC").
+		First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSchemaSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to find schema snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// FindByVersion returns a specific schema snapshot version of a datasource
+func (r *schemaSnapshotRepository) FindByVersion(dataSourceID string, version int) (*model.SchemaSnapshot, error) {
+	var s model.SchemaSnapshot
+	if err := r.db.Where("data_source_id = ? AND version = ?", dataSourceID, version).
+		First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSchemaSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to find schema snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// FindAll returns the schema snapshots of a datasource, newest first, without their tables
+func (r *schemaSnapshotRepository) FindAll(dataSourceID string, page, size int) ([]model.SchemaSnapshot, int64, error) {
+	var snapshots []model.SchemaSnapshot
+	var total int64
+
+	offset := (page - 1) * size
+
+	if err := r.db.Model(&model.SchemaSnapshot{}).
+		Where("data_source_id = ?", dataSourceID).
+		Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count schema snapshots: %w", err)
+	}
+
+	if err := r.db.Omit("tables").
+		Where("data_source_id = ?", dataSourceID).
+		Order("version DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&snapshots).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to find schema snapshots: %w", err)
+	}
+
+	return snapshots, total, nil
+}
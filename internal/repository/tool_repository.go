@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -15,6 +16,7 @@ var (
 
 // ToolRepository handles database operations for tools
 type ToolRepository interface {
+	WithContext(ctx context.Context) ToolRepository
 	Create(t *model.Tool) error
 	FindAll(userID uint, page, size int) ([]model.Tool, int64, error)
 	FindByID(id string) (*model.Tool, error)
@@ -28,6 +30,8 @@ type ToolRepository interface {
 	FindByMcpServerID(mcpServerID string) ([]model.Tool, error)
 	CountByQueryID(queryID string) (int64, error)
 	IncrementVersion(id string, userID uint) error
+	MarkNeedsReview(ids []string, reason string) error
+	ClearNeedsReview(id string, userID uint) error
 }
 
 type toolRepository struct {
@@ -39,6 +43,11 @@ func NewToolRepository(db *gorm.DB) ToolRepository {
 	return &toolRepository{db: db}
 }
 
+// WithContext returns a repository whose queries are bound to ctx
+func (r *toolRepository) WithContext(ctx context.Context) ToolRepository {
+	return &toolRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new tool
 func (r *toolRepository) Create(t *model.Tool) error {
 	// Check if name already exists for this user
@@ -229,3 +238,36 @@ func (r *toolRepository) IncrementVersion(id string, userID uint) error {
 	}
 	return nil
 }
+
+// MarkNeedsReview flags tools for review after a change they depend on
+func (r *toolRepository) MarkNeedsReview(ids []string, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.db.Model(&model.Tool{}).
+		Where("id IN ?", ids).
+		UpdateColumns(map[string]interface{}{
+			"needs_review":  true,
+			"review_reason": reason,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to mark tools for review: %w", err)
+	}
+	return nil
+}
+
+// ClearNeedsReview marks a tool as reviewed
+func (r *toolRepository) ClearNeedsReview(id string, userID uint) error {
+	result := r.db.Model(&model.Tool{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		UpdateColumns(map[string]interface{}{
+			"needs_review":  false,
+			"review_reason": "",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to clear tool review: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrToolNotFound
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// alertNotifyWorkers is the number of webhook deliveries made at once
+	alertNotifyWorkers = 4
+	// alertNotifyQueueSize is the number of notifications each worker buffers
+	alertNotifyQueueSize = 100
+)
+
+// alertNotification is a state change of a rule waiting to be delivered
+type alertNotification struct {
+	rule    model.AlertRule
+	event   model.AlertEvent
+	value   float64
+	message string
+}
+
+// alertNotifier delivers alert notifications off the evaluation loop, so a
+// slow or failing webhook and its retries do not hold up the other rules. The
+// notifications of a rule are delivered in order, by the same worker.
+type alertNotifier struct {
+	deliver func(ctx context.Context, n *alertNotification) error
+
+	mu     sync.RWMutex // guards closed against concurrent enqueues
+	closed bool
+	queues []chan *alertNotification
+	wg     sync.WaitGroup
+
+	ctx    context.Context // cancelled when shutdown gives up waiting
+	cancel context.CancelFunc
+}
+
+func newAlertNotifier(deliver func(ctx context.Context, n *alertNotification) error) *alertNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &alertNotifier{
+		deliver: deliver,
+		queues:  make([]chan *alertNotification, alertNotifyWorkers),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for i := range n.queues {
+		n.queues[i] = make(chan *alertNotification, alertNotifyQueueSize)
+		n.wg.Add(1)
+		go n.run(n.queues[i])
+	}
+	return n
+}
+
+// enqueue queues a notification without waiting. It reports false when the
+// queue of the rule's worker is full or the notifier is shut down.
+func (n *alertNotifier) enqueue(notification *alertNotification) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(notification.rule.ID))
+	select {
+	case n.queues[h.Sum32()%uint32(len(n.queues))] <- notification:
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *alertNotifier) run(queue chan *alertNotification) {
+	defer n.wg.Done()
+	for notification := range queue {
+		if err := n.deliver(n.ctx, notification); err != nil {
+			logger.Warn("Failed to deliver alert notification",
+				zap.String("rule_id", notification.rule.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// Shutdown delivers the queued notifications, abandoning them once ctx is done
+func (n *alertNotifier) Shutdown(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		for _, queue := range n.queues {
+			close(queue)
+		}
+	}
+	n.mu.Unlock()
+
+	defer n.cancel()
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
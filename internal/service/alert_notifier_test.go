@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+)
+
+func TestAlertNotifier_DeliversInOrderPerRule(t *testing.T) {
+	var mu sync.Mutex
+	delivered := make(map[string][]model.AlertEvent)
+	notifier := newAlertNotifier(func(ctx context.Context, n *alertNotification) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered[n.rule.ID] = append(delivered[n.rule.ID], n.event)
+		return nil
+	})
+
+	for _, id := range []string{"r1", "r2", "r3"} {
+		for _, event := range []model.AlertEvent{model.AlertEventFiring, model.AlertEventResolved, model.AlertEventFiring} {
+			require.True(t, notifier.enqueue(&alertNotification{rule: model.AlertRule{ID: id}, event: event}))
+		}
+	}
+	require.NoError(t, notifier.Shutdown(context.Background()))
+
+	for _, id := range []string{"r1", "r2", "r3"} {
+		assert.Equal(t, []model.AlertEvent{model.AlertEventFiring, model.AlertEventResolved, model.AlertEventFiring}, delivered[id], id)
+	}
+	assert.False(t, notifier.enqueue(&alertNotification{rule: model.AlertRule{ID: "r1"}}), "nothing is queued after shutdown")
+}
+
+func TestAlertNotifier_SlowWebhookDoesNotBlock(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	notifier := newAlertNotifier(func(ctx context.Context, n *alertNotification) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	})
+	defer close(release)
+
+	// The first notification holds its worker; the rest fill its queue
+	require.True(t, notifier.enqueue(&alertNotification{rule: model.AlertRule{ID: "slow"}}))
+	<-started
+	for i := 0; i < alertNotifyQueueSize; i++ {
+		require.True(t, notifier.enqueue(&alertNotification{rule: model.AlertRule{ID: "slow"}}))
+	}
+	assert.False(t, notifier.enqueue(&alertNotification{rule: model.AlertRule{ID: "slow"}}), "a full queue drops notifications instead of waiting")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, notifier.Shutdown(ctx), context.DeadlineExceeded)
+}
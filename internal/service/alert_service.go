@@ -0,0 +1,521 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	// alertWebhookTimeout bounds a single webhook request
+	alertWebhookTimeout = 10 * time.Second
+	// alertWebhookAttempts is the number of delivery attempts per notification
+	alertWebhookAttempts = 3
+	// alertWebhookBackoff is the wait before the first retry; it doubles after each retry
+	alertWebhookBackoff = 2 * time.Second
+	// alertPingTimeout bounds the reachability check of a single data source
+	alertPingTimeout = 5 * time.Second
+)
+
+var (
+	ErrInvalidAlertRule = errors.New("invalid alert rule")
+)
+
+// AlertService handles business logic for alert rules
+type AlertService interface {
+	Create(userID uint, req *model.CreateAlertRuleRequest) (*model.AlertRuleResponse, error)
+	List(userID uint, page, size int) ([]model.AlertRuleResponse, int64, error)
+	Get(id string, userID uint) (*model.AlertRuleResponse, error)
+	Update(id string, userID uint, req *model.UpdateAlertRuleRequest) (*model.AlertRuleResponse, error)
+	Delete(id string, userID uint) error
+
+	// Notifications
+	TestRule(id string, userID uint) (*model.AlertDelivery, error)
+	GetDeliveries(id string, userID uint, page, size int) ([]model.AlertDelivery, int64, error)
+
+	// Evaluate checks every enabled rule and notifies on state changes
+	Evaluate(ctx context.Context) error
+	// Shutdown delivers the notifications still queued
+	Shutdown(ctx context.Context) error
+}
+
+type alertService struct {
+	alertRepo  repository.AlertRepository
+	mcpRepo    repository.McpServerRepository
+	toolRepo   repository.ToolRepository
+	queryRepo  repository.QueryRepository
+	dsRepo     repository.DataSourceRepository
+	mcpService McpServerService
+	pool       *dbconnector.Pool
+	sender     *webhook.Sender
+	notifier   *alertNotifier
+}
+
+// NewAlertService creates a new AlertService
+func NewAlertService(
+	alertRepo repository.AlertRepository,
+	mcpRepo repository.McpServerRepository,
+	toolRepo repository.ToolRepository,
+	queryRepo repository.QueryRepository,
+	dsRepo repository.DataSourceRepository,
+	mcpService McpServerService,
+	pool *dbconnector.Pool,
+) AlertService {
+	svc := &alertService{
+		alertRepo:  alertRepo,
+		mcpRepo:    mcpRepo,
+		toolRepo:   toolRepo,
+		queryRepo:  queryRepo,
+		dsRepo:     dsRepo,
+		mcpService: mcpService,
+		pool:       pool,
+		sender:     webhook.NewSender(alertWebhookTimeout, alertWebhookAttempts, alertWebhookBackoff),
+	}
+	svc.notifier = newAlertNotifier(func(ctx context.Context, n *alertNotification) error {
+		_, err := svc.notify(ctx, &n.rule, n.event, n.value, n.message)
+		return err
+	})
+	return svc
+}
+
+// Shutdown delivers the notifications still queued
+func (s *alertService) Shutdown(ctx context.Context) error {
+	return s.notifier.Shutdown(ctx)
+}
+
+// Create creates a new alert rule
+func (s *alertService) Create(userID uint, req *model.CreateAlertRuleRequest) (*model.AlertRuleResponse, error) {
+	// Rules can only watch the user's own servers
+	if _, err := s.mcpRepo.FindByIDAndUserID(req.McpServerID, userID); err != nil {
+		return nil, err
+	}
+
+	secret, err := encryptSecret(req.WebhookSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &model.AlertRule{
+		UserID:        userID,
+		McpServerID:   req.McpServerID,
+		ToolName:      req.ToolName,
+		Name:          req.Name,
+		Type:          req.Type,
+		Threshold:     req.Threshold,
+		WindowMinutes: req.WindowMinutes,
+		MinCalls:      req.MinCalls,
+		WebhookURL:    req.WebhookURL,
+		WebhookSecret: secret,
+		Enabled:       true,
+		State:         string(model.AlertStateOK),
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if rule.WindowMinutes == 0 {
+		rule.WindowMinutes = 5
+	}
+	if rule.MinCalls == 0 {
+		rule.MinCalls = 1
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepo.Create(rule); err != nil {
+		return nil, err
+	}
+
+	return rule.ToResponse(), nil
+}
+
+// List returns all alert rules for a user
+func (s *alertService) List(userID uint, page, size int) ([]model.AlertRuleResponse, int64, error) {
+	// Set defaults
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	rules, total, err := s.alertRepo.FindAll(userID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]model.AlertRuleResponse, len(rules))
+	for i, rule := range rules {
+		responses[i] = *rule.ToResponse()
+	}
+
+	return responses, total, nil
+}
+
+// Get returns an alert rule by ID
+func (s *alertService) Get(id string, userID uint) (*model.AlertRuleResponse, error) {
+	rule, err := s.alertRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return rule.ToResponse(), nil
+}
+
+// Update updates an alert rule
+func (s *alertService) Update(id string, userID uint, req *model.UpdateAlertRuleRequest) (*model.AlertRuleResponse, error) {
+	rule, err := s.alertRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.ToolName != nil {
+		rule.ToolName = *req.ToolName
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.WindowMinutes != nil {
+		rule.WindowMinutes = *req.WindowMinutes
+	}
+	if req.MinCalls != nil {
+		rule.MinCalls = *req.MinCalls
+	}
+	if req.WebhookURL != nil {
+		rule.WebhookURL = *req.WebhookURL
+	}
+	if req.WebhookSecret != nil {
+		secret, err := encryptSecret(*req.WebhookSecret)
+		if err != nil {
+			return nil, err
+		}
+		rule.WebhookSecret = secret
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+		// Reset a disabled rule so a stale firing state does not suppress the next alert
+		if !rule.Enabled {
+			rule.State = string(model.AlertStateOK)
+		}
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepo.Update(rule); err != nil {
+		return nil, err
+	}
+
+	return rule.ToResponse(), nil
+}
+
+// Delete deletes an alert rule
+func (s *alertService) Delete(id string, userID uint) error {
+	return s.alertRepo.Delete(id, userID)
+}
+
+// TestRule sends a test notification to the webhook of an alert rule
+func (s *alertService) TestRule(id string, userID uint) (*model.AlertDelivery, error) {
+	rule, err := s.alertRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Test notification for alert rule %q", rule.Name)
+	return s.notify(context.Background(), rule, model.AlertEventTest, rule.LastValue, message)
+}
+
+// GetDeliveries returns the webhook deliveries of an alert rule
+func (s *alertService) GetDeliveries(id string, userID uint, page, size int) ([]model.AlertDelivery, int64, error) {
+	if _, err := s.alertRepo.FindByIDAndUserID(id, userID); err != nil {
+		return nil, 0, err
+	}
+
+	// Set defaults
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	return s.alertRepo.FindDeliveries(id, page, size)
+}
+
+// Evaluate checks every enabled rule. A notification is queued for delivery
+// when a rule starts firing and when it resolves; rules that stay in the same
+// state are silent.
+func (s *alertService) Evaluate(ctx context.Context) error {
+	rules, err := s.alertRepo.WithContext(ctx).FindEnabled()
+	if err != nil {
+		return err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		now := time.Now()
+
+		value, firing, message, err := s.evaluateRule(ctx, rule, now)
+		if err != nil {
+			logger.Warn("Failed to evaluate alert rule",
+				zap.String("rule_id", rule.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		state := model.AlertStateOK
+		if firing {
+			state = model.AlertStateFiring
+		}
+
+		var triggeredAt *time.Time
+		if string(state) != rule.State {
+			event := model.AlertEventResolved
+			if firing {
+				event = model.AlertEventFiring
+				triggeredAt = &now
+			}
+			notification := &alertNotification{rule: *rule, event: event, value: value, message: message}
+			if !s.notifier.enqueue(notification) {
+				logger.Warn("Alert notification queue is full, dropping notification",
+					zap.String("rule_id", rule.ID),
+					zap.String("event", string(event)),
+				)
+			}
+		}
+
+		if err := s.alertRepo.WithContext(ctx).UpdateState(rule.ID, state, value, now, triggeredAt); err != nil {
+			logger.Warn("Failed to record alert rule state",
+				zap.String("rule_id", rule.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// evaluateRule returns the current value of the rule's metric and whether it breaches the threshold
+func (s *alertService) evaluateRule(ctx context.Context, rule *model.AlertRule, now time.Time) (float64, bool, string, error) {
+	switch model.AlertRuleType(rule.Type) {
+	case model.AlertRuleErrorRate, model.AlertRuleP95Latency:
+		start := now.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+		stats, err := s.mcpRepo.WithContext(ctx).GetWindowStats(rule.McpServerID, rule.ToolName, start, now)
+		if err != nil {
+			return 0, false, "", err
+		}
+		// Too little traffic to judge; keep the rule quiet
+		if stats.CallCount < rule.MinCalls {
+			return 0, false, fmt.Sprintf("%d calls in the last %d minutes, below the minimum of %d", stats.CallCount, rule.WindowMinutes, rule.MinCalls), nil
+		}
+
+		if model.AlertRuleType(rule.Type) == model.AlertRuleErrorRate {
+			value := float64(stats.ErrorCount) / float64(stats.CallCount) * 100
+			return value, value >= rule.Threshold,
+				fmt.Sprintf("Error rate %.1f%% (%d of %d calls) in the last %d minutes, threshold %.1f%%",
+					value, stats.ErrorCount, stats.CallCount, rule.WindowMinutes, rule.Threshold), nil
+		}
+
+		value := stats.P95ResponseMs
+		return value, value >= rule.Threshold,
+			fmt.Sprintf("p95 latency %.0fms over %d calls in the last %d minutes, threshold %.0fms",
+				value, stats.CallCount, rule.WindowMinutes, rule.Threshold), nil
+
+	case model.AlertRuleQuotaUsage:
+		usage, err := s.mcpService.GetUsage(rule.McpServerID, rule.UserID)
+		if err != nil {
+			return 0, false, "", err
+		}
+		value, metric := maxQuotaUsage(usage)
+		if metric == "" {
+			return 0, false, "No quota limits configured", nil
+		}
+		return value, value >= rule.Threshold,
+			fmt.Sprintf("Quota %s at %.1f%% of its limit, threshold %.1f%%", metric, value, rule.Threshold), nil
+
+	case model.AlertRuleDataSourceUnreachable:
+		unreachable, err := s.unreachableDataSources(ctx, rule.McpServerID)
+		if err != nil {
+			return 0, false, "", err
+		}
+		value := float64(len(unreachable))
+		message := "All data sources are reachable"
+		if len(unreachable) > 0 {
+			message = fmt.Sprintf("Unreachable data sources: %s", strings.Join(unreachable, ", "))
+		}
+		return value, value >= rule.Threshold, message, nil
+
+	default:
+		return 0, false, "", fmt.Errorf("%w: unknown type %q", ErrInvalidAlertRule, rule.Type)
+	}
+}
+
+// maxQuotaUsage returns the highest usage percentage among the limited quota metrics
+func maxQuotaUsage(usage *model.McpUsageResponse) (float64, string) {
+	var max float64
+	var name string
+
+	periods := []model.PeriodUsageResponse{usage.Daily, usage.Monthly}
+	for _, period := range periods {
+		metrics := []struct {
+			name   string
+			metric model.UsageMetric
+		}{
+			{"calls", period.Calls},
+			{"rows", period.Rows},
+			{"execution_ms", period.ExecutionMs},
+		}
+		for _, entry := range metrics {
+			m := entry.metric
+			if m.Limit <= 0 {
+				continue
+			}
+			if name == "" || m.PercentUsed > max {
+				max = m.PercentUsed
+				name = fmt.Sprintf("%s %s", period.Period, entry.name)
+			}
+		}
+	}
+
+	return max, name
+}
+
+// unreachableDataSources returns the names of the server's data sources that cannot be reached
+func (s *alertService) unreachableDataSources(ctx context.Context, serverID string) ([]string, error) {
+	server, err := s.mcpRepo.WithContext(ctx).FindByID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := make(map[string]bool)
+	var unreachable []string
+
+	for _, toolID := range server.ToolIDs {
+		tool, err := s.toolRepo.WithContext(ctx).FindByID(toolID)
+		if err != nil {
+			continue
+		}
+		query, err := s.queryRepo.WithContext(ctx).FindByID(tool.QueryID)
+		if err != nil || checked[query.DataSourceID] {
+			continue
+		}
+		checked[query.DataSourceID] = true
+
+		ds, err := s.dsRepo.WithContext(ctx).FindByID(query.DataSourceID)
+		if err != nil {
+			continue
+		}
+		if err := pingDataSource(ctx, s.pool, ds, alertPingTimeout); err != nil {
+			unreachable = append(unreachable, ds.Name)
+		}
+	}
+
+	return unreachable, nil
+}
+
+// notify delivers a notification to the rule's webhook and records the delivery
+func (s *alertService) notify(ctx context.Context, rule *model.AlertRule, event model.AlertEvent, value float64, message string) (*model.AlertDelivery, error) {
+	payload := model.AlertPayload{
+		Event:       string(event),
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		RuleType:    rule.Type,
+		McpServerID: rule.McpServerID,
+		ToolName:    rule.ToolName,
+		Threshold:   rule.Threshold,
+		Value:       value,
+		Window:      rule.WindowMinutes,
+		Message:     message,
+		Timestamp:   time.Now(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode alert payload: %w", err)
+	}
+
+	secret := ""
+	if rule.WebhookSecret != "" {
+		secret, err = crypto.Decrypt(rule.WebhookSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+		}
+	}
+
+	result := s.sender.Send(ctx, rule.WebhookURL, secret, string(event), body)
+
+	delivery := &model.AlertDelivery{
+		AlertRuleID: rule.ID,
+		Event:       string(event),
+		Payload:     string(body),
+		Attempts:    result.Attempts,
+		StatusCode:  result.StatusCode,
+		Success:     result.Err == nil,
+		DurationMs:  result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		delivery.ErrorMessage = result.Err.Error()
+	}
+
+	if err := s.alertRepo.WithContext(ctx).CreateDelivery(delivery); err != nil {
+		return delivery, err
+	}
+
+	return delivery, nil
+}
+
+// encryptSecret encrypts a webhook secret; an empty secret disables signing
+func encryptSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	encrypted, err := crypto.Encrypt(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	return encrypted, nil
+}
+
+// validateAlertRule checks the webhook of a rule and its thresholds against its type
+func validateAlertRule(rule *model.AlertRule) error {
+	if err := webhook.ValidateURL(rule.WebhookURL); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+	}
+
+	switch model.AlertRuleType(rule.Type) {
+	case model.AlertRuleErrorRate, model.AlertRuleQuotaUsage:
+		if rule.Threshold > 100 {
+			return fmt.Errorf("%w: threshold of a %s rule is a percentage and must not exceed 100", ErrInvalidAlertRule, rule.Type)
+		}
+	case model.AlertRuleP95Latency:
+	case model.AlertRuleDataSourceUnreachable:
+		if rule.Threshold != float64(int64(rule.Threshold)) {
+			return fmt.Errorf("%w: threshold of a %s rule is a number of data sources", ErrInvalidAlertRule, rule.Type)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAlertRule, rule.Type)
+	}
+
+	// Quota and reachability are properties of the whole server
+	if rule.ToolName != "" && (model.AlertRuleType(rule.Type) == model.AlertRuleQuotaUsage ||
+		model.AlertRuleType(rule.Type) == model.AlertRuleDataSourceUnreachable) {
+		return fmt.Errorf("%w: %s rules apply to the whole server and cannot target a tool", ErrInvalidAlertRule, rule.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// AlertWorker periodically evaluates every enabled alert rule
+type AlertWorker struct {
+	periodicTask
+	alertService AlertService
+}
+
+// NewAlertWorker creates a new AlertWorker
+func NewAlertWorker(alertService AlertService, interval time.Duration) *AlertWorker {
+	w := &AlertWorker{alertService: alertService}
+	w.periodicTask = newPeriodicTask(interval, w.run)
+	return w
+}
+
+func (w *AlertWorker) run() {
+	if err := w.alertService.Evaluate(context.Background()); err != nil {
+		logger.Error("Failed to evaluate alert rules", zap.Error(err))
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+)
+
+// connectionConfig builds the connector configuration of a datasource, decrypting its password
+func connectionConfig(ds *model.DataSource) (*dbconnector.ConnectionConfig, error) {
+	password, err := crypto.Decrypt(ds.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt datasource password: %w", err)
+	}
+	tlsConfig, err := dataSourceTLSConfig(ds.TLS)
+	if err != nil {
+		return nil, err
+	}
+	tunnel, err := sshTunnelConfig(ds.SSHTunnel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbconnector.ConnectionConfig{
+		Type:     dbconnector.DBType(ds.Type),
+		Host:     ds.Host,
+		Port:     ds.Port,
+		Username: ds.Username,
+		Password: password,
+		Database: ds.Database,
+		SSLMode:  ds.SSLMode,
+		Options:  ds.Options,
+
+		SessionInit: ds.SessionInit,
+		TLS:         tlsConfig,
+		SSHTunnel:   tunnel,
+	}, nil
+}
+
+// dataSourceTLSConfig decrypts the TLS settings of a datasource, nil when it has none
+func dataSourceTLSConfig(t model.DataSourceTLS) (*dbconnector.TLSConfig, error) {
+	if t.IsEmpty() {
+		return nil, nil
+	}
+
+	config := &dbconnector.TLSConfig{ServerName: t.ServerName}
+	secrets := []struct {
+		encrypted string
+		plain     *string
+	}{
+		{t.CACert, &config.CACert},
+		{t.ClientCert, &config.ClientCert},
+		{t.ClientKey, &config.ClientKey},
+	}
+	for _, secret := range secrets {
+		if secret.encrypted == "" {
+			continue
+		}
+		plain, err := crypto.Decrypt(secret.encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt tls certificate: %w", err)
+		}
+		*secret.plain = plain
+	}
+	return config, nil
+}
+
+// encryptDataSourceTLS converts validated TLS settings to their stored form
+func encryptDataSourceTLS(config *dbconnector.TLSConfig) (model.DataSourceTLS, error) {
+	if config == nil {
+		return model.DataSourceTLS{}, nil
+	}
+
+	t := model.DataSourceTLS{ServerName: config.ServerName}
+	secrets := []struct {
+		plain     string
+		encrypted *string
+	}{
+		{config.CACert, &t.CACert},
+		{config.ClientCert, &t.ClientCert},
+		{config.ClientKey, &t.ClientKey},
+	}
+	for _, secret := range secrets {
+		if secret.plain == "" {
+			continue
+		}
+		encrypted, err := crypto.Encrypt(secret.plain)
+		if err != nil {
+			return model.DataSourceTLS{}, fmt.Errorf("failed to encrypt tls certificate: %w", err)
+		}
+		*secret.encrypted = encrypted
+	}
+	return t, nil
+}
+
+// sshTunnelConfig decrypts the SSH tunnel of a datasource, nil when it connects directly
+func sshTunnelConfig(t model.SSHTunnelConfig) (*dbconnector.SSHTunnelConfig, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	config := &dbconnector.SSHTunnelConfig{
+		Host:       t.Host,
+		Port:       t.Port,
+		User:       t.User,
+		KnownHosts: t.KnownHosts,
+	}
+	secrets := []struct {
+		encrypted string
+		plain     *string
+	}{
+		{t.Password, &config.Password},
+		{t.PrivateKey, &config.PrivateKey},
+		{t.Passphrase, &config.Passphrase},
+	}
+	for _, secret := range secrets {
+		if secret.encrypted == "" {
+			continue
+		}
+		plain, err := crypto.Decrypt(secret.encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt ssh tunnel secret: %w", err)
+		}
+		*secret.plain = plain
+	}
+	return config, nil
+}
+
+// encryptSSHTunnel converts validated tunnel settings to their stored form
+func encryptSSHTunnel(config *dbconnector.SSHTunnelConfig) (model.SSHTunnelConfig, error) {
+	t := model.SSHTunnelConfig{
+		Enabled:    true,
+		Host:       config.Host,
+		Port:       config.Port,
+		User:       config.User,
+		KnownHosts: config.KnownHosts,
+	}
+	secrets := []struct {
+		plain     string
+		encrypted *string
+	}{
+		{config.Password, &t.Password},
+		{config.PrivateKey, &t.PrivateKey},
+		{config.Passphrase, &t.Passphrase},
+	}
+	for _, secret := range secrets {
+		if secret.plain == "" {
+			continue
+		}
+		encrypted, err := crypto.Encrypt(secret.plain)
+		if err != nil {
+			return model.SSHTunnelConfig{}, fmt.Errorf("failed to encrypt ssh tunnel secret: %w", err)
+		}
+		*secret.encrypted = encrypted
+	}
+	return t, nil
+}
+
+// pingDataSource checks that a datasource accepts connections, reusing its pooled connection
+func pingDataSource(ctx context.Context, pool *dbconnector.Pool, ds *model.DataSource, timeout time.Duration) error {
+	config, err := connectionConfig(ds)
+	if err != nil {
+		return err
+	}
+
+	connector, err := pool.Get(ds.ID, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return connector.DB().PingContext(ctx)
+}
+
+// toolParamTypes returns the declared type of each tool parameter, used by
+// databases that bind typed parameters
+func toolParamTypes(params model.ToolParameters) map[string]string {
+	types := make(map[string]string, len(params))
+	for _, p := range params {
+		types[p.Name] = bindType(p.Type, p.Format)
+	}
+	return types
+}
+
+// queryParamTypes returns the declared type of each query parameter
+func queryParamTypes(params model.JSONParameters) map[string]string {
+	types := make(map[string]string, len(params))
+	for _, p := range params {
+		types[p.Name] = bindType(p.Type, "")
+	}
+	return types
+}
+
+// bindType maps a declared parameter type and format to a dbconnector parameter
+// type. Unknown types map to "", leaving the type to be inferred from the value.
+func bindType(paramType, format string) string {
+	switch format {
+	case "date-time":
+		return dbconnector.ParamTypeDateTime
+	case "date":
+		return dbconnector.ParamTypeDate
+	}
+
+	switch paramType {
+	case dbconnector.ParamTypeString, dbconnector.ParamTypeNumber, dbconnector.ParamTypeInteger,
+		dbconnector.ParamTypeBoolean, dbconnector.ParamTypeDate:
+		return paramType
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/sqlparser"
+)
+
+// GetDependents returns the queries of a datasource, the tools built on them
+// and the MCP servers exposing those tools
+func (s *dataSourceService) GetDependents(id string, userID uint) (*model.DataSourceDependentsResponse, error) {
+	if _, err := s.repo.FindByIDAndUserID(id, userID); err != nil {
+		return nil, err
+	}
+	deps, err := s.repo.FindDependents(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A tool is exposed by the servers listing it and by the server it names
+	serversByTool := make(map[string][]model.DependentServer)
+	serverByID := make(map[string]model.DependentServer, len(deps.Servers))
+	published := 0
+	for _, server := range deps.Servers {
+		dependent := model.DependentServer{ID: server.ID, Name: server.Name, Status: server.Status}
+		serverByID[server.ID] = dependent
+		if server.Status == string(model.McpServerStatusPublished) {
+			published++
+		}
+		for _, toolID := range server.ToolIDs {
+			serversByTool[toolID] = append(serversByTool[toolID], dependent)
+		}
+	}
+	toolsByQuery := make(map[string][]model.DependentTool)
+	for _, tool := range deps.Tools {
+		servers := append([]model.DependentServer{}, serversByTool[tool.ID]...)
+		if tool.McpServerID != nil {
+			if server, ok := serverByID[*tool.McpServerID]; ok && !containsServer(servers, server.ID) {
+				servers = append(servers, server)
+			}
+		}
+		toolsByQuery[tool.QueryID] = append(toolsByQuery[tool.QueryID], model.DependentTool{
+			ID:      tool.ID,
+			Name:    tool.Name,
+			Status:  tool.Status,
+			Servers: servers,
+		})
+	}
+
+	result := &model.DataSourceDependentsResponse{
+		DataSourceID:         id,
+		Queries:              make([]model.DependentQuery, len(deps.Queries)),
+		QueryCount:           len(deps.Queries),
+		ToolCount:            len(deps.Tools),
+		ServerCount:          len(deps.Servers),
+		PublishedServerCount: published,
+	}
+	for i, q := range deps.Queries {
+		tools := toolsByQuery[q.ID]
+		if tools == nil {
+			tools = []model.DependentTool{}
+		}
+		result.Queries[i] = model.DependentQuery{ID: q.ID, Name: q.Name, Tables: queryTables(q.SQLTemplate), Tools: tools}
+	}
+	return result, nil
+}
+
+// RepointDependents moves the queries of a datasource to another datasource
+// of the same type, so their tools and servers read from it. Every table the
+// queries read must exist on the target first; otherwise, or on a dry run,
+// nothing is repointed and the response lists the missing tables.
+func (s *dataSourceService) RepointDependents(id string, userID uint, req *model.RepointDependentsRequest) (*model.RepointDependentsResponse, error) {
+	if req.TargetDataSourceID == id {
+		return nil, fmt.Errorf("%w: the target is the datasource itself", ErrInvalidRepointTarget)
+	}
+	source, err := s.repo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.repo.FindDependents(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.repo.FindByIDAndUserID(req.TargetDataSourceID, userID)
+	if errors.Is(err, repository.ErrDataSourceNotFound) {
+		return nil, fmt.Errorf("%w: target datasource not found", ErrInvalidRepointTarget)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if canonicalType(target.Type) != canonicalType(source.Type) {
+		return nil, fmt.Errorf("%w: the queries are written for %s, the target is %s", ErrInvalidRepointTarget, source.Type, target.Type)
+	}
+
+	// The check counts against the target's limits like any other read of it
+	ctx, cancel := context.WithTimeout(context.Background(), tableProfileTimeout)
+	defer cancel()
+	var tables []dbconnector.TableInfo
+	err = runOnDataSource(ctx, s.router, s.breakers, s.limiters, target, func(connector *dbconnector.Connector) error {
+		var err error
+		if tables, err = connector.GetSchema(); err != nil {
+			return fmt.Errorf("failed to get schema of target datasource: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("target datasource: %w", err)
+	}
+
+	result := &model.RepointDependentsResponse{Queries: make([]model.RepointedQuery, len(deps.Queries))}
+	missing := 0
+	for i, q := range deps.Queries {
+		checked := model.RepointedQuery{ID: q.ID, Name: q.Name, Tables: queryTables(q.SQLTemplate)}
+		for _, ref := range checked.Tables {
+			if !hasTable(tables, ref) {
+				checked.MissingTables = append(checked.MissingTables, ref)
+			}
+		}
+		missing += len(checked.MissingTables)
+		result.Queries[i] = checked
+	}
+
+	switch {
+	case len(deps.Queries) == 0:
+		result.Message = "no queries depend on the datasource"
+		return result, nil
+	case missing > 0:
+		result.Message = fmt.Sprintf("%d tables read by the queries do not exist on %s", missing, target.Name)
+		return result, nil
+	case req.DryRun:
+		result.Message = fmt.Sprintf("%d queries can be repointed to %s", len(deps.Queries), target.Name)
+		return result, nil
+	}
+
+	baseline, err := schemaBaseline(tables)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RepointQueries(id, target.ID, userID, deps.Queries, baseline); err != nil {
+		if errors.Is(err, repository.ErrDependentsChanged) {
+			return nil, fmt.Errorf("%w: queries were added, edited or moved while repointing, try again", ErrDependentsChanged)
+		}
+		return nil, err
+	}
+	result.Repointed = true
+	result.Message = fmt.Sprintf("%d queries repointed to %s", len(deps.Queries), target.Name)
+	return result, nil
+}
+
+// schemaBaseline returns a schema snapshot of tables, the schema of a
+// repointing target the moved queries were checked against
+func schemaBaseline(tables []dbconnector.TableInfo) (*model.SchemaSnapshot, error) {
+	snapshot := &model.SchemaSnapshot{Tables: make(model.SchemaTables, len(tables))}
+	for i, t := range tables {
+		snapshot.Tables[i] = toTableInfoResponse(t)
+	}
+	checksum, err := schemaChecksum(snapshot.Tables)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Checksum = checksum
+	return snapshot, nil
+}
+
+// containsServer reports whether servers include the server with id
+func containsServer(servers []model.DependentServer, id string) bool {
+	for _, server := range servers {
+		if server.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// describeDependents summarizes what depends on a datasource, e.g. for
+// telling why it cannot be deleted
+func describeDependents(deps *repository.DataSourceDependents) string {
+	names := make([]string, 0, len(deps.Queries))
+	for _, q := range deps.Queries {
+		names = append(names, q.Name)
+	}
+	published := 0
+	for _, server := range deps.Servers {
+		if server.Status == string(model.McpServerStatusPublished) {
+			published++
+		}
+	}
+	return fmt.Sprintf("%d queries (%s), %d tools and %d MCP servers (%d published) depend on it",
+		len(deps.Queries), strings.Join(names, ", "), len(deps.Tools), len(deps.Servers), published)
+}
+
+// cteNamePattern matches the names defined by the WITH clause of a query,
+// which ExtractReferences reports as tables where the query reads them
+var cteNamePattern = regexp.MustCompile(`(?i)(?:\bwith(?:\s+recursive)?|,)\s*([A-Za-z_][\w$]*|"[^"]+")\s*(?:\([^()]*\)\s*)?as\s*(?:not\s+)?(?:materialized\s*)?\(`)
+
+// queryTables returns the tables a SQL template reads, leaving out common
+// table expressions
+func queryTables(sqlTemplate string) []string {
+	ctes := make(map[string]bool)
+	for _, m := range cteNamePattern.FindAllStringSubmatch(sqlTemplate, -1) {
+		ctes[strings.ToLower(strings.Trim(m[1], `"`))] = true
+	}
+
+	tables := []string{}
+	for _, ref := range sqlparser.ExtractReferences(sqlTemplate).Tables {
+		if !ctes[ref] {
+			tables = append(tables, ref)
+		}
+	}
+	return tables
+}
+
+// hasTable reports whether one of tables is the table ref names
+func hasTable(tables []dbconnector.TableInfo, ref string) bool {
+	refs := sqlparser.References{Tables: []string{ref}}
+	for _, t := range tables {
+		if refs.HasTable(t.Schema, t.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+)
+
+// ParseDSN splits a connection string into datasource fields. Options the
+// database type does not support are returned with a warning, as creating
+// the datasource would reject them.
+func (s *dataSourceService) ParseDSN(req *model.ParseDSNRequest) (*model.ParseDSNResponse, error) {
+	if req.Type != "" && !isValidType(req.Type) {
+		return nil, ErrInvalidDataSourceType
+	}
+
+	parsed, err := dbconnector.ParseDSN(req.ConnectionURL, dbconnector.DBType(req.Type))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConnectionURL, err)
+	}
+
+	warnings := parsed.Warnings
+	for key, value := range parsed.Options {
+		if err := dbconnector.ValidateOptions(parsed.Type, map[string]string{key: value}); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	return &model.ParseDSNResponse{
+		Type:          string(parsed.Type),
+		Host:          parsed.Host,
+		Port:          parsed.Port,
+		Database:      parsed.Database,
+		Username:      parsed.Username,
+		Password:      parsed.Password,
+		SSLMode:       parsed.SSLMode,
+		TLSServerName: parsed.TLSServerName,
+		Options:       parsed.Options,
+		Warnings:      warnings,
+	}, nil
+}
+
+// applyConnectionURL fills the connection fields a create request leaves
+// empty from its connection string, then checks the fields the request
+// binding could not require
+func applyConnectionURL(req *model.CreateDataSourceRequest) error {
+	if req.ConnectionURL == "" {
+		return nil
+	}
+	if req.Type != "" && !isValidType(req.Type) {
+		return ErrInvalidDataSourceType
+	}
+
+	parsed, err := dbconnector.ParseDSN(req.ConnectionURL, dbconnector.DBType(req.Type))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConnectionURL, err)
+	}
+
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&req.Type, string(parsed.Type))
+	fill(&req.Host, parsed.Host)
+	fill(&req.Database, parsed.Database)
+	fill(&req.Username, parsed.Username)
+	fill(&req.Password, parsed.Password)
+	fill(&req.SSLMode, parsed.SSLMode)
+	if req.Port == 0 {
+		req.Port = parsed.Port
+	}
+
+	if parsed.TLSServerName != "" {
+		if req.TLS == nil {
+			req.TLS = &model.DataSourceTLSRequest{}
+		}
+		if req.TLS.ServerName == nil {
+			serverName := parsed.TLSServerName
+			req.TLS.ServerName = &serverName
+		}
+	}
+
+	if len(parsed.Options) > 0 {
+		options := make(model.DataSourceOptions, len(parsed.Options)+len(req.Options))
+		for key, value := range parsed.Options {
+			options[key] = value
+		}
+		for key, value := range req.Options {
+			options[key] = value
+		}
+		req.Options = options
+	}
+
+	switch {
+	case req.Database == "":
+		return fmt.Errorf("%w: database is required", ErrInvalidConnectionURL)
+	case req.Host == "":
+		return fmt.Errorf("%w: host is required", ErrInvalidConnectionURL)
+	case req.Username == "":
+		return fmt.Errorf("%w: username is required", ErrInvalidConnectionURL)
+	case req.Password == "":
+		return fmt.Errorf("%w: password is required", ErrInvalidConnectionURL)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+)
+
+// maxReplicas caps the replicas of a datasource, each of which keeps its own connection pool
+const maxReplicas = 8
+
+// resolveReplicas validates the replicas of a request for a datasource of
+// type dsType listening on port, and encrypts their passwords. Passwords the
+// request leaves empty are taken from current, the replicas stored so far.
+func resolveReplicas(dsType string, port int, reqs []model.DataSourceReplicaRequest, current model.DataSourceReplicas) (model.DataSourceReplicas, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if dbconnector.IsFileBased(dbconnector.DBType(dsType)) {
+		return nil, fmt.Errorf("%w: %s datasources cannot have replicas", ErrInvalidReplicas, dsType)
+	}
+	if len(reqs) > maxReplicas {
+		return nil, fmt.Errorf("%w: at most %d replicas are allowed", ErrInvalidReplicas, maxReplicas)
+	}
+
+	stored := make(map[string]model.DataSourceReplica, len(current))
+	for _, replica := range current {
+		stored[replica.Name] = replica
+	}
+
+	replicas := make(model.DataSourceReplicas, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		replica := model.DataSourceReplica{
+			Name:     req.Name,
+			Host:     req.Host,
+			Port:     req.Port,
+			Username: req.Username,
+			Weight:   1,
+		}
+		if replica.Host == "" {
+			return nil, fmt.Errorf("%w: host is required", ErrInvalidReplicas)
+		}
+		if replica.Port == 0 {
+			replica.Port = port
+		}
+		if replica.Port < 1 || replica.Port > 65535 {
+			return nil, fmt.Errorf("%w: port of replica %s must be between 1 and 65535", ErrInvalidReplicas, replica.Host)
+		}
+		if replica.Name == "" {
+			replica.Name = net.JoinHostPort(replica.Host, strconv.Itoa(replica.Port))
+		}
+		if seen[replica.Name] {
+			return nil, fmt.Errorf("%w: replica %s is listed twice", ErrInvalidReplicas, replica.Name)
+		}
+		seen[replica.Name] = true
+		if req.Weight != nil {
+			if *req.Weight < 0 || *req.Weight > 100 {
+				return nil, fmt.Errorf("%w: weight of replica %s must be between 0 and 100", ErrInvalidReplicas, replica.Name)
+			}
+			replica.Weight = *req.Weight
+		}
+
+		switch {
+		case req.Password != "":
+			encrypted, err := crypto.Encrypt(req.Password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt replica password: %w", err)
+			}
+			replica.Password = encrypted
+		case replica.Username != "":
+			previous, ok := stored[replica.Name]
+			if !ok || previous.Username != replica.Username || previous.Password == "" {
+				return nil, fmt.Errorf("%w: password of replica %s is required with its username", ErrInvalidReplicas, replica.Name)
+			}
+			replica.Password = previous.Password
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// validateReplicaRouting checks that the lag of the replicas of a datasource
+// of type dsType can be checked when routing asks for it
+func validateReplicaRouting(dsType string, routing model.ReplicaRouting) error {
+	if routing.MaxLagSeconds < 0 {
+		return fmt.Errorf("%w: max_lag_seconds must not be negative", ErrInvalidReplicas)
+	}
+	if routing.MaxLagSeconds > 0 && !dbconnector.SupportsReplicationLag(dbconnector.DBType(dsType)) {
+		return fmt.Errorf("%w: replication lag cannot be checked for %s", ErrInvalidReplicas, dsType)
+	}
+	return nil
+}
+
+// replicaSet builds the connector configuration of a datasource and its
+// replicas, decrypting their passwords. Reads pass it to the router, which
+// reuses the pooled connection of the primary or of a healthy replica.
+func replicaSet(ds *model.DataSource) (*dbconnector.ReplicaSet, error) {
+	primary, err := connectionConfig(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &dbconnector.ReplicaSet{
+		Primary:           primary,
+		MaxLag:            time.Duration(ds.ReplicaRouting.MaxLagSeconds) * time.Second,
+		FallbackToPrimary: ds.ReplicaRouting.FallbackToPrimary,
+	}
+	for _, replica := range ds.Replicas {
+		config := *primary
+		config.Host = replica.Host
+		config.Port = replica.Port
+		if replica.Username != "" {
+			password, err := crypto.Decrypt(replica.Password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt replica password: %w", err)
+			}
+			config.Username = replica.Username
+			config.Password = password
+		}
+		set.Replicas = append(set.Replicas, dbconnector.Replica{Name: replica.Name, Weight: replica.Weight, Config: &config})
+	}
+	return set, nil
+}
@@ -1,20 +1,36 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
 	"github.com/yourusername/dataweaver/pkg/crypto"
 	"github.com/yourusername/dataweaver/pkg/dbconnector"
 )
 
 var (
-	ErrInvalidDataSourceType = errors.New("invalid datasource type")
-	ErrDataSourceInUse       = errors.New("datasource is in use by queries")
-	ErrConnectionFailed      = errors.New("connection test failed")
+	ErrInvalidDataSourceType    = errors.New("invalid datasource type")
+	ErrInvalidDataSourcePath    = errors.New("invalid datasource path")
+	ErrInvalidDataSourceOptions = errors.New("invalid datasource options")
+	ErrInvalidDataFileUpload    = errors.New("invalid data file upload")
+	ErrInvalidSSHTunnel         = errors.New("invalid ssh tunnel")
+	ErrInvalidTLSConfig         = errors.New("invalid tls settings")
+	ErrInvalidConnectionURL     = errors.New("invalid connection url")
+	ErrInvalidSessionInit       = errors.New("invalid session initialization")
+	ErrInvalidReplicas          = errors.New("invalid replicas")
+	ErrDataSourceInUse          = errors.New("datasource is in use by queries")
+	ErrInvalidRepointTarget     = errors.New("invalid repoint target")
+	ErrDependentsChanged        = errors.New("dependents of the datasource changed")
+	ErrConnectionFailed         = errors.New("connection test failed")
+	ErrDataSourceBusy           = errors.New("datasource busy")
+	ErrDataSourceUnavailable    = errors.New("datasource unavailable")
 )
 
 // DataSourceService handles business logic for datasources
@@ -27,29 +43,56 @@ type DataSourceService interface {
 	Delete(id string, userID uint) error
 	TestConnection(id string, userID uint) (*model.TestConnectionResult, error)
 	TestConnectionDirect(req *model.CreateDataSourceRequest) (*model.TestConnectionResult, error)
+	ParseDSN(req *model.ParseDSNRequest) (*model.ParseDSNResponse, error)
 	GetTables(id string, userID uint) ([]model.TableInfoResponse, error)
+	PreviewTable(id string, userID uint, schema, table string, limit int) (*model.TablePreviewResponse, error)
+	ProfileTable(id string, userID uint, schema, table string) (*model.TableProfile, error)
+	GetTableProfile(id string, userID uint, schema, table string) (*model.TableProfile, error)
+	GetHealthHistory(id string, userID uint, limit int) (*model.DataSourceHealthHistoryResponse, error)
+	UploadDataFile(name string, r io.Reader) (*model.UploadDataFileResponse, error)
+	GetDependents(id string, userID uint) (*model.DataSourceDependentsResponse, error)
+	RepointDependents(id string, userID uint, req *model.RepointDependentsRequest) (*model.RepointDependentsResponse, error)
 }
 
 type dataSourceService struct {
-	repo repository.DataSourceRepository
+	repo     repository.DataSourceRepository
+	router   *dbconnector.Router
+	breakers *circuitbreaker.Set
+	limiters *concurrency.Set
 }
 
-// NewDataSourceService creates a new DataSourceService
-func NewDataSourceService(repo repository.DataSourceRepository) DataSourceService {
-	return &dataSourceService{repo: repo}
+// NewDataSourceService creates a new DataSourceService. Reads of table data go
+// through the same router, circuit breakers and concurrency limits as tool
+// executions, and the pooled connections of a datasource are closed through
+// router when it is changed or deleted.
+func NewDataSourceService(repo repository.DataSourceRepository, router *dbconnector.Router, breakers *circuitbreaker.Set, limiters *concurrency.Set) DataSourceService {
+	return &dataSourceService{repo: repo, router: router, breakers: breakers, limiters: limiters}
 }
 
 // Create creates a new datasource
 func (s *dataSourceService) Create(userID uint, req *model.CreateDataSourceRequest) (*model.DataSourceResponse, error) {
+	if err := applyConnectionURL(req); err != nil {
+		return nil, err
+	}
+
 	// Validate type
 	if !isValidType(req.Type) {
 		return nil, ErrInvalidDataSourceType
 	}
+	dsType := canonicalType(req.Type)
 
-	// Encrypt password
-	encryptedPassword, err := crypto.Encrypt(req.Password)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt password: %w", err)
+	if err := validateDataSourcePath(dsType, req.Database); err != nil {
+		return nil, err
+	}
+	if err := dbconnector.ValidateOptions(dbconnector.DBType(dsType), req.Options); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDataSourceOptions, err)
+	}
+	if err := dbconnector.ValidateSessionInit(dbconnector.DBType(dsType), req.SessionInit); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSessionInit, err)
+	}
+
+	if err := req.Concurrency.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConcurrencyConfig, err)
 	}
 
 	// Set default SSL mode
@@ -58,18 +101,60 @@ func (s *dataSourceService) Create(userID uint, req *model.CreateDataSourceReque
 		sslMode = "disable"
 	}
 
+	tlsConfig, err := resolveTLS(dsType, sslMode, req.TLS, nil)
+	if err != nil {
+		return nil, err
+	}
+	storedTLS, err := encryptDataSourceTLS(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnel model.SSHTunnelConfig
+	if req.SSHTunnel != nil && req.SSHTunnel.Enabled {
+		config, err := resolveSSHTunnel(dsType, req.SSHTunnel, nil)
+		if err != nil {
+			return nil, err
+		}
+		if tunnel, err = encryptSSHTunnel(config); err != nil {
+			return nil, err
+		}
+	}
+
+	replicas, err := resolveReplicas(dsType, req.Port, req.Replicas, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateReplicaRouting(dsType, req.ReplicaRouting); err != nil {
+		return nil, err
+	}
+
+	// Encrypt password
+	encryptedPassword, err := crypto.Encrypt(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
 	ds := &model.DataSource{
 		UserID:      userID,
 		Name:        req.Name,
 		Description: req.Description,
-		Type:        req.Type,
+		Type:        dsType,
 		Host:        req.Host,
 		Port:        req.Port,
 		Database:    req.Database,
 		Username:    req.Username,
 		Password:    encryptedPassword,
 		SSLMode:     sslMode,
+		Options:     req.Options,
+		SessionInit: model.StringArray(req.SessionInit),
 		Status:      "active",
+		Concurrency: req.Concurrency,
+		TLS:         storedTLS,
+		SSHTunnel:   tunnel,
+
+		Replicas:       replicas,
+		ReplicaRouting: req.ReplicaRouting,
 	}
 
 	if err := s.repo.Create(ds); err != nil {
@@ -155,7 +240,7 @@ func (s *dataSourceService) Update(id string, userID uint, req *model.UpdateData
 		if !isValidType(*req.Type) {
 			return nil, ErrInvalidDataSourceType
 		}
-		ds.Type = *req.Type
+		ds.Type = canonicalType(*req.Type)
 	}
 	if req.Host != nil {
 		ds.Host = *req.Host
@@ -182,10 +267,85 @@ func (s *dataSourceService) Update(id string, userID uint, req *model.UpdateData
 	if req.Status != nil {
 		ds.Status = *req.Status
 	}
+	if req.Options != nil {
+		ds.Options = *req.Options
+	}
+	if req.Type != nil || req.Database != nil {
+		if err := validateDataSourcePath(ds.Type, ds.Database); err != nil {
+			return nil, err
+		}
+	}
+	if req.Type != nil || req.Options != nil {
+		if err := dbconnector.ValidateOptions(dbconnector.DBType(ds.Type), ds.Options); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDataSourceOptions, err)
+		}
+	}
+	if req.SessionInit != nil {
+		ds.SessionInit = model.StringArray(req.SessionInit)
+	}
+	if req.Type != nil || req.SessionInit != nil {
+		if err := dbconnector.ValidateSessionInit(dbconnector.DBType(ds.Type), ds.SessionInit); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSessionInit, err)
+		}
+	}
+	if req.Concurrency != nil {
+		if err := req.Concurrency.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConcurrencyConfig, err)
+		}
+		ds.Concurrency = *req.Concurrency
+	}
+	if req.Type != nil || req.SSLMode != nil || req.TLS != nil {
+		current, err := dataSourceTLSConfig(ds.TLS)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig, err := resolveTLS(ds.Type, ds.SSLMode, req.TLS, current)
+		if err != nil {
+			return nil, err
+		}
+		if ds.TLS, err = encryptDataSourceTLS(tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	if req.SSHTunnel != nil {
+		if !req.SSHTunnel.Enabled {
+			ds.SSHTunnel = model.SSHTunnelConfig{}
+		} else {
+			current, err := sshTunnelConfig(ds.SSHTunnel)
+			if err != nil {
+				return nil, err
+			}
+			config, err := resolveSSHTunnel(ds.Type, req.SSHTunnel, current)
+			if err != nil {
+				return nil, err
+			}
+			if ds.SSHTunnel, err = encryptSSHTunnel(config); err != nil {
+				return nil, err
+			}
+		}
+	} else if req.Type != nil && ds.SSHTunnel.Enabled && dbconnector.IsFileBased(dbconnector.DBType(ds.Type)) {
+		return nil, fmt.Errorf("%w: %s datasources cannot use an SSH tunnel", ErrInvalidSSHTunnel, ds.Type)
+	}
+	if req.Replicas != nil {
+		if ds.Replicas, err = resolveReplicas(ds.Type, ds.Port, req.Replicas, ds.Replicas); err != nil {
+			return nil, err
+		}
+	} else if req.Type != nil && len(ds.Replicas) > 0 && dbconnector.IsFileBased(dbconnector.DBType(ds.Type)) {
+		return nil, fmt.Errorf("%w: %s datasources cannot have replicas", ErrInvalidReplicas, ds.Type)
+	}
+	if req.ReplicaRouting != nil {
+		ds.ReplicaRouting = *req.ReplicaRouting
+	}
+	if req.Type != nil || req.ReplicaRouting != nil {
+		if err := validateReplicaRouting(ds.Type, ds.ReplicaRouting); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.Update(ds); err != nil {
 		return nil, err
 	}
+	s.router.Remove(ds.ID)
 
 	return ds.ToResponse(), nil
 }
@@ -198,15 +358,24 @@ func (s *dataSourceService) Delete(id string, userID uint) error {
 		return err
 	}
 	if hasQueries {
-		return ErrDataSourceInUse
+		deps, err := s.repo.FindDependents(id, userID)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrDataSourceInUse, describeDependents(deps))
 	}
 
-	return s.repo.Delete(id, userID)
+	if err := s.repo.Delete(id, userID); err != nil {
+		return err
+	}
+	s.router.Remove(id)
+	s.limiters.Remove(limiterKey(concurrencyScopeDataSource, id))
+	return nil
 }
 
 // TestConnection tests the connection to a datasource
 func (s *dataSourceService) TestConnection(id string, userID uint) (*model.TestConnectionResult, error) {
-	ds, err := s.GetWithPassword(id, userID)
+	ds, err := s.repo.FindByIDAndUserID(id, userID)
 	if err != nil {
 		return &model.TestConnectionResult{
 			Success: false,
@@ -214,36 +383,84 @@ func (s *dataSourceService) TestConnection(id string, userID uint) (*model.TestC
 		}, nil
 	}
 
-	return s.testConnection(ds.Type, ds.Host, ds.Port, ds.Username, ds.Password, ds.Database, ds.SSLMode)
+	config, err := connectionConfig(ds)
+	if err != nil {
+		return &model.TestConnectionResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	return s.testConnection(config)
 }
 
 // TestConnectionDirect tests connection without saving to database
 func (s *dataSourceService) TestConnectionDirect(req *model.CreateDataSourceRequest) (*model.TestConnectionResult, error) {
+	if err := applyConnectionURL(req); err != nil {
+		return &model.TestConnectionResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	if err := dbconnector.ValidateSessionInit(dbconnector.DBType(req.Type), req.SessionInit); err != nil {
+		return &model.TestConnectionResult{
+			Success: false,
+			Message: fmt.Errorf("%w: %v", ErrInvalidSessionInit, err).Error(),
+		}, nil
+	}
+
 	sslMode := req.SSLMode
 	if sslMode == "" {
 		sslMode = "disable"
 	}
 
-	return s.testConnection(req.Type, req.Host, req.Port, req.Username, req.Password, req.Database, sslMode)
-}
-
-func (s *dataSourceService) testConnection(dbType, host string, port int, username, password, database, sslMode string) (*model.TestConnectionResult, error) {
-	start := time.Now()
-
 	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(dbType),
-		Host:     host,
-		Port:     port,
-		Username: username,
-		Password: password,
-		Database: database,
+		Type:     dbconnector.DBType(req.Type),
+		Host:     req.Host,
+		Port:     req.Port,
+		Username: req.Username,
+		Password: req.Password,
+		Database: req.Database,
 		SSLMode:  sslMode,
+		Options:  req.Options,
+
+		SessionInit: req.SessionInit,
+	}
+	tlsConfig, err := resolveTLS(req.Type, sslMode, req.TLS, nil)
+	if err != nil {
+		return &model.TestConnectionResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	config.TLS = tlsConfig
+	if req.SSHTunnel != nil && req.SSHTunnel.Enabled {
+		tunnel, err := resolveSSHTunnel(req.Type, req.SSHTunnel, nil)
+		if err != nil {
+			return &model.TestConnectionResult{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		config.SSHTunnel = tunnel
 	}
 
-	connector := dbconnector.NewConnector(config)
-	err := connector.TestConnection()
-	latency := time.Since(start).Milliseconds()
+	return s.testConnection(config)
+}
+
+// connectionTestTimeout bounds the network stages of a connection test
+const connectionTestTimeout = 30 * time.Second
+
+// testConnection diagnoses a connection stage by stage, so a failure names
+// the stage it happened at instead of surfacing only the driver's error
+func (s *dataSourceService) testConnection(config *dbconnector.ConnectionConfig) (*model.TestConnectionResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTestTimeout)
+	defer cancel()
 
+	start := time.Now()
+	diagnosis, err := dbconnector.Diagnose(ctx, config)
+	latency := time.Since(start).Milliseconds()
 	if err != nil {
 		return &model.TestConnectionResult{
 			Success: false,
@@ -252,67 +469,412 @@ func (s *dataSourceService) testConnection(dbType, host string, port int, userna
 		}, nil
 	}
 
-	return &model.TestConnectionResult{
+	result := &model.TestConnectionResult{
 		Success: true,
 		Message: "Connection successful",
 		Latency: latency,
-	}, nil
+		Hop:     string(diagnosis.Hop),
+		Stages:  make([]model.ConnectionStageResult, len(diagnosis.Stages)),
+	}
+	for i, stage := range diagnosis.Stages {
+		result.Stages[i] = model.ConnectionStageResult{
+			Stage:   string(stage.Stage),
+			Status:  string(stage.Status),
+			Latency: stage.Latency.Milliseconds(),
+			Message: stage.Message,
+			Details: stage.Details,
+		}
+	}
+	if failed := diagnosis.Failed(); failed != nil {
+		result.Success = false
+		result.Message = fmt.Sprintf("%s check failed: %s", failed.Stage, failed.Message)
+	}
+	return result, nil
 }
 
 // GetTables returns the list of tables in a datasource
 func (s *dataSourceService) GetTables(id string, userID uint) ([]model.TableInfoResponse, error) {
-	ds, err := s.GetWithPassword(id, userID)
+	var tables []dbconnector.TableInfo
+	err := s.withConnection(context.Background(), id, userID, func(connector *dbconnector.Connector) error {
+		var err error
+		if tables, err = connector.GetSchema(); err != nil {
+			return fmt.Errorf("failed to get schema: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(ds.Type),
-		Host:     ds.Host,
-		Port:     ds.Port,
-		Username: ds.Username,
-		Password: ds.Password,
-		Database: ds.Database,
-		SSLMode:  ds.SSLMode,
+	responses := make([]model.TableInfoResponse, len(tables))
+	for i, t := range tables {
+		responses[i] = toTableInfoResponse(t)
 	}
 
-	connector := dbconnector.NewConnector(config)
-	if err := connector.Connect(); err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+	return responses, nil
+}
+
+// tableProfileTimeout bounds the aggregate queries of a table preview or profile
+const tableProfileTimeout = 2 * time.Minute
+
+// withConnection runs fn on the pooled connection of a datasource of the user
+func (s *dataSourceService) withConnection(ctx context.Context, id string, userID uint, fn func(*dbconnector.Connector) error) error {
+	ds, err := s.repo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return err
 	}
-	defer connector.Close()
+	return runOnDataSource(ctx, s.router, s.breakers, s.limiters, ds, fn)
+}
 
-	tables, err := connector.GetSchema()
+// runOnDataSource runs fn on the pooled connection of ds, taking a slot of the
+// datasource's concurrency limit and failing fast while its circuit is open,
+// like tool executions do. fn's error is returned as is.
+func runOnDataSource(ctx context.Context, router *dbconnector.Router, breakers *circuitbreaker.Set, limiters *concurrency.Set, ds *model.DataSource, fn func(*dbconnector.Connector) error) error {
+	release, _, err := acquireSlot(ctx, limiters, concurrencyScopeDataSource, ds.ID, ds.Concurrency)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get schema: %w", err)
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrDataSourceBusy, err)
 	}
+	defer release()
 
-	responses := make([]model.TableInfoResponse, len(tables))
-	for i, t := range tables {
-		columns := make([]model.ColumnInfoResponse, len(t.Columns))
-		for j, c := range t.Columns {
-			columns[j] = model.ColumnInfoResponse{
-				Name:       c.Name,
-				Type:       c.Type,
-				Nullable:   c.Nullable,
-				PrimaryKey: c.PrimaryKey,
-			}
+	set, err := replicaSet(ds)
+	if err != nil {
+		return err
+	}
+
+	breaker := breakers.Get(ds.ID)
+	if err := breaker.Allow(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDataSourceUnavailable, err)
+	}
+	connector, _, err := router.Get(ctx, ds.ID, set)
+	if errors.Is(err, dbconnector.ErrNoHealthyReplica) {
+		breaker.Release()
+		return fmt.Errorf("%w: %v", ErrDataSourceUnavailable, err)
+	}
+	if err != nil {
+		breaker.Failure()
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	err = fn(connector)
+	// Only connection failures count against the datasource
+	if dbconnector.IsConnectionError(err) {
+		breaker.Failure()
+	} else {
+		breaker.Success()
+	}
+	return err
+}
+
+// PreviewTable returns a sample of the rows of a table, leaving out sensitive columns
+func (s *dataSourceService) PreviewTable(id string, userID uint, schema, table string, limit int) (*model.TablePreviewResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tableProfileTimeout)
+	defer cancel()
+
+	var preview *dbconnector.TablePreview
+	err := s.withConnection(ctx, id, userID, func(connector *dbconnector.Connector) error {
+		var err error
+		preview, err = connector.PreviewTable(ctx, schema, table, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := preview.Data
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	excluded := preview.ExcludedColumns
+	if excluded == nil {
+		excluded = []string{}
+	}
+
+	return &model.TablePreviewResponse{
+		Columns:         preview.Columns,
+		Rows:            rows,
+		RowCount:        len(rows),
+		ExcludedColumns: excluded,
+	}, nil
+}
+
+// ProfileTable profiles the columns of a table and stores the result
+func (s *dataSourceService) ProfileTable(id string, userID uint, schema, table string) (*model.TableProfile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tableProfileTimeout)
+	defer cancel()
+
+	var profile *dbconnector.TableProfile
+	err := s.withConnection(ctx, id, userID, func(connector *dbconnector.Connector) error {
+		var err error
+		profile, err = connector.ProfileTable(ctx, schema, table)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stored := toTableProfile(id, profile)
+	if err := s.repo.SaveTableProfile(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// GetTableProfile returns the stored profile of a table
+func (s *dataSourceService) GetTableProfile(id string, userID uint, schema, table string) (*model.TableProfile, error) {
+	if _, err := s.repo.FindByIDAndUserID(id, userID); err != nil {
+		return nil, err
+	}
+	profile, err := s.repo.FindTableProfile(id, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	hideSensitiveColumns(profile)
+	return profile, nil
+}
+
+// hideSensitiveColumns moves the columns of a stored profile that became
+// sensitive after it was taken to its excluded columns
+func hideSensitiveColumns(profile *model.TableProfile) {
+	columns := profile.Columns[:0:0]
+	for _, col := range profile.Columns {
+		if dbconnector.IsSensitiveColumn(col.Name) {
+			profile.ExcludedColumns = append(profile.ExcludedColumns, col.Name)
+			continue
+		}
+		columns = append(columns, col)
+	}
+	profile.Columns = columns
+}
+
+// toTableProfile converts a computed table profile to its stored form
+func toTableProfile(dataSourceID string, p *dbconnector.TableProfile) *model.TableProfile {
+	columns := make(model.ColumnProfiles, len(p.Columns))
+	for i, c := range p.Columns {
+		col := model.ColumnProfile{
+			Name:          c.Name,
+			Type:          c.Type,
+			NullCount:     c.NullCount,
+			NullRatio:     c.NullRatio,
+			DistinctCount: c.DistinctCount,
+			Min:           c.Min,
+			Max:           c.Max,
+			Error:         c.Error,
+		}
+		for _, v := range c.TopValues {
+			col.TopValues = append(col.TopValues, model.ValueCount{Value: v.Value, Count: v.Count})
 		}
-		responses[i] = model.TableInfoResponse{
-			Name:    t.Name,
-			Schema:  t.Schema,
-			Columns: columns,
+		for _, b := range c.Histogram {
+			col.Histogram = append(col.Histogram, model.HistogramBucket{Lower: b.Lower, Upper: b.Upper, Count: b.Count})
 		}
+		columns[i] = col
 	}
 
-	return responses, nil
+	excluded := model.StringArray(p.ExcludedColumns)
+	if excluded == nil {
+		excluded = model.StringArray{}
+	}
+
+	return &model.TableProfile{
+		DataSourceID:    dataSourceID,
+		Schema:          p.Schema,
+		Table:           p.Table,
+		RowCount:        p.RowCount,
+		Sampled:         p.Sampled,
+		Columns:         columns,
+		ExcludedColumns: excluded,
+		ProfiledAt:      time.Now(),
+	}
 }
 
+// toTableInfoResponse converts introspected table information to its API form
+func toTableInfoResponse(t dbconnector.TableInfo) model.TableInfoResponse {
+	columns := make([]model.ColumnInfoResponse, len(t.Columns))
+	for i, c := range t.Columns {
+		columns[i] = model.ColumnInfoResponse{
+			Name:       c.Name,
+			Type:       c.Type,
+			Nullable:   c.Nullable,
+			PrimaryKey: c.PrimaryKey,
+			Default:    c.Default,
+			Comment:    c.Comment,
+			ParamType:  c.ParamType,
+		}
+	}
+
+	var foreignKeys []model.ForeignKeyInfoResponse
+	for _, fk := range t.ForeignKeys {
+		foreignKeys = append(foreignKeys, model.ForeignKeyInfoResponse{
+			Name:              fk.Name,
+			Columns:           fk.Columns,
+			ReferencedSchema:  fk.ReferencedSchema,
+			ReferencedTable:   fk.ReferencedTable,
+			ReferencedColumns: fk.ReferencedColumns,
+		})
+	}
+
+	var indexes []model.IndexInfoResponse
+	for _, idx := range t.Indexes {
+		indexes = append(indexes, model.IndexInfoResponse{
+			Name:    idx.Name,
+			Columns: idx.Columns,
+			Unique:  idx.Unique,
+			Primary: idx.Primary,
+		})
+	}
+
+	return model.TableInfoResponse{
+		Name:        t.Name,
+		Schema:      t.Schema,
+		Kind:        t.Kind,
+		Comment:     t.Comment,
+		RowEstimate: t.RowEstimate,
+		Columns:     columns,
+		ForeignKeys: foreignKeys,
+		Indexes:     indexes,
+	}
+}
+
+// isValidType reports whether a dialect is registered for the datasource type
 func isValidType(t string) bool {
-	switch t {
-	case "mysql", "postgresql", "sqlserver", "oracle":
-		return true
-	default:
-		return false
+	_, ok := dbconnector.Lookup(dbconnector.DBType(t))
+	return ok
+}
+
+// canonicalType resolves aliases, such as mssql, to the registered datasource type
+func canonicalType(t string) string {
+	if d, ok := dbconnector.Lookup(dbconnector.DBType(t)); ok {
+		return string(d.Name())
+	}
+	return t
+}
+
+// resolveTLS applies the TLS settings of a request to current, the settings
+// stored so far, and validates them for the datasource type and SSL mode. It
+// returns nil when no settings remain.
+func resolveTLS(dsType, sslMode string, req *model.DataSourceTLSRequest, current *dbconnector.TLSConfig) (*dbconnector.TLSConfig, error) {
+	config := &dbconnector.TLSConfig{}
+	if current != nil {
+		*config = *current
+	}
+	if req != nil {
+		fields := []struct {
+			value *string
+			field *string
+		}{
+			{req.CACert, &config.CACert},
+			{req.ClientCert, &config.ClientCert},
+			{req.ClientKey, &config.ClientKey},
+			{req.ServerName, &config.ServerName},
+		}
+		for _, f := range fields {
+			if f.value != nil {
+				*f.field = *f.value
+			}
+		}
+	}
+	if *config == (dbconnector.TLSConfig{}) {
+		config = nil
+	}
+
+	if err := dbconnector.ValidateTLS(dbconnector.DBType(dsType), sslMode, config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTLSConfig, err)
+	}
+	return config, nil
+}
+
+// resolveSSHTunnel validates the SSH tunnel of a request for a datasource of
+// type dsType. Secrets the request leaves empty are taken from current, the
+// tunnel stored so far, if there is one.
+func resolveSSHTunnel(dsType string, req *model.SSHTunnelRequest, current *dbconnector.SSHTunnelConfig) (*dbconnector.SSHTunnelConfig, error) {
+	if dbconnector.IsFileBased(dbconnector.DBType(dsType)) {
+		return nil, fmt.Errorf("%w: %s datasources cannot use an SSH tunnel", ErrInvalidSSHTunnel, dsType)
+	}
+
+	config := &dbconnector.SSHTunnelConfig{
+		Host:       req.Host,
+		Port:       req.Port,
+		User:       req.User,
+		Password:   req.Password,
+		PrivateKey: req.PrivateKey,
+		Passphrase: req.Passphrase,
+		KnownHosts: req.KnownHosts,
+	}
+	if config.Port == 0 {
+		config.Port = 22
+	}
+	if current != nil {
+		if config.Password == "" {
+			config.Password = current.Password
+		}
+		if config.PrivateKey == "" {
+			config.PrivateKey = current.PrivateKey
+			if config.Passphrase == "" {
+				config.Passphrase = current.Passphrase
+			}
+		}
 	}
+
+	if err := dbconnector.ValidateSSHTunnel(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSSHTunnel, err)
+	}
+	return config, nil
+}
+
+// validateDataSourcePath checks that the file of a file-based datasource
+// exists inside the directory its dialect allows
+func validateDataSourcePath(dsType, path string) error {
+	d, ok := dbconnector.Lookup(dbconnector.DBType(dsType))
+	if !ok {
+		return nil
+	}
+	resolver, ok := d.(dbconnector.PathResolver)
+	if !ok {
+		return nil
+	}
+	if _, err := resolver.ResolvePath(path); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDataSourcePath, err)
+	}
+	return nil
+}
+
+// UploadDataFile stores an uploaded CSV, NDJSON or Parquet file in the data
+// directory and returns the path a file datasource reads it from
+func (s *dataSourceService) UploadDataFile(name string, r io.Reader) (*model.UploadDataFileResponse, error) {
+	path, size, err := dbconnector.SaveFileUpload(name, r)
+	if err != nil {
+		if errors.Is(err, dbconnector.ErrFilesDisabled) || errors.Is(err, dbconnector.ErrUnsupportedFileFormat) ||
+			errors.Is(err, dbconnector.ErrFileTooLarge) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDataFileUpload, err)
+		}
+		return nil, err
+	}
+
+	return &model.UploadDataFileResponse{Path: path, Size: size}, nil
+}
+
+// GetHealthHistory returns the current health of a datasource and its most recent health checks
+func (s *dataSourceService) GetHealthHistory(id string, userID uint, limit int) (*model.DataSourceHealthHistoryResponse, error) {
+	ds, err := s.repo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	checks, err := s.repo.FindHealthChecks(id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DataSourceHealthHistoryResponse{
+		Status:        ds.HealthStatus,
+		Error:         ds.HealthError,
+		LastCheckedAt: ds.LastHealthCheckAt,
+		Checks:        checks,
+	}, nil
 }
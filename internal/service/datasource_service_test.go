@@ -1,13 +1,33 @@
 package service
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
 	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func init() {
@@ -15,9 +35,22 @@ func init() {
 	_ = crypto.Init("12345678901234567890123456789012")
 }
 
+// newTestRouter returns a router over an empty pool, closed when the test ends
+func newTestRouter(t *testing.T) *dbconnector.Router {
+	pool := dbconnector.NewPool(dbconnector.DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	return dbconnector.NewRouter(pool, dbconnector.DefaultRouterOptions())
+}
+
+// newTestDataSourceService returns a service over repo with its own router,
+// circuit breakers and concurrency limits
+func newTestDataSourceService(t *testing.T, repo repository.DataSourceRepository) DataSourceService {
+	return NewDataSourceService(repo, newTestRouter(t), circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), concurrency.NewSet())
+}
+
 func TestDataSourceService_Create(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	req := &model.CreateDataSourceRequest{
 		Name:     "Test DB",
@@ -47,7 +80,7 @@ func TestDataSourceService_Create(t *testing.T) {
 
 func TestDataSourceService_Create_InvalidType(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	req := &model.CreateDataSourceRequest{
 		Name:     "Test DB",
@@ -68,7 +101,7 @@ func TestDataSourceService_Create_InvalidType(t *testing.T) {
 
 func TestDataSourceService_List(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	datasources := []model.DataSource{
 		{
@@ -110,7 +143,7 @@ func TestDataSourceService_List(t *testing.T) {
 
 func TestDataSourceService_List_WithSearch(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	datasources := []model.DataSource{
 		{
@@ -140,7 +173,7 @@ func TestDataSourceService_List_WithSearch(t *testing.T) {
 
 func TestDataSourceService_Get(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	ds := &model.DataSource{
 		ID:       "uuid-1",
@@ -168,7 +201,7 @@ func TestDataSourceService_Get(t *testing.T) {
 
 func TestDataSourceService_Get_NotFound(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	mockRepo.On("FindByIDAndUserID", "uuid-not-found", uint(1)).Return(nil, repository.ErrDataSourceNotFound)
 
@@ -183,7 +216,7 @@ func TestDataSourceService_Get_NotFound(t *testing.T) {
 
 func TestDataSourceService_Update(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	ds := &model.DataSource{
 		ID:       "uuid-1",
@@ -217,7 +250,7 @@ func TestDataSourceService_Update(t *testing.T) {
 
 func TestDataSourceService_Delete(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	mockRepo.On("HasAssociatedQueries", "uuid-1").Return(false, nil)
 	mockRepo.On("Delete", "uuid-1", uint(1)).Return(nil)
@@ -231,14 +264,19 @@ func TestDataSourceService_Delete(t *testing.T) {
 
 func TestDataSourceService_Delete_WithAssociatedQueries(t *testing.T) {
 	mockRepo := new(repository.MockDataSourceRepository)
-	svc := NewDataSourceService(mockRepo)
+	svc := newTestDataSourceService(t, mockRepo)
 
 	mockRepo.On("HasAssociatedQueries", "uuid-1").Return(true, nil)
+	mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(&repository.DataSourceDependents{
+		Queries: []model.Query{{ID: "q1", Name: "orders"}},
+		Tools:   []model.Tool{{ID: "t1", QueryID: "q1"}},
+		Servers: []model.McpServer{{ID: "s1", Status: string(model.McpServerStatusPublished)}},
+	}, nil)
 
 	err := svc.Delete("uuid-1", 1)
 
-	assert.Error(t, err)
-	assert.Equal(t, ErrDataSourceInUse, err)
+	assert.ErrorIs(t, err, ErrDataSourceInUse)
+	assert.Contains(t, err.Error(), "1 queries (orders), 1 tools and 1 MCP servers (1 published)")
 
 	mockRepo.AssertExpectations(t)
 }
@@ -264,3 +302,697 @@ func TestIsValidType(t *testing.T) {
 		})
 	}
 }
+
+// testSSHTunnelRequest returns tunnel settings authenticating with a fresh private key
+func testSSHTunnelRequest(t *testing.T) *model.SSHTunnelRequest {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	hostKey, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	return &model.SSHTunnelRequest{
+		Enabled:    true,
+		Host:       "bastion.example.com",
+		User:       "tunnel",
+		PrivateKey: string(pem.EncodeToMemory(block)),
+		KnownHosts: knownhosts.Line([]string{"bastion.example.com"}, hostKey),
+	}
+}
+
+func TestDataSourceService_Create_WithSSHTunnel(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	tunnel := testSSHTunnelRequest(t)
+	req := &model.CreateDataSourceRequest{
+		Name:      "Test DB",
+		Type:      "postgresql",
+		Host:      "db.internal",
+		Port:      5432,
+		Database:  "testdb",
+		Username:  "user",
+		Password:  "password",
+		SSHTunnel: tunnel,
+	}
+
+	var stored *model.DataSource
+	mockRepo.On("Create", mock.AnythingOfType("*model.DataSource")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*model.DataSource)
+	}).Return(nil)
+
+	result, err := svc.Create(1, req)
+
+	require.NoError(t, err)
+	require.NotNil(t, result.SSHTunnel)
+	assert.Equal(t, 22, result.SSHTunnel.Port)
+	assert.Equal(t, "private_key", result.SSHTunnel.AuthMethod)
+
+	assert.NotEqual(t, tunnel.PrivateKey, stored.SSHTunnel.PrivateKey, "private key stored in plain text")
+	config, err := connectionConfig(stored)
+	require.NoError(t, err)
+	require.NotNil(t, config.SSHTunnel)
+	assert.Equal(t, tunnel.PrivateKey, config.SSHTunnel.PrivateKey)
+}
+
+func TestDataSourceService_Create_InvalidSSHTunnel(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	tunnel := testSSHTunnelRequest(t)
+	tunnel.KnownHosts = ""
+	req := &model.CreateDataSourceRequest{
+		Name:      "Test DB",
+		Type:      "postgresql",
+		Host:      "db.internal",
+		Port:      5432,
+		Database:  "testdb",
+		Username:  "user",
+		Password:  "password",
+		SSHTunnel: tunnel,
+	}
+
+	_, err := svc.Create(1, req)
+
+	assert.ErrorIs(t, err, ErrInvalidSSHTunnel)
+}
+
+func TestDataSourceService_Update_KeepsSSHTunnelSecrets(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	tunnel := testSSHTunnelRequest(t)
+	config, err := resolveSSHTunnel("postgresql", tunnel, nil)
+	require.NoError(t, err)
+	stored, err := encryptSSHTunnel(config)
+	require.NoError(t, err)
+
+	ds := &model.DataSource{
+		ID:        "uuid-1",
+		UserID:    1,
+		Type:      "postgresql",
+		Host:      "db.internal",
+		Port:      5432,
+		Password:  "encrypted",
+		SSHTunnel: stored,
+	}
+
+	// Moving to another bastion without sending the key again
+	update := *tunnel
+	update.Host = "bastion2.example.com"
+	update.PrivateKey = ""
+	req := &model.UpdateDataSourceRequest{SSHTunnel: &update}
+
+	mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(ds, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.DataSource")).Return(nil)
+
+	result, err := svc.Update("uuid-1", 1, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "bastion2.example.com", result.SSHTunnel.Host)
+	updated, err := sshTunnelConfig(ds.SSHTunnel)
+	require.NoError(t, err)
+	assert.Equal(t, tunnel.PrivateKey, updated.PrivateKey)
+}
+
+// testCACert returns a self-signed PEM encoded CA certificate
+func testCACert(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestDataSourceService_Create_WithTLS(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	caCert := testCACert(t)
+	serverName := "db.example.com"
+	req := &model.CreateDataSourceRequest{
+		Name:     "Test DB",
+		Type:     "mysql",
+		Host:     "10.0.0.5",
+		Port:     3306,
+		Database: "testdb",
+		Username: "user",
+		Password: "password",
+		SSLMode:  "verify-full",
+		TLS:      &model.DataSourceTLSRequest{CACert: &caCert, ServerName: &serverName},
+	}
+
+	var stored *model.DataSource
+	mockRepo.On("Create", mock.AnythingOfType("*model.DataSource")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*model.DataSource)
+	}).Return(nil)
+
+	result, err := svc.Create(1, req)
+
+	require.NoError(t, err)
+	require.NotNil(t, result.TLS)
+	assert.True(t, result.TLS.HasCACert)
+	assert.False(t, result.TLS.HasClientCert)
+	assert.Equal(t, serverName, result.TLS.ServerName)
+
+	assert.NotEqual(t, caCert, stored.TLS.CACert, "ca certificate stored in plain text")
+	config, err := connectionConfig(stored)
+	require.NoError(t, err)
+	require.NotNil(t, config.TLS)
+	assert.Equal(t, caCert, config.TLS.CACert)
+}
+
+func TestDataSourceService_Create_InvalidTLS(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	req := &model.CreateDataSourceRequest{
+		Name:     "Test DB",
+		Type:     "postgresql",
+		Host:     "localhost",
+		Port:     5432,
+		Database: "testdb",
+		Username: "user",
+		Password: "password",
+		SSLMode:  "prefer",
+	}
+
+	_, err := svc.Create(1, req)
+
+	assert.ErrorIs(t, err, ErrInvalidTLSConfig)
+}
+
+func TestDataSourceService_Update_KeepsTLSCertificates(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	caCert := testCACert(t)
+	stored, err := encryptDataSourceTLS(&dbconnector.TLSConfig{CACert: caCert})
+	require.NoError(t, err)
+
+	ds := &model.DataSource{
+		ID:       "uuid-1",
+		UserID:   1,
+		Type:     "postgresql",
+		Host:     "10.0.0.5",
+		Port:     5432,
+		Password: "encrypted",
+		SSLMode:  "verify-ca",
+		TLS:      stored,
+	}
+
+	serverName := "db.example.com"
+	sslMode := "verify-full"
+	req := &model.UpdateDataSourceRequest{
+		SSLMode: &sslMode,
+		TLS:     &model.DataSourceTLSRequest{ServerName: &serverName},
+	}
+
+	mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(ds, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.DataSource")).Return(nil)
+
+	result, err := svc.Update("uuid-1", 1, req)
+
+	require.NoError(t, err)
+	assert.True(t, result.TLS.HasCACert)
+	assert.Equal(t, serverName, result.TLS.ServerName)
+	updated, err := dataSourceTLSConfig(ds.TLS)
+	require.NoError(t, err)
+	assert.Equal(t, caCert, updated.CACert)
+}
+
+func TestDataSourceService_TestConnectionDirect_ReportsStages(t *testing.T) {
+	svc := newTestDataSourceService(t, new(repository.MockDataSourceRepository))
+
+	// A port nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	result, err := svc.TestConnectionDirect(&model.CreateDataSourceRequest{
+		Name:     "Test DB",
+		Type:     "postgresql",
+		Host:     "127.0.0.1",
+		Port:     port,
+		Database: "testdb",
+		Username: "user",
+		Password: "password",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.True(t, strings.HasPrefix(result.Message, "tcp check failed"), result.Message)
+	require.NotEmpty(t, result.Stages)
+	assert.Equal(t, "dns", result.Stages[0].Stage)
+	assert.Equal(t, "passed", result.Stages[0].Status)
+	assert.Equal(t, "tcp", result.Stages[1].Stage)
+	assert.Equal(t, "failed", result.Stages[1].Status)
+}
+
+func TestDataSourceService_Create_WithConnectionURL(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	req := &model.CreateDataSourceRequest{
+		Name:          "Test DB",
+		Database:      "reporting", // explicit fields win over the connection string
+		ConnectionURL: "jdbc:sqlserver://srv.example.com:1444;databaseName=sales;user=app;password=pw;encrypt=true;hostNameInCertificate=db.example.com;applicationName=dw",
+	}
+
+	var stored *model.DataSource
+	mockRepo.On("Create", mock.AnythingOfType("*model.DataSource")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*model.DataSource)
+	}).Return(nil)
+
+	result, err := svc.Create(1, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "sqlserver", result.Type)
+	assert.Equal(t, "srv.example.com", result.Host)
+	assert.Equal(t, 1444, result.Port)
+	assert.Equal(t, "reporting", result.Database)
+	assert.Equal(t, "app", result.Username)
+	assert.Equal(t, "verify-full", result.SSLMode)
+	assert.Equal(t, model.DataSourceOptions{"app name": "dw"}, result.Options)
+	require.NotNil(t, result.TLS)
+	assert.Equal(t, "db.example.com", result.TLS.ServerName)
+
+	password, err := crypto.Decrypt(stored.Password)
+	require.NoError(t, err)
+	assert.Equal(t, "pw", password)
+}
+
+func TestDataSourceService_Create_InvalidConnectionURL(t *testing.T) {
+	svc := newTestDataSourceService(t, new(repository.MockDataSourceRepository))
+
+	_, err := svc.Create(1, &model.CreateDataSourceRequest{Name: "Test DB", ConnectionURL: "redis://localhost:6379"})
+	assert.ErrorIs(t, err, ErrInvalidConnectionURL)
+
+	// Nothing to take the password from
+	_, err = svc.Create(1, &model.CreateDataSourceRequest{Name: "Test DB", ConnectionURL: "postgres://app@localhost/orders"})
+	assert.ErrorIs(t, err, ErrInvalidConnectionURL)
+}
+
+func TestDataSourceService_Create_SessionInit(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	req := &model.CreateDataSourceRequest{
+		Name:        "Test DB",
+		Type:        "postgresql",
+		Host:        "localhost",
+		Port:        5432,
+		Database:    "testdb",
+		Username:    "user",
+		Password:    "pass",
+		SessionInit: []string{"SET search_path TO analytics, public", "SET ROLE analyst_ro"},
+	}
+	mockRepo.On("Create", mock.AnythingOfType("*model.DataSource")).Return(nil)
+
+	result, err := svc.Create(1, req)
+	require.NoError(t, err)
+	assert.Equal(t, req.SessionInit, result.SessionInit)
+
+	req.SessionInit = []string{"SET default_transaction_read_only = off"}
+	_, err = svc.Create(1, req)
+	assert.ErrorIs(t, err, ErrInvalidSessionInit)
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestDataSourceService_Create_WithReplicas(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	weight := 3
+	req := &model.CreateDataSourceRequest{
+		Name:     "Test DB",
+		Type:     "postgresql",
+		Host:     "primary.example.com",
+		Port:     5432,
+		Database: "testdb",
+		Username: "user",
+		Password: "pass",
+		Replicas: []model.DataSourceReplicaRequest{
+			{Host: "replica1.example.com"},
+			{Name: "reporting", Host: "replica2.example.com", Port: 5433, Username: "reader", Password: "secret", Weight: &weight},
+		},
+		ReplicaRouting: model.ReplicaRouting{MaxLagSeconds: 30},
+	}
+
+	var stored *model.DataSource
+	mockRepo.On("Create", mock.AnythingOfType("*model.DataSource")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*model.DataSource)
+	}).Return(nil)
+
+	result, err := svc.Create(1, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.DataSourceReplicaResponse{
+		{Name: "replica1.example.com:5432", Host: "replica1.example.com", Port: 5432, Weight: 1},
+		{Name: "reporting", Host: "replica2.example.com", Port: 5433, Username: "reader", Weight: 3},
+	}, result.Replicas)
+	assert.Equal(t, 30, result.ReplicaRouting.MaxLagSeconds)
+
+	set, err := replicaSet(stored)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, set.MaxLag)
+	require.Len(t, set.Replicas, 2)
+	assert.Equal(t, "user", set.Replicas[0].Config.Username, "replicas without a username share the primary's credentials")
+	assert.Equal(t, "pass", set.Replicas[0].Config.Password)
+	assert.Equal(t, "secret", set.Replicas[1].Config.Password)
+	assert.Equal(t, "primary.example.com", set.Primary.Host)
+}
+
+func TestDataSourceService_Create_InvalidReplicas(t *testing.T) {
+	svc := newTestDataSourceService(t, new(repository.MockDataSourceRepository))
+
+	newRequest := func(dsType string) *model.CreateDataSourceRequest {
+		return &model.CreateDataSourceRequest{
+			Name: "Test DB", Type: dsType, Host: "localhost", Port: 5432, Database: "testdb", Username: "user", Password: "pass",
+			Replicas: []model.DataSourceReplicaRequest{{Host: "replica.example.com"}},
+		}
+	}
+
+	req := newRequest("postgresql")
+	req.Replicas = append(req.Replicas, model.DataSourceReplicaRequest{Host: "replica.example.com"})
+	_, err := svc.Create(1, req)
+	assert.ErrorIs(t, err, ErrInvalidReplicas, "duplicate names")
+
+	req = newRequest("postgresql")
+	req.Replicas[0].Username = "reader"
+	_, err = svc.Create(1, req)
+	assert.ErrorIs(t, err, ErrInvalidReplicas, "username without password")
+
+	req = newRequest("postgresql")
+	req.ReplicaRouting.MaxLagSeconds = -1
+	_, err = svc.Create(1, req)
+	assert.ErrorIs(t, err, ErrInvalidReplicas)
+}
+
+func TestResolveReplicas_KeepsStoredPassword(t *testing.T) {
+	current, err := resolveReplicas("mysql", 3306, []model.DataSourceReplicaRequest{
+		{Name: "r1", Host: "replica.example.com", Username: "reader", Password: "secret"},
+	}, nil)
+	require.NoError(t, err)
+
+	weight := 0
+	replicas, err := resolveReplicas("mysql", 3306, []model.DataSourceReplicaRequest{
+		{Name: "r1", Host: "replica.example.com", Username: "reader", Weight: &weight},
+	}, current)
+	require.NoError(t, err)
+	assert.Equal(t, current[0].Password, replicas[0].Password)
+	assert.Equal(t, 0, replicas[0].Weight)
+
+	_, err = resolveReplicas("mysql", 3306, []model.DataSourceReplicaRequest{
+		{Name: "r1", Host: "replica.example.com", Username: "admin"},
+	}, current)
+	assert.ErrorIs(t, err, ErrInvalidReplicas, "a new username needs its password")
+}
+
+func TestDataSourceService_ParseDSN_WarnsAboutUnsupportedOptions(t *testing.T) {
+	svc := newTestDataSourceService(t, new(repository.MockDataSourceRepository))
+
+	result, err := svc.ParseDSN(&model.ParseDSNRequest{ConnectionURL: "mysql://root:pw@db/shop?charset=utf8mb4&allowAllFiles=true"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "mysql", result.Type)
+	assert.Equal(t, model.DataSourceOptions{"charset": "utf8mb4", "allowAllFiles": "true"}, result.Options)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "allowAllFiles")
+}
+
+func TestDataSourceService_GetDependents(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	serverID := "s2"
+	mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(&model.DataSource{ID: "uuid-1"}, nil)
+	mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(&repository.DataSourceDependents{
+		Queries: []model.Query{
+			{ID: "q1", Name: "orders", SQLTemplate: "WITH recent AS (SELECT * FROM sales.orders) SELECT * FROM recent JOIN customers c ON c.id = recent.customer_id"},
+			{ID: "q2", Name: "unused", SQLTemplate: "SELECT 1"},
+		},
+		Tools: []model.Tool{
+			{ID: "t1", Name: "list_orders", QueryID: "q1", Status: "active"},
+			{ID: "t2", Name: "order_stats", QueryID: "q1", Status: "active", McpServerID: &serverID},
+		},
+		Servers: []model.McpServer{
+			{ID: "s1", Name: "sales", Status: string(model.McpServerStatusPublished), ToolIDs: model.StringArray{"t1", "t2"}},
+			{ID: "s2", Name: "stats", Status: string(model.McpServerStatusDraft)},
+		},
+	}, nil)
+
+	deps, err := svc.GetDependents("uuid-1", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, deps.QueryCount)
+	assert.Equal(t, 2, deps.ToolCount)
+	assert.Equal(t, 2, deps.ServerCount)
+	assert.Equal(t, 1, deps.PublishedServerCount)
+
+	orders := deps.Queries[0]
+	assert.ElementsMatch(t, []string{"sales.orders", "customers"}, orders.Tables, "CTEs are not tables")
+	require.Len(t, orders.Tools, 2)
+	assert.Equal(t, []model.DependentServer{{ID: "s1", Name: "sales", Status: "published"}}, orders.Tools[0].Servers)
+	assert.Len(t, orders.Tools[1].Servers, 2, "listed by s1 and naming s2")
+	assert.Empty(t, deps.Queries[1].Tools)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// sqliteDataSource creates a SQLite database with tables and returns a
+// datasource reading it
+func sqliteDataSource(t *testing.T, id string, tables ...string) *model.DataSource {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, id+".db"))
+	require.NoError(t, err)
+	defer db.Close()
+	for _, table := range tables {
+		_, err := db.Exec("CREATE TABLE " + table + " (id INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+	}
+
+	password, err := crypto.Encrypt("")
+	require.NoError(t, err)
+
+	dbconnector.ConfigureSQLite(dbconnector.SQLiteConfig{AllowedDir: dir})
+	t.Cleanup(func() { dbconnector.ConfigureSQLite(dbconnector.SQLiteConfig{}) })
+	return &model.DataSource{ID: id, Name: id, Type: "sqlite", Database: id + ".db", Password: password}
+}
+
+func TestDataSourceService_RepointDependents(t *testing.T) {
+	dependents := &repository.DataSourceDependents{
+		Queries: []model.Query{
+			{ID: "q1", Name: "orders", SQLTemplate: "SELECT * FROM orders o JOIN main.customers c ON c.id = o.customer_id"},
+			{ID: "q2", Name: "refunds", SQLTemplate: "SELECT * FROM refunds"},
+		},
+	}
+	source := &model.DataSource{ID: "uuid-1", Name: "old", Type: "sqlite"}
+
+	t.Run("missing tables", func(t *testing.T) {
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := newTestDataSourceService(t, mockRepo)
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(source, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(sqliteDataSource(t, "target", "orders", "customers"), nil)
+
+		result, err := svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target"})
+
+		require.NoError(t, err)
+		assert.False(t, result.Repointed)
+		assert.Empty(t, result.Queries[0].MissingTables)
+		assert.Equal(t, []string{"refunds"}, result.Queries[1].MissingTables)
+		mockRepo.AssertNotCalled(t, "RepointQueries", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := newTestDataSourceService(t, mockRepo)
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(source, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(sqliteDataSource(t, "target", "orders", "customers", "refunds"), nil)
+
+		result, err := svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target", DryRun: true})
+
+		require.NoError(t, err)
+		assert.False(t, result.Repointed)
+		assert.Contains(t, result.Message, "2 queries can be repointed")
+		mockRepo.AssertNotCalled(t, "RepointQueries", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repoints", func(t *testing.T) {
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := newTestDataSourceService(t, mockRepo)
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(source, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(sqliteDataSource(t, "target", "orders", "customers", "refunds"), nil)
+		var baseline *model.SchemaSnapshot
+		mockRepo.On("RepointQueries", "uuid-1", "target", uint(1), dependents.Queries, mock.Anything).
+			Run(func(args mock.Arguments) { baseline = args.Get(4).(*model.SchemaSnapshot) }).
+			Return(nil)
+
+		result, err := svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target"})
+
+		require.NoError(t, err)
+		assert.True(t, result.Repointed)
+		mockRepo.AssertExpectations(t)
+
+		require.NotNil(t, baseline, "the schema the queries were checked with is passed on")
+		assert.Len(t, baseline.Tables, 3)
+		checksum, err := schemaChecksum(baseline.Tables)
+		require.NoError(t, err)
+		assert.Equal(t, checksum, baseline.Checksum)
+	})
+
+	t.Run("target busy", func(t *testing.T) {
+		limiters := concurrency.NewSet()
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := NewDataSourceService(mockRepo, newTestRouter(t), circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), limiters)
+		target := sqliteDataSource(t, "target", "orders", "customers", "refunds")
+		target.Concurrency = model.ConcurrencyConfig{MaxConcurrent: 1, QueueTimeoutMs: 10}
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(source, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(target, nil)
+
+		release, _, err := acquireSlot(context.Background(), limiters, concurrencyScopeDataSource, target.ID, target.Concurrency)
+		require.NoError(t, err)
+		defer release()
+
+		_, err = svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target"})
+		assert.ErrorIs(t, err, ErrDataSourceBusy, "checking the target counts against its limit")
+		mockRepo.AssertNotCalled(t, "RepointQueries", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("dependents changed", func(t *testing.T) {
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := newTestDataSourceService(t, mockRepo)
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(source, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(sqliteDataSource(t, "target", "orders", "customers", "refunds"), nil)
+		mockRepo.On("RepointQueries", "uuid-1", "target", uint(1), dependents.Queries, mock.Anything).Return(repository.ErrDependentsChanged)
+
+		_, err := svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target"})
+
+		assert.ErrorIs(t, err, ErrDependentsChanged)
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		mockRepo := new(repository.MockDataSourceRepository)
+		svc := newTestDataSourceService(t, mockRepo)
+
+		_, err := svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "uuid-1"})
+		assert.ErrorIs(t, err, ErrInvalidRepointTarget)
+
+		mockRepo.On("FindByIDAndUserID", "uuid-1", uint(1)).Return(&model.DataSource{ID: "uuid-1", Type: "postgresql"}, nil)
+		mockRepo.On("FindDependents", "uuid-1", uint(1)).Return(dependents, nil)
+		mockRepo.On("FindByIDAndUserID", "target", uint(1)).Return(sqliteDataSource(t, "target"), nil)
+		_, err = svc.RepointDependents("uuid-1", 1, &model.RepointDependentsRequest{TargetDataSourceID: "target"})
+		assert.ErrorIs(t, err, ErrInvalidRepointTarget, "types differ")
+	})
+}
+
+func TestDataSourceService_PreviewTable_ReusesPooledConnection(t *testing.T) {
+	pool := dbconnector.NewPool(dbconnector.DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	router := dbconnector.NewRouter(pool, dbconnector.DefaultRouterOptions())
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := NewDataSourceService(mockRepo, router, circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), concurrency.NewSet())
+
+	mockRepo.On("FindByIDAndUserID", "ds", uint(1)).Return(sqliteDataSource(t, "ds", "orders"), nil)
+
+	for i := 0; i < 2; i++ {
+		preview, err := svc.PreviewTable("ds", 1, "main", "orders", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id"}, preview.Columns)
+	}
+	assert.Contains(t, pool.Stats(), "ds", "the preview's connection stays pooled")
+}
+
+func TestDataSourceService_PreviewTable_Busy(t *testing.T) {
+	limiters := concurrency.NewSet()
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := NewDataSourceService(mockRepo, newTestRouter(t), circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), limiters)
+
+	ds := sqliteDataSource(t, "ds", "orders")
+	ds.Concurrency = model.ConcurrencyConfig{MaxConcurrent: 1, QueueTimeoutMs: 10}
+	mockRepo.On("FindByIDAndUserID", "ds", uint(1)).Return(ds, nil)
+
+	// A tool execution holds the only slot of the datasource
+	release, _, err := acquireSlot(context.Background(), limiters, concurrencyScopeDataSource, ds.ID, ds.Concurrency)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = svc.PreviewTable("ds", 1, "main", "orders", 10)
+	assert.ErrorIs(t, err, ErrDataSourceBusy)
+}
+
+func TestDataSourceService_Delete_EvictsLimiter(t *testing.T) {
+	limiters := concurrency.NewSet()
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := NewDataSourceService(mockRepo, newTestRouter(t), circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), limiters)
+
+	mockRepo.On("HasAssociatedQueries", "ds").Return(false, nil)
+	mockRepo.On("Delete", "ds", uint(1)).Return(nil)
+
+	limits := model.ConcurrencyConfig{MaxConcurrent: 1, QueueTimeoutMs: 10}
+	release, _, err := acquireSlot(context.Background(), limiters, concurrencyScopeDataSource, "ds", limits)
+	require.NoError(t, err)
+	defer release()
+
+	require.NoError(t, svc.Delete("ds", 1))
+	again, _, err := acquireSlot(context.Background(), limiters, concurrencyScopeDataSource, "ds", limits)
+	require.NoError(t, err, "the deleted datasource's limiter is gone")
+	again()
+}
+
+func TestRejectSlot(t *testing.T) {
+	start := time.Now()
+
+	log := &model.McpLog{}
+	err := rejectSlot(log, "Datasource ds", concurrency.ErrWaitTimeout, start)
+	assert.ErrorIs(t, err, ErrServerBusy)
+	assert.Equal(t, string(model.McpErrorClassBusy), log.ErrorClass)
+
+	log = &model.McpLog{}
+	err = rejectSlot(log, "Datasource ds", context.Canceled, start)
+	assert.ErrorIs(t, err, ErrCallCancelled, "a client that gave up is not told the datasource is busy")
+	assert.Equal(t, string(model.McpErrorClassCancelled), log.ErrorClass)
+}
+
+func TestDataSourceService_GetTableProfile_HidesSensitiveColumns(t *testing.T) {
+	mockRepo := new(repository.MockDataSourceRepository)
+	svc := newTestDataSourceService(t, mockRepo)
+
+	mockRepo.On("FindByIDAndUserID", "ds", uint(1)).Return(&model.DataSource{ID: "ds"}, nil)
+	mockRepo.On("FindTableProfile", "ds", "public", "users").Return(&model.TableProfile{
+		DataSourceID: "ds", Schema: "public", Table: "users",
+		Columns: model.ColumnProfiles{
+			{Name: "id", DistinctCount: 10},
+			{Name: "email", DistinctCount: 10, TopValues: []model.ValueCount{{Value: "a@example.com", Count: 1}}},
+		},
+		ExcludedColumns: model.StringArray{},
+	}, nil)
+
+	// email was profiled before it was marked sensitive
+	cfg := dbconnector.DefaultProfileConfig()
+	cfg.SensitiveColumns = []string{"email"}
+	dbconnector.ConfigureProfiling(cfg)
+	t.Cleanup(func() { dbconnector.ConfigureProfiling(dbconnector.DefaultProfileConfig()) })
+
+	profile, err := svc.GetTableProfile("ds", 1, "public", "users")
+	require.NoError(t, err)
+	require.Len(t, profile.Columns, 1)
+	assert.Equal(t, "id", profile.Columns[0].Name)
+	assert.Equal(t, model.StringArray{"email"}, profile.ExcludedColumns)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// healthProbeTimeout bounds the ping of a single datasource
+	healthProbeTimeout = 5 * time.Second
+	// healthProbeConcurrency is the number of datasources probed at once
+	healthProbeConcurrency = 8
+)
+
+// HealthProber periodically pings every active datasource, records the
+// result in its health history and feeds it to the datasource's circuit breaker
+type HealthProber struct {
+	periodicTask
+	dsRepo   repository.DataSourceRepository
+	pool     *dbconnector.Pool
+	breakers *circuitbreaker.Set
+	history  time.Duration
+}
+
+// NewHealthProber creates a new HealthProber. Health checks older than history are deleted.
+func NewHealthProber(
+	dsRepo repository.DataSourceRepository,
+	pool *dbconnector.Pool,
+	breakers *circuitbreaker.Set,
+	interval, history time.Duration,
+) *HealthProber {
+	p := &HealthProber{
+		dsRepo:   dsRepo,
+		pool:     pool,
+		breakers: breakers,
+		history:  history,
+	}
+	p.periodicTask = newPeriodicTask(interval, p.run)
+	return p
+}
+
+func (p *HealthProber) run() {
+	ctx := context.Background()
+
+	datasources, err := p.dsRepo.FindAllActive()
+	if err != nil {
+		logger.Error("Failed to load datasources for health probing", zap.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, healthProbeConcurrency)
+	var wg sync.WaitGroup
+	for i := range datasources {
+		ds := &datasources[i]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.probe(ctx, ds)
+		}()
+	}
+	wg.Wait()
+
+	if p.history > 0 {
+		if _, err := p.dsRepo.DeleteHealthChecksBefore(time.Now().Add(-p.history)); err != nil {
+			logger.Warn("Failed to prune datasource health history", zap.Error(err))
+		}
+	}
+}
+
+// probe pings a datasource and records the outcome
+func (p *HealthProber) probe(ctx context.Context, ds *model.DataSource) {
+	start := time.Now()
+	err := pingDataSource(ctx, p.pool, ds, healthProbeTimeout)
+	latency := time.Since(start)
+
+	status := model.DataSourceHealthHealthy
+	errMsg := ""
+	breaker := p.breakers.Get(ds.ID)
+	if err != nil {
+		status = model.DataSourceHealthUnhealthy
+		errMsg = err.Error()
+		// Like calls, only an unreachable database counts against its circuit;
+		// bad credentials or a rejected login say nothing about whether it is up
+		if dbconnector.IsConnectionError(err) {
+			breaker.Failure()
+		}
+	} else {
+		// A successful probe lets trial calls through without waiting for the
+		// open timeout; only they close the circuit
+		breaker.Recover()
+	}
+
+	if status != model.DataSourceHealthStatus(ds.HealthStatus) {
+		logger.Info("Datasource health changed",
+			zap.String("datasource_id", ds.ID),
+			zap.String("from", ds.HealthStatus),
+			zap.String("to", string(status)),
+		)
+	}
+
+	if err := p.dsRepo.UpdateHealth(ds.ID, status, errMsg, start); err != nil {
+		logger.Warn("Failed to update datasource health", zap.String("datasource_id", ds.ID), zap.Error(err))
+	}
+
+	check := &model.DataSourceHealthCheck{
+		DataSourceID: ds.ID,
+		Status:       string(status),
+		LatencyMs:    latency.Milliseconds(),
+		ErrorMessage: errMsg,
+		CheckedAt:    start,
+	}
+	if err := p.dsRepo.CreateHealthCheck(check); err != nil {
+		logger.Warn("Failed to record datasource health check", zap.String("datasource_id", ds.ID), zap.Error(err))
+	}
+}
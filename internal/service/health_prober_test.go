@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+)
+
+func TestHealthProber_OnlyConnectionErrorsOpenCircuit(t *testing.T) {
+	// A port nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	password, err := crypto.Encrypt("secret")
+	require.NoError(t, err)
+	unreachable := &model.DataSource{ID: "down", Type: "postgresql", Host: "127.0.0.1", Port: port, Database: "app", Username: "app", Password: password}
+	undecryptable := &model.DataSource{ID: "bad-secret", Type: "postgresql", Host: "127.0.0.1", Port: port, Database: "app", Username: "app", Password: "not encrypted"}
+
+	mockRepo := new(repository.MockDataSourceRepository)
+	mockRepo.On("UpdateHealth", mock.Anything, model.DataSourceHealthUnhealthy, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateHealthCheck", mock.Anything).Return(nil)
+
+	pool := dbconnector.NewPool(dbconnector.DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	breakers := circuitbreaker.NewSet(circuitbreaker.Config{FailureThreshold: 1, OpenTimeout: time.Hour})
+	prober := NewHealthProber(mockRepo, pool, breakers, time.Hour, time.Hour)
+
+	prober.probe(context.Background(), undecryptable)
+	assert.Equal(t, circuitbreaker.StateClosed, breakers.Get("bad-secret").Snapshot().State, "a credential error says nothing about the database")
+
+	prober.probe(context.Background(), unreachable)
+	assert.Equal(t, circuitbreaker.StateOpen, breakers.Get("down").Snapshot().State)
+}
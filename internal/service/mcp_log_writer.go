@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// McpLogWriterOptions configures how MCP call logs are queued and persisted
+type McpLogWriterOptions struct {
+	QueueSize     int           // number of logs buffered in memory
+	BatchSize     int           // a batch is flushed as soon as it reaches this size
+	FlushInterval time.Duration // a partial batch is flushed after this interval
+	SpillFile     string        // optional NDJSON file that receives logs the database cannot take
+}
+
+// DefaultMcpLogWriterOptions returns the default log writer settings
+func DefaultMcpLogWriterOptions() McpLogWriterOptions {
+	return McpLogWriterOptions{
+		QueueSize:     1000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	}
+}
+
+// mcpLogWriter batches MCP call logs off the request path. Logs that cannot
+// be inserted are appended to the spill file, when configured, and replayed
+// once the database accepts writes again.
+type mcpLogWriter struct {
+	opts  McpLogWriterOptions
+	write func(logs []*model.McpLog) error
+
+	mu     sync.RWMutex // guards closed against concurrent enqueues
+	closed bool
+	queue  chan *model.McpLog
+	done   chan struct{}
+
+	spillMu sync.Mutex
+	spilled bool // the spill file may hold logs waiting to be replayed
+}
+
+func newMcpLogWriter(opts McpLogWriterOptions, write func(logs []*model.McpLog) error) *mcpLogWriter {
+	defaults := DefaultMcpLogWriterOptions()
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+
+	w := &mcpLogWriter{
+		opts:  opts,
+		write: write,
+		queue: make(chan *model.McpLog, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+
+	// Logs spilled before a restart are replayed on the first flush
+	if opts.SpillFile != "" {
+		if info, err := os.Stat(opts.SpillFile); err == nil && info.Size() > 0 {
+			w.spilled = true
+		}
+	}
+
+	go w.run()
+	return w
+}
+
+// Enqueue queues a log without blocking. When the queue is full the log is
+// spilled to disk if a spill file is configured and dropped otherwise. The log
+// is given its ID here, so writing it again after a spill is recognized.
+func (w *mcpLogWriter) Enqueue(log *model.McpLog) {
+	if log.ID == "" {
+		log.ID = uuid.NewString()
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.discard([]*model.McpLog{log}, "shutdown")
+		return
+	}
+
+	select {
+	case w.queue <- log:
+		metrics.SetLogQueueDepth(len(w.queue))
+	default:
+		w.discard([]*model.McpLog{log}, "queue_full")
+	}
+}
+
+// Shutdown stops accepting logs and flushes everything still queued.
+// It returns an error if the queue could not be drained before ctx expires.
+func (w *mcpLogWriter) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("mcp log writer did not drain %d queued logs: %w", len(w.queue), ctx.Err())
+	}
+}
+
+func (w *mcpLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.McpLog, 0, w.opts.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.flush(batch)
+			batch = batch[:0]
+		}
+		metrics.SetLogQueueDepth(len(w.queue))
+	}
+
+	for {
+		select {
+		case log, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, log)
+			if len(batch) >= w.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			w.replaySpill()
+		}
+	}
+}
+
+// flush inserts a batch, spilling or dropping it if the insert fails
+func (w *mcpLogWriter) flush(batch []*model.McpLog) {
+	if err := w.write(batch); err != nil {
+		logger.Warn("Failed to write MCP logs", zap.Int("count", len(batch)), zap.Error(err))
+		w.discard(batch, "write_error")
+		return
+	}
+	metrics.ObserveLogBatch(len(batch))
+}
+
+// discard spills logs that could not be queued or written, or drops them
+// when no spill file is configured
+func (w *mcpLogWriter) discard(logs []*model.McpLog, reason string) {
+	if w.opts.SpillFile == "" {
+		metrics.AddLogsDropped(reason, len(logs))
+		return
+	}
+
+	if err := w.spill(logs); err != nil {
+		logger.Error("Failed to spill MCP logs", zap.String("file", w.opts.SpillFile), zap.Error(err))
+		metrics.AddLogsDropped(reason, len(logs))
+		return
+	}
+	metrics.AddLogsSpilled(len(logs))
+}
+
+// spill appends logs to the spill file as NDJSON
+func (w *mcpLogWriter) spill(logs []*model.McpLog) error {
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	f, err := os.OpenFile(w.opts.SpillFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, log := range logs {
+		if err := enc.Encode(log); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	w.spilled = true
+	return f.Close()
+}
+
+// replaySpill moves spilled logs back into the database. Logs that still
+// cannot be written stay in the spill file for the next attempt; if the file
+// cannot be rewritten, the next attempt writes the same logs again, which the
+// writer skips by ID.
+func (w *mcpLogWriter) replaySpill() {
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	if !w.spilled {
+		return
+	}
+
+	logs, err := readSpillFile(w.opts.SpillFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			w.spilled = false
+			return
+		}
+		logger.Error("Failed to read MCP log spill file", zap.String("file", w.opts.SpillFile), zap.Error(err))
+		return
+	}
+
+	written := 0
+	for written < len(logs) {
+		end := written + w.opts.BatchSize
+		if end > len(logs) {
+			end = len(logs)
+		}
+		if err := w.write(logs[written:end]); err != nil {
+			break
+		}
+		metrics.ObserveLogBatch(end - written)
+		written = end
+	}
+	if written == 0 {
+		return
+	}
+
+	if err := rewriteSpillFile(w.opts.SpillFile, logs[written:]); err != nil {
+		logger.Error("Failed to rewrite MCP log spill file", zap.String("file", w.opts.SpillFile), zap.Error(err))
+		return
+	}
+	w.spilled = written < len(logs)
+
+	logger.Info("Replayed spilled MCP logs", zap.Int("count", written), zap.Int("remaining", len(logs)-written))
+}
+
+// readSpillFile decodes every log in a spill file, skipping corrupt lines
+func readSpillFile(path string) ([]*model.McpLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var logs []*model.McpLog
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var log model.McpLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			metrics.AddLogsDropped("spill_corrupt", 1)
+			continue
+		}
+		logs = append(logs, &log)
+	}
+	return logs, scanner.Err()
+}
+
+// rewriteSpillFile replaces the spill file with the logs not yet replayed
+func rewriteSpillFile(path string, logs []*model.McpLog) error {
+	if len(logs) == 0 {
+		return os.Remove(path)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, log := range logs {
+		if err := enc.Encode(log); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
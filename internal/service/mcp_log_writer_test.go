@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func init() {
+	// The writer logs failed flushes; discard them in tests
+	logger.Log = zap.NewNop()
+}
+
+// recordingWriter collects written batches and can simulate an unavailable database
+type recordingWriter struct {
+	mu      sync.Mutex
+	batches [][]*model.McpLog
+	fail    bool
+}
+
+func (r *recordingWriter) write(logs []*model.McpLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return errors.New("database unavailable")
+	}
+	r.batches = append(r.batches, append([]*model.McpLog(nil), logs...))
+	return nil
+}
+
+func (r *recordingWriter) setFail(fail bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fail = fail
+}
+
+func (r *recordingWriter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, batch := range r.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+func TestMcpLogWriter_BatchesBySize(t *testing.T) {
+	rec := &recordingWriter{}
+	w := newMcpLogWriter(McpLogWriterOptions{BatchSize: 2, FlushInterval: time.Hour}, rec.write)
+
+	for i := 0; i < 5; i++ {
+		w.Enqueue(&model.McpLog{ToolName: "tool"})
+	}
+
+	require.NoError(t, w.Shutdown(context.Background()))
+	assert.Equal(t, 5, rec.count())
+	assert.Len(t, rec.batches, 3)
+	assert.Len(t, rec.batches[0], 2)
+}
+
+func TestMcpLogWriter_FlushesOnInterval(t *testing.T) {
+	rec := &recordingWriter{}
+	w := newMcpLogWriter(McpLogWriterOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond}, rec.write)
+	defer w.Shutdown(context.Background())
+
+	w.Enqueue(&model.McpLog{ToolName: "tool"})
+
+	assert.Eventually(t, func() bool { return rec.count() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestMcpLogWriter_DropsAfterShutdown(t *testing.T) {
+	rec := &recordingWriter{}
+	w := newMcpLogWriter(McpLogWriterOptions{}, rec.write)
+
+	require.NoError(t, w.Shutdown(context.Background()))
+	w.Enqueue(&model.McpLog{ToolName: "late"})
+
+	assert.Equal(t, 0, rec.count())
+}
+
+func TestMcpLogWriter_SpillsAndReplays(t *testing.T) {
+	spillFile := filepath.Join(t.TempDir(), "spill.ndjson")
+	rec := &recordingWriter{fail: true}
+	w := newMcpLogWriter(McpLogWriterOptions{BatchSize: 1, FlushInterval: 10 * time.Millisecond, SpillFile: spillFile}, rec.write)
+	defer w.Shutdown(context.Background())
+
+	log := &model.McpLog{McpServerID: "server", ToolName: "tool"}
+	w.Enqueue(log)
+	require.NotEmpty(t, log.ID)
+
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(spillFile)
+		return err == nil && info.Size() > 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, rec.count())
+
+	rec.setFail(false)
+
+	assert.Eventually(t, func() bool { return rec.count() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "tool", rec.batches[0][0].ToolName)
+	assert.Equal(t, log.ID, rec.batches[0][0].ID, "a replayed log keeps its ID")
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(spillFile)
+		return os.IsNotExist(err)
+	}, time.Second, 5*time.Millisecond)
+}
@@ -1,19 +1,29 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
 	"github.com/yourusername/dataweaver/pkg/analytics"
-	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
 	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/metrics"
 	"github.com/yourusername/dataweaver/pkg/sqlparser"
+	"github.com/yourusername/dataweaver/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+// logExportBatchSize is the number of logs loaded per query during export
+const logExportBatchSize = 1000
+
 var (
 	ErrMcpServerNotFound   = errors.New("mcp server not found")
 	ErrMcpServerNameExists = errors.New("mcp server name already exists")
@@ -22,6 +32,14 @@ var (
 	ErrToolNotInServer     = errors.New("tool not found in server")
 	ErrInvalidApiKey       = errors.New("invalid api key")
 	ErrNoToolsToPublish    = errors.New("at least one tool is required to publish")
+	ErrToolNeedsReview     = errors.New("tool needs review after a schema change")
+	ErrQuotaExceeded       = errors.New("quota exceeded")
+	ErrInvalidAccessConfig = errors.New("invalid access config")
+	ErrAccessDenied        = errors.New("access denied")
+	ErrServerBusy          = errors.New("server busy")
+	ErrCallCancelled       = errors.New("call cancelled")
+
+	ErrInvalidConcurrencyConfig = errors.New("invalid concurrency config")
 )
 
 // McpServerService handles business logic for MCP servers
@@ -39,24 +57,37 @@ type McpServerService interface {
 
 	// Logging
 	LogToolCall(log *model.McpLog) error
-	GetLogs(serverID string, userID uint, page, size int) ([]model.McpLogResponse, int64, error)
+	GetLogs(serverID string, userID uint, filter *model.McpLogFilter, page, size int) ([]model.McpLogResponse, int64, error)
+	ExportLogs(serverID string, userID uint, filter *model.McpLogFilter, fn func([]model.McpLog) error) error
+	GetLogAggregates(serverID string, userID uint, timeRange analytics.TimeRange) ([]model.McpLogAggregateResponse, error)
+	EnforceRetention() ([]model.RetentionResult, error)
 
 	// Statistics
-	GetStatistics(serverID string, userID uint, days int) (*analytics.Statistics, error)
+	GetStatistics(serverID string, userID uint, timeRange analytics.TimeRange) (*analytics.Statistics, error)
+
+	// Quotas
+	GetUsage(serverID string, userID uint) (*model.McpUsageResponse, error)
 
 	// Runtime operations
 	GetServerByApiKey(apiKey string) (*model.McpServer, error)
+	GetPublishedServer(serverID string) (*model.McpServer, error)
 	GetServerTools(serverID string) ([]model.Tool, error)
-	ExecuteTool(serverID, toolName string, params map[string]interface{}) (*model.McpToolCallResult, *model.McpLog, error)
+	GetServerHealth(serverID string) (*model.McpServerHealthResponse, error)
+	ExecuteTool(ctx context.Context, serverID, toolName string, params map[string]interface{}) (*model.McpToolCallResult, *model.McpLog, error)
+
+	// Lifecycle
+	Shutdown(ctx context.Context) error
 }
 
 type mcpServerService struct {
-	mcpRepo    repository.McpServerRepository
-	toolRepo   repository.ToolRepository
-	queryRepo  repository.QueryRepository
-	dsRepo     repository.DataSourceRepository
-	logChannel chan *model.McpLog
-	logWg      sync.WaitGroup
+	mcpRepo   repository.McpServerRepository
+	toolRepo  repository.ToolRepository
+	queryRepo repository.QueryRepository
+	dsRepo    repository.DataSourceRepository
+	router    *dbconnector.Router
+	breakers  *circuitbreaker.Set
+	limiters  *concurrency.Set
+	logWriter *mcpLogWriter
 }
 
 // NewMcpServerService creates a new McpServerService
@@ -65,34 +96,41 @@ func NewMcpServerService(
 	toolRepo repository.ToolRepository,
 	queryRepo repository.QueryRepository,
 	dsRepo repository.DataSourceRepository,
+	router *dbconnector.Router,
+	breakers *circuitbreaker.Set,
+	limiters *concurrency.Set,
+	logOpts McpLogWriterOptions,
 ) McpServerService {
 	svc := &mcpServerService{
-		mcpRepo:    mcpRepo,
-		toolRepo:   toolRepo,
-		queryRepo:  queryRepo,
-		dsRepo:     dsRepo,
-		logChannel: make(chan *model.McpLog, 1000),
+		mcpRepo:   mcpRepo,
+		toolRepo:  toolRepo,
+		queryRepo: queryRepo,
+		dsRepo:    dsRepo,
+		router:    router,
+		breakers:  breakers,
+		limiters:  limiters,
 	}
 
-	// Start async log writer
-	svc.startLogWriter()
+	// Start async log writer; the repository adds the logs to the usage counters
+	svc.logWriter = newMcpLogWriter(logOpts, mcpRepo.CreateLogs)
 
 	return svc
 }
 
-// startLogWriter starts the async log writer goroutine
-func (s *mcpServerService) startLogWriter() {
-	s.logWg.Add(1)
-	go func() {
-		defer s.logWg.Done()
-		for log := range s.logChannel {
-			_ = s.mcpRepo.CreateLog(log)
-		}
-	}()
+// Shutdown flushes queued tool call logs
+func (s *mcpServerService) Shutdown(ctx context.Context) error {
+	return s.logWriter.Shutdown(ctx)
 }
 
 // Create creates a new MCP server
 func (s *mcpServerService) Create(userID uint, req *model.CreateMcpServerRequest) (*model.McpServerResponse, error) {
+	if err := req.Config.Access.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccessConfig, err)
+	}
+	if err := req.Config.Concurrency.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConcurrencyConfig, err)
+	}
+
 	// Validate all tools exist and belong to the user
 	for _, toolID := range req.ToolIDs {
 		_, err := s.toolRepo.FindByIDAndUserID(toolID, userID)
@@ -210,6 +248,12 @@ func (s *mcpServerService) Update(id string, userID uint, req *model.UpdateMcpSe
 		server.ToolIDs = model.StringArray(req.ToolIDs)
 	}
 	if req.Config != nil {
+		if err := req.Config.Access.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAccessConfig, err)
+		}
+		if err := req.Config.Concurrency.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConcurrencyConfig, err)
+		}
 		server.Config = model.ServerConfigJSON{ServerConfig: *req.Config}
 	}
 	if req.Status != nil {
@@ -231,7 +275,11 @@ func (s *mcpServerService) Update(id string, userID uint, req *model.UpdateMcpSe
 
 // Delete deletes an MCP server
 func (s *mcpServerService) Delete(id string, userID uint) error {
-	return s.mcpRepo.Delete(id, userID)
+	if err := s.mcpRepo.Delete(id, userID); err != nil {
+		return err
+	}
+	s.limiters.Remove(limiterKey(concurrencyScopeServer, id))
+	return nil
 }
 
 // Publish publishes an MCP server
@@ -255,6 +303,9 @@ func (s *mcpServerService) Publish(id string, userID uint, baseURL string) (*mod
 		if tool.Status != "active" {
 			return nil, fmt.Errorf("tool %s is not active", tool.Name)
 		}
+		if server.Config.BlockPublishOnReview && tool.NeedsReview {
+			return nil, fmt.Errorf("%w: %s", ErrToolNeedsReview, tool.Name)
+		}
 	}
 
 	// Generate endpoint and API key if not already set
@@ -348,22 +399,15 @@ func (s *mcpServerService) generateMcpConfigInternal(server *model.McpServer, ba
 	}
 }
 
-// LogToolCall logs a tool call asynchronously
+// LogToolCall queues a tool call log for batched, asynchronous persistence
 func (s *mcpServerService) LogToolCall(log *model.McpLog) error {
 	log.Timestamp = time.Now()
-
-	// Send to async channel (non-blocking)
-	select {
-	case s.logChannel <- log:
-		return nil
-	default:
-		// Channel full, log synchronously
-		return s.mcpRepo.CreateLog(log)
-	}
+	s.logWriter.Enqueue(log)
+	return nil
 }
 
-// GetLogs returns logs for an MCP server
-func (s *mcpServerService) GetLogs(serverID string, userID uint, page, size int) ([]model.McpLogResponse, int64, error) {
+// GetLogs returns logs for an MCP server matching a filter
+func (s *mcpServerService) GetLogs(serverID string, userID uint, filter *model.McpLogFilter, page, size int) ([]model.McpLogResponse, int64, error) {
 	// Verify ownership
 	_, err := s.mcpRepo.FindByIDAndUserID(serverID, userID)
 	if err != nil {
@@ -377,7 +421,7 @@ func (s *mcpServerService) GetLogs(serverID string, userID uint, page, size int)
 		size = 20
 	}
 
-	logs, total, err := s.mcpRepo.FindLogsByServerID(serverID, page, size)
+	logs, total, err := s.mcpRepo.FindLogs(serverID, filter, page, size)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -390,59 +434,182 @@ func (s *mcpServerService) GetLogs(serverID string, userID uint, page, size int)
 	return responses, total, nil
 }
 
-// GetStatistics returns statistics for an MCP server
-func (s *mcpServerService) GetStatistics(serverID string, userID uint, days int) (*analytics.Statistics, error) {
+// ExportLogs passes every log matching a filter to fn in batches
+func (s *mcpServerService) ExportLogs(serverID string, userID uint, filter *model.McpLogFilter, fn func([]model.McpLog) error) error {
+	// Verify ownership
+	_, err := s.mcpRepo.FindByIDAndUserID(serverID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.mcpRepo.FindLogsInBatches(serverID, filter, logExportBatchSize, fn)
+}
+
+// GetLogAggregates returns the hourly log aggregates kept by the retention policy
+func (s *mcpServerService) GetLogAggregates(serverID string, userID uint, timeRange analytics.TimeRange) ([]model.McpLogAggregateResponse, error) {
 	// Verify ownership
 	_, err := s.mcpRepo.FindByIDAndUserID(serverID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if days <= 0 {
-		days = 30
+	if err := timeRange.Validate(); err != nil {
+		return nil, err
 	}
 
-	// Get statistics
-	totalCalls, err := s.mcpRepo.CountLogsByServerID(serverID)
+	aggregates, err := s.mcpRepo.FindLogAggregates(serverID, timeRange.Start, timeRange.End)
 	if err != nil {
 		return nil, err
 	}
 
-	successCalls, err := s.mcpRepo.CountLogsByStatus(serverID, string(model.McpLogStatusSuccess))
+	responses := make([]model.McpLogAggregateResponse, len(aggregates))
+	for i, aggregate := range aggregates {
+		responses[i] = *aggregate.ToResponse()
+	}
+
+	return responses, nil
+}
+
+// EnforceRetention applies every server's log retention policy. A server
+// whose policy fails to apply is logged and skipped.
+func (s *mcpServerService) EnforceRetention() ([]model.RetentionResult, error) {
+	servers, err := s.mcpRepo.FindAllServers()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []model.RetentionResult
+	for _, server := range servers {
+		retention := server.Config.Retention
+		if retention.IsUnlimited() && retention.AggregateDays == 0 {
+			continue
+		}
+
+		result, err := s.applyRetention(server.ID, retention, time.Now())
+		if err != nil {
+			// One server's failure must not keep the others' logs growing
+			logger.Error("Failed to apply MCP log retention", zap.String("server_id", server.ID), zap.Error(err))
+			continue
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// applyRetention deletes a server's logs beyond its age and row limits,
+// rolling them up into hourly aggregates first when configured
+func (s *mcpServerService) applyRetention(serverID string, retention model.RetentionConfig, now time.Time) (*model.RetentionResult, error) {
+	result := &model.RetentionResult{ServerID: serverID}
+	aggregate := retention.AggregateDays > 0
+
+	// A log is deleted once it is beyond either limit
+	var cutoff repository.LogCutoff
+	if retention.MaxAgeDays > 0 {
+		ageCutoff := now.AddDate(0, 0, -retention.MaxAgeDays)
+		cutoff.Before = &ageCutoff
+	}
+	if retention.MaxRows > 0 {
+		rowCutoff, err := s.mcpRepo.FindLogCutoffByRowCount(serverID, retention.MaxRows)
+		if err != nil {
+			return nil, err
+		}
+		cutoff.Through = rowCutoff
+	}
+
+	if !cutoff.IsZero() {
+		deleted, err := s.mcpRepo.DeleteLogsBefore(serverID, cutoff, aggregate)
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedLogs = deleted
+	}
+
+	if aggregate {
+		deleted, err := s.mcpRepo.DeleteLogAggregatesBefore(serverID, now.AddDate(0, 0, -retention.AggregateDays))
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedAggregates = deleted
+	}
+
+	return result, nil
+}
+
+// GetStatistics returns statistics for an MCP server within a time range
+func (s *mcpServerService) GetStatistics(serverID string, userID uint, timeRange analytics.TimeRange) (*analytics.Statistics, error) {
+	// Verify ownership
+	_, err := s.mcpRepo.FindByIDAndUserID(serverID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	errorCalls, err := s.mcpRepo.CountLogsByStatus(serverID, string(model.McpLogStatusError))
+	if err := timeRange.Validate(); err != nil {
+		return nil, err
+	}
+	start, end := timeRange.Start, timeRange.End
+
+	// Get statistics
+	summary, err := s.mcpRepo.GetLogSummary(serverID, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	avgResponseTime, err := s.mcpRepo.GetAvgResponseTime(serverID)
+	errorStats, err := s.mcpRepo.GetErrorStatsByClass(serverID, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	toolStats, err := s.mcpRepo.GetLogStatsByTool(serverID)
+	toolStats, err := s.mcpRepo.GetLogStatsByTool(serverID, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	dayStats, err := s.mcpRepo.GetLogStatsByDay(serverID, days)
+	dayStats, err := s.mcpRepo.GetLogStatsByDay(serverID, start, end)
 	if err != nil {
 		return nil, err
 	}
 
+	// Per-hour buckets are only useful for short ranges
+	var callsByHour []analytics.HourStats
+	if timeRange.Duration() <= analytics.HourlyBucketMaxRange {
+		hourStats, err := s.mcpRepo.GetLogStatsByHour(serverID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		callsByHour = make([]analytics.HourStats, len(hourStats))
+		for i, hs := range hourStats {
+			callsByHour[i] = analytics.HourStats{
+				Hour:          hs.Hour,
+				CallCount:     hs.CallCount,
+				SuccessCount:  hs.SuccessCount,
+				ErrorCount:    hs.ErrorCount,
+				AvgResponseMs: hs.AvgResponseMs,
+			}
+		}
+	}
+
 	// Convert to analytics types
+	errorsByClass := make([]analytics.ErrorClassStats, len(errorStats))
+	for i, es := range errorStats {
+		errorsByClass[i] = analytics.ErrorClassStats{
+			ErrorClass: es.ErrorClass,
+			Count:      es.Count,
+		}
+	}
+
 	topTools := make([]analytics.ToolStats, len(toolStats))
 	for i, ts := range toolStats {
 		topTools[i] = analytics.ToolStats{
-			ToolID:        ts.ToolID,
-			ToolName:      ts.ToolName,
-			CallCount:     ts.CallCount,
-			SuccessCount:  ts.SuccessCount,
-			ErrorCount:    ts.ErrorCount,
-			AvgResponseMs: ts.AvgResponseMs,
+			ToolID:         ts.ToolID,
+			ToolName:       ts.ToolName,
+			CallCount:      ts.CallCount,
+			SuccessCount:   ts.SuccessCount,
+			ErrorCount:     ts.ErrorCount,
+			AvgResponseMs:  ts.AvgResponseMs,
+			P90ResponseMs:  ts.P90ResponseMs,
+			TotalRows:      ts.TotalRows,
+			AvgQueueWaitMs: ts.AvgQueueWaitMs,
 		}
 	}
 
@@ -457,23 +624,97 @@ func (s *mcpServerService) GetStatistics(serverID string, userID uint, days int)
 	}
 
 	// Build statistics
-	timeRange := analytics.TimeRange{
-		Start: time.Now().AddDate(0, 0, -days),
-		End:   time.Now(),
-	}
-
 	stats := analytics.NewStatisticsBuilder(serverID, timeRange).
-		SetTotalCalls(totalCalls).
-		SetSuccessfulCalls(successCalls).
-		SetFailedCalls(errorCalls).
-		SetAvgResponseTime(avgResponseTime).
+		SetTotalCalls(summary.TotalCalls).
+		SetSuccessfulCalls(summary.SuccessCount).
+		SetFailedCalls(summary.ErrorCount).
+		SetAvgResponseTime(summary.AvgResponseMs).
+		SetLatency(analytics.LatencyPercentiles{
+			P50: summary.P50ResponseMs,
+			P90: summary.P90ResponseMs,
+			P99: summary.P99ResponseMs,
+		}).
+		SetQueue(analytics.QueueStats{
+			AvgWaitMs:     summary.AvgQueueWaitMs,
+			MaxWaitMs:     summary.MaxQueueWaitMs,
+			RejectedCalls: summary.BusyCount,
+		}).
+		SetTotalRows(summary.TotalRows).
+		SetErrorsByClass(errorsByClass).
 		SetTopTools(topTools).
 		SetCallsByDay(callsByDay).
+		SetCallsByHour(callsByHour).
 		Build()
 
 	return &stats, nil
 }
 
+// GetUsage returns the current daily and monthly usage of an MCP server against its quotas
+func (s *mcpServerService) GetUsage(serverID string, userID uint) (*model.McpUsageResponse, error) {
+	server, err := s.mcpRepo.FindByIDAndUserID(serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	quota := server.Config.Quota
+
+	daily, err := s.currentUsage(serverID, model.UsagePeriodDay, now)
+	if err != nil {
+		return nil, err
+	}
+
+	monthly, err := s.currentUsage(serverID, model.UsagePeriodMonth, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.McpUsageResponse{
+		ServerID: serverID,
+		Daily:    model.NewPeriodUsageResponse(model.UsagePeriodDay, daily, quota.Daily),
+		Monthly:  model.NewPeriodUsageResponse(model.UsagePeriodMonth, monthly, quota.Monthly),
+	}, nil
+}
+
+// currentUsage returns the usage counter of the period containing now
+func (s *mcpServerService) currentUsage(serverID string, period model.UsagePeriod, now time.Time) (*model.McpUsageCounter, error) {
+	periodStart, _ := period.PeriodBounds(now)
+	return s.mcpRepo.GetUsage(serverID, period, periodStart)
+}
+
+// checkQuota returns ErrQuotaExceeded if the server has used up any of its quotas
+func (s *mcpServerService) checkQuota(server *model.McpServer) error {
+	quota := server.Config.Quota
+	now := time.Now()
+
+	checks := []struct {
+		period model.UsagePeriod
+		limits model.QuotaLimits
+	}{
+		{model.UsagePeriodDay, quota.Daily},
+		{model.UsagePeriodMonth, quota.Monthly},
+	}
+
+	for _, check := range checks {
+		if check.limits.IsUnlimited() {
+			continue
+		}
+
+		usage, err := s.currentUsage(server.ID, check.period, now)
+		if err != nil {
+			return err
+		}
+
+		if metric := check.limits.Exceeded(usage); metric != "" {
+			_, periodEnd := check.period.PeriodBounds(now)
+			return fmt.Errorf("%w: %s %s limit reached, resets at %s",
+				ErrQuotaExceeded, quotaPeriodName(check.period), metric, periodEnd.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
 // GetServerByApiKey returns a server by API key (for runtime)
 func (s *mcpServerService) GetServerByApiKey(apiKey string) (*model.McpServer, error) {
 	server, err := s.mcpRepo.FindByApiKey(apiKey)
@@ -486,6 +727,22 @@ func (s *mcpServerService) GetServerByApiKey(apiKey string) (*model.McpServer, e
 	return server, nil
 }
 
+// GetPublishedServer returns a published server by ID (for runtime).
+// Used for client certificate authentication, where no API key is presented.
+func (s *mcpServerService) GetPublishedServer(serverID string) (*model.McpServer, error) {
+	server, err := s.mcpRepo.FindByID(serverID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMcpServerNotFound) {
+			return nil, ErrMcpServerNotFound
+		}
+		return nil, err
+	}
+	if server.Status != string(model.McpServerStatusPublished) {
+		return nil, ErrServerNotPublished
+	}
+	return server, nil
+}
+
 // GetServerTools returns all tools for a server
 func (s *mcpServerService) GetServerTools(serverID string) ([]model.Tool, error) {
 	server, err := s.mcpRepo.FindByID(serverID)
@@ -505,17 +762,99 @@ func (s *mcpServerService) GetServerTools(serverID string) ([]model.Tool, error)
 	return tools, nil
 }
 
+// GetServerHealth returns the health of every datasource the server's tools depend on
+func (s *mcpServerService) GetServerHealth(serverID string) (*model.McpServerHealthResponse, error) {
+	tools, err := s.GetServerTools(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &model.McpServerHealthResponse{
+		Status:      string(model.McpServerHealthy),
+		ServerID:    serverID,
+		ToolsCount:  len(tools),
+		DataSources: []model.DataSourceHealthResponse{},
+		Timestamp:   time.Now().Unix(),
+	}
+
+	// Group tools by the datasource their query runs against
+	index := make(map[string]int)
+	for _, tool := range tools {
+		query, err := s.queryRepo.FindByID(tool.QueryID)
+		if err != nil {
+			continue
+		}
+
+		if i, ok := index[query.DataSourceID]; ok {
+			health.DataSources[i].Tools = append(health.DataSources[i].Tools, tool.Name)
+			continue
+		}
+
+		ds, err := s.dsRepo.FindByID(query.DataSourceID)
+		if err != nil {
+			continue
+		}
+
+		circuit := s.breakers.Get(ds.ID).Snapshot()
+		status := ds.HealthStatus
+		if circuit.State == circuitbreaker.StateOpen {
+			status = string(model.DataSourceHealthUnhealthy)
+		}
+
+		index[ds.ID] = len(health.DataSources)
+		health.DataSources = append(health.DataSources, model.DataSourceHealthResponse{
+			ID:            ds.ID,
+			Name:          ds.Name,
+			Type:          ds.Type,
+			Status:        status,
+			Error:         ds.HealthError,
+			LastCheckedAt: ds.LastHealthCheckAt,
+			CircuitState:  string(circuit.State),
+			Tools:         []string{tool.Name},
+		})
+	}
+
+	unhealthy := 0
+	for _, ds := range health.DataSources {
+		if ds.Status == string(model.DataSourceHealthUnhealthy) {
+			unhealthy++
+		}
+	}
+	switch {
+	case unhealthy > 0 && unhealthy == len(health.DataSources):
+		health.Status = string(model.McpServerUnhealthy)
+	case unhealthy > 0:
+		health.Status = string(model.McpServerDegraded)
+	}
+
+	return health, nil
+}
+
 // ExecuteTool executes a tool and returns the result
-func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[string]interface{}) (*model.McpToolCallResult, *model.McpLog, error) {
-	server, err := s.mcpRepo.FindByID(serverID)
+func (s *mcpServerService) ExecuteTool(ctx context.Context, serverID, toolName string, params map[string]interface{}) (*model.McpToolCallResult, *model.McpLog, error) {
+	ctx, span := tracing.StartSpan(ctx, "McpServerService.ExecuteTool",
+		trace.WithAttributes(
+			attribute.String("mcp.server_id", serverID),
+			attribute.String("mcp.tool", toolName),
+		),
+	)
+	defer span.End()
+
+	mcpRepo := s.mcpRepo.WithContext(ctx)
+	toolRepo := s.toolRepo.WithContext(ctx)
+	queryRepo := s.queryRepo.WithContext(ctx)
+	dsRepo := s.dsRepo.WithContext(ctx)
+
+	server, err := mcpRepo.FindByID(serverID)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, nil, err
 	}
 
 	// Find the tool by name
 	var tool *model.Tool
 	for _, toolID := range server.ToolIDs {
-		t, err := s.toolRepo.FindByID(toolID)
+		t, err := toolRepo.FindByID(toolID)
 		if err != nil {
 			continue
 		}
@@ -529,6 +868,11 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 		return nil, nil, ErrToolNotInServer
 	}
 
+	// Reject the call before touching the datasource if a quota is used up
+	if err := s.checkQuota(server); err != nil {
+		return nil, nil, err
+	}
+
 	// Create log entry
 	log := &model.McpLog{
 		McpServerID: serverID,
@@ -536,16 +880,26 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 		ToolName:    tool.Name,
 		Parameters:  model.McpLogParameters(params),
 		Status:      string(model.McpLogStatusSuccess),
+		TraceID:     tracing.TraceID(ctx),
 		Timestamp:   time.Now(),
 	}
 
 	start := time.Now()
 
+	// Wait for a slot on the server before doing any work
+	releaseServer, wait, err := acquireSlot(ctx, s.limiters, concurrencyScopeServer, server.ID, server.Config.Concurrency)
+	log.QueueWaitMs = wait.Milliseconds()
+	if err != nil {
+		return nil, log, rejectSlot(log, fmt.Sprintf("MCP server %s", server.Name), err, start)
+	}
+	defer releaseServer()
+
 	// Get the query
-	query, err := s.queryRepo.FindByID(tool.QueryID)
+	query, err := queryRepo.FindByID(tool.QueryID)
 	if err != nil {
 		log.Status = string(model.McpLogStatusError)
 		log.ErrorMessage = fmt.Sprintf("Query not found: %v", err)
+		log.ErrorClass = string(model.McpErrorClassQueryNotFound)
 		log.ResponseTimeMs = time.Since(start).Milliseconds()
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
@@ -557,6 +911,7 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 	if err := sqlparser.ValidateParameters(query.SQLTemplate, params); err != nil {
 		log.Status = string(model.McpLogStatusError)
 		log.ErrorMessage = fmt.Sprintf("Parameter validation failed: %v", err)
+		log.ErrorClass = string(model.McpErrorClassInvalidParameters)
 		log.ResponseTimeMs = time.Since(start).Milliseconds()
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
@@ -565,10 +920,11 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 	}
 
 	// Get DataSource
-	ds, err := s.dsRepo.FindByID(query.DataSourceID)
+	ds, err := dsRepo.FindByID(query.DataSourceID)
 	if err != nil {
 		log.Status = string(model.McpLogStatusError)
 		log.ErrorMessage = fmt.Sprintf("DataSource not found: %v", err)
+		log.ErrorClass = string(model.McpErrorClassDataSourceNotFound)
 		log.ResponseTimeMs = time.Since(start).Milliseconds()
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
@@ -576,11 +932,20 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 		}, log, nil
 	}
 
-	// Decrypt password
-	password, err := crypto.Decrypt(ds.Password)
+	// Wait for a slot on the datasource, shared by every server querying it
+	releaseDataSource, wait, err := acquireSlot(ctx, s.limiters, concurrencyScopeDataSource, ds.ID, ds.Concurrency)
+	log.QueueWaitMs += wait.Milliseconds()
+	if err != nil {
+		return nil, log, rejectSlot(log, fmt.Sprintf("Datasource %s", ds.Name), err, start)
+	}
+	defer releaseDataSource()
+
+	// Decrypt credentials
+	set, err := replicaSet(ds)
 	if err != nil {
 		log.Status = string(model.McpLogStatusError)
-		log.ErrorMessage = "Failed to decrypt datasource password"
+		log.ErrorMessage = "Failed to decrypt datasource credentials"
+		log.ErrorClass = string(model.McpErrorClassCredentials)
 		log.ResponseTimeMs = time.Since(start).Milliseconds()
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
@@ -588,36 +953,62 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 		}, log, nil
 	}
 
-	// Create database connection
-	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(ds.Type),
-		Host:     ds.Host,
-		Port:     ds.Port,
-		Username: ds.Username,
-		Password: password,
-		Database: ds.Database,
-		SSLMode:  ds.SSLMode,
+	// Fail fast instead of waiting for a connection timeout while the datasource is down
+	breaker := s.breakers.Get(ds.ID)
+	if err := breaker.Allow(); err != nil {
+		log.Status = string(model.McpLogStatusError)
+		log.ErrorMessage = fmt.Sprintf("Datasource %s is unavailable, try again later", ds.Name)
+		log.ErrorClass = string(model.McpErrorClassCircuitOpen)
+		log.ResponseTimeMs = time.Since(start).Milliseconds()
+		return &model.McpToolCallResult{
+			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
+			IsError: true,
+		}, log, nil
 	}
 
-	connector := dbconnector.NewConnector(config)
-	if err := connector.Connect(); err != nil {
+	// The route is logged with the call
+	connector, route, err := s.router.Get(ctx, ds.ID, set)
+	if errors.Is(err, dbconnector.ErrNoHealthyReplica) {
+		// The replicas' own checks report them down; the call decides nothing
+		breaker.Release()
+		log.Status = string(model.McpLogStatusError)
+		log.ErrorMessage = fmt.Sprintf("Datasource %s is unavailable: %v", ds.Name, err)
+		log.ErrorClass = string(model.McpErrorClassNoReplica)
+		log.ResponseTimeMs = time.Since(start).Milliseconds()
+		return &model.McpToolCallResult{
+			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
+			IsError: true,
+		}, log, nil
+	}
+	log.RoutedTo = route.Target
+	if err != nil {
+		breaker.Failure()
 		log.Status = string(model.McpLogStatusError)
 		log.ErrorMessage = fmt.Sprintf("Failed to connect to datasource: %v", err)
+		log.ErrorClass = string(model.McpErrorClassConnection)
 		log.ResponseTimeMs = time.Since(start).Milliseconds()
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
 			IsError: true,
 		}, log, nil
 	}
-	defer connector.Close()
 
 	// Execute query
-	result, err := connector.ExecuteQueryWithColumns(query.SQLTemplate, params)
+	result, err := connector.ExecuteTypedQueryContext(ctx, query.SQLTemplate, params, toolParamTypes(tool.Parameters))
 	log.ResponseTimeMs = time.Since(start).Milliseconds()
 
+	// Only connection failures count against the datasource; a rejected statement means it is up
+	if dbconnector.IsConnectionError(err) {
+		breaker.Failure()
+	} else {
+		breaker.Success()
+	}
+
 	if err != nil {
+		tracing.RecordError(span, err)
 		log.Status = string(model.McpLogStatusError)
 		log.ErrorMessage = fmt.Sprintf("Query execution failed: %v", err)
+		log.ErrorClass = string(model.McpErrorClassExecution)
 		return &model.McpToolCallResult{
 			Content: []model.McpContent{{Type: "text", Text: log.ErrorMessage}},
 			IsError: true,
@@ -637,6 +1028,62 @@ func (s *mcpServerService) ExecuteTool(serverID, toolName string, params map[str
 
 // Helper functions
 
+// Scopes of the concurrency limits applied to tool executions
+const (
+	concurrencyScopeServer     = "server"
+	concurrencyScopeDataSource = "datasource"
+)
+
+// defaultQueueTimeout is how long a queued execution waits when no timeout is configured
+const defaultQueueTimeout = 5 * time.Second
+
+// acquireSlot takes an execution slot on the limiter of a server or datasource.
+// It returns a function releasing the slot and how long the caller was queued.
+func acquireSlot(ctx context.Context, limiters *concurrency.Set, scope, id string, cfg model.ConcurrencyConfig) (func(), time.Duration, error) {
+	limits := concurrency.Limits{
+		MaxConcurrent: cfg.MaxConcurrent,
+		MaxQueued:     cfg.MaxQueued,
+		WaitTimeout:   time.Duration(cfg.QueueTimeoutMs) * time.Millisecond,
+	}
+	if limits.WaitTimeout <= 0 {
+		limits.WaitTimeout = defaultQueueTimeout
+	}
+
+	release, wait, err := limiters.Get(limiterKey(scope, id), limits).Acquire(ctx)
+	if err != nil {
+		// A caller that gave up waiting was not turned away
+		if ctx.Err() == nil {
+			metrics.IncBusyRejections(scope)
+		}
+		return nil, wait, err
+	}
+	if wait > 0 {
+		metrics.ObserveQueueWait(scope, wait)
+	}
+	return release, wait, nil
+}
+
+// limiterKey is the key of the limiter of a server or datasource
+func limiterKey(scope, id string) string {
+	return scope + ":" + id
+}
+
+// rejectSlot records a call that got no slot of a concurrency limit, because
+// the limit turned it away or the client cancelled it while it waited, and
+// returns the error reported to the MCP client
+func rejectSlot(log *model.McpLog, resource string, err error, start time.Time) error {
+	log.Status = string(model.McpLogStatusError)
+	log.ResponseTimeMs = time.Since(start).Milliseconds()
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		log.ErrorMessage = fmt.Sprintf("Call cancelled while waiting for %s: %v", resource, err)
+		log.ErrorClass = string(model.McpErrorClassCancelled)
+		return fmt.Errorf("%w: %s", ErrCallCancelled, log.ErrorMessage)
+	}
+	log.ErrorMessage = fmt.Sprintf("%s is busy, try again later: %v", resource, err)
+	log.ErrorClass = string(model.McpErrorClassBusy)
+	return fmt.Errorf("%w: %s", ErrServerBusy, log.ErrorMessage)
+}
+
 // loadTools loads tools by IDs for a user
 func (s *mcpServerService) loadTools(toolIDs []string, userID uint) []model.Tool {
 	tools := make([]model.Tool, 0, len(toolIDs))
@@ -658,6 +1105,14 @@ func incrementVersion(version string) string {
 	return fmt.Sprintf("%d.%d.%d", major, minor, patch+1)
 }
 
+// quotaPeriodName returns the adjective used for a quota period in messages
+func quotaPeriodName(period model.UsagePeriod) string {
+	if period == model.UsagePeriodMonth {
+		return "monthly"
+	}
+	return "daily"
+}
+
 // formatQueryResult formats query result as readable text
 func formatQueryResult(result *dbconnector.QueryResult) string {
 	if len(result.Data) == 0 {
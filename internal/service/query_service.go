@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,9 +9,13 @@ import (
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
-	"github.com/yourusername/dataweaver/pkg/crypto"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
 	"github.com/yourusername/dataweaver/pkg/dbconnector"
 	"github.com/yourusername/dataweaver/pkg/sqlparser"
+	"github.com/yourusername/dataweaver/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -28,7 +33,7 @@ type QueryService interface {
 	Get(id string, userID uint) (*model.QueryResponse, error)
 	Update(id string, userID uint, req *model.UpdateQueryRequest) (*model.QueryResponse, error)
 	Delete(id string, userID uint) error
-	Execute(id string, userID uint, req *model.ExecuteQueryRequest) (*model.ExecuteQueryResponse, error)
+	Execute(ctx context.Context, id string, userID uint, req *model.ExecuteQueryRequest) (*model.ExecuteQueryResponse, error)
 	ValidateSQL(sqlTemplate string) (*model.ValidateSQLResponse, error)
 	GetParameters(id string, userID uint) ([]model.QueryParameter, error)
 	ExtractParameters(sqlTemplate string) ([]model.QueryParameter, error)
@@ -39,13 +44,21 @@ type QueryService interface {
 type queryService struct {
 	queryRepo repository.QueryRepository
 	dsRepo    repository.DataSourceRepository
+	router    *dbconnector.Router
+	breakers  *circuitbreaker.Set
+	limiters  *concurrency.Set
 }
 
-// NewQueryService creates a new QueryService
-func NewQueryService(queryRepo repository.QueryRepository, dsRepo repository.DataSourceRepository) QueryService {
+// NewQueryService creates a new QueryService. Executions read through router,
+// from the primary or a replica of the query's datasource, and count against
+// the datasource's concurrency limit and circuit breaker like tool executions.
+func NewQueryService(queryRepo repository.QueryRepository, dsRepo repository.DataSourceRepository, router *dbconnector.Router, breakers *circuitbreaker.Set, limiters *concurrency.Set) QueryService {
 	return &queryService{
 		queryRepo: queryRepo,
 		dsRepo:    dsRepo,
+		router:    router,
+		breakers:  breakers,
+		limiters:  limiters,
 	}
 }
 
@@ -214,9 +227,17 @@ func (s *queryService) Delete(id string, userID uint) error {
 }
 
 // Execute executes a query with the provided parameters
-func (s *queryService) Execute(id string, userID uint, req *model.ExecuteQueryRequest) (*model.ExecuteQueryResponse, error) {
+func (s *queryService) Execute(ctx context.Context, id string, userID uint, req *model.ExecuteQueryRequest) (*model.ExecuteQueryResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "QueryService.Execute",
+		trace.WithAttributes(attribute.String("query.id", id)),
+	)
+	defer span.End()
+
+	queryRepo := s.queryRepo.WithContext(ctx)
+	dsRepo := s.dsRepo.WithContext(ctx)
+
 	// Get the query with DataSource
-	q, err := s.queryRepo.FindByIDWithDataSource(id, userID)
+	q, err := queryRepo.FindByIDWithDataSource(id, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -227,41 +248,31 @@ func (s *queryService) Execute(id string, userID uint, req *model.ExecuteQueryRe
 	}
 
 	// Get DataSource with decrypted password
-	ds, err := s.dsRepo.FindByIDAndUserID(q.DataSourceID, userID)
+	ds, err := dsRepo.FindByIDAndUserID(q.DataSourceID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decrypt password
-	password, err := crypto.Decrypt(ds.Password)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt datasource password: %w", err)
-	}
-
-	// Create database connection
-	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(ds.Type),
-		Host:     ds.Host,
-		Port:     ds.Port,
-		Username: ds.Username,
-		Password: password,
-		Database: ds.Database,
-		SSLMode:  ds.SSLMode,
-	}
-
-	connector := dbconnector.NewConnector(config)
-	if err := connector.Connect(); err != nil {
-		return nil, fmt.Errorf("failed to connect to datasource: %w", err)
-	}
-	defer connector.Close()
-
 	// Serialize parameters for history
 	paramsJSON, _ := serializeParams(req.Parameters)
 
 	// Execute query with ordered columns
-	start := time.Now()
-	queryResult, execErr := connector.ExecuteQueryWithColumns(q.SQLTemplate, req.Parameters)
-	executionTime := time.Since(start).Milliseconds()
+	var (
+		queryResult   *dbconnector.QueryResult
+		execErr       error
+		executionTime int64
+		executed      bool
+	)
+	err = runOnDataSource(ctx, s.router, s.breakers, s.limiters, ds, func(connector *dbconnector.Connector) error {
+		executed = true
+		start := time.Now()
+		queryResult, execErr = connector.ExecuteTypedQueryContext(ctx, q.SQLTemplate, req.Parameters, queryParamTypes(q.Parameters))
+		executionTime = time.Since(start).Milliseconds()
+		return execErr
+	})
+	if !executed {
+		return nil, err
+	}
 
 	// Save execution history
 	execution := &model.QueryExecution{
@@ -269,6 +280,7 @@ func (s *queryService) Execute(id string, userID uint, req *model.ExecuteQueryRe
 		QueryID:         id,
 		Parameters:      paramsJSON,
 		ExecutionTimeMs: executionTime,
+		TraceID:         tracing.TraceID(ctx),
 	}
 
 	if execErr != nil {
@@ -281,9 +293,10 @@ func (s *queryService) Execute(id string, userID uint, req *model.ExecuteQueryRe
 	}
 
 	// Save execution record (ignore errors, don't affect main flow)
-	_ = s.queryRepo.CreateExecution(execution)
+	_ = queryRepo.CreateExecution(execution)
 
 	if execErr != nil {
+		tracing.RecordError(span, execErr)
 		return nil, fmt.Errorf("%w: %v", ErrQueryExecution, execErr)
 	}
 
@@ -385,36 +398,23 @@ func (s *queryService) ExecuteRawQuery(userID uint, dataSourceID, sqlTemplate st
 		return nil, err
 	}
 
-	// Decrypt password
-	password, err := crypto.Decrypt(ds.Password)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt datasource password: %w", err)
-	}
-
-	// Create database connection
-	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(ds.Type),
-		Host:     ds.Host,
-		Port:     ds.Port,
-		Username: ds.Username,
-		Password: password,
-		Database: ds.Database,
-		SSLMode:  ds.SSLMode,
-	}
-
-	connector := dbconnector.NewConnector(config)
-	if err := connector.Connect(); err != nil {
-		return nil, fmt.Errorf("failed to connect to datasource: %w", err)
-	}
-	defer connector.Close()
-
 	// Execute query with ordered columns
-	start := time.Now()
-	queryResult, err := connector.ExecuteQueryWithColumns(sqlTemplate, params)
-	executionTime := time.Since(start).Milliseconds()
-
+	var (
+		queryResult   *dbconnector.QueryResult
+		execErr       error
+		executionTime int64
+	)
+	err = runOnDataSource(context.Background(), s.router, s.breakers, s.limiters, ds, func(connector *dbconnector.Connector) error {
+		start := time.Now()
+		queryResult, execErr = connector.ExecuteQueryWithColumns(sqlTemplate, params)
+		executionTime = time.Since(start).Milliseconds()
+		return execErr
+	})
+	if execErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQueryExecution, execErr)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrQueryExecution, err)
+		return nil, err
 	}
 
 	return &model.ExecuteQueryResponse{
@@ -483,6 +483,7 @@ func (s *queryService) GetExecutionHistory(userID uint, queryID string, page, si
 			ExecutionTimeMs: exec.ExecutionTimeMs,
 			Status:          exec.Status,
 			ErrorMessage:    exec.ErrorMessage,
+			TraceID:         exec.TraceID,
 			CreatedAt:       exec.CreatedAt,
 		}
 		if exec.Query.ID != "" {
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
+)
+
+// executionRepo serves one query and records its executions; other calls
+// panic through the nil embedded repository
+type executionRepo struct {
+	repository.QueryRepository
+
+	query      *model.Query
+	executions []*model.QueryExecution
+}
+
+func (r *executionRepo) WithContext(ctx context.Context) repository.QueryRepository {
+	return r
+}
+
+func (r *executionRepo) FindByIDWithDataSource(id string, userID uint) (*model.Query, error) {
+	return r.query, nil
+}
+
+func (r *executionRepo) CreateExecution(execution *model.QueryExecution) error {
+	r.executions = append(r.executions, execution)
+	return nil
+}
+
+func TestQueryService_Execute_CountsAgainstDataSourceLimit(t *testing.T) {
+	limiters := concurrency.NewSet()
+	dsRepo := new(repository.MockDataSourceRepository)
+	queryRepo := &executionRepo{query: &model.Query{ID: "q1", DataSourceID: "ds", SQLTemplate: "SELECT id FROM orders"}}
+	svc := NewQueryService(queryRepo, dsRepo, newTestRouter(t), circuitbreaker.NewSet(circuitbreaker.DefaultConfig()), limiters)
+
+	ds := sqliteDataSource(t, "ds", "orders")
+	ds.Concurrency = model.ConcurrencyConfig{MaxConcurrent: 1, QueueTimeoutMs: 10}
+	dsRepo.On("FindByIDAndUserID", "ds", uint(1)).Return(ds, nil)
+
+	result, err := svc.Execute(context.Background(), "q1", 1, &model.ExecuteQueryRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, result.Columns)
+	require.Len(t, queryRepo.executions, 1)
+
+	// A tool execution holds the only slot of the datasource
+	release, _, err := acquireSlot(context.Background(), limiters, concurrencyScopeDataSource, ds.ID, ds.Concurrency)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = svc.Execute(context.Background(), "q1", 1, &model.ExecuteQueryRequest{})
+	assert.ErrorIs(t, err, ErrDataSourceBusy)
+	assert.Len(t, queryRepo.executions, 1, "a run turned away never reached the datasource")
+}
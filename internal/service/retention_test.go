@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+)
+
+// retentionRepo records the retention deletes of servers; calls outside
+// retention panic through the nil embedded repository
+type retentionRepo struct {
+	repository.McpServerRepository
+
+	servers    []model.McpServer
+	rowCutoffs map[string]*repository.LogPosition
+	failing    map[string]bool
+
+	logsBefore       map[string]repository.LogCutoff
+	aggregated       map[string]bool
+	aggregatesBefore map[string]time.Time
+}
+
+func newRetentionRepo(servers ...model.McpServer) *retentionRepo {
+	return &retentionRepo{
+		servers:          servers,
+		rowCutoffs:       make(map[string]*repository.LogPosition),
+		failing:          make(map[string]bool),
+		logsBefore:       make(map[string]repository.LogCutoff),
+		aggregated:       make(map[string]bool),
+		aggregatesBefore: make(map[string]time.Time),
+	}
+}
+
+func (r *retentionRepo) FindAllServers() ([]model.McpServer, error) {
+	return r.servers, nil
+}
+
+func (r *retentionRepo) FindLogCutoffByRowCount(serverID string, maxRows int64) (*repository.LogPosition, error) {
+	return r.rowCutoffs[serverID], nil
+}
+
+func (r *retentionRepo) DeleteLogsBefore(serverID string, cutoff repository.LogCutoff, aggregate bool) (int64, error) {
+	if r.failing[serverID] {
+		return 0, errors.New("connection reset")
+	}
+	r.logsBefore[serverID] = cutoff
+	r.aggregated[serverID] = aggregate
+	return 3, nil
+}
+
+func (r *retentionRepo) DeleteLogAggregatesBefore(serverID string, before time.Time) (int64, error) {
+	r.aggregatesBefore[serverID] = before
+	return 1, nil
+}
+
+func retentionServer(id string, retention model.RetentionConfig) model.McpServer {
+	server := model.McpServer{ID: id}
+	server.Config.Retention = retention
+	return server
+}
+
+func TestApplyRetention_Cutoff(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	ageCutoff := now.AddDate(0, 0, -30)
+	rowCutoff := &repository.LogPosition{Timestamp: ageCutoff.Add(48 * time.Hour), ID: "log-7"}
+
+	tests := []struct {
+		name      string
+		retention model.RetentionConfig
+		rowCutoff *repository.LogPosition
+		want      *repository.LogCutoff
+	}{
+		{name: "age only", retention: model.RetentionConfig{MaxAgeDays: 30}, want: &repository.LogCutoff{Before: &ageCutoff}},
+		{name: "rows only", retention: model.RetentionConfig{MaxRows: 100}, rowCutoff: rowCutoff, want: &repository.LogCutoff{Through: rowCutoff}},
+		{name: "rows within limit", retention: model.RetentionConfig{MaxRows: 100}},
+		{name: "both limits", retention: model.RetentionConfig{MaxAgeDays: 30, MaxRows: 100}, rowCutoff: rowCutoff, want: &repository.LogCutoff{Before: &ageCutoff, Through: rowCutoff}},
+		{name: "both limits, rows within", retention: model.RetentionConfig{MaxAgeDays: 30, MaxRows: 100}, want: &repository.LogCutoff{Before: &ageCutoff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRetentionRepo()
+			repo.rowCutoffs["s1"] = tt.rowCutoff
+			svc := &mcpServerService{mcpRepo: repo}
+
+			result, err := svc.applyRetention("s1", tt.retention, now)
+			require.NoError(t, err)
+
+			cutoff, deleted := repo.logsBefore["s1"]
+			if tt.want == nil {
+				assert.False(t, deleted, "nothing is beyond the limits")
+				assert.Zero(t, result.DeletedLogs)
+				return
+			}
+			require.True(t, deleted)
+			assert.Equal(t, *tt.want, cutoff)
+			assert.Equal(t, int64(3), result.DeletedLogs)
+			assert.False(t, repo.aggregated["s1"])
+		})
+	}
+}
+
+func TestApplyRetention_Aggregates(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	repo := newRetentionRepo()
+	svc := &mcpServerService{mcpRepo: repo}
+
+	result, err := svc.applyRetention("s1", model.RetentionConfig{MaxAgeDays: 7, AggregateDays: 90}, now)
+	require.NoError(t, err)
+
+	assert.True(t, repo.aggregated["s1"], "deleted logs are rolled up first")
+	assert.Equal(t, now.AddDate(0, 0, -90), repo.aggregatesBefore["s1"])
+	assert.Equal(t, int64(1), result.DeletedAggregates)
+}
+
+func TestEnforceRetention_ContinuesPastFailures(t *testing.T) {
+	repo := newRetentionRepo(
+		retentionServer("unlimited", model.RetentionConfig{}),
+		retentionServer("failing", model.RetentionConfig{MaxAgeDays: 30}),
+		retentionServer("healthy", model.RetentionConfig{MaxAgeDays: 30}),
+	)
+	repo.failing["failing"] = true
+	svc := &mcpServerService{mcpRepo: repo}
+
+	results, err := svc.EnforceRetention()
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "healthy", results[0].ServerID)
+	assert.NotContains(t, repo.logsBefore, "unlimited", "servers keeping their logs forever are skipped")
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"time"
+
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RetentionWorker periodically enforces the log retention policy of every MCP server
+type RetentionWorker struct {
+	periodicTask
+	mcpService McpServerService
+}
+
+// NewRetentionWorker creates a new RetentionWorker
+func NewRetentionWorker(mcpService McpServerService, interval time.Duration) *RetentionWorker {
+	w := &RetentionWorker{mcpService: mcpService}
+	w.periodicTask = newPeriodicTask(interval, w.run)
+	return w
+}
+
+func (w *RetentionWorker) run() {
+	results, err := w.mcpService.EnforceRetention()
+	if err != nil {
+		logger.Error("Failed to enforce MCP log retention", zap.Error(err))
+	}
+
+	for _, result := range results {
+		if result.DeletedLogs == 0 && result.DeletedAggregates == 0 {
+			continue
+		}
+		logger.Info("Enforced MCP log retention",
+			zap.String("server_id", result.ServerID),
+			zap.Int64("deleted_logs", result.DeletedLogs),
+			zap.Int64("deleted_aggregates", result.DeletedAggregates),
+		)
+	}
+}
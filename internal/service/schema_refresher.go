@@ -0,0 +1,28 @@
+package service
+
+import (
+	"time"
+
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SchemaRefresher periodically refreshes the schema snapshots of every active
+// datasource so drift is detected without an explicit refresh
+type SchemaRefresher struct {
+	periodicTask
+	schemaService SchemaService
+}
+
+// NewSchemaRefresher creates a new SchemaRefresher
+func NewSchemaRefresher(schemaService SchemaService, interval time.Duration) *SchemaRefresher {
+	r := &SchemaRefresher{schemaService: schemaService}
+	r.periodicTask = newPeriodicTask(interval, r.run)
+	return r
+}
+
+func (r *SchemaRefresher) run() {
+	if err := r.schemaService.RefreshAll(); err != nil {
+		logger.Error("Failed to refresh datasource schemas", zap.Error(err))
+	}
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/internal/repository"
+	"github.com/yourusername/dataweaver/pkg/circuitbreaker"
+	"github.com/yourusername/dataweaver/pkg/concurrency"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+	"github.com/yourusername/dataweaver/pkg/logger"
+	"github.com/yourusername/dataweaver/pkg/sqlparser"
+	"go.uber.org/zap"
+)
+
+// SchemaService stores versioned schema snapshots of datasources and flags
+// the tools whose queries are affected when the schema drifts
+type SchemaService interface {
+	GetLatest(dataSourceID string, userID uint) (*model.SchemaSnapshotResponse, error)
+	Refresh(dataSourceID string, userID uint) (*model.RefreshSchemaResponse, error)
+	ListSnapshots(dataSourceID string, userID uint, page, size int) ([]model.SchemaSnapshotResponse, int64, error)
+	GetSnapshot(dataSourceID string, userID uint, version int) (*model.SchemaSnapshotResponse, error)
+	RefreshAll() error
+}
+
+type schemaService struct {
+	snapshotRepo repository.SchemaSnapshotRepository
+	dsRepo       repository.DataSourceRepository
+	queryRepo    repository.QueryRepository
+	toolRepo     repository.ToolRepository
+	router       *dbconnector.Router
+	breakers     *circuitbreaker.Set
+	limiters     *concurrency.Set
+
+	// mu serializes refreshes so two of them cannot race for the same version
+	mu sync.Mutex
+}
+
+// NewSchemaService creates a new SchemaService. Schemas are read through
+// router, counting against the datasources' limits and circuit breakers.
+func NewSchemaService(
+	snapshotRepo repository.SchemaSnapshotRepository,
+	dsRepo repository.DataSourceRepository,
+	queryRepo repository.QueryRepository,
+	toolRepo repository.ToolRepository,
+	router *dbconnector.Router,
+	breakers *circuitbreaker.Set,
+	limiters *concurrency.Set,
+) SchemaService {
+	return &schemaService{
+		snapshotRepo: snapshotRepo,
+		dsRepo:       dsRepo,
+		queryRepo:    queryRepo,
+		toolRepo:     toolRepo,
+		router:       router,
+		breakers:     breakers,
+		limiters:     limiters,
+	}
+}
+
+// GetLatest returns the latest schema snapshot of a datasource, taking the first one if none exists
+func (s *schemaService) GetLatest(dataSourceID string, userID uint) (*model.SchemaSnapshotResponse, error) {
+	ds, err := s.dsRepo.FindByIDAndUserID(dataSourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.snapshotRepo.FindLatest(ds.ID)
+	if errors.Is(err, repository.ErrSchemaSnapshotNotFound) {
+		snapshot, _, err = s.refresh(ds)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.ToResponse(), nil
+}
+
+// Refresh introspects a datasource and stores a new snapshot if its schema changed
+func (s *schemaService) Refresh(dataSourceID string, userID uint) (*model.RefreshSchemaResponse, error) {
+	ds, err := s.dsRepo.FindByIDAndUserID(dataSourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, changed, err := s.refresh(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RefreshSchemaResponse{
+		Changed:  changed,
+		Snapshot: snapshot.ToResponse(),
+	}, nil
+}
+
+// ListSnapshots returns the schema snapshots of a datasource, newest first and without their tables
+func (s *schemaService) ListSnapshots(dataSourceID string, userID uint, page, size int) ([]model.SchemaSnapshotResponse, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	if _, err := s.dsRepo.FindByIDAndUserID(dataSourceID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	snapshots, total, err := s.snapshotRepo.FindAll(dataSourceID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]model.SchemaSnapshotResponse, len(snapshots))
+	for i := range snapshots {
+		responses[i] = *snapshots[i].ToResponse()
+	}
+
+	return responses, total, nil
+}
+
+// GetSnapshot returns a specific schema snapshot version of a datasource
+func (s *schemaService) GetSnapshot(dataSourceID string, userID uint, version int) (*model.SchemaSnapshotResponse, error) {
+	if _, err := s.dsRepo.FindByIDAndUserID(dataSourceID, userID); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.snapshotRepo.FindByVersion(dataSourceID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.ToResponse(), nil
+}
+
+// RefreshAll refreshes the schema snapshot of every active datasource. A
+// datasource that cannot be introspected is logged and skipped.
+func (s *schemaService) RefreshAll() error {
+	datasources, err := s.dsRepo.FindAllActive()
+	if err != nil {
+		return err
+	}
+
+	for i := range datasources {
+		ds := &datasources[i]
+		snapshot, changed, err := s.refresh(ds)
+		if err != nil {
+			logger.Warn("Failed to refresh datasource schema",
+				zap.String("datasource_id", ds.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if changed && snapshot.Version > 1 {
+			logger.Info("Datasource schema changed",
+				zap.String("datasource_id", ds.ID),
+				zap.Int("version", snapshot.Version),
+				zap.Int("changes", len(snapshot.Changes)),
+				zap.Int("affected_tools", len(snapshot.AffectedToolIDs)),
+			)
+		}
+	}
+
+	return nil
+}
+
+// refresh introspects ds and stores a new snapshot version when the schema
+// differs from the latest one, flagging the tools the changes affect. It
+// returns the latest snapshot and whether a new version was stored.
+func (s *schemaService) refresh(ds *model.DataSource) (*model.SchemaSnapshot, bool, error) {
+	tables, err := s.introspectTables(ds)
+	if err != nil {
+		return nil, false, err
+	}
+	checksum, err := schemaChecksum(tables)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.snapshotRepo.FindLatest(ds.ID)
+	if err != nil && !errors.Is(err, repository.ErrSchemaSnapshotNotFound) {
+		return nil, false, err
+	}
+	if latest != nil && latest.Checksum == checksum {
+		return latest, false, nil
+	}
+
+	snapshot := &model.SchemaSnapshot{
+		DataSourceID: ds.ID,
+		Version:      1,
+		Tables:       tables,
+		Checksum:     checksum,
+	}
+
+	// Tools to flag for review, grouped by the reason their query is affected
+	toolsByReason := make(map[string][]string)
+	if latest != nil {
+		snapshot.Version = latest.Version + 1
+		snapshot.Changes = diffSchemas(latest.Tables, tables)
+
+		queries, err := s.queryRepo.FindByDataSourceID(ds.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		affected, reasons := affectedQueries(queries, snapshot.Changes)
+		for _, q := range affected {
+			snapshot.AffectedQueryIDs = append(snapshot.AffectedQueryIDs, q.ID)
+
+			tools, err := s.toolRepo.FindByQueryID(q.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			reason := fmt.Sprintf("Schema version %d of datasource %s: %s", snapshot.Version, ds.Name, reasons[q.ID])
+			for _, t := range tools {
+				snapshot.AffectedToolIDs = append(snapshot.AffectedToolIDs, t.ID)
+				toolsByReason[reason] = append(toolsByReason[reason], t.ID)
+			}
+		}
+	}
+
+	if err := s.snapshotRepo.CreateWithReviews(snapshot, toolsByReason); err != nil {
+		return nil, false, err
+	}
+
+	return snapshot, true, nil
+}
+
+// introspectTables reads the current schema of a datasource over its pooled connection
+func (s *schemaService) introspectTables(ds *model.DataSource) (model.SchemaTables, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tableProfileTimeout)
+	defer cancel()
+
+	var tables []dbconnector.TableInfo
+	err := runOnDataSource(ctx, s.router, s.breakers, s.limiters, ds, func(connector *dbconnector.Connector) error {
+		var err error
+		if tables, err = connector.GetSchema(); err != nil {
+			return fmt.Errorf("failed to get schema: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(model.SchemaTables, len(tables))
+	for i, t := range tables {
+		responses[i] = toTableInfoResponse(t)
+	}
+	return responses, nil
+}
+
+// schemaChecksum hashes the structure of tables, leaving out row estimates
+// which change without the schema changing
+func schemaChecksum(tables model.SchemaTables) (string, error) {
+	stripped := make([]model.TableInfoResponse, len(tables))
+	for i, t := range tables {
+		t.RowCount = 0
+		t.RowEstimate = nil
+		stripped[i] = t
+	}
+
+	data, err := json.Marshal(stripped)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode schema: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func schemaTableKey(schema, name string) string {
+	return strings.ToLower(schema) + "." + strings.ToLower(name)
+}
+
+// diffSchemas lists the tables and columns added, dropped, retyped or renamed
+// between two snapshots. A dropped and an added column of the same type are
+// taken to be a rename when they sit at the same position, or when they are
+// the only columns of the table that changed.
+func diffSchemas(old, new model.SchemaTables) model.SchemaChanges {
+	var changes model.SchemaChanges
+
+	oldTables := make(map[string]*model.TableInfoResponse, len(old))
+	for i := range old {
+		oldTables[schemaTableKey(old[i].Schema, old[i].Name)] = &old[i]
+	}
+	newTables := make(map[string]bool, len(new))
+
+	for i := range new {
+		table := &new[i]
+		key := schemaTableKey(table.Schema, table.Name)
+		newTables[key] = true
+
+		prev, ok := oldTables[key]
+		if !ok {
+			changes = append(changes, model.SchemaChange{
+				Kind: model.SchemaChangeTableAdded, Schema: table.Schema, Table: table.Name,
+			})
+			continue
+		}
+		changes = append(changes, diffColumns(prev, table)...)
+	}
+
+	for _, table := range old {
+		if !newTables[schemaTableKey(table.Schema, table.Name)] {
+			changes = append(changes, model.SchemaChange{
+				Kind: model.SchemaChangeTableDropped, Schema: table.Schema, Table: table.Name,
+			})
+		}
+	}
+
+	return changes
+}
+
+// diffColumns lists the column changes between two versions of a table
+func diffColumns(old, new *model.TableInfoResponse) []model.SchemaChange {
+	var changes []model.SchemaChange
+
+	oldIndex := make(map[string]int, len(old.Columns))
+	for i, c := range old.Columns {
+		oldIndex[strings.ToLower(c.Name)] = i
+	}
+	newIndex := make(map[string]int, len(new.Columns))
+	for i, c := range new.Columns {
+		newIndex[strings.ToLower(c.Name)] = i
+	}
+
+	var dropped, added []int
+	for i, c := range old.Columns {
+		if _, ok := newIndex[strings.ToLower(c.Name)]; !ok {
+			dropped = append(dropped, i)
+		}
+	}
+	for i, c := range new.Columns {
+		j, ok := oldIndex[strings.ToLower(c.Name)]
+		if !ok {
+			added = append(added, i)
+			continue
+		}
+		if prev := old.Columns[j]; !strings.EqualFold(prev.Type, c.Type) {
+			changes = append(changes, model.SchemaChange{
+				Kind: model.SchemaChangeColumnTypeChanged, Schema: new.Schema, Table: new.Name,
+				Column: c.Name, OldType: prev.Type, NewType: c.Type,
+			})
+		}
+	}
+
+	renamed := make(map[int]int) // old column index -> new column index
+	for _, d := range dropped {
+		for _, a := range added {
+			if d == a && strings.EqualFold(old.Columns[d].Type, new.Columns[a].Type) {
+				renamed[d] = a
+			}
+		}
+	}
+	if len(renamed) == 0 && len(dropped) == 1 && len(added) == 1 &&
+		strings.EqualFold(old.Columns[dropped[0]].Type, new.Columns[added[0]].Type) {
+		renamed[dropped[0]] = added[0]
+	}
+	renamedTo := make(map[int]bool, len(renamed))
+	for _, a := range renamed {
+		renamedTo[a] = true
+	}
+
+	for _, d := range dropped {
+		col := old.Columns[d]
+		if a, ok := renamed[d]; ok {
+			changes = append(changes, model.SchemaChange{
+				Kind: model.SchemaChangeColumnRenamed, Schema: new.Schema, Table: new.Name,
+				Column: col.Name, NewColumn: new.Columns[a].Name, OldType: col.Type, NewType: new.Columns[a].Type,
+			})
+			continue
+		}
+		changes = append(changes, model.SchemaChange{
+			Kind: model.SchemaChangeColumnDropped, Schema: new.Schema, Table: new.Name,
+			Column: col.Name, OldType: col.Type,
+		})
+	}
+	for _, a := range added {
+		if renamedTo[a] {
+			continue
+		}
+		col := new.Columns[a]
+		changes = append(changes, model.SchemaChange{
+			Kind: model.SchemaChangeColumnAdded, Schema: new.Schema, Table: new.Name,
+			Column: col.Name, NewType: col.Type,
+		})
+	}
+
+	return changes
+}
+
+// affectedQueries returns the queries whose SQL references an object a
+// breaking change touched, with a description of those changes per query ID.
+// A query selecting * from a changed table is affected by every breaking
+// column change of it, as it reads the column without naming it.
+func affectedQueries(queries []model.Query, changes model.SchemaChanges) ([]model.Query, map[string]string) {
+	var affected []model.Query
+	reasons := make(map[string]string)
+
+	for _, q := range queries {
+		refs := sqlparser.ExtractReferences(q.SQLTemplate)
+
+		var descriptions []string
+		for _, change := range changes {
+			if !change.Kind.Breaking() || !refs.HasTable(change.Schema, change.Table) {
+				continue
+			}
+			if change.Kind != model.SchemaChangeTableDropped && !refs.SelectsAll && !refs.HasIdentifier(change.Column) {
+				continue
+			}
+			descriptions = append(descriptions, describeSchemaChange(change))
+		}
+
+		if len(descriptions) > 0 {
+			affected = append(affected, q)
+			reasons[q.ID] = strings.Join(descriptions, "; ")
+		}
+	}
+
+	return affected, reasons
+}
+
+// describeSchemaChange returns a short human readable description of a schema change
+func describeSchemaChange(c model.SchemaChange) string {
+	table := c.Table
+	if c.Schema != "" {
+		table = c.Schema + "." + c.Table
+	}
+
+	switch c.Kind {
+	case model.SchemaChangeTableDropped:
+		return fmt.Sprintf("table %s dropped", table)
+	case model.SchemaChangeColumnDropped:
+		return fmt.Sprintf("column %s.%s dropped", table, c.Column)
+	case model.SchemaChangeColumnTypeChanged:
+		return fmt.Sprintf("column %s.%s changed from %s to %s", table, c.Column, c.OldType, c.NewType)
+	case model.SchemaChangeColumnRenamed:
+		return fmt.Sprintf("column %s.%s renamed to %s", table, c.Column, c.NewColumn)
+	default:
+		return fmt.Sprintf("%s %s", c.Kind, table)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/dataweaver/internal/model"
+)
+
+func schemaTable(name string, columns ...string) model.TableInfoResponse {
+	table := model.TableInfoResponse{Schema: "public", Name: name, Kind: "table"}
+	for i := 0; i < len(columns); i += 2 {
+		table.Columns = append(table.Columns, model.ColumnInfoResponse{Name: columns[i], Type: columns[i+1]})
+	}
+	return table
+}
+
+func TestDiffSchemas(t *testing.T) {
+	old := model.SchemaTables{
+		schemaTable("orders", "id", "integer", "total", "numeric", "status", "text", "note", "text"),
+		schemaTable("legacy", "id", "integer"),
+		schemaTable("users", "id", "integer", "mail", "text"),
+	}
+	new := model.SchemaTables{
+		schemaTable("orders", "id", "bigint", "amount", "numeric", "status", "text", "created_at", "timestamp"),
+		schemaTable("users", "id", "integer", "email", "text"),
+		schemaTable("events", "id", "integer"),
+	}
+
+	changes := diffSchemas(old, new)
+
+	assert.Equal(t, model.SchemaChanges{
+		{Kind: model.SchemaChangeColumnTypeChanged, Schema: "public", Table: "orders", Column: "id", OldType: "integer", NewType: "bigint"},
+		{Kind: model.SchemaChangeColumnRenamed, Schema: "public", Table: "orders", Column: "total", NewColumn: "amount", OldType: "numeric", NewType: "numeric"},
+		{Kind: model.SchemaChangeColumnDropped, Schema: "public", Table: "orders", Column: "note", OldType: "text"},
+		{Kind: model.SchemaChangeColumnAdded, Schema: "public", Table: "orders", Column: "created_at", NewType: "timestamp"},
+		{Kind: model.SchemaChangeColumnRenamed, Schema: "public", Table: "users", Column: "mail", NewColumn: "email", OldType: "text", NewType: "text"},
+		{Kind: model.SchemaChangeTableAdded, Schema: "public", Table: "events"},
+		{Kind: model.SchemaChangeTableDropped, Schema: "public", Table: "legacy"},
+	}, changes)
+
+	assert.Empty(t, diffSchemas(old, old))
+}
+
+func TestSchemaChecksum_IgnoresRowEstimates(t *testing.T) {
+	estimate := int64(42)
+	tables := model.SchemaTables{schemaTable("orders", "id", "integer")}
+	estimated := model.SchemaTables{schemaTable("orders", "id", "integer")}
+	estimated[0].RowEstimate = &estimate
+
+	first, err := schemaChecksum(tables)
+	require.NoError(t, err)
+	second, err := schemaChecksum(estimated)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, &estimate, estimated[0].RowEstimate)
+
+	changed, err := schemaChecksum(model.SchemaTables{schemaTable("orders", "id", "bigint")})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+}
+
+func TestAffectedQueries(t *testing.T) {
+	queries := []model.Query{
+		{ID: "q1", SQLTemplate: "SELECT id, total FROM orders WHERE status = :status"},
+		{ID: "q2", SQLTemplate: "SELECT id, status FROM public.orders"},
+		{ID: "q3", SQLTemplate: "SELECT * FROM legacy"},
+		{ID: "q4", SQLTemplate: "SELECT total FROM sales.orders"},
+		{ID: "q5", SQLTemplate: "SELECT created_at FROM orders"},
+		{ID: "q6", SQLTemplate: "SELECT o.* FROM orders o"},
+		{ID: "q7", SQLTemplate: "SELECT count(*) FROM orders"},
+	}
+	changes := model.SchemaChanges{
+		{Kind: model.SchemaChangeColumnRenamed, Schema: "public", Table: "orders", Column: "total", NewColumn: "amount"},
+		{Kind: model.SchemaChangeColumnAdded, Schema: "public", Table: "orders", Column: "created_at"},
+		{Kind: model.SchemaChangeTableDropped, Schema: "public", Table: "legacy"},
+	}
+
+	affected, reasons := affectedQueries(queries, changes)
+
+	ids := make([]string, len(affected))
+	for i, q := range affected {
+		ids[i] = q.ID
+	}
+	assert.Equal(t, []string{"q1", "q3", "q6"}, ids)
+	assert.Equal(t, "column public.orders.total renamed to amount", reasons["q1"])
+	assert.Equal(t, "table public.legacy dropped", reasons["q3"])
+	assert.Equal(t, "column public.orders.total renamed to amount", reasons["q6"])
+}
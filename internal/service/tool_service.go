@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -10,7 +11,6 @@ import (
 
 	"github.com/yourusername/dataweaver/internal/model"
 	"github.com/yourusername/dataweaver/internal/repository"
-	"github.com/yourusername/dataweaver/pkg/crypto"
 	"github.com/yourusername/dataweaver/pkg/dbconnector"
 	"github.com/yourusername/dataweaver/pkg/sqlparser"
 )
@@ -30,6 +30,7 @@ type ToolService interface {
 	Get(id string, userID uint) (*model.ToolResponse, error)
 	Update(id string, userID uint, req *model.UpdateToolRequest) (*model.ToolResponse, error)
 	Delete(id string, userID uint) error
+	MarkReviewed(id string, userID uint) (*model.ToolResponse, error)
 	TestTool(id string, userID uint, req *model.TestToolRequest) (*model.TestToolResponse, error)
 	Export(id string, userID uint) (*model.MCPToolDefinition, error)
 	ExportAll(userID uint) ([]*model.MCPToolDefinition, error)
@@ -40,18 +41,22 @@ type toolService struct {
 	toolRepo  repository.ToolRepository
 	queryRepo repository.QueryRepository
 	dsRepo    repository.DataSourceRepository
+	router    *dbconnector.Router
 }
 
-// NewToolService creates a new ToolService
+// NewToolService creates a new ToolService. Tool tests read through router,
+// from the primary or a replica of the tool's datasource.
 func NewToolService(
 	toolRepo repository.ToolRepository,
 	queryRepo repository.QueryRepository,
 	dsRepo repository.DataSourceRepository,
+	router *dbconnector.Router,
 ) ToolService {
 	return &toolService{
 		toolRepo:  toolRepo,
 		queryRepo: queryRepo,
 		dsRepo:    dsRepo,
+		router:    router,
 	}
 }
 
@@ -280,6 +285,14 @@ func (s *toolService) Delete(id string, userID uint) error {
 	return s.toolRepo.Delete(id, userID)
 }
 
+// MarkReviewed clears the needs review flag a schema change set on a tool
+func (s *toolService) MarkReviewed(id string, userID uint) (*model.ToolResponse, error) {
+	if err := s.toolRepo.ClearNeedsReview(id, userID); err != nil {
+		return nil, err
+	}
+	return s.Get(id, userID)
+}
+
 // TestTool tests a tool by executing its associated query
 func (s *toolService) TestTool(id string, userID uint, req *model.TestToolRequest) (*model.TestToolResponse, error) {
 	// Get tool with query and datasource
@@ -322,38 +335,26 @@ func (s *toolService) TestTool(id string, userID uint, req *model.TestToolReques
 		}, nil
 	}
 
-	// Decrypt password
-	password, err := crypto.Decrypt(ds.Password)
+	// Test on the connection the tool will read through once published
+	set, err := replicaSet(ds)
 	if err != nil {
 		return &model.TestToolResponse{
 			Success: false,
-			Message: "Failed to decrypt datasource password",
+			Message: "Failed to decrypt datasource credentials",
 		}, nil
 	}
 
-	// Create database connection
-	config := &dbconnector.ConnectionConfig{
-		Type:     dbconnector.DBType(ds.Type),
-		Host:     ds.Host,
-		Port:     ds.Port,
-		Username: ds.Username,
-		Password: password,
-		Database: ds.Database,
-		SSLMode:  ds.SSLMode,
-	}
-
-	connector := dbconnector.NewConnector(config)
-	if err := connector.Connect(); err != nil {
+	connector, _, err := s.router.Get(context.Background(), ds.ID, set)
+	if err != nil {
 		return &model.TestToolResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to connect to datasource: %v", err),
 		}, nil
 	}
-	defer connector.Close()
 
 	// Execute query
 	start := time.Now()
-	result, err := connector.ExecuteQueryWithColumns(query.SQLTemplate, req.Parameters)
+	result, err := connector.ExecuteTypedQueryContext(context.Background(), query.SQLTemplate, req.Parameters, toolParamTypes(tool.Parameters))
 	executionTime := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -422,6 +423,17 @@ func (s *toolService) GenerateDescription(id string, userID uint, req *model.Gen
 		generated = true
 	}
 
+	// Mention the values the query's columns hold, from the stored table profiles
+	if tool.Query.ID != "" {
+		profiles, err := s.dsRepo.FindTableProfiles(tool.Query.DataSourceID)
+		if err != nil {
+			return nil, err
+		}
+		if notes := describeColumnProfiles(tool.Query.SQLTemplate, profiles); notes != "" {
+			description += ". " + notes
+		}
+	}
+
 	return &model.GenerateDescriptionResponse{
 		Description: description,
 		Generated:   generated,
@@ -522,6 +534,43 @@ func generateToolDescriptionFromTool(tool *model.Tool) string {
 	return sb.String()
 }
 
+// describeColumnProfiles summarizes the profiled values of the columns a
+// query uses: the values of columns with few distinct values and the range
+// of numeric columns. Columns sensitive now are left out, even if they were
+// not when the table was profiled.
+func describeColumnProfiles(sqlTemplate string, profiles []model.TableProfile) string {
+	refs := sqlparser.ExtractReferences(sqlTemplate)
+
+	var notes []string
+	for _, p := range profiles {
+		if !refs.HasTable(p.Schema, p.Table) {
+			continue
+		}
+		for _, col := range p.Columns {
+			if col.Error != "" || !refs.HasIdentifier(col.Name) || dbconnector.IsSensitiveColumn(col.Name) {
+				continue
+			}
+
+			name := p.Table + "." + col.Name
+			switch {
+			case len(col.TopValues) > 0 && col.DistinctCount <= int64(len(col.TopValues)):
+				values := make([]string, len(col.TopValues))
+				for i, v := range col.TopValues {
+					values[i] = fmt.Sprintf("%v", v.Value)
+				}
+				notes = append(notes, fmt.Sprintf("%s is one of %s", name, strings.Join(values, ", ")))
+			case len(col.Histogram) > 0:
+				notes = append(notes, fmt.Sprintf("%s ranges from %v to %v", name, col.Min, col.Max))
+			}
+		}
+	}
+
+	if len(notes) == 0 {
+		return ""
+	}
+	return "Data notes: " + strings.Join(notes, "; ")
+}
+
 // inferOutputSchema infers output schema from query (basic implementation)
 func inferOutputSchema(query *model.Query) map[string]interface{} {
 	// Basic output schema - in a real implementation, this could analyze
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/dataweaver/internal/model"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
+)
+
+func TestDescribeColumnProfiles(t *testing.T) {
+	profiles := []model.TableProfile{
+		{
+			Schema: "public",
+			Table:  "payments",
+			Columns: model.ColumnProfiles{
+				{Name: "id", DistinctCount: 100, TopValues: []model.ValueCount{{Value: 1, Count: 1}}},
+				{Name: "status", DistinctCount: 2, TopValues: []model.ValueCount{{Value: "paid", Count: 3}, {Value: "open", Count: 1}}},
+				{Name: "amount", DistinctCount: 40, Min: 10.0, Max: 100.0, Histogram: []model.HistogramBucket{{Lower: 10, Upper: 100, Count: 40}}},
+				{Name: "note", Error: "unsupported type"},
+			},
+		},
+		{
+			Schema:  "public",
+			Table:   "refunds",
+			Columns: model.ColumnProfiles{{Name: "status", DistinctCount: 1, TopValues: []model.ValueCount{{Value: "done", Count: 1}}}},
+		},
+	}
+
+	notes := describeColumnProfiles("SELECT id, status, amount, note FROM payments WHERE status = :status", profiles)
+	assert.Equal(t, "Data notes: payments.status is one of paid, open; payments.amount ranges from 10 to 100", notes)
+
+	assert.Empty(t, describeColumnProfiles("SELECT id FROM payments", profiles))
+
+	// Marking a column sensitive hides what was profiled before
+	cfg := dbconnector.DefaultProfileConfig()
+	cfg.SensitiveColumns = []string{"STAT*"}
+	dbconnector.ConfigureProfiling(cfg)
+	t.Cleanup(func() { dbconnector.ConfigureProfiling(dbconnector.DefaultProfileConfig()) })
+
+	notes = describeColumnProfiles("SELECT id, status, amount, note FROM payments WHERE status = :status", profiles)
+	assert.Equal(t, "Data notes: payments.amount ranges from 10 to 100", notes)
+}
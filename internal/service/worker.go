@@ -0,0 +1,48 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// periodicTask runs a function on a fixed interval in the background
+type periodicTask struct {
+	interval time.Duration
+	run      func()
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newPeriodicTask(interval time.Duration, run func()) periodicTask {
+	return periodicTask{
+		interval: interval,
+		run:      run,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the task in the background until Stop is called
+func (t *periodicTask) Start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.run()
+			}
+		}
+	}()
+}
+
+// Stop stops the task and waits for a running pass to finish
+func (t *periodicTask) Stop() {
+	close(t.stop)
+	t.wg.Wait()
+}
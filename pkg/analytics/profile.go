@@ -0,0 +1,26 @@
+package analytics
+
+// CalculateRatio returns part as a fraction of total, or 0 when total is 0
+func CalculateRatio(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// HistogramBounds splits [min, max] into buckets of equal width and returns
+// the buckets+1 bounds between them. It returns nil when the range is empty.
+func HistogramBounds(min, max float64, buckets int) []float64 {
+	if buckets < 1 || !(max > min) {
+		return nil
+	}
+
+	width := (max - min) / float64(buckets)
+	bounds := make([]float64, buckets+1)
+	for i := range bounds {
+		bounds[i] = min + float64(i)*width
+	}
+	// Avoid rounding leaving the maximum outside the last bucket
+	bounds[buckets] = max
+	return bounds
+}
@@ -1,9 +1,16 @@
 package analytics
 
 import (
+	"errors"
 	"time"
 )
 
+// HourlyBucketMaxRange is the longest time range for which per-hour buckets are reported
+const HourlyBucketMaxRange = 48 * time.Hour
+
+// ErrInvalidTimeRange is returned when a time range ends before it starts
+var ErrInvalidTimeRange = errors.New("invalid time range: end must be after start")
+
 // TimeRange represents a time range for statistics queries
 type TimeRange struct {
 	Start time.Time `json:"start"`
@@ -15,6 +22,28 @@ func NewTimeRange(start, end time.Time) TimeRange {
 	return TimeRange{Start: start, End: end}
 }
 
+// LastDays returns a TimeRange covering the given number of days up to now
+func LastDays(days int) TimeRange {
+	now := time.Now()
+	return TimeRange{
+		Start: now.AddDate(0, 0, -days),
+		End:   now,
+	}
+}
+
+// Validate checks that the range is not empty or inverted
+func (t TimeRange) Validate() error {
+	if !t.End.After(t.Start) {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// Duration returns the length of the range
+func (t TimeRange) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
 // Last24Hours returns a TimeRange for the last 24 hours
 func Last24Hours() TimeRange {
 	now := time.Now()
@@ -44,13 +73,16 @@ func Last30Days() TimeRange {
 
 // ToolStats represents statistics for a specific tool
 type ToolStats struct {
-	ToolID        string  `json:"tool_id"`
-	ToolName      string  `json:"tool_name"`
-	CallCount     int64   `json:"call_count"`
-	SuccessCount  int64   `json:"success_count"`
-	ErrorCount    int64   `json:"error_count"`
-	SuccessRate   float64 `json:"success_rate"`
-	AvgResponseMs float64 `json:"avg_response_ms"`
+	ToolID         string  `json:"tool_id"`
+	ToolName       string  `json:"tool_name"`
+	CallCount      int64   `json:"call_count"`
+	SuccessCount   int64   `json:"success_count"`
+	ErrorCount     int64   `json:"error_count"`
+	SuccessRate    float64 `json:"success_rate"`
+	AvgResponseMs  float64 `json:"avg_response_ms"`
+	P90ResponseMs  float64 `json:"p90_response_ms"`
+	TotalRows      int64   `json:"total_rows"`
+	AvgQueueWaitMs float64 `json:"avg_queue_wait_ms"`
 }
 
 // DayStats represents statistics for a specific day
@@ -62,25 +94,67 @@ type DayStats struct {
 	SuccessRate  float64 `json:"success_rate"`
 }
 
+// HourStats represents statistics for a specific hour
+type HourStats struct {
+	Hour          string  `json:"hour"`
+	CallCount     int64   `json:"call_count"`
+	SuccessCount  int64   `json:"success_count"`
+	ErrorCount    int64   `json:"error_count"`
+	SuccessRate   float64 `json:"success_rate"`
+	AvgResponseMs float64 `json:"avg_response_ms"`
+}
+
+// ErrorClassStats represents failed calls of a specific error class
+type ErrorClassStats struct {
+	ErrorClass string  `json:"error_class"`
+	Count      int64   `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// LatencyPercentiles represents response time percentiles in milliseconds
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+}
+
+// QueueStats represents time calls spent waiting for an execution slot
+type QueueStats struct {
+	AvgWaitMs     float64 `json:"avg_wait_ms"`
+	MaxWaitMs     int64   `json:"max_wait_ms"`
+	RejectedCalls int64   `json:"rejected_calls"` // calls turned away as busy
+}
+
 // Statistics represents overall statistics for an MCP server
 type Statistics struct {
-	ServerID        string      `json:"server_id"`
-	TimeRange       TimeRange   `json:"time_range"`
-	TotalCalls      int64       `json:"total_calls"`
-	SuccessfulCalls int64       `json:"successful_calls"`
-	FailedCalls     int64       `json:"failed_calls"`
-	SuccessRate     float64     `json:"success_rate"`
-	AvgResponseTime float64     `json:"avg_response_time_ms"`
-	TopTools        []ToolStats `json:"top_tools"`
-	CallsByDay      []DayStats  `json:"calls_by_day"`
+	ServerID        string             `json:"server_id"`
+	TimeRange       TimeRange          `json:"time_range"`
+	TotalCalls      int64              `json:"total_calls"`
+	SuccessfulCalls int64              `json:"successful_calls"`
+	FailedCalls     int64              `json:"failed_calls"`
+	SuccessRate     float64            `json:"success_rate"`
+	AvgResponseTime float64            `json:"avg_response_time_ms"`
+	Latency         LatencyPercentiles `json:"latency_ms"`
+	Queue           QueueStats         `json:"queue"`
+	TotalRows       int64              `json:"total_rows"`
+	AvgRowsPerCall  float64            `json:"avg_rows_per_call"`
+	ErrorsByClass   []ErrorClassStats  `json:"errors_by_class"`
+	TopTools        []ToolStats        `json:"top_tools"`
+	CallsByDay      []DayStats         `json:"calls_by_day"`
+	CallsByHour     []HourStats        `json:"calls_by_hour,omitempty"`
 }
 
 // CalculateSuccessRate calculates the success rate from counts
 func CalculateSuccessRate(successCount, totalCount int64) float64 {
-	if totalCount == 0 {
+	return CalculatePercentage(successCount, totalCount)
+}
+
+// CalculatePercentage calculates part as a percentage of total
+func CalculatePercentage(part, total int64) float64 {
+	if total == 0 {
 		return 0
 	}
-	return float64(successCount) / float64(totalCount) * 100
+	return float64(part) / float64(total) * 100
 }
 
 // StatisticsBuilder helps build Statistics objects
@@ -92,10 +166,11 @@ type StatisticsBuilder struct {
 func NewStatisticsBuilder(serverID string, timeRange TimeRange) *StatisticsBuilder {
 	return &StatisticsBuilder{
 		stats: Statistics{
-			ServerID:   serverID,
-			TimeRange:  timeRange,
-			TopTools:   []ToolStats{},
-			CallsByDay: []DayStats{},
+			ServerID:      serverID,
+			TimeRange:     timeRange,
+			ErrorsByClass: []ErrorClassStats{},
+			TopTools:      []ToolStats{},
+			CallsByDay:    []DayStats{},
 		},
 	}
 }
@@ -124,6 +199,36 @@ func (b *StatisticsBuilder) SetAvgResponseTime(avgMs float64) *StatisticsBuilder
 	return b
 }
 
+// SetLatency sets the response time percentiles
+func (b *StatisticsBuilder) SetLatency(latency LatencyPercentiles) *StatisticsBuilder {
+	b.stats.Latency = latency
+	return b
+}
+
+// SetQueue sets the queue wait statistics
+func (b *StatisticsBuilder) SetQueue(queue QueueStats) *StatisticsBuilder {
+	b.stats.Queue = queue
+	return b
+}
+
+// SetTotalRows sets the total number of rows returned
+func (b *StatisticsBuilder) SetTotalRows(rows int64) *StatisticsBuilder {
+	b.stats.TotalRows = rows
+	return b
+}
+
+// SetErrorsByClass sets the error breakdown by error class
+func (b *StatisticsBuilder) SetErrorsByClass(classes []ErrorClassStats) *StatisticsBuilder {
+	b.stats.ErrorsByClass = classes
+	return b
+}
+
+// SetCallsByHour sets the hourly call statistics
+func (b *StatisticsBuilder) SetCallsByHour(hours []HourStats) *StatisticsBuilder {
+	b.stats.CallsByHour = hours
+	return b
+}
+
 // SetTopTools sets the top tools statistics
 func (b *StatisticsBuilder) SetTopTools(tools []ToolStats) *StatisticsBuilder {
 	b.stats.TopTools = tools
@@ -157,6 +262,26 @@ func (b *StatisticsBuilder) Build() Statistics {
 		)
 	}
 
+	// Calculate success rates for hourly stats
+	for i := range b.stats.CallsByHour {
+		b.stats.CallsByHour[i].SuccessRate = CalculateSuccessRate(
+			b.stats.CallsByHour[i].SuccessCount,
+			b.stats.CallsByHour[i].CallCount,
+		)
+	}
+
+	// Calculate the share of each error class among failed calls
+	for i := range b.stats.ErrorsByClass {
+		b.stats.ErrorsByClass[i].Percentage = CalculatePercentage(
+			b.stats.ErrorsByClass[i].Count,
+			b.stats.FailedCalls,
+		)
+	}
+
+	if b.stats.TotalCalls > 0 {
+		b.stats.AvgRowsPerCall = float64(b.stats.TotalRows) / float64(b.stats.TotalCalls)
+	}
+
 	return b.stats
 }
 
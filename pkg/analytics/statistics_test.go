@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeRange_Validate(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, NewTimeRange(start, start.Add(time.Hour)).Validate())
+	assert.ErrorIs(t, NewTimeRange(start, start).Validate(), ErrInvalidTimeRange)
+	assert.ErrorIs(t, NewTimeRange(start, start.Add(-time.Hour)).Validate(), ErrInvalidTimeRange)
+}
+
+func TestStatisticsBuilder_Build(t *testing.T) {
+	latency := LatencyPercentiles{P50: 12, P90: 80, P99: 450.5}
+	stats := NewStatisticsBuilder("server-1", LastDays(1)).
+		SetTotalCalls(200).
+		SetSuccessfulCalls(150).
+		SetFailedCalls(50).
+		SetTotalRows(1000).
+		SetLatency(latency).
+		SetErrorsByClass([]ErrorClassStats{
+			{ErrorClass: "timeout", Count: 40},
+			{ErrorClass: "busy", Count: 10},
+		}).
+		SetTopTools([]ToolStats{{ToolName: "list_orders", CallCount: 4, SuccessCount: 3}}).
+		SetCallsByHour([]HourStats{{Hour: "2024-05-01 10:00", CallCount: 0}}).
+		Build()
+
+	assert.Equal(t, latency, stats.Latency, "percentiles are reported as computed")
+	assert.Equal(t, 75.0, stats.SuccessRate)
+	assert.Equal(t, 5.0, stats.AvgRowsPerCall)
+	assert.Equal(t, 80.0, stats.ErrorsByClass[0].Percentage, "error classes are a share of the failed calls")
+	assert.Equal(t, 20.0, stats.ErrorsByClass[1].Percentage)
+	assert.Equal(t, 75.0, stats.TopTools[0].SuccessRate)
+	assert.Equal(t, 0.0, stats.CallsByHour[0].SuccessRate, "an hour without calls has no success rate")
+}
+
+func TestStatisticsBuilder_Build_NoCalls(t *testing.T) {
+	stats := NewStatisticsBuilder("server-1", LastDays(1)).Build()
+
+	assert.Equal(t, 0.0, stats.SuccessRate)
+	assert.Equal(t, 0.0, stats.AvgRowsPerCall)
+	assert.NotNil(t, stats.ErrorsByClass)
+	assert.NotNil(t, stats.TopTools)
+	assert.NotNil(t, stats.CallsByDay)
+}
@@ -0,0 +1,207 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State represents the state of a circuit breaker
+type State string
+
+const (
+	// StateClosed lets every call through and counts consecutive failures
+	StateClosed State = "closed"
+	// StateOpen rejects every call until the open timeout elapses
+	StateOpen State = "open"
+	// StateHalfOpen lets a limited number of trial calls through
+	StateHalfOpen State = "half_open"
+)
+
+// ErrOpen is returned by Allow while the circuit is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Config configures when a circuit opens and how it recovers
+type Config struct {
+	FailureThreshold int           // consecutive failures that open the circuit
+	OpenTimeout      time.Duration // time the circuit stays open before trial calls are allowed
+	HalfOpenMaxCalls int           // trial calls allowed at once while half-open
+}
+
+// DefaultConfig returns the default circuit breaker settings
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// Snapshot describes the current state of a circuit breaker
+type Snapshot struct {
+	State    State      `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker is a circuit breaker guarding a single dependency
+type Breaker struct {
+	cfg Config
+	now func() time.Time
+
+	mu            sync.Mutex
+	state         State
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+}
+
+// New creates a new closed circuit breaker
+func New(cfg Config) *Breaker {
+	defaults := DefaultConfig()
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaults.FailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaults.OpenTimeout
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = defaults.HalfOpenMaxCalls
+	}
+	return &Breaker{cfg: cfg, now: time.Now, state: StateClosed}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by Success, Failure or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	switch b.state {
+	case StateOpen:
+		return ErrOpen
+	case StateHalfOpen:
+		if b.halfOpenCalls >= b.cfg.HalfOpenMaxCalls {
+			return ErrOpen
+		}
+		b.halfOpenCalls++
+	}
+	return nil
+}
+
+// Success records a successful call. A successful trial call closes the circuit.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.halfOpenCalls = 0
+}
+
+// Release records an allowed call that ended without reaching the dependency,
+// such as one with no replica to route to. It frees the call's trial slot
+// while half-open and leaves the state alone.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.halfOpenCalls > 0 {
+		b.halfOpenCalls--
+	}
+}
+
+// Recover records that the dependency answered outside of a call, such as to
+// a health probe. An open circuit becomes half-open right away, so trial calls
+// decide whether it closes; the state is left alone otherwise.
+func (b *Breaker) Recover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		b.state = StateHalfOpen
+		b.halfOpenCalls = 0
+	}
+}
+
+// Failure records a failed call. The circuit opens once the failure threshold
+// is reached, or immediately if a trial call fails.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	switch b.state {
+	case StateClosed:
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		b.open()
+	}
+}
+
+// Snapshot returns the current state of the breaker
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	snapshot := Snapshot{State: b.state, Failures: b.failures}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// open trips the circuit. The caller must hold b.mu.
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.halfOpenCalls = 0
+}
+
+// advance moves an open circuit to half-open once its timeout has elapsed.
+// The caller must hold b.mu.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = StateHalfOpen
+		b.halfOpenCalls = 0
+	}
+}
+
+// Set holds one circuit breaker per key, created on first use
+type Set struct {
+	cfg      Config
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewSet creates a new Set whose breakers share cfg
+func NewSet(cfg Config) *Set {
+	return &Set{cfg: cfg, breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker for key
+func (s *Set) Get(key string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[key]
+	if !ok {
+		b = New(s.cfg)
+		s.breakers[key] = b
+	}
+	return b
+}
+
+// Remove forgets the breaker for key
+func (s *Set) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.breakers, key)
+}
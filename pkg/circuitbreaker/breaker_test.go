@@ -0,0 +1,117 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBreaker returns a breaker driven by a fake clock
+func newTestBreaker(cfg Config) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New(cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureThreshold: 3, OpenTimeout: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+
+	assert.NoError(t, b.Allow())
+	b.Failure()
+
+	assert.Equal(t, StateOpen, b.Snapshot().State)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureThreshold: 2, OpenTimeout: time.Minute})
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+}
+
+func TestBreaker_HalfOpenAfterTimeout(t *testing.T) {
+	b, now := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1})
+
+	b.Failure()
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	*now = now.Add(time.Minute)
+	assert.Equal(t, StateHalfOpen, b.Snapshot().State)
+
+	// Only one trial call at a time
+	assert.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	b.Success()
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_ReleaseFreesTrial(t *testing.T) {
+	b, now := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1})
+
+	b.Failure()
+	*now = now.Add(time.Minute)
+
+	// A trial that never reached the dependency decides nothing
+	assert.NoError(t, b.Allow())
+	b.Release()
+	assert.Equal(t, StateHalfOpen, b.Snapshot().State)
+
+	assert.NoError(t, b.Allow(), "the released trial slot is free again")
+	b.Success()
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+
+	b.Release()
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+}
+
+func TestBreaker_FailedTrialReopens(t *testing.T) {
+	b, now := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	b.Failure()
+	*now = now.Add(time.Minute)
+
+	assert.NoError(t, b.Allow())
+	b.Failure()
+
+	assert.Equal(t, StateOpen, b.Snapshot().State)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_RecoverHalfOpens(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Hour})
+
+	b.Failure()
+	b.Recover()
+	assert.Equal(t, StateHalfOpen, b.Snapshot().State, "a probe does not close the circuit")
+
+	assert.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "only trial calls are let through")
+	b.Failure()
+	assert.Equal(t, StateOpen, b.Snapshot().State)
+
+	b.Recover()
+	assert.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+}
+
+func TestSet_Get(t *testing.T) {
+	s := NewSet(DefaultConfig())
+
+	assert.Same(t, s.Get("a"), s.Get("a"))
+	assert.NotSame(t, s.Get("a"), s.Get("b"))
+}
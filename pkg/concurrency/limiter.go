@@ -0,0 +1,177 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned when every slot is taken and the wait queue is full
+	ErrQueueFull = errors.New("too many queued executions")
+	// ErrWaitTimeout is returned when no slot frees up within the wait timeout
+	ErrWaitTimeout = errors.New("timed out waiting for an execution slot")
+)
+
+// Limits caps the concurrent executions against a resource
+type Limits struct {
+	MaxConcurrent int           // executions running at once; 0 means unlimited
+	MaxQueued     int           // executions waiting for a slot; 0 means callers do not wait
+	WaitTimeout   time.Duration // how long a queued execution waits for a slot
+}
+
+// Unlimited reports whether the limits allow any number of concurrent executions
+func (l Limits) Unlimited() bool {
+	return l.MaxConcurrent <= 0
+}
+
+// Limiter bounds concurrent executions. Callers that find every slot taken
+// wait in a bounded queue and are served in arrival order. Its limits can
+// change while executions hold slots; they keep counting against the new
+// limits until released.
+type Limiter struct {
+	mu      sync.Mutex
+	limits  Limits
+	running int
+	waiters list.List // of chan struct{}, closed once the waiter is given a slot
+}
+
+// NewLimiter creates a new Limiter
+func NewLimiter(limits Limits) *Limiter {
+	return &Limiter{limits: limits}
+}
+
+// Acquire takes an execution slot, waiting in the queue if none is free.
+// It returns a function that releases the slot and how long the caller waited.
+// When ctx ends first, its error is returned.
+func (l *Limiter) Acquire(ctx context.Context) (func(), time.Duration, error) {
+	l.mu.Lock()
+	// Fast path: a slot is free and nobody is queued ahead of us
+	if l.waiters.Len() == 0 && l.hasFreeSlotLocked() {
+		l.running++
+		l.mu.Unlock()
+		return l.releaseFunc(), 0, nil
+	}
+	if l.waiters.Len() >= l.limits.MaxQueued {
+		l.mu.Unlock()
+		return nil, 0, ErrQueueFull
+	}
+	ready := make(chan struct{})
+	waiter := l.waiters.PushBack(ready)
+	timeout := l.limits.WaitTimeout
+	l.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-ready:
+		return l.releaseFunc(), time.Since(start), nil
+	case <-timer.C:
+		err = ErrWaitTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	select {
+	case <-ready:
+		// Given a slot while giving up; pass it on
+		l.releaseLocked()
+	default:
+		l.waiters.Remove(waiter)
+	}
+	l.mu.Unlock()
+	return nil, time.Since(start), err
+}
+
+// SetLimits changes the limits. Slots already held count against the new
+// limits, and queued callers are given slots the new limits free up.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.grantLocked()
+}
+
+// InFlight returns the number of running and queued executions
+func (l *Limiter) InFlight() (running, queued int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running, l.waiters.Len()
+}
+
+func (l *Limiter) hasFreeSlotLocked() bool {
+	return l.limits.Unlimited() || l.running < l.limits.MaxConcurrent
+}
+
+// releaseFunc returns a function releasing one slot, however often it is called
+func (l *Limiter) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.releaseLocked()
+		})
+	}
+}
+
+func (l *Limiter) releaseLocked() {
+	l.running--
+	l.grantLocked()
+}
+
+// grantLocked gives free slots to queued callers in arrival order
+func (l *Limiter) grantLocked() {
+	for l.waiters.Len() > 0 && l.hasFreeSlotLocked() {
+		ready := l.waiters.Remove(l.waiters.Front()).(chan struct{})
+		l.running++
+		close(ready)
+	}
+}
+
+// Set holds one limiter per key. When a key is requested with different
+// limits, its limiter takes them on, so executions holding a slot keep
+// counting against them.
+type Set struct {
+	mu       sync.Mutex
+	limiters map[string]*Limiter
+}
+
+// NewSet creates a new Set
+func NewSet() *Set {
+	return &Set{limiters: make(map[string]*Limiter)}
+}
+
+// Get returns the limiter for key, creating it or updating its limits to match limits
+func (s *Set) Get(key string, limits Limits) *Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.limiters[key]
+	if !ok {
+		l = NewLimiter(limits)
+		s.limiters[key] = l
+		return l
+	}
+	l.mu.Lock()
+	changed := l.limits != limits
+	l.mu.Unlock()
+	if changed {
+		l.SetLimits(limits)
+	}
+	return l
+}
+
+// Remove forgets the limiter for key, e.g. once the resource it limits is
+// deleted. Executions holding a slot of it release it normally.
+func (s *Set) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.limiters, key)
+}
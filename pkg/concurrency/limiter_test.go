@@ -0,0 +1,136 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := NewLimiter(Limits{})
+
+	for i := 0; i < 100; i++ {
+		release, wait, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+		defer release()
+	}
+}
+
+func TestLimiter_QueueFull(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrent: 1, MaxQueued: 0, WaitTimeout: time.Second})
+
+	release, _, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	_, _, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestLimiter_WaitTimeout(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrent: 1, MaxQueued: 1, WaitTimeout: 20 * time.Millisecond})
+
+	release, _, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	_, wait, err := l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrWaitTimeout)
+	assert.GreaterOrEqual(t, wait, 20*time.Millisecond)
+}
+
+func TestLimiter_WaitsForRelease(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrent: 1, MaxQueued: 1, WaitTimeout: time.Second})
+
+	release, _, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+
+	release2, wait, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, wait, time.Duration(0))
+	release2()
+
+	running, queued := l.InFlight()
+	assert.Equal(t, 0, running)
+	assert.Equal(t, 0, queued)
+}
+
+func TestLimiter_CancelledWhileQueued(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrent: 1, MaxQueued: 1, WaitTimeout: time.Second})
+
+	release, _, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err = l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, queued := l.InFlight()
+	assert.Zero(t, queued, "a cancelled caller leaves the queue")
+}
+
+func TestSet_UpdatesLimitsInPlace(t *testing.T) {
+	s := NewSet()
+	limits := Limits{MaxConcurrent: 2, MaxQueued: 1, WaitTimeout: 20 * time.Millisecond}
+
+	a := s.Get("ds", limits)
+	assert.Same(t, a, s.Get("ds", limits))
+	release1, _, err := a.Acquire(context.Background())
+	require.NoError(t, err)
+	release2, _, err := a.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// Slots held before the change count against the new limits
+	limits.MaxConcurrent = 1
+	l := s.Get("ds", limits)
+	assert.Same(t, a, l)
+	release1()
+	_, _, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrWaitTimeout, "one slot is still held")
+
+	// Raising the limits gives queued callers the freed slots
+	limits.WaitTimeout = time.Second
+	s.Get("ds", limits)
+	done := make(chan error)
+	go func() {
+		_, _, err := l.Acquire(context.Background())
+		done <- err
+	}()
+	assert.Eventually(t, func() bool {
+		_, queued := l.InFlight()
+		return queued == 1
+	}, time.Second, time.Millisecond)
+	limits.MaxConcurrent = 2
+	s.Get("ds", limits)
+	assert.NoError(t, <-done)
+
+	running, _ := l.InFlight()
+	assert.Equal(t, 2, running)
+	release2()
+	release2()
+	running, _ = l.InFlight()
+	assert.Equal(t, 1, running, "releasing twice frees one slot")
+}
+
+func TestSet_Remove(t *testing.T) {
+	s := NewSet()
+	limits := Limits{MaxConcurrent: 1}
+
+	a := s.Get("ds", limits)
+	s.Remove("ds")
+	assert.NotSame(t, a, s.Get("ds", limits))
+}
@@ -0,0 +1,108 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+)
+
+// schemaCatalog assembles GetSchema results from batched catalog queries, each
+// of which returns the rows of every table at once instead of one query per table
+type schemaCatalog struct {
+	tables []TableInfo
+	byName map[tableKey]int
+}
+
+type tableKey struct {
+	schema, name string
+}
+
+func newSchemaCatalog(tables []TableInfo) *schemaCatalog {
+	c := &schemaCatalog{tables: tables, byName: make(map[tableKey]int, len(tables))}
+	for i, t := range tables {
+		c.byName[tableKey{t.Schema, t.Name}] = i
+	}
+	return c
+}
+
+// table returns the named table, or nil for rows of tables the table query left out
+func (c *schemaCatalog) table(schema, name string) *TableInfo {
+	i, ok := c.byName[tableKey{schema, name}]
+	if !ok {
+		return nil
+	}
+	return &c.tables[i]
+}
+
+func (c *schemaCatalog) addColumn(schema, table string, column ColumnInfo) {
+	if t := c.table(schema, table); t != nil {
+		t.Columns = append(t.Columns, column)
+	}
+}
+
+// addForeignKeyColumn adds a column pair to the named foreign key, creating the
+// key for its first pair. Rows must be ordered by table, key and position.
+func (c *schemaCatalog) addForeignKeyColumn(schema, table, name, refSchema, refTable, column, refColumn string) {
+	t := c.table(schema, table)
+	if t == nil {
+		return
+	}
+	if n := len(t.ForeignKeys); n == 0 || t.ForeignKeys[n-1].Name != name {
+		t.ForeignKeys = append(t.ForeignKeys, ForeignKeyInfo{
+			Name:             name,
+			ReferencedSchema: refSchema,
+			ReferencedTable:  refTable,
+		})
+	}
+	fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+	fk.Columns = append(fk.Columns, column)
+	fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+}
+
+// addIndexColumn adds a column to the named index, creating the index for its
+// first column. Rows must be ordered by table, index and position.
+func (c *schemaCatalog) addIndexColumn(schema, table, name string, unique, primary bool, column string) {
+	t := c.table(schema, table)
+	if t == nil {
+		return
+	}
+	if n := len(t.Indexes); n == 0 || t.Indexes[n-1].Name != name {
+		t.Indexes = append(t.Indexes, IndexInfo{Name: name, Unique: unique, Primary: primary})
+	}
+	idx := &t.Indexes[len(t.Indexes)-1]
+	idx.Columns = append(idx.Columns, column)
+}
+
+// forEachRow runs a catalog query and calls scan for every row it returns
+func forEachRow(ctx context.Context, db *sql.DB, query string, args []interface{}, scan func(rows *sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// rowEstimate converts a catalog row count, which databases report as NULL or
+// negative when the table was never analyzed, to a TableInfo.RowEstimate
+func rowEstimate(n sql.NullInt64) *int64 {
+	if !n.Valid || n.Int64 < 0 {
+		return nil
+	}
+	v := n.Int64
+	return &v
+}
+
+// nullableString converts a NULL-able catalog value to a ColumnInfo.Default
+func nullableString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	v := s.String
+	return &v
+}
@@ -0,0 +1,439 @@
+package dbconnector
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ClickHouseOptionProtocol selects the native (default) or HTTP interface of a ClickHouse server
+const ClickHouseOptionProtocol = "protocol"
+
+// Values of ClickHouseOptionProtocol
+const (
+	ClickHouseProtocolNative = "native"
+	ClickHouseProtocolHTTP   = "http"
+)
+
+func init() {
+	Register(clickHouseDialect{})
+}
+
+type clickHouseDialect struct{}
+
+func (clickHouseDialect) Name() DBType       { return ClickHouse }
+func (clickHouseDialect) DriverName() string { return "clickhouse" }
+
+func (clickHouseDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	return buildClickHouseDSN(config)
+}
+
+// NewDriverConnector replaces the TLS configuration the DSN's secure and
+// skip_verify settings give with one carrying the datasource's certificates
+func (clickHouseDialect) NewDriverConnector(config *ConnectionConfig) (driver.Connector, error) {
+	dsn, err := buildClickHouseDSN(config)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts.TLS = tlsConfig
+	}
+	return clickhouse.Connector(opts), nil
+}
+
+// StartTLS runs the handshake straight away: ClickHouse's secure ports
+// expect TLS from the first byte, over both the native and HTTP interfaces
+func (clickHouseDialect) StartTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	return directTLS(ctx, conn, config)
+}
+
+func (clickHouseDialect) VersionQuery() string { return "SELECT version()" }
+
+// ReplicationLag reads the largest delay of the replicated tables of the database
+func (clickHouseDialect) ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	return queryLagSeconds(ctx, db, "SELECT toFloat64(max(absolute_delay)) FROM system.replicas WHERE database = currentDatabase()")
+}
+
+// IsUnknownDatabase reports exception 81, UNKNOWN_DATABASE, of the native
+// interface; the HTTP interface only reports it in the message
+func (clickHouseDialect) IsUnknownDatabase(err error) bool {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		return exception.Code == 81
+	}
+	return err != nil && strings.Contains(err.Error(), "UNKNOWN_DATABASE")
+}
+
+// Bind replaces :name parameters with {name:Type} and attaches the values to
+// the context, which the driver sends to the server to bind
+func (clickHouseDialect) Bind(ctx context.Context, query string, params map[string]interface{}, types map[string]string) (context.Context, string, []interface{}, error) {
+	converted, bound, err := convertClickHouseParams(query, params, types)
+	if err != nil {
+		return ctx, "", nil, err
+	}
+	return clickHouseContext(ctx, bound), converted, nil, nil
+}
+
+func (clickHouseDialect) QuoteIdentifier(name string) string { return quoteWith(name, "`", "`") }
+
+// ReadOnlySession returns nil: the DSN sets readonly=1
+func (clickHouseDialect) ReadOnlySession() []string { return nil }
+
+func (clickHouseDialect) ParamType(columnType string) string {
+	t := columnType
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		if strings.HasPrefix(t, wrapper) {
+			t = strings.TrimSuffix(strings.TrimPrefix(t, wrapper), ")")
+		}
+	}
+	switch {
+	case strings.HasPrefix(t, "DateTime"):
+		return ParamTypeDateTime
+	case strings.HasPrefix(t, "Date"):
+		return ParamTypeDate
+	default:
+		return genericParamType(t)
+	}
+}
+
+// clickHouseDriverOptions are the client settings a datasource may pass in
+// the DSN. clickhouse-go sends parameters it does not know to the server as
+// query settings, which readonly=1 rejects.
+var clickHouseDriverOptions = []string{"dial_timeout", "read_timeout", "compress"}
+
+func (clickHouseDialect) ValidateOptions(options map[string]string) error {
+	if err := checkOptionNames(ClickHouse, options, append(clickHouseDriverOptions, ClickHouseOptionProtocol)...); err != nil {
+		return err
+	}
+	switch options[ClickHouseOptionProtocol] {
+	case "", ClickHouseProtocolNative, ClickHouseProtocolHTTP:
+		return nil
+	default:
+		return fmt.Errorf("%s must be one of: %s, %s", ClickHouseOptionProtocol,
+			ClickHouseProtocolNative, ClickHouseProtocolHTTP)
+	}
+}
+
+// clickHouseTypes maps parameter types to ClickHouse types
+var clickHouseTypes = map[string]string{
+	ParamTypeString:   "String",
+	ParamTypeNumber:   "Float64",
+	ParamTypeInteger:  "Int64",
+	ParamTypeBoolean:  "Bool",
+	ParamTypeDate:     "Date",
+	ParamTypeDateTime: "DateTime64(3, 'UTC')",
+}
+
+// clickHouseEscaper escapes parameter values, which the server parses in the Escaped format
+var clickHouseEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// clickHouseParamPattern matches :name parameters in a query template
+var clickHouseParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// buildClickHouseDSN returns a clickhouse-go URL. Every query runs with
+// readonly=1 so tools cannot modify data or settings.
+func buildClickHouseDSN(config *ConnectionConfig) (string, error) {
+	query := url.Values{}
+	query.Set("readonly", "1")
+
+	secure := false
+	switch config.SSLMode {
+	case "", SSLModeDisable:
+	case SSLModeRequire:
+		secure = true
+		query.Set("secure", "true")
+		query.Set("skip_verify", "true")
+	case SSLModeVerifyCA, SSLModeVerifyFull:
+		secure = true
+		query.Set("secure", "true")
+	default:
+		return "", fmt.Errorf("unsupported ssl mode for clickhouse: %s", config.SSLMode)
+	}
+
+	var scheme string
+	switch config.Options[ClickHouseOptionProtocol] {
+	case "", ClickHouseProtocolNative:
+		scheme = "clickhouse"
+	case ClickHouseProtocolHTTP:
+		scheme = "http"
+		if secure {
+			scheme = "https"
+		}
+	default:
+		return "", fmt.Errorf("unsupported clickhouse protocol: %s", config.Options[ClickHouseOptionProtocol])
+	}
+
+	for _, key := range clickHouseDriverOptions {
+		if value, ok := config.Options[key]; ok {
+			query.Set(key, value)
+		}
+	}
+
+	dsn := url.URL{
+		Scheme:   scheme,
+		User:     url.UserPassword(config.Username, config.Password),
+		Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Path:     "/" + config.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// convertClickHouseParams replaces :name parameters with {name:Type} and
+// returns the values to bind server-side. types maps parameter names to
+// parameter types; names without a type are typed from their value.
+func convertClickHouseParams(query string, params map[string]interface{}, types map[string]string) (string, clickhouse.Parameters, error) {
+	bound := make(clickhouse.Parameters)
+	var convErr error
+
+	converted := clickHouseParamPattern.ReplaceAllStringFunc(query, func(match string) string {
+		name := strings.TrimPrefix(match, ":")
+		value := params[name]
+
+		paramType := types[name]
+		if paramType == "" {
+			paramType = inferParamType(value)
+		}
+		chType, ok := clickHouseTypes[paramType]
+		if !ok {
+			if convErr == nil {
+				convErr = fmt.Errorf("unsupported type %q for parameter %s", paramType, name)
+			}
+			return match
+		}
+
+		if value == nil {
+			bound[name] = `\N`
+			return fmt.Sprintf("{%s:Nullable(%s)}", name, chType)
+		}
+
+		formatted, err := formatClickHouseParam(value, paramType)
+		if err != nil && convErr == nil {
+			convErr = fmt.Errorf("parameter %s: %w", name, err)
+		}
+		bound[name] = clickHouseEscaper.Replace(formatted)
+		return fmt.Sprintf("{%s:%s}", name, chType)
+	})
+
+	return converted, bound, convErr
+}
+
+// inferParamType types a parameter from its value
+func inferParamType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return ParamTypeBoolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ParamTypeInteger
+	case float32, float64:
+		return ParamTypeNumber
+	case time.Time:
+		return ParamTypeDateTime
+	default:
+		return ParamTypeString
+	}
+}
+
+// formatClickHouseParam renders a value in the text form ClickHouse parses for paramType
+func formatClickHouseParam(value interface{}, paramType string) (string, error) {
+	switch paramType {
+	case ParamTypeInteger:
+		if f, ok := value.(float64); ok {
+			if f != math.Trunc(f) {
+				return "", fmt.Errorf("%v is not an integer", f)
+			}
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+	case ParamTypeNumber:
+		if f, ok := value.(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+	case ParamTypeBoolean:
+		if b, ok := value.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	case ParamTypeDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v.Format("2006-01-02"), nil
+		case string:
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t.Format("2006-01-02"), nil
+			}
+		}
+	case ParamTypeDateTime:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().Format("2006-01-02 15:04:05.000"), nil
+		case string:
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t.UTC().Format("2006-01-02 15:04:05.000"), nil
+			}
+		}
+	}
+	return fmt.Sprint(value), nil
+}
+
+// clickHouseContext attaches the server-side parameters of a query to ctx
+func clickHouseContext(ctx context.Context, params clickhouse.Parameters) context.Context {
+	if len(params) == 0 {
+		return ctx
+	}
+	return clickhouse.Context(ctx, clickhouse.WithParameters(params))
+}
+
+// GetSchema lists the tables of the current database. ClickHouse has no
+// foreign keys; the primary key and data skipping indexes are reported as indexes.
+func (clickHouseDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	var tables []TableInfo
+	primaryKeys := make(map[tableKey]string)
+	err := forEachRow(ctx, db, `
+		SELECT database, name, engine, comment, ifNull(toInt64(total_rows), -1), primary_key
+		FROM system.tables
+		WHERE database = currentDatabase() AND NOT is_temporary
+		ORDER BY name
+	`, nil, func(rows *sql.Rows) error {
+		var t TableInfo
+		var engine, primaryKey string
+		var estimate int64
+		if err := rows.Scan(&t.Schema, &t.Name, &engine, &t.Comment, &estimate, &primaryKey); err != nil {
+			return err
+		}
+		switch {
+		case engine == "MaterializedView":
+			t.Kind = TableKindMaterializedView
+		case strings.HasSuffix(engine, "View"):
+			t.Kind = TableKindView
+		default:
+			t.Kind = TableKindTable
+		}
+		t.RowEstimate = rowEstimate(sql.NullInt64{Int64: estimate, Valid: true})
+		tables = append(tables, t)
+		primaryKeys[tableKey{t.Schema, t.Name}] = primaryKey
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanClickHouseColumns(ctx, db, "database = currentDatabase()", catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	for key, primaryKey := range primaryKeys {
+		for _, expr := range splitClickHouseKey(primaryKey) {
+			catalog.addIndexColumn(key.schema, key.name, "PRIMARY", false, true, expr)
+		}
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT database, table, name, expr
+		FROM system.data_skipping_indices
+		WHERE database = currentDatabase()
+		ORDER BY table, name
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, expr string
+		if err := rows.Scan(&schema, &table, &name, &expr); err != nil {
+			return err
+		}
+		catalog.addIndexColumn(schema, table, name, false, false, expr)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func (clickHouseDialect) GetColumns(db *sql.DB, config *ConnectionConfig, schema, tableName string) ([]ColumnInfo, error) {
+	if schema == "" {
+		schema = config.Database
+	}
+
+	ctx := clickHouseContext(context.Background(), clickhouse.Parameters{
+		"schema": clickHouseEscaper.Replace(schema),
+		"table":  clickHouseEscaper.Replace(tableName),
+	})
+	var columns []ColumnInfo
+	err := scanClickHouseColumns(ctx, db, "database = {schema:String} AND table = {table:String}",
+		func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanClickHouseColumns reads the columns of the tables matching filter, which
+// binds its values through the context
+func scanClickHouseColumns(ctx context.Context, db *sql.DB, filter string, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT database, table, name, type, is_in_primary_key, default_kind, default_expression, comment
+		FROM system.columns
+		WHERE `+filter+`
+		ORDER BY table, position
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, defaultKind, defaultExpr string
+		var column ColumnInfo
+		var isPK uint8
+		if err := rows.Scan(&schema, &table, &column.Name, &column.Type, &isPK,
+			&defaultKind, &defaultExpr, &column.Comment); err != nil {
+			return err
+		}
+		column.Nullable = strings.HasPrefix(column.Type, "Nullable(")
+		column.PrimaryKey = isPK == 1
+		// MATERIALIZED and ALIAS columns are computed, not defaulted
+		if defaultKind == "DEFAULT" {
+			column.Default = &defaultExpr
+		}
+		add(schema, table, column)
+		return nil
+	})
+}
+
+// splitClickHouseKey splits a key expression such as "toDate(ts), cityHash64(a, b)"
+// into its top-level elements
+func splitClickHouseKey(key string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range key {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(key[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(key[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
@@ -118,6 +118,9 @@ func TestConnector_getDriverName(t *testing.T) {
 		{MySQL, "mysql"},
 		{MSSQL, "sqlserver"},
 		{Oracle, "oracle"},
+		{SQLite, sqliteDriverName},
+		{ClickHouse, "clickhouse"},
+		{File, sqliteDriverName},
 		{"unknown", ""},
 	}
 
@@ -0,0 +1,416 @@
+package dbconnector
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diagnoseDialTimeout bounds connecting to the database port
+const diagnoseDialTimeout = 10 * time.Second
+
+// Stage names one check of a connection diagnosis
+type Stage string
+
+// Stages of a diagnosis, in the order they run
+const (
+	StageDNS            Stage = "dns"            // resolving the database host, or the bastion's
+	StageTCP            Stage = "tcp"            // connecting to the database port
+	StageSSHTunnel      Stage = "ssh_tunnel"     // reaching the database through the bastion
+	StageTLS            Stage = "tls"            // the TLS handshake and server certificate
+	StageAuthentication Stage = "authentication" // the server accepting the credentials
+	StageDatabase       Stage = "database"       // the database existing and opening
+	StageServerVersion  Stage = "server_version"
+	StagePrivileges     Stage = "privileges" // SELECT on every schema
+)
+
+// StageStatus is the outcome of a stage
+type StageStatus string
+
+const (
+	StagePassed  StageStatus = "passed"
+	StageFailed  StageStatus = "failed"
+	StageWarning StageStatus = "warning" // passed, but something needs attention
+	StageSkipped StageStatus = "skipped" // not applicable, or an earlier stage failed
+)
+
+// StageResult reports one stage of a diagnosis
+type StageResult struct {
+	Stage   Stage
+	Status  StageStatus
+	Latency time.Duration
+	Message string
+	Details map[string]string
+}
+
+// Diagnosis reports the stages of a connection test
+type Diagnosis struct {
+	Stages []StageResult
+	Hop    Hop // the failed hop of an SSH tunneled connection, empty otherwise
+}
+
+// Failed returns the first failed stage, or nil if none failed
+func (d *Diagnosis) Failed() *StageResult {
+	for i := range d.Stages {
+		if d.Stages[i].Status == StageFailed {
+			return &d.Stages[i]
+		}
+	}
+	return nil
+}
+
+// Latency returns the time all stages took
+func (d *Diagnosis) Latency() time.Duration {
+	var total time.Duration
+	for _, s := range d.Stages {
+		total += s.Latency
+	}
+	return total
+}
+
+// Diagnoser is implemented by dialects that can tell a diagnosis more about
+// their server than whether it accepted the connection
+type Diagnoser interface {
+	// VersionQuery returns a query whose single value is the server version
+	VersionQuery() string
+	// IsUnknownDatabase reports whether a connect error means the server does
+	// not know the database
+	IsUnknownDatabase(err error) bool
+}
+
+// PrivilegeChecker is implemented by dialects that can list the schemas the
+// connected user lacks SELECT privilege on
+type PrivilegeChecker interface {
+	UnreadableSchemas(ctx context.Context, db *sql.DB) ([]string, error)
+}
+
+// TLSStarter is implemented by dialects whose TLS handshake can be run on its
+// own over a TCP connection to the server, so it is diagnosed apart from the
+// driver. Dialects without it report TLS failures as authentication failures.
+type TLSStarter interface {
+	StartTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error)
+}
+
+// Diagnose tests a connection stage by stage, skipping the stages after the
+// first that fails. It returns an error only for an unsupported database type.
+func Diagnose(ctx context.Context, config *ConnectionConfig) (*Diagnosis, error) {
+	dialect, ok := Lookup(config.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+	}
+
+	d := &diagnosis{ctx: ctx, config: config, dialect: dialect}
+	defer d.release()
+	if !IsFileBased(config.Type) && !d.reachServer() {
+		for _, stage := range []Stage{StageAuthentication, StageDatabase, StageServerVersion, StagePrivileges} {
+			d.skip(stage, "skipped after an earlier stage failed")
+		}
+		return &d.Diagnosis, nil
+	}
+
+	connector := NewConnector(config)
+	if d.connect(connector) {
+		defer connector.Close()
+		d.inspect(connector.DB())
+	}
+	return &d.Diagnosis, nil
+}
+
+// diagnosis runs the stages of Diagnose
+type diagnosis struct {
+	Diagnosis
+	ctx     context.Context
+	config  *ConnectionConfig
+	dialect Dialect
+	tunnel  *sshTunnel // held until the diagnosis ends, so the connector shares it
+	failed  bool
+}
+
+func (d *diagnosis) release() {
+	if d.tunnel != nil {
+		d.tunnel.release()
+	}
+}
+
+// run times a stage. check returns the stage's message and details, or the
+// error it failed with; stages after a failed one are skipped.
+func (d *diagnosis) run(stage Stage, check func() (string, map[string]string, error)) {
+	if d.failed {
+		d.skip(stage, "skipped after an earlier stage failed")
+		return
+	}
+
+	start := time.Now()
+	message, details, err := check()
+	result := StageResult{Stage: stage, Status: StagePassed, Latency: time.Since(start), Message: message, Details: details}
+	if err != nil {
+		result.Status = StageFailed
+		result.Message = err.Error()
+		d.failed = true
+	}
+	d.Stages = append(d.Stages, result)
+}
+
+func (d *diagnosis) skip(stage Stage, message string) {
+	d.Stages = append(d.Stages, StageResult{Stage: stage, Status: StageSkipped, Message: message})
+}
+
+// reachServer runs the network stages: resolving the host, connecting to it
+// directly or through the SSH tunnel, and the TLS handshake
+func (d *diagnosis) reachServer() bool {
+	if d.config.Host == "" {
+		// e.g. an Oracle connect descriptor naming its hosts itself
+		for _, stage := range []Stage{StageDNS, StageTCP, StageTLS} {
+			d.skip(stage, "the driver resolves the host from the database setting")
+		}
+		return true
+	}
+
+	host := d.config.Host
+	if d.config.SSHTunnel != nil {
+		host = d.config.SSHTunnel.Host
+	}
+	d.run(StageDNS, func() (string, map[string]string, error) {
+		return resolve(d.ctx, host)
+	})
+
+	target := net.JoinHostPort(d.config.Host, strconv.Itoa(d.config.Port))
+	var conn net.Conn
+	if d.config.SSHTunnel != nil {
+		d.run(StageSSHTunnel, func() (string, map[string]string, error) {
+			tunnel, err := acquireTunnel(d.config.SSHTunnel, target)
+			if err != nil {
+				var hopErr *HopError
+				if errors.As(err, &hopErr) {
+					d.Hop = hopErr.Hop
+				}
+				return "", nil, err
+			}
+			d.tunnel = tunnel
+
+			host, port := tunnel.LocalAddr()
+			conn, err = d.dial(net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				return "", nil, err
+			}
+			return "reached " + target + " through " + d.config.SSHTunnel.Addr(), map[string]string{"bastion": d.config.SSHTunnel.Addr()}, nil
+		})
+	} else {
+		d.run(StageTCP, func() (string, map[string]string, error) {
+			var err error
+			if conn, err = d.dial(target); err != nil {
+				return "", nil, err
+			}
+			return "connected to " + target, map[string]string{"remote_addr": conn.RemoteAddr().String()}, nil
+		})
+	}
+	if conn != nil {
+		defer conn.Close()
+	}
+
+	tlsConfig, err := buildTLSConfig(d.config)
+	starter, ok := d.dialect.(TLSStarter)
+	switch {
+	case d.failed:
+		d.skip(StageTLS, "skipped after an earlier stage failed")
+	case err == nil && tlsConfig == nil:
+		d.skip(StageTLS, "ssl mode is disable")
+	case err == nil && !ok:
+		d.skip(StageTLS, fmt.Sprintf("%s negotiates TLS while authenticating", d.config.Type))
+	default:
+		d.run(StageTLS, func() (string, map[string]string, error) {
+			if err != nil {
+				return "", nil, err
+			}
+			return probeTLS(d.ctx, starter, conn, d.config.SSLMode, tlsConfig)
+		})
+	}
+	return !d.failed
+}
+
+func (d *diagnosis) dial(addr string) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: diagnoseDialTimeout}
+	return dialer.DialContext(d.ctx, "tcp", addr)
+}
+
+// connect runs the authentication and database stages
+func (d *diagnosis) connect(connector *Connector) bool {
+	start := time.Now()
+	err := connector.Connect()
+	latency := time.Since(start)
+
+	if err == nil {
+		if !IsFileBased(d.config.Type) {
+			d.Stages = append(d.Stages, StageResult{Stage: StageAuthentication, Status: StagePassed, Latency: latency, Message: "credentials accepted"})
+			latency = 0
+		}
+		d.Stages = append(d.Stages, StageResult{Stage: StageDatabase, Status: StagePassed, Latency: latency, Message: "opened " + d.config.Database})
+		return true
+	}
+
+	var hopErr *HopError
+	if errors.As(err, &hopErr) {
+		d.Hop = hopErr.Hop
+	}
+	d.failed = true
+
+	diagnoser, ok := d.dialect.(Diagnoser)
+	var sessionErr *SessionError
+	switch {
+	case IsFileBased(d.config.Type):
+		d.Stages = append(d.Stages, StageResult{Stage: StageDatabase, Status: StageFailed, Latency: latency, Message: err.Error()})
+	case errors.As(err, &sessionErr):
+		// The server ran the statement, so the login succeeded
+		d.Stages = append(d.Stages, StageResult{Stage: StageAuthentication, Status: StagePassed, Latency: latency, Message: "credentials accepted"})
+		d.Stages = append(d.Stages, StageResult{Stage: StageDatabase, Status: StageFailed, Message: sessionErr.Error()})
+	case ok && diagnoser.IsUnknownDatabase(err):
+		// Servers differ in whether they check the credentials first
+		d.skip(StageAuthentication, "not reported: the database was not found")
+		d.Stages = append(d.Stages, StageResult{Stage: StageDatabase, Status: StageFailed, Latency: latency, Message: err.Error()})
+	default:
+		d.Stages = append(d.Stages, StageResult{Stage: StageAuthentication, Status: StageFailed, Latency: latency, Message: err.Error()})
+		d.skip(StageDatabase, "skipped after an earlier stage failed")
+	}
+	d.skip(StageServerVersion, "skipped after an earlier stage failed")
+	d.skip(StagePrivileges, "skipped after an earlier stage failed")
+	return false
+}
+
+// inspect runs the stages that query the connected database. Their failures
+// are reported without failing the diagnosis, as the connection works.
+func (d *diagnosis) inspect(db *sql.DB) {
+	if diagnoser, ok := d.dialect.(Diagnoser); ok {
+		d.inspectStage(StageServerVersion, func() (string, map[string]string, error) {
+			var version string
+			if err := db.QueryRowContext(d.ctx, diagnoser.VersionQuery()).Scan(&version); err != nil {
+				return "", nil, fmt.Errorf("failed to read server version: %w", err)
+			}
+			return version, nil, nil
+		})
+	} else {
+		d.skip(StageServerVersion, fmt.Sprintf("not supported for %s", d.config.Type))
+	}
+
+	if checker, ok := d.dialect.(PrivilegeChecker); ok {
+		d.inspectStage(StagePrivileges, func() (string, map[string]string, error) {
+			schemas, err := checker.UnreadableSchemas(d.ctx, db)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to check privileges: %w", err)
+			}
+			if len(schemas) > 0 {
+				return "", map[string]string{"schemas": strings.Join(schemas, ",")},
+					fmt.Errorf("no SELECT privilege on schemas: %s", strings.Join(schemas, ", "))
+			}
+			return "SELECT granted on every schema", nil, nil
+		})
+	} else {
+		d.skip(StagePrivileges, fmt.Sprintf("not supported for %s", d.config.Type))
+	}
+}
+
+func (d *diagnosis) inspectStage(stage Stage, check func() (string, map[string]string, error)) {
+	start := time.Now()
+	message, details, err := check()
+	result := StageResult{Stage: stage, Status: StagePassed, Latency: time.Since(start), Message: message, Details: details}
+	if err != nil {
+		result.Status = StageWarning
+		result.Message = err.Error()
+	}
+	d.Stages = append(d.Stages, result)
+}
+
+// resolve looks up the addresses of host
+func resolve(ctx context.Context, host string) (string, map[string]string, error) {
+	if net.ParseIP(host) != nil {
+		return host + " is an IP address", nil, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s resolved to %d addresses", host, len(addrs)), map[string]string{"addresses": strings.Join(addrs, ",")}, nil
+}
+
+// probeTLS runs the TLS handshake on conn and verifies the server certificate
+// as sslMode asks. The certificate is verified after the handshake rather
+// than during it, so its details are reported when verification fails.
+func probeTLS(ctx context.Context, starter TLSStarter, conn net.Conn, sslMode string, config *tls.Config) (string, map[string]string, error) {
+	probe := config.Clone()
+	probe.InsecureSkipVerify = true
+	probe.VerifyPeerCertificate = nil
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	tlsConn, err := starter.StartTLS(ctx, conn, probe)
+	if err != nil {
+		return "", nil, err
+	}
+
+	state := tlsConn.ConnectionState()
+	details := map[string]string{
+		"version":      tls.VersionName(state.Version),
+		"cipher_suite": tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) == 0 {
+		return "", details, errors.New("server sent no certificate")
+	}
+	leaf := state.PeerCertificates[0]
+	details["subject"] = leaf.Subject.String()
+	details["issuer"] = leaf.Issuer.String()
+	details["not_after"] = leaf.NotAfter.UTC().Format(time.RFC3339)
+	if len(leaf.DNSNames) > 0 {
+		details["dns_names"] = strings.Join(leaf.DNSNames, ",")
+	}
+
+	rawCerts := make([][]byte, len(state.PeerCertificates))
+	for i, cert := range state.PeerCertificates {
+		rawCerts[i] = cert.Raw
+	}
+	switch sslMode {
+	case SSLModeVerifyCA:
+		err = verifyChain(rawCerts, config.RootCAs, "")
+	case SSLModeVerifyFull:
+		err = verifyChain(rawCerts, config.RootCAs, config.ServerName)
+	}
+	if err != nil {
+		return "", details, fmt.Errorf("server certificate rejected: %w", err)
+	}
+	return fmt.Sprintf("%s handshake, certificate of %s", details["version"], leaf.Subject.CommonName), details, nil
+}
+
+// directTLS runs a TLS handshake straight away, for servers that expect TLS
+// from the first byte on their secure port
+func directTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// queryStrings returns the single string column of a query's rows
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,199 @@
+package dbconnector
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stageStatuses maps each stage of a diagnosis to its status
+func stageStatuses(d *Diagnosis) map[Stage]StageStatus {
+	statuses := make(map[Stage]StageStatus)
+	for _, s := range d.Stages {
+		statuses[s.Stage] = s.Status
+	}
+	return statuses
+}
+
+func stageResult(t *testing.T, d *Diagnosis, stage Stage) StageResult {
+	for _, s := range d.Stages {
+		if s.Stage == stage {
+			return s
+		}
+	}
+	t.Fatalf("stage %s not reported", stage)
+	return StageResult{}
+}
+
+// listenPort returns the port of a listener
+func listenPort(t *testing.T, listener net.Listener) int {
+	_, portStr, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return port
+}
+
+func TestDiagnose_SQLite(t *testing.T) {
+	setupSQLite(t)
+
+	d, err := Diagnose(context.Background(), &ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, err)
+
+	assert.Nil(t, d.Failed())
+	assert.Equal(t, map[Stage]StageStatus{
+		StageDatabase:      StagePassed,
+		StageServerVersion: StagePassed,
+		StagePrivileges:    StageSkipped,
+	}, stageStatuses(d))
+	assert.Contains(t, stageResult(t, d, StageServerVersion).Message, "SQLite")
+}
+
+func TestDiagnose_TCPRefused(t *testing.T) {
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listenPort(t, closed)
+	closed.Close()
+
+	d, err := Diagnose(context.Background(), &ConnectionConfig{
+		Type: PostgreSQL, Host: "127.0.0.1", Port: port, Username: "user", Database: "db",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[Stage]StageStatus{
+		StageDNS:            StagePassed,
+		StageTCP:            StageFailed,
+		StageTLS:            StageSkipped,
+		StageAuthentication: StageSkipped,
+		StageDatabase:       StageSkipped,
+		StageServerVersion:  StageSkipped,
+		StagePrivileges:     StageSkipped,
+	}, stageStatuses(d))
+	assert.Equal(t, StageTCP, d.Failed().Stage)
+}
+
+func TestDiagnose_TLSCertificateRejected(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	server := newTestCert(t, "db.internal", ca).tlsCertificate(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err != nil || binary.BigEndian.Uint32(request[4:]) != postgresSSLRequest {
+			return
+		}
+		conn.Write([]byte("S"))
+		tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{server}}).Handshake()
+	}()
+
+	d, err := Diagnose(context.Background(), &ConnectionConfig{
+		Type:     PostgreSQL,
+		Host:     "127.0.0.1",
+		Port:     listenPort(t, listener),
+		Username: "user",
+		Database: "db",
+		SSLMode:  SSLModeVerifyFull,
+		TLS:      &TLSConfig{CACert: ca.certPEM, ServerName: "other.internal"},
+	})
+	require.NoError(t, err)
+
+	result := stageResult(t, d, StageTLS)
+	assert.Equal(t, StageFailed, result.Status)
+	assert.Contains(t, result.Message, "server certificate rejected")
+	assert.Equal(t, "db.internal", result.Details["dns_names"], "certificate details are reported on failure")
+	assert.Equal(t, StageSkipped, stageResult(t, d, StageAuthentication).Status)
+}
+
+func TestDiagnose_SSHTunnelReportsHop(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listenPort(t, closed)
+	closed.Close()
+
+	d, err := Diagnose(context.Background(), &ConnectionConfig{
+		Type:      PostgreSQL,
+		Host:      "127.0.0.1",
+		Port:      port,
+		Username:  "user",
+		Database:  "db",
+		SSHTunnel: tunnelConfig(t, sshAddr, hostKey),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, StageSSHTunnel, d.Failed().Stage)
+	assert.Equal(t, HopTarget, d.Hop)
+	assert.NotContains(t, stageStatuses(d), StageTCP)
+
+	tunnelsMu.Lock()
+	assert.Empty(t, tunnels)
+	tunnelsMu.Unlock()
+}
+
+func TestMySQLStartTLS(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	server := newTestCert(t, "db.internal", ca).tlsCertificate(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	sslRequest := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// protocol 10, version, connection id, auth data, filler, capabilities
+		greeting := append([]byte{10}, "8.0.36\x00"...)
+		greeting = append(greeting, 1, 0, 0, 0)
+		greeting = append(greeting, "12345678"...)
+		greeting = append(greeting, 0)
+		greeting = binary.LittleEndian.AppendUint16(greeting, mysqlClientProtocol41|mysqlClientSSL)
+		conn.Write(append([]byte{byte(len(greeting)), 0, 0, 0}, greeting...))
+
+		request := make([]byte, 36)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		sslRequest <- request
+		tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{server}}).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tlsConn, err := mysqlDialect{}.StartTLS(context.Background(), conn, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	request := <-sslRequest
+	assert.Equal(t, []byte{32, 0, 0, 1}, request[:4])
+	assert.NotZero(t, binary.LittleEndian.Uint32(request[4:8])&mysqlClientSSL)
+}
+
+func TestDialects_IsUnknownDatabase(t *testing.T) {
+	assert.True(t, postgresDialect{}.IsUnknownDatabase(fmt.Errorf("failed to ping database: %w", &pq.Error{Code: "3D000"})))
+	assert.False(t, postgresDialect{}.IsUnknownDatabase(&pq.Error{Code: "28P01"}))
+}
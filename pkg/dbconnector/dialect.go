@@ -0,0 +1,221 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Dialect is everything a Connector needs to know about one kind of database.
+// Dialects register themselves with Register, so a new database can be added
+// as a self-contained package without touching the connector.
+type Dialect interface {
+	// Name is the datasource type the dialect is registered under
+	Name() DBType
+	// DriverName is the database/sql driver connections are opened with
+	DriverName() string
+	// BuildDSN returns the data source name the driver opens
+	BuildDSN(config *ConnectionConfig) (string, error)
+	// Bind replaces :name parameters in query with the dialect's placeholders
+	// and returns the arguments to pass with it. types maps parameter names to
+	// parameter types (ParamTypeString, ...) for dialects that bind typed values;
+	// the returned context carries binds that travel out of band.
+	Bind(ctx context.Context, query string, params map[string]interface{}, types map[string]string) (context.Context, string, []interface{}, error)
+	// QuoteIdentifier quotes a table, column or schema name
+	QuoteIdentifier(name string) string
+	// GetSchema lists the tables and views of the connected database with their
+	// columns, foreign keys and indexes. It runs a fixed number of batched
+	// catalog queries, however many tables there are.
+	GetSchema(db *sql.DB, config *ConnectionConfig) ([]TableInfo, error)
+	// GetColumns lists the columns of a table
+	GetColumns(db *sql.DB, config *ConnectionConfig, schema, table string) ([]ColumnInfo, error)
+	// ReadOnlySession returns statements run on every new connection to make
+	// the session read-only; dialects that enforce it in the DSN return nil
+	ReadOnlySession() []string
+	// ParamType maps a column type reported by GetColumns to a parameter type
+	ParamType(columnType string) string
+}
+
+// Opener is implemented by dialects that open their database themselves
+// instead of through a DSN. The closer releases what the database depends on
+// and is called after the database is closed.
+type Opener interface {
+	Open(config *ConnectionConfig) (*sql.DB, io.Closer, error)
+}
+
+// DriverConnector is implemented by dialects whose driver takes settings a DSN
+// cannot carry, such as a *tls.Config or a dialer, through a driver.Connector.
+// The read-only session statements still run on every connection it opens.
+type DriverConnector interface {
+	NewDriverConnector(config *ConnectionConfig) (driver.Connector, error)
+}
+
+// PathResolver is implemented by file-based dialects, whose Database is a path
+// on the server rather than a database on a host
+type PathResolver interface {
+	// ResolvePath checks that path may be opened and returns its absolute form
+	ResolvePath(path string) (string, error)
+}
+
+// DataVersioner is implemented by dialects that load their data when a
+// connection is opened. DataVersion changes whenever the data does, so
+// pooled connections are reopened rather than serving stale data.
+type DataVersioner interface {
+	DataVersion(config *ConnectionConfig) string
+}
+
+// OptionValidator is implemented by dialects that accept ConnectionConfig.Options
+type OptionValidator interface {
+	ValidateOptions(options map[string]string) error
+}
+
+// SessionValidator is implemented by dialects that accept
+// ConnectionConfig.SessionInit statements. ValidateSessionStatement rejects
+// anything but the session settings the dialect allows.
+type SessionValidator interface {
+	ValidateSessionStatement(stmt string) error
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = make(map[DBType]Dialect)
+	aliases    = make(map[DBType]DBType)
+)
+
+// Register makes a dialect available under its name. It panics if the name
+// is already taken, like database/sql.Register.
+func Register(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	if _, ok := dialects[d.Name()]; ok {
+		panic(fmt.Sprintf("dbconnector: dialect %s registered twice", d.Name()))
+	}
+	dialects[d.Name()] = d
+}
+
+// RegisterAlias makes a registered dialect available under another name as well
+func RegisterAlias(alias, name DBType) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	aliases[alias] = name
+}
+
+// Lookup returns the dialect registered for a datasource type or one of its aliases
+func Lookup(dbType DBType) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	if name, ok := aliases[dbType]; ok {
+		dbType = name
+	}
+	d, ok := dialects[dbType]
+	return d, ok
+}
+
+// Types returns the names of the registered dialects, sorted
+func Types() []DBType {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	types := make([]DBType, 0, len(dialects))
+	for name := range dialects {
+		types = append(types, name)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// IsFileBased reports whether a datasource type reads a file on the server,
+// and so needs no host, port or credentials
+func IsFileBased(dbType DBType) bool {
+	d, ok := Lookup(dbType)
+	if !ok {
+		return false
+	}
+	_, ok = d.(PathResolver)
+	return ok
+}
+
+// namedParamPattern matches :name parameters in a query template
+var namedParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// BindNumbered replaces each distinct :name parameter with one numbered
+// placeholder, so a parameter used twice binds the same argument
+func BindNumbered(query string, params map[string]interface{}, placeholder func(n int) string) (string, []interface{}) {
+	if len(params) == 0 || !namedParamPattern.MatchString(query) {
+		return query, nil
+	}
+
+	positions := make(map[string]int)
+	var args []interface{}
+	converted := namedParamPattern.ReplaceAllStringFunc(query, func(match string) string {
+		name := strings.TrimPrefix(match, ":")
+		n, ok := positions[name]
+		if !ok {
+			args = append(args, params[name])
+			n = len(args)
+			positions[name] = n
+		}
+		return placeholder(n)
+	})
+	return converted, args
+}
+
+// BindPositional replaces every :name occurrence with its own placeholder,
+// for drivers that bind arguments by position, so a parameter used twice
+// gets two arguments
+func BindPositional(query string, params map[string]interface{}, placeholder func(n int) string) (string, []interface{}) {
+	if len(params) == 0 || !namedParamPattern.MatchString(query) {
+		return query, nil
+	}
+
+	var args []interface{}
+	converted := namedParamPattern.ReplaceAllStringFunc(query, func(match string) string {
+		args = append(args, params[strings.TrimPrefix(match, ":")])
+		return placeholder(len(args))
+	})
+	return converted, args
+}
+
+// questionMark is the placeholder of drivers that bind ? by position
+func questionMark(int) string { return "?" }
+
+// quoteWith quotes name between left and right, doubling right inside it
+func quoteWith(name, left, right string) string {
+	return left + strings.ReplaceAll(name, right, right+right) + right
+}
+
+// genericParamType maps common SQL type names to parameter types
+func genericParamType(columnType string) string {
+	t := strings.ToLower(columnType)
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = t[:i]
+	}
+	t = strings.TrimSpace(t)
+
+	switch {
+	case strings.HasSuffix(t, "[]"), strings.HasPrefix(t, "interval"), strings.Contains(t, "point"):
+		return ParamTypeString
+	case strings.Contains(t, "bool"):
+		return ParamTypeBoolean
+	case strings.Contains(t, "int"):
+		return ParamTypeInteger
+	case strings.Contains(t, "numeric"), strings.Contains(t, "decimal"), strings.Contains(t, "real"),
+		strings.Contains(t, "float"), strings.Contains(t, "double"), strings.Contains(t, "money"):
+		return ParamTypeNumber
+	case strings.Contains(t, "timestamp"), strings.Contains(t, "datetime"):
+		return ParamTypeDateTime
+	case t == "date":
+		return ParamTypeDate
+	default:
+		return ParamTypeString
+	}
+}
@@ -0,0 +1,138 @@
+package dbconnector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	for _, dbType := range []DBType{PostgreSQL, MySQL, MSSQL, Oracle, SQLite, ClickHouse, File} {
+		d, ok := Lookup(dbType)
+		require.True(t, ok, dbType)
+		assert.Equal(t, dbType, d.Name())
+	}
+
+	// Aliases resolve to the canonical dialect
+	d, ok := Lookup("mssql")
+	require.True(t, ok)
+	assert.Equal(t, MSSQL, d.Name())
+
+	_, ok = Lookup("unknown")
+	assert.False(t, ok)
+
+	assert.Contains(t, Types(), MSSQL)
+	assert.NotContains(t, Types(), DBType("mssql"))
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	assert.Panics(t, func() { Register(postgresDialect{}) })
+}
+
+func TestIsFileBased(t *testing.T) {
+	assert.True(t, IsFileBased(SQLite))
+	assert.True(t, IsFileBased(File))
+	assert.False(t, IsFileBased(PostgreSQL))
+	assert.False(t, IsFileBased("unknown"))
+}
+
+func TestConnector_convertNamedParams_MySQL_RepeatedParam(t *testing.T) {
+	connector := NewConnector(&ConnectionConfig{Type: MySQL})
+
+	convertedQuery, args := connector.convertNamedParams(
+		"SELECT * FROM users WHERE id = :id OR parent_id = :id",
+		map[string]interface{}{"id": 1},
+	)
+
+	// ? binds by position, so each occurrence needs its own argument
+	assert.Equal(t, "SELECT * FROM users WHERE id = ? OR parent_id = ?", convertedQuery)
+	assert.Equal(t, []interface{}{1, 1}, args)
+}
+
+func TestBindNumbered_ParamPrefix(t *testing.T) {
+	query, args := BindNumbered("SELECT :id, :id2", map[string]interface{}{"id": 1, "id2": 2},
+		func(n int) string { return fmt.Sprintf("$%d", n) })
+
+	assert.Equal(t, "SELECT $1, $2", query)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestDialect_QuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		dbType   DBType
+		expected string
+	}{
+		{PostgreSQL, `"odd""name"`},
+		{MySQL, "`odd\"name`"},
+		{MSSQL, `[odd"name]`},
+		{Oracle, `"odd""name"`},
+		{SQLite, `"odd""name"`},
+		{ClickHouse, "`odd\"name`"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NewConnector(&ConnectionConfig{Type: tt.dbType}).QuoteIdentifier(`odd"name`), tt.dbType)
+	}
+}
+
+func TestDialect_ParamType(t *testing.T) {
+	tests := []struct {
+		dbType     DBType
+		columnType string
+		expected   string
+	}{
+		{PostgreSQL, "integer", ParamTypeInteger},
+		{PostgreSQL, "numeric", ParamTypeNumber},
+		{PostgreSQL, "boolean", ParamTypeBoolean},
+		{PostgreSQL, "timestamp with time zone", ParamTypeDateTime},
+		{PostgreSQL, "date", ParamTypeDate},
+		{PostgreSQL, "interval", ParamTypeString},
+		{PostgreSQL, "integer[]", ParamTypeString},
+		{PostgreSQL, "numeric(10,2)", ParamTypeNumber},
+		{PostgreSQL, "character varying", ParamTypeString},
+		{MySQL, "tinyint(1)", ParamTypeBoolean},
+		{MySQL, "bigint", ParamTypeInteger},
+		{MSSQL, "bit", ParamTypeBoolean},
+		{Oracle, "NUMBER", ParamTypeNumber},
+		{Oracle, "DATE", ParamTypeDateTime},
+		{ClickHouse, "Nullable(UInt64)", ParamTypeInteger},
+		{ClickHouse, "DateTime64(3)", ParamTypeDateTime},
+		{ClickHouse, "LowCardinality(String)", ParamTypeString},
+	}
+
+	for _, tt := range tests {
+		d, ok := Lookup(tt.dbType)
+		require.True(t, ok)
+		assert.Equal(t, tt.expected, d.ParamType(tt.columnType), "%s %s", tt.dbType, tt.columnType)
+	}
+}
+
+func TestValidateOptions_UnsupportedType(t *testing.T) {
+	assert.NoError(t, ValidateOptions(MySQL, nil))
+	assert.NoError(t, ValidateOptions(MySQL, map[string]string{"charset": "utf8mb4"}))
+	assert.Error(t, ValidateOptions(MySQL, map[string]string{"allowAllFiles": "true"}))
+	assert.Error(t, ValidateOptions("unknown", nil))
+}
+
+func TestOpenDB_Session(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.db")
+
+	db, err := openDB("sqlite", file, []string{"PRAGMA query_only = 1"})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every pooled connection runs the session statements
+	db.SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		_, err = db.ExecContext(context.Background(), "CREATE TABLE t (id INTEGER)")
+		assert.Error(t, err)
+	}
+
+	var queryOnly int
+	require.NoError(t, db.QueryRow("PRAGMA query_only").Scan(&queryOnly))
+	assert.Equal(t, 1, queryOnly)
+}
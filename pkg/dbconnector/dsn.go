@@ -0,0 +1,748 @@
+package dbconnector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ParsedDSN is a connection string split into the fields of a datasource
+type ParsedDSN struct {
+	Type          DBType
+	Host          string
+	Port          int
+	Database      string
+	Username      string
+	Password      string
+	SSLMode       string
+	TLSServerName string            // server name the certificate is verified against, when the string names one
+	Options       map[string]string // parameters without a field of their own
+	Warnings      []string          // parts of the string that were not kept
+}
+
+// defaultPorts are the ports of a database type when a connection string names none
+var defaultPorts = map[DBType]int{
+	PostgreSQL: 5432,
+	MySQL:      3306,
+	MSSQL:      1433,
+	Oracle:     1521,
+	ClickHouse: 9000,
+}
+
+// urlSchemes maps URL schemes to the database type they connect to
+var urlSchemes = map[string]DBType{
+	"postgres":   PostgreSQL,
+	"postgresql": PostgreSQL,
+	"mysql":      MySQL,
+	"mariadb":    MySQL,
+	"sqlserver":  MSSQL,
+	"mssql":      MSSQL,
+	"oracle":     Oracle,
+	"clickhouse": ClickHouse,
+	"ch":         ClickHouse,
+}
+
+// ParseDSN splits a connection string into datasource fields. It accepts
+// URLs (postgres://, mysql://, sqlserver://, oracle://, clickhouse://), JDBC
+// URLs, go-sql-driver/mysql DSNs and key=value strings: libpq's, separated by
+// spaces, and ADO.NET's, separated by semicolons. dbType, which may be empty,
+// settles strings that fit several database types.
+func ParseDSN(dsn string, dbType DBType) (*ParsedDSN, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, errors.New("connection string is empty")
+	}
+	if dbType != "" {
+		d, ok := Lookup(dbType)
+		if !ok {
+			return nil, fmt.Errorf("unsupported database type: %s", dbType)
+		}
+		dbType = d.Name()
+	}
+
+	var p *ParsedDSN
+	var err error
+	lower := strings.ToLower(dsn)
+	switch {
+	case strings.HasPrefix(lower, "jdbc:"):
+		p, err = parseJDBC(dsn[len("jdbc:"):])
+	case strings.Contains(dsn, "://"):
+		p, err = parseURLDSN(dsn, dbType)
+	case strings.Contains(dsn, "@tcp(") || strings.Contains(dsn, "@unix(") || strings.Contains(dsn, "@/"):
+		p, err = parseMySQLDSN(dsn)
+	case strings.Contains(dsn, "="):
+		p, err = parseKeyValueDSN(dsn, dbType)
+	default:
+		return nil, errors.New("unrecognized connection string format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if dbType != "" && p.Type != dbType {
+		return nil, fmt.Errorf("connection string is for %s, not %s", p.Type, dbType)
+	}
+	if p.Port == 0 {
+		p.Port = defaultPorts[p.Type]
+	}
+	if p.SSLMode == "" {
+		p.SSLMode = SSLModeDisable
+	}
+	return p, nil
+}
+
+func newParsedDSN(dbType DBType) *ParsedDSN {
+	return &ParsedDSN{Type: dbType, Options: make(map[string]string)}
+}
+
+func (p *ParsedDSN) warn(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// setHostPort sets Host and Port from host, host:port or, as SQL Server
+// writes it, host,port
+func (p *ParsedDSN) setHostPort(addr string) error {
+	addr = strings.TrimPrefix(addr, "tcp:")
+	if host, port, ok := strings.Cut(addr, ","); ok {
+		addr = net.JoinHostPort(host, strings.TrimSpace(port))
+	}
+
+	host, port := addr, ""
+	if h, pt, err := net.SplitHostPort(addr); err == nil {
+		host, port = h, pt
+	}
+	if i := strings.IndexByte(host, '\\'); i >= 0 {
+		p.warn("named instance %q is ignored; connect to the port of the instance instead", host[i+1:])
+		host = host[:i]
+	}
+	p.Host = host
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		p.Port = n
+	}
+	return nil
+}
+
+// parseURLDSN parses a URL whose scheme names the database type. ClickHouse
+// HTTP URLs are recognized when dbType is clickhouse.
+func parseURLDSN(dsn string, dbType DBType) (*ParsedDSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection url: %w", err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	t, ok := urlSchemes[scheme]
+	if !ok && (scheme == "http" || scheme == "https") && dbType == ClickHouse {
+		t, ok = ClickHouse, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported connection url scheme: %s", u.Scheme)
+	}
+
+	p := newParsedDSN(t)
+	if err := p.setHostPort(u.Host); err != nil {
+		return nil, err
+	}
+	if u.User != nil {
+		p.Username = u.User.Username()
+		p.Password, _ = u.User.Password()
+	}
+
+	path := strings.TrimPrefix(u.Path, "/")
+	switch t {
+	case MSSQL:
+		// The path of a go-mssqldb URL names an instance, not a database
+		if path != "" {
+			p.warn("named instance %q is ignored; connect to the port of the instance instead", path)
+		}
+	default:
+		p.Database = path
+	}
+
+	if t == ClickHouse && (scheme == "http" || scheme == "https") {
+		p.Options[ClickHouseOptionProtocol] = ClickHouseProtocolHTTP
+		if scheme == "https" {
+			p.SSLMode = SSLModeVerifyFull
+		}
+	}
+
+	if err := p.applyParams(queryParams(u.Query())); err != nil {
+		return nil, err
+	}
+
+	if t == ClickHouse && p.Port == 0 {
+		secure := p.SSLMode != "" && p.SSLMode != SSLModeDisable
+		switch {
+		case p.Options[ClickHouseOptionProtocol] == ClickHouseProtocolHTTP && secure:
+			p.Port = 8443
+		case p.Options[ClickHouseOptionProtocol] == ClickHouseProtocolHTTP:
+			p.Port = 8123
+		case secure:
+			p.Port = 9440
+		}
+	}
+	return p, nil
+}
+
+// queryParams returns the parameters of a URL query in key order, which
+// puts a setting before the parameters that refine it, like secure before
+// skip_verify
+func queryParams(values url.Values) []dsnParam {
+	params := make([]dsnParam, 0, len(values))
+	for _, key := range sortedKeys(flatten(values)) {
+		params = append(params, dsnParam{key: key, value: values.Get(key)})
+	}
+	return params
+}
+
+// flatten keeps the first value of each key
+func flatten(values url.Values) map[string]string {
+	m := make(map[string]string, len(values))
+	for key := range values {
+		m[key] = values.Get(key)
+	}
+	return m
+}
+
+// parseJDBC parses a JDBC URL without its jdbc: prefix
+func parseJDBC(rest string) (*ParsedDSN, error) {
+	lower := strings.ToLower(rest)
+	switch {
+	case strings.HasPrefix(lower, "sqlserver://"):
+		return parseJDBCSQLServer(rest[len("sqlserver://"):])
+	case strings.HasPrefix(lower, "oracle:"):
+		return parseJDBCOracle(rest)
+	case strings.HasPrefix(lower, "clickhouse:http://"), strings.HasPrefix(lower, "clickhouse:https://"):
+		return parseURLDSN(rest[len("clickhouse:"):], ClickHouse)
+	case strings.HasPrefix(lower, "clickhouse://"), strings.HasPrefix(lower, "ch://"):
+		// The JDBC driver talks to the HTTP interface
+		_, address, _ := strings.Cut(rest, "://")
+		return parseURLDSN("http://"+address, ClickHouse)
+	case strings.Contains(rest, "://"):
+		return parseURLDSN(rest, "")
+	default:
+		return nil, errors.New("unsupported jdbc url")
+	}
+}
+
+// parseJDBCSQLServer parses host[\instance][:port];key=value;... after jdbc:sqlserver://
+func parseJDBCSQLServer(rest string) (*ParsedDSN, error) {
+	hostPort, rest, _ := strings.Cut(rest, ";")
+	p := newParsedDSN(MSSQL)
+	if err := p.setHostPort(hostPort); err != nil {
+		return nil, err
+	}
+	params, err := splitKeyValues(rest)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyParams(params); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseJDBCOracle parses oracle:thin:[user/password]@target, where target is
+// host:port:SID, host:port/service, //host:port/service or a TNS descriptor
+func parseJDBCOracle(rest string) (*ParsedDSN, error) {
+	driver, rest, ok := strings.Cut(rest, "@")
+	if !ok {
+		return nil, errors.New("oracle jdbc url must name a target after @")
+	}
+	p := newParsedDSN(Oracle)
+	// oracle:thin:scott/tiger
+	if fields := strings.SplitN(driver, ":", 3); len(fields) == 3 && fields[2] != "" {
+		p.Username, p.Password, _ = strings.Cut(fields[2], "/")
+	}
+
+	if strings.HasPrefix(rest, "(") {
+		p.Database = rest
+		p.Options[OracleOptionConnectType] = OracleConnectTNS
+		return p, nil
+	}
+
+	rest = strings.TrimPrefix(rest, "//")
+	target, query, _ := strings.Cut(rest, "?")
+	if addr, service, ok := strings.Cut(target, "/"); ok {
+		if err := p.setHostPort(addr); err != nil {
+			return nil, err
+		}
+		p.Database = service
+	} else {
+		// host:port:SID
+		fields := strings.Split(target, ":")
+		if len(fields) != 3 {
+			return nil, errors.New("oracle jdbc url must name a service or SID")
+		}
+		if err := p.setHostPort(net.JoinHostPort(fields[0], fields[1])); err != nil {
+			return nil, err
+		}
+		p.Database = fields[2]
+		p.Options[OracleOptionConnectType] = OracleConnectSID
+	}
+
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oracle jdbc url parameters: %w", err)
+		}
+		if err := p.applyParams(queryParams(values)); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// parseMySQLDSN parses a go-sql-driver/mysql DSN with the driver's own parser
+func parseMySQLDSN(dsn string) (*ParsedDSN, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql dsn: %w", err)
+	}
+	if cfg.Net != "tcp" {
+		return nil, fmt.Errorf("mysql connections over %s are not supported", cfg.Net)
+	}
+
+	p := newParsedDSN(MySQL)
+	if err := p.setHostPort(cfg.Addr); err != nil {
+		return nil, err
+	}
+	p.Username, p.Password, p.Database = cfg.User, cfg.Passwd, cfg.DBName
+
+	// The parser moves the parameters it knows into fields
+	values := url.Values{}
+	for key, value := range cfg.Params {
+		values.Set(key, value)
+	}
+	if cfg.TLSConfig != "" {
+		values.Set("tls", cfg.TLSConfig)
+	}
+	for key, d := range map[string]time.Duration{"timeout": cfg.Timeout, "readTimeout": cfg.ReadTimeout, "writeTimeout": cfg.WriteTimeout} {
+		if d > 0 {
+			values.Set(key, d.String())
+		}
+	}
+	if cfg.Loc != nil && cfg.Loc != time.UTC {
+		values.Set("loc", cfg.Loc.String())
+	}
+	if err := p.applyParams(queryParams(values)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseKeyValueDSN parses key=value strings: separated by semicolons they are
+// ADO.NET strings, of SQL Server unless dbType says MySQL; separated by
+// spaces they are libpq strings
+func parseKeyValueDSN(dsn string, dbType DBType) (*ParsedDSN, error) {
+	if strings.Contains(dsn, ";") {
+		t := MSSQL
+		if dbType == MySQL {
+			t = MySQL
+		}
+		params, err := splitKeyValues(dsn)
+		if err != nil {
+			return nil, err
+		}
+		p := newParsedDSN(t)
+		if err := p.applyParams(params); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	params, err := splitLibpqValues(dsn)
+	if err != nil {
+		return nil, err
+	}
+	p := newParsedDSN(PostgreSQL)
+	if err := p.applyParams(params); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// dsnParam is one key=value parameter of a connection string
+type dsnParam struct {
+	key   string
+	value string
+}
+
+// splitKeyValues splits a semicolon-separated key=value string following
+// ADO.NET's rules: a value may be quoted with "...", '...' or {...}, doubling
+// the closing quote to include it, and only quoted values may contain a
+// semicolon. Unquoted values are trimmed, except passwords, whose spaces may
+// be part of them.
+func splitKeyValues(s string) ([]dsnParam, error) {
+	var params []dsnParam
+	rest := s
+	for {
+		// Skip empty parts
+		rest = strings.TrimLeft(rest, " \t;")
+		if rest == "" {
+			return params, nil
+		}
+		part, value, ok := strings.Cut(rest, "=")
+		if end := strings.IndexByte(part, ';'); end >= 0 {
+			ok, part = false, part[:end]
+		}
+		key := strings.TrimSpace(part)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid connection string part %q", part)
+		}
+
+		value, next, err := splitADOValue(key, value)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, dsnParam{key: key, value: value})
+		rest = next
+	}
+}
+
+// adoQuotes maps the characters opening a quoted ADO.NET value to the ones
+// closing it
+var adoQuotes = map[byte]byte{'"': '"', '\'': '\'', '{': '}'}
+
+// splitADOValue reads the value of key at the start of s, returning it
+// unquoted along with what follows its separating semicolon
+func splitADOValue(key, s string) (string, string, error) {
+	trimmed := strings.TrimLeft(s, " \t")
+	if trimmed != "" {
+		if closing, ok := adoQuotes[trimmed[0]]; ok {
+			var value strings.Builder
+			i := 1
+			for ; i < len(trimmed); i++ {
+				if trimmed[i] == closing {
+					if i+1 < len(trimmed) && trimmed[i+1] == closing {
+						i++
+					} else {
+						break
+					}
+				}
+				value.WriteByte(trimmed[i])
+			}
+			if i == len(trimmed) {
+				return "", "", fmt.Errorf("unterminated quoted value of %s", key)
+			}
+			after, next, _ := strings.Cut(trimmed[i+1:], ";")
+			if strings.TrimSpace(after) != "" {
+				return "", "", fmt.Errorf("unexpected %q after the quoted value of %s", after, key)
+			}
+			return value.String(), next, nil
+		}
+	}
+
+	value, next, _ := strings.Cut(s, ";")
+	if !isPasswordKey(key) {
+		value = strings.TrimSpace(value)
+	}
+	return value, next, nil
+}
+
+// isPasswordKey reports whether an ADO.NET key names the password
+func isPasswordKey(key string) bool {
+	switch strings.ToLower(key) {
+	case "password", "pwd":
+		return true
+	}
+	return false
+}
+
+// splitLibpqValues splits a libpq key=value string, where values may be
+// single-quoted with backslash escapes
+func splitLibpqValues(dsn string) ([]dsnParam, error) {
+	var params []dsnParam
+	rest := strings.TrimSpace(dsn)
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid connection string part %q", rest)
+		}
+		key := strings.TrimSpace(rest[:eq])
+		rest = strings.TrimLeft(rest[eq+1:], " ")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, "'") {
+			i := 1
+			for ; i < len(rest) && rest[i] != '\''; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i == len(rest) {
+				return nil, fmt.Errorf("unterminated quoted value of %s", key)
+			}
+			rest = rest[i+1:]
+		} else {
+			end := strings.IndexByte(rest, ' ')
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(rest[:end])
+			rest = rest[end:]
+		}
+		params = append(params, dsnParam{key: key, value: value.String()})
+		rest = strings.TrimLeft(rest, " ")
+	}
+	return params, nil
+}
+
+// applyParams sets fields from the parameters of a connection string and
+// keeps the rest as options
+func (p *ParsedDSN) applyParams(params []dsnParam) error {
+	var apply func(key, value string) (bool, error)
+	switch p.Type {
+	case PostgreSQL:
+		apply = p.applyPostgresParam
+	case MySQL:
+		apply = p.applyMySQLParam
+	case MSSQL:
+		return p.applyMSSQLParams(params)
+	case Oracle:
+		apply = p.applyOracleParam
+	case ClickHouse:
+		apply = p.applyClickHouseParam
+	}
+
+	for _, param := range params {
+		done, err := apply(param.key, param.value)
+		if err != nil {
+			return err
+		}
+		if !done {
+			p.Options[param.key] = param.value
+		}
+	}
+	return nil
+}
+
+func (p *ParsedDSN) applyPostgresParam(key, value string) (bool, error) {
+	switch key {
+	case "host", "hostaddr":
+		p.Host = value
+	case "port":
+		return true, p.setHostPort(net.JoinHostPort(p.Host, value))
+	case "dbname":
+		p.Database = value
+	case "user":
+		p.Username = value
+	case "password":
+		p.Password = value
+	case "sslmode":
+		switch value {
+		case "disable", "allow":
+			p.SSLMode = SSLModeDisable
+		case "prefer", "require":
+			p.SSLMode = SSLModeRequire
+		case SSLModeVerifyCA, SSLModeVerifyFull:
+			p.SSLMode = value
+		default:
+			return false, fmt.Errorf("unsupported sslmode %q", value)
+		}
+		if value == "allow" || value == "prefer" {
+			p.warn("sslmode %s falls back between plain and TLS connections, which is not supported; using %s", value, p.SSLMode)
+		}
+	case "sslrootcert", "sslcert", "sslkey", "sslpassword":
+		p.warn("%s names a file on the client; paste the certificates into the TLS settings instead", key)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *ParsedDSN) applyMySQLParam(key, value string) (bool, error) {
+	switch strings.ToLower(key) {
+	case "server", "host":
+		return true, p.setHostPort(value)
+	case "port":
+		return true, p.setHostPort(net.JoinHostPort(p.Host, value))
+	case "database":
+		p.Database = value
+	case "user", "uid", "user id", "username":
+		p.Username = value
+	case "password", "pwd":
+		p.Password = value
+	case "tls":
+		// go-sql-driver/mysql
+		switch strings.ToLower(value) {
+		case "true":
+			p.SSLMode = SSLModeVerifyFull
+		case "skip-verify", "preferred":
+			p.SSLMode = SSLModeRequire
+		case "false":
+			p.SSLMode = SSLModeDisable
+		default:
+			p.warn("tls configuration %q is registered in another program; set the TLS settings instead", value)
+		}
+	case "ssl-mode", "sslmode":
+		// MySQL clients, Connector/J and ADO.NET
+		switch strings.ToLower(strings.ReplaceAll(value, "_", "")) {
+		case "disabled", "none":
+			p.SSLMode = SSLModeDisable
+		case "preferred", "required":
+			p.SSLMode = SSLModeRequire
+		case "verifyca":
+			p.SSLMode = SSLModeVerifyCA
+		case "verifyidentity", "verifyfull":
+			p.SSLMode = SSLModeVerifyFull
+		default:
+			return false, fmt.Errorf("unsupported ssl mode %q", value)
+		}
+	case "usessl", "requiressl":
+		if b, _ := strconv.ParseBool(value); b {
+			if p.SSLMode == "" || p.SSLMode == SSLModeDisable {
+				p.SSLMode = SSLModeRequire
+			}
+		} else if strings.EqualFold(key, "useSSL") {
+			p.SSLMode = SSLModeDisable
+		}
+	case "verifyservercertificate":
+		if b, _ := strconv.ParseBool(value); b && p.SSLMode == SSLModeRequire {
+			p.SSLMode = SSLModeVerifyCA
+		}
+	case "parsetime":
+		// Always set by the connector
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// mssqlOptionNames maps ADO.NET and JDBC keys to the go-mssqldb parameters they mean
+var mssqlOptionNames = map[string]string{
+	"application name":   "app name",
+	"applicationname":    "app name",
+	"application intent": "applicationintent",
+	"connect timeout":    "connection timeout",
+	"connection timeout": "connection timeout",
+	"logintimeout":       "connection timeout",
+	"packetsize":         "packet size",
+}
+
+// mssqlIgnoredKeys are ADO.NET keys about the client's own connection pool and
+// behavior, which have no go-mssqldb counterpart
+var mssqlIgnoredKeys = map[string]bool{
+	"persist security info":    true,
+	"multipleactiveresultsets": true,
+	"pooling":                  true,
+	"max pool size":            true,
+	"min pool size":            true,
+}
+
+// applyMSSQLParams sets fields from SQL Server parameters. Encryption is
+// settled once all parameters are read, as it depends on two of them.
+func (p *ParsedDSN) applyMSSQLParams(params []dsnParam) error {
+	encrypt, trust := "", false
+	for _, param := range params {
+		key, value := strings.ToLower(param.key), param.value
+		switch key {
+		case "server", "data source", "address", "addr", "network address":
+			if err := p.setHostPort(value); err != nil {
+				return err
+			}
+		case "port", "portnumber":
+			if err := p.setHostPort(net.JoinHostPort(p.Host, value)); err != nil {
+				return err
+			}
+		case "servername":
+			p.Host = value
+		case "database", "databasename", "initial catalog":
+			p.Database = value
+		case "user", "user id", "uid", "username":
+			p.Username = value
+		case "password", "pwd":
+			p.Password = value
+		case "encrypt":
+			encrypt = strings.ToLower(value)
+		case "trustservercertificate":
+			trust, _ = strconv.ParseBool(value)
+		case "hostnameincertificate":
+			p.TLSServerName = value
+		case "integrated security", "integratedsecurity", "trusted_connection", "authentication":
+			p.warn("%s is not supported; use SQL Server authentication with a user and password", param.key)
+		default:
+			if mssqlIgnoredKeys[key] {
+				p.warn("%s does not apply and is ignored", param.key)
+				continue
+			}
+			if name, ok := mssqlOptionNames[key]; ok {
+				key = name
+			}
+			p.Options[key] = value
+		}
+	}
+
+	switch encrypt {
+	case "", "false", "no", "optional", "disable":
+		p.SSLMode = SSLModeDisable
+	case "true", "yes", "mandatory", "strict":
+		p.SSLMode = SSLModeVerifyFull
+		if trust {
+			p.SSLMode = SSLModeRequire
+		}
+	default:
+		return fmt.Errorf("unsupported encrypt value %q", encrypt)
+	}
+	return nil
+}
+
+func (p *ParsedDSN) applyOracleParam(key, value string) (bool, error) {
+	switch strings.ToLower(key) {
+	case "ssl":
+		if b, _ := strconv.ParseBool(value); b {
+			if p.SSLMode == "" || p.SSLMode == SSLModeDisable {
+				p.SSLMode = SSLModeVerifyFull
+			}
+		}
+	case "ssl verify":
+		if b, _ := strconv.ParseBool(value); !b && p.SSLMode != "" && p.SSLMode != SSLModeDisable {
+			p.SSLMode = SSLModeRequire
+		}
+	case "wallet":
+		p.Options[OracleOptionWalletPath] = value
+	case "sid":
+		p.Database = value
+		p.Options[OracleOptionConnectType] = OracleConnectSID
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *ParsedDSN) applyClickHouseParam(key, value string) (bool, error) {
+	switch key {
+	case "database":
+		p.Database = value
+	case "username", "user":
+		p.Username = value
+	case "password":
+		p.Password = value
+	case "secure", "ssl":
+		if b, _ := strconv.ParseBool(value); b {
+			if p.SSLMode == "" || p.SSLMode == SSLModeDisable {
+				p.SSLMode = SSLModeVerifyFull
+			}
+		}
+	case "skip_verify":
+		if b, _ := strconv.ParseBool(value); b && p.SSLMode != "" && p.SSLMode != SSLModeDisable {
+			p.SSLMode = SSLModeRequire
+		}
+	case "readonly":
+		// Always set by the connector
+	default:
+		return false, nil
+	}
+	return true, nil
+}
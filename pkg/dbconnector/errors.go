@@ -0,0 +1,27 @@
+package dbconnector
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// IsConnectionError reports whether err means the database could not be
+// reached, as opposed to the database rejecting a statement. Timeouts are not
+// counted: a slow query says nothing about whether the database is up.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	}
+	return false
+}
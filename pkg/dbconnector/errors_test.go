@@ -0,0 +1,26 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	assert.False(t, IsConnectionError(nil))
+	assert.False(t, IsConnectionError(errors.New("syntax error at or near \"SELEC\"")))
+	assert.True(t, IsConnectionError(fmt.Errorf("failed to ping database: %w", driver.ErrBadConn)))
+	assert.True(t, IsConnectionError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.True(t, IsConnectionError(&net.OpError{Op: "dial", Err: errors.New("i/o timeout")}))
+	assert.True(t, IsConnectionError(fmt.Errorf("query execution failed: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET})))
+
+	// Slow queries do not mean the database is down
+	assert.False(t, IsConnectionError(fmt.Errorf("query execution failed: %w", context.DeadlineExceeded)))
+	assert.False(t, IsConnectionError(&net.OpError{Op: "read", Err: errors.New("i/o timeout")}))
+}
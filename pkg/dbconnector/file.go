@@ -0,0 +1,369 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	Register(fileDialect{})
+}
+
+// fileDialect serves CSV, NDJSON and Parquet files through SQLite. Database
+// names a data file, or a directory of them, inside the data directory.
+type fileDialect struct {
+	sqliteDialect
+}
+
+func (fileDialect) Name() DBType { return File }
+
+func (fileDialect) BuildDSN(*ConnectionConfig) (string, error) {
+	return "", errors.New("file datasources are loaded from their data files, not opened through a DSN")
+}
+
+func (fileDialect) ResolvePath(path string) (string, error) {
+	return ResolveFilePath(path)
+}
+
+var (
+	// ErrFilesDisabled is returned when no directory is configured for file datasources
+	ErrFilesDisabled = errors.New("file datasources are disabled: no data directory is configured")
+	// ErrFilePathNotAllowed is returned when a data file resolves outside the data directory
+	ErrFilePathNotAllowed = errors.New("file datasource path is outside the data directory")
+	// ErrFileTooLarge is returned for data files and uploads above the configured size limit
+	ErrFileTooLarge = errors.New("file exceeds the maximum file size")
+	// ErrUnsupportedFileFormat is returned for files that are not CSV, NDJSON or Parquet
+	ErrUnsupportedFileFormat = errors.New("unsupported file format: expected .csv, .ndjson, .jsonl, .json or .parquet")
+)
+
+// FileConfig restricts where file datasources read their data files from
+type FileConfig struct {
+	Dir         string // data files must resolve inside this directory, uploads are stored in it; empty disables file datasources
+	MaxFileSize int64  // largest data file or upload in bytes; 0 means no limit
+}
+
+var (
+	filesMu     sync.RWMutex
+	filesConfig FileConfig
+)
+
+// ConfigureFiles sets the sandbox applied to every file datasource
+func ConfigureFiles(cfg FileConfig) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	filesConfig = cfg
+}
+
+func currentFileConfig() FileConfig {
+	filesMu.RLock()
+	defer filesMu.RUnlock()
+	return filesConfig
+}
+
+// MaxFileSize is the largest data file or upload in bytes; 0 means no limit
+func MaxFileSize() int64 {
+	return currentFileConfig().MaxFileSize
+}
+
+// fileUploadDir is the subdirectory of the data directory uploads are stored in
+const fileUploadDir = "uploads"
+
+// ResolveFilePath resolves the path of a data file, or of a directory of data
+// files, against the data directory. Relative paths are taken relative to that
+// directory. Symlinks are followed before the check so they cannot point outside of it.
+func ResolveFilePath(path string) (string, error) {
+	cfg := currentFileConfig()
+	if cfg.Dir == "" {
+		return "", ErrFilesDisabled
+	}
+	if path == "" {
+		return "", errors.New("file datasource path is required")
+	}
+
+	resolved, err := resolveInDir(cfg.Dir, path)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOutsideDir):
+			return "", ErrFilePathNotAllowed
+		case errors.Is(err, os.ErrNotExist):
+			return "", fmt.Errorf("file datasource path not found: %s", path)
+		default:
+			return "", fmt.Errorf("failed to resolve file datasource path: %w", err)
+		}
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file datasource path: %w", err)
+	}
+	if !info.IsDir() && fileFormatOf(resolved) == "" {
+		return "", ErrUnsupportedFileFormat
+	}
+
+	return resolved, nil
+}
+
+// SaveFileUpload stores an uploaded data file in the data directory and returns
+// its path relative to that directory, to be used as the Database of a file datasource
+func SaveFileUpload(name string, r io.Reader) (string, int64, error) {
+	cfg := currentFileConfig()
+	if cfg.Dir == "" {
+		return "", 0, ErrFilesDisabled
+	}
+
+	name = filepath.Base(filepath.Clean("/" + name))
+	if fileFormatOf(name) == "" {
+		return "", 0, ErrUnsupportedFileFormat
+	}
+
+	rel := filepath.Join(fileUploadDir, uuid.NewString(), name)
+	dest := filepath.Join(cfg.Dir, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return "", 0, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create upload file: %w", err)
+	}
+
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one
+	src := r
+	if cfg.MaxFileSize > 0 {
+		src = io.LimitReader(r, cfg.MaxFileSize+1)
+	}
+	size, err := io.Copy(f, src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && cfg.MaxFileSize > 0 && size > cfg.MaxFileSize {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		_ = os.RemoveAll(filepath.Dir(dest))
+		if errors.Is(err, ErrFileTooLarge) {
+			return "", 0, err
+		}
+		return "", 0, fmt.Errorf("failed to write upload file: %w", err)
+	}
+
+	return rel, size, nil
+}
+
+// Open loads the data files into an in-memory SQLite database, one table per
+// file, and opens it read-only. The database lives as long as the anchor
+// connection the returned closer releases.
+func (fileDialect) Open(config *ConnectionConfig) (*sql.DB, io.Closer, error) {
+	files, err := listDataFiles(config.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The shared-cache database is reachable by name from the whole process,
+	// so the name is random rather than guessable
+	name := fmt.Sprintf("file:dwfile_%s?mode=memory&cache=shared", strings.ReplaceAll(uuid.NewString(), "-", ""))
+
+	loader, err := sql.Open(sqliteDriverName, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	anchor, err := loader.Conn(context.Background())
+	if err != nil {
+		loader.Close()
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	memory := &memoryDatabase{loader: loader, anchor: anchor}
+
+	tableNames := make(map[string]bool)
+	for _, file := range files {
+		table, err := readDataFile(file)
+		if err != nil {
+			memory.Close()
+			return nil, nil, fmt.Errorf("failed to load %s: %w", filepath.Base(file), err)
+		}
+		table.name = uniqueName(tableNameOf(file), tableNames)
+		if err := loadTable(anchor, table); err != nil {
+			memory.Close()
+			return nil, nil, fmt.Errorf("failed to load %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	// Fill sqlite_stat1, which GetSchema reports the row counts from
+	if _, err := anchor.ExecContext(context.Background(), "ANALYZE"); err != nil {
+		memory.Close()
+		return nil, nil, fmt.Errorf("failed to analyze data files: %w", err)
+	}
+
+	db, err := sql.Open(sqliteDriverName, name+"&_pragma=query_only(1)")
+	if err != nil {
+		memory.Close()
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, memory, nil
+}
+
+// DataVersion identifies the data files by name, size and modification time,
+// so pooled connections reload them once they change
+func (fileDialect) DataVersion(config *ConnectionConfig) string {
+	files, err := listDataFiles(config.Database)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// memoryDatabase keeps a shared-cache in-memory database alive
+type memoryDatabase struct {
+	loader *sql.DB
+	anchor *sql.Conn
+}
+
+func (m *memoryDatabase) Close() error {
+	err := m.anchor.Close()
+	if closeErr := m.loader.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// listDataFiles returns the data file at path, or the data files directly inside
+// the directory at path, sorted by name
+func listDataFiles(path string) ([]string, error) {
+	resolved, err := ResolveFilePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file datasource path: %w", err)
+	}
+	var files []string
+	if !info.IsDir() {
+		files = []string{resolved}
+	} else {
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || fileFormatOf(entry.Name()) == "" {
+				continue
+			}
+			// Entries may be symlinks, which must stay inside the data directory as well
+			file, err := ResolveFilePath(filepath.Join(resolved, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		sort.Strings(files)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no csv, ndjson or parquet files found in %s", path)
+	}
+
+	if maxSize := currentFileConfig().MaxFileSize; maxSize > 0 {
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat data file: %w", err)
+			}
+			if info.Size() > maxSize {
+				return nil, fmt.Errorf("%s: %w", filepath.Base(file), ErrFileTooLarge)
+			}
+		}
+	}
+	return files, nil
+}
+
+// loadTable creates the table and inserts its rows in a single transaction
+func loadTable(conn *sql.Conn, table *fileTable) error {
+	ctx := context.Background()
+
+	columns := make([]string, len(table.columns))
+	placeholders := make([]string, len(table.columns))
+	for i, col := range table.columns {
+		columns[i] = quoteIdent(col.name) + " " + col.typ
+		placeholders[i] = "?"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table.name), strings.Join(columns, ", "))); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(table.name), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range table.rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// identPattern matches the characters not allowed in generated table and column names
+var identPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// tableNameOf derives a table name from the name of a data file
+func tableNameOf(file string) string {
+	base := filepath.Base(file)
+	return sanitizeIdent(strings.TrimSuffix(base, filepath.Ext(base)), "data")
+}
+
+// sanitizeIdent turns s into a name usable without quoting, or fallback when nothing is left of it
+func sanitizeIdent(s, fallback string) string {
+	name := strings.Trim(identPattern.ReplaceAllString(s, "_"), "_")
+	if name == "" {
+		return fallback
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// uniqueName returns name, suffixed with _2, _3, ... if it was already used
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// quoteIdent quotes a SQLite identifier
+func quoteIdent(name string) string {
+	return quoteWith(name, `"`, `"`)
+}
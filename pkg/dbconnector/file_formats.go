@@ -0,0 +1,507 @@
+package dbconnector
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats of the data files a file datasource can load
+const (
+	fileFormatCSV     = "csv"
+	fileFormatJSON    = "json"
+	fileFormatParquet = "parquet"
+)
+
+// SQLite column types of loaded tables
+const (
+	columnTypeInteger  = "INTEGER"
+	columnTypeReal     = "REAL"
+	columnTypeText     = "TEXT"
+	columnTypeBlob     = "BLOB"
+	columnTypeBoolean  = "BOOLEAN"
+	columnTypeDate     = "DATE"
+	columnTypeDateTime = "DATETIME"
+)
+
+// fileTable is the contents of a data file, ready to be loaded as a table
+type fileTable struct {
+	name    string
+	columns []fileColumn
+	rows    [][]interface{}
+}
+
+type fileColumn struct {
+	name string
+	typ  string
+}
+
+// fileFormatOf returns the format of a data file from its extension, or "" if it is not supported
+func fileFormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return fileFormatCSV
+	case ".ndjson", ".jsonl", ".json":
+		return fileFormatJSON
+	case ".parquet":
+		return fileFormatParquet
+	default:
+		return ""
+	}
+}
+
+// readDataFile reads a data file into a table
+func readDataFile(path string) (*fileTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch fileFormatOf(path) {
+	case fileFormatCSV:
+		return readCSV(f)
+	case fileFormatJSON:
+		return readJSON(f)
+	case fileFormatParquet:
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return readParquet(f, info.Size())
+	default:
+		return nil, ErrUnsupportedFileFormat
+	}
+}
+
+// columnNames makes header names unique and usable as column names
+func columnNames(header []string) []string {
+	used := make(map[string]bool, len(header))
+	names := make([]string, len(header))
+	for i, h := range header {
+		names[i] = uniqueName(sanitizeIdent(h, fmt.Sprintf("column_%d", i+1)), used)
+	}
+	return names
+}
+
+// readCSV reads a CSV file whose first record is the header. Columns whose
+// values all parse as integers or numbers are typed INTEGER or REAL; empty
+// values in them load as NULL.
+func readCSV(r io.Reader) (*fileTable, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > len(header) {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %d fields, header has %d", line, len(record), len(header))
+		}
+		records = append(records, record)
+	}
+
+	names := columnNames(header)
+	table := &fileTable{columns: make([]fileColumn, len(names))}
+	for i, name := range names {
+		table.columns[i] = fileColumn{name: name, typ: inferCSVType(records, i)}
+	}
+
+	table.rows = make([][]interface{}, len(records))
+	for r, record := range records {
+		row := make([]interface{}, len(names))
+		for i, col := range table.columns {
+			if i >= len(record) {
+				continue
+			}
+			row[i] = csvValue(record[i], col.typ)
+		}
+		table.rows[r] = row
+	}
+	return table, nil
+}
+
+// inferCSVType types column i from its non-empty values
+func inferCSVType(records [][]string, i int) string {
+	typ := ""
+	for _, record := range records {
+		if i >= len(record) || record[i] == "" {
+			continue
+		}
+		v := record[i]
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if typ == "" {
+				typ = columnTypeInteger
+			}
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			typ = columnTypeReal
+			continue
+		}
+		return columnTypeText
+	}
+	if typ == "" {
+		return columnTypeText
+	}
+	return typ
+}
+
+func csvValue(v, typ string) interface{} {
+	switch typ {
+	case columnTypeInteger:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+		return nil
+	case columnTypeReal:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+		return nil
+	default:
+		return v
+	}
+}
+
+// readJSON reads newline-delimited JSON objects, or a JSON array of objects.
+// Columns are the union of the object keys in order of appearance; nested
+// objects and arrays load as JSON text.
+func readJSON(r io.Reader) (*fileTable, error) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil, errors.New("json file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	isArray := first == '['
+	if isArray {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []string
+	index := make(map[string]int)
+	var objects []map[string]interface{}
+	for dec.More() {
+		obj, order, err := decodeObject(dec)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", len(objects)+1, err)
+		}
+		for _, key := range order {
+			if _, ok := index[key]; !ok {
+				index[key] = len(keys)
+				keys = append(keys, key)
+			}
+		}
+		objects = append(objects, obj)
+	}
+	if isArray {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("json file has no fields")
+	}
+
+	names := columnNames(keys)
+	table := &fileTable{columns: make([]fileColumn, len(keys))}
+	for i, key := range keys {
+		table.columns[i] = fileColumn{name: names[i], typ: inferJSONType(objects, key)}
+	}
+
+	table.rows = make([][]interface{}, len(objects))
+	for r, obj := range objects {
+		row := make([]interface{}, len(keys))
+		for i, key := range keys {
+			row[i] = jsonValue(obj[key], table.columns[i].typ)
+		}
+		table.rows[r] = row
+	}
+	return table, nil
+}
+
+// peekNonSpace returns the first byte that is not white space without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+		case 0xEF:
+			// Skip a UTF-8 byte order mark
+			bom, err := br.Peek(3)
+			if err != nil || string(bom) != "\ufeff" {
+				return b[0], nil
+			}
+			_, _ = br.Discard(3)
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// decodeObject decodes the next JSON object and returns its keys in order
+func decodeObject(dec *json.Decoder) (map[string]interface{}, []string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, nil, errors.New("expected a JSON object")
+	}
+
+	obj := make(map[string]interface{})
+	var order []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := obj[key]; !ok {
+			order = append(order, key)
+		}
+		obj[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return obj, order, nil
+}
+
+// inferJSONType types a column from the values of key. Columns mixing kinds of values are TEXT.
+func inferJSONType(objects []map[string]interface{}, key string) string {
+	typ := ""
+	for _, obj := range objects {
+		var valueType string
+		switch v := obj[key].(type) {
+		case nil:
+			continue
+		case bool:
+			valueType = columnTypeBoolean
+		case json.Number:
+			valueType = columnTypeReal
+			if _, err := v.Int64(); err == nil {
+				valueType = columnTypeInteger
+			}
+		default:
+			return columnTypeText
+		}
+
+		switch {
+		case typ == "" || typ == valueType:
+			typ = valueType
+		case typ == columnTypeInteger && valueType == columnTypeReal, typ == columnTypeReal && valueType == columnTypeInteger:
+			typ = columnTypeReal
+		default:
+			return columnTypeText
+		}
+	}
+	if typ == "" {
+		return columnTypeText
+	}
+	return typ
+}
+
+func jsonValue(v interface{}, typ string) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case bool:
+		if typ == columnTypeText {
+			return strconv.FormatBool(v)
+		}
+		return v
+	case json.Number:
+		switch typ {
+		case columnTypeInteger:
+			n, _ := v.Int64()
+			return n
+		case columnTypeReal:
+			f, _ := v.Float64()
+			return f
+		default:
+			return v.String()
+		}
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return string(encoded)
+	}
+}
+
+// readParquet reads a Parquet file. Nested and repeated columns load as JSON
+// text, dates and timestamps as ISO 8601 text.
+func readParquet(r io.ReaderAt, size int64) (*fileTable, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+	reader := parquet.NewReader(file)
+	defer reader.Close()
+
+	fields := file.Schema().Fields()
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.Name()
+	}
+
+	names := columnNames(keys)
+	table := &fileTable{columns: make([]fileColumn, len(fields))}
+	for i, field := range fields {
+		table.columns[i] = fileColumn{name: names[i], typ: parquetColumnType(field)}
+	}
+
+	for {
+		record := make(map[string]interface{}, len(fields))
+		if err := reader.Read(&record); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		row := make([]interface{}, len(fields))
+		for i, field := range fields {
+			row[i] = parquetValue(field, record[field.Name()])
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table, nil
+}
+
+// parquetColumnType maps a top-level Parquet field to a column type
+func parquetColumnType(field parquet.Field) string {
+	if !field.Leaf() || field.Repeated() {
+		return columnTypeText
+	}
+
+	if lt := field.Type().LogicalType(); lt != nil {
+		switch {
+		case lt.Date != nil:
+			return columnTypeDate
+		case lt.Timestamp != nil:
+			return columnTypeDateTime
+		case lt.UTF8 != nil, lt.Enum != nil, lt.Json != nil, lt.UUID != nil, lt.Decimal != nil, lt.Time != nil:
+			return columnTypeText
+		}
+	}
+
+	switch field.Type().Kind() {
+	case parquet.Boolean:
+		return columnTypeBoolean
+	case parquet.Int32, parquet.Int64:
+		return columnTypeInteger
+	case parquet.Float, parquet.Double:
+		return columnTypeReal
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return columnTypeBlob
+	default:
+		return columnTypeText
+	}
+}
+
+// parquetValue converts a value read from a top-level Parquet field for loading
+func parquetValue(field parquet.Field, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if !field.Leaf() || field.Repeated() {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return string(encoded)
+	}
+
+	if lt := field.Type().LogicalType(); lt != nil {
+		switch {
+		case lt.Date != nil:
+			if days, ok := toInt64(v); ok {
+				return time.Unix(days*86400, 0).UTC().Format("2006-01-02")
+			}
+		case lt.Timestamp != nil:
+			if n, ok := toInt64(v); ok {
+				var t time.Time
+				switch {
+				case lt.Timestamp.Unit.Millis != nil:
+					t = time.UnixMilli(n)
+				case lt.Timestamp.Unit.Micros != nil:
+					t = time.UnixMicro(n)
+				default:
+					t = time.Unix(0, n)
+				}
+				return t.UTC().Format(time.RFC3339Nano)
+			}
+		case lt.UTF8 != nil, lt.Enum != nil, lt.Json != nil:
+			if b, ok := v.([]byte); ok {
+				return string(b)
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case string, []byte, bool, int32, int64, float32, float64:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,245 @@
+package dbconnector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parquetOrder struct {
+	ID       int64     `parquet:"id"`
+	Customer string    `parquet:"customer,optional"`
+	Total    float64   `parquet:"total"`
+	Paid     bool      `parquet:"paid"`
+	PlacedAt time.Time `parquet:"placed_at,timestamp(millisecond)"`
+	Tags     []string  `parquet:"tags,list"`
+}
+
+// setupFiles creates a data directory holding one file of each format
+func setupFiles(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	sales := filepath.Join(dir, "sales")
+	require.NoError(t, os.Mkdir(sales, 0o750))
+
+	require.NoError(t, os.WriteFile(filepath.Join(sales, "users.csv"), []byte(
+		"\ufeffid,name,score,Joined At\n1,alice,9.5,2024-01-02\n2,bob,,2024-02-03\n3,\"carol, jr\",7,\n"), 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(sales, "events.ndjson"), []byte(
+		`{"id": 1, "kind": "click", "meta": {"x": 1}}`+"\n"+
+			`{"id": 2, "kind": "view", "amount": 1.5, "ok": true}`+"\n"), 0o600))
+
+	var buf bytes.Buffer
+	placedAt := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	require.NoError(t, parquet.Write(&buf, []parquetOrder{
+		{ID: 10, Customer: "alice", Total: 12.5, Paid: true, PlacedAt: placedAt, Tags: []string{"a", "b"}},
+		{ID: 11, Total: 3, PlacedAt: placedAt},
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(sales, "orders.parquet"), buf.Bytes(), 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(sales, "notes.txt"), []byte("ignored"), 0o600))
+
+	ConfigureFiles(FileConfig{Dir: dir})
+	t.Cleanup(func() { ConfigureFiles(FileConfig{}) })
+	return dir
+}
+
+func connectFiles(t *testing.T, path string) *Connector {
+	t.Helper()
+
+	connector := NewConnector(&ConnectionConfig{Type: File, Database: path})
+	require.NoError(t, connector.Connect())
+	t.Cleanup(func() { connector.Close() })
+	return connector
+}
+
+func TestConnector_File_Schema(t *testing.T) {
+	setupFiles(t)
+	connector := connectFiles(t, "sales")
+
+	tables, err := connector.GetSchema()
+	require.NoError(t, err)
+	require.Len(t, tables, 3)
+	assert.Equal(t, "events", tables[0].Name)
+	assert.Equal(t, "orders", tables[1].Name)
+	assert.Equal(t, "users", tables[2].Name)
+	assert.Equal(t, TableKindTable, tables[2].Kind)
+	require.NotNil(t, tables[2].RowEstimate)
+	assert.Equal(t, int64(3), *tables[2].RowEstimate)
+
+	columns, err := connector.GetTableSchema("main", "users")
+	require.NoError(t, err)
+	assert.Equal(t, []ColumnInfo{
+		{Name: "id", Type: "INTEGER", Nullable: true, ParamType: ParamTypeInteger},
+		{Name: "name", Type: "TEXT", Nullable: true, ParamType: ParamTypeString},
+		{Name: "score", Type: "REAL", Nullable: true, ParamType: ParamTypeNumber},
+		{Name: "Joined_At", Type: "TEXT", Nullable: true, ParamType: ParamTypeString},
+	}, columns)
+
+	columns, err = connector.GetTableSchema("main", "events")
+	require.NoError(t, err)
+	types := make(map[string]string)
+	for _, col := range columns {
+		types[col.Name] = col.Type
+	}
+	assert.Equal(t, map[string]string{
+		"id": "INTEGER", "kind": "TEXT", "meta": "TEXT", "amount": "REAL", "ok": "BOOLEAN",
+	}, types)
+}
+
+func TestConnector_File_Query(t *testing.T) {
+	setupFiles(t)
+	connector := connectFiles(t, "sales")
+
+	result, err := connector.ExecuteQueryWithColumns(
+		"SELECT name, score FROM users WHERE id >= :min ORDER BY id",
+		map[string]interface{}{"min": 2},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "score"}, result.Columns)
+	require.Len(t, result.Data, 2)
+	assert.Equal(t, "bob", result.Data[0]["name"])
+	assert.Nil(t, result.Data[0]["score"])
+	assert.Equal(t, "carol, jr", result.Data[1]["name"])
+
+	result, err = connector.ExecuteQueryWithColumns("SELECT meta FROM events WHERE id = :id", map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, `{"x":1}`, result.Data[0]["meta"])
+}
+
+func TestConnector_File_Parquet(t *testing.T) {
+	setupFiles(t)
+	connector := connectFiles(t, "sales/orders.parquet")
+
+	result, err := connector.ExecuteQueryWithColumns("SELECT * FROM orders ORDER BY id", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "customer", "total", "paid", "placed_at", "tags"}, result.Columns)
+	require.Len(t, result.Data, 2)
+	assert.EqualValues(t, 10, result.Data[0]["id"])
+	assert.Equal(t, "alice", result.Data[0]["customer"])
+	assert.Equal(t, 12.5, result.Data[0]["total"])
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC), result.Data[0]["placed_at"])
+	assert.Contains(t, result.Data[0]["tags"], `"a"`)
+	assert.Nil(t, result.Data[1]["customer"])
+}
+
+func TestConnector_File_ReadOnly(t *testing.T) {
+	setupFiles(t)
+	connector := connectFiles(t, "sales")
+
+	_, err := connector.Exec("DELETE FROM users")
+	assert.Error(t, err)
+}
+
+func TestConnector_File_SeparateDatabases(t *testing.T) {
+	setupFiles(t)
+	first := connectFiles(t, "sales/users.csv")
+	second := connectFiles(t, "sales/events.ndjson")
+
+	tables, err := first.GetSchema()
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	assert.Equal(t, "users", tables[0].Name)
+
+	tables, err = second.GetSchema()
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	assert.Equal(t, "events", tables[0].Name)
+}
+
+func TestConnector_File_NoAttach(t *testing.T) {
+	setupFiles(t)
+	first := connectFiles(t, "sales/users.csv")
+	connectFiles(t, "sales/events.ndjson")
+
+	// Another datasource's in-memory database cannot be opened by name
+	_, err := first.Exec("ATTACH DATABASE 'file:dwfile_2?mode=memory&cache=shared' AS other")
+	assert.Error(t, err)
+	_, err = first.ExecuteQueryWithColumns("SELECT 1; ATTACH DATABASE ':memory:' AS other", nil)
+	assert.Error(t, err)
+}
+
+func TestPool_File_ReloadsChangedFiles(t *testing.T) {
+	dir := setupFiles(t)
+	file := filepath.Join(dir, "sales", "users.csv")
+	pool := NewPool(DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	config := &ConnectionConfig{Type: File, Database: "sales/users.csv"}
+
+	count := func() int64 {
+		connector, err := pool.Get("ds", config)
+		require.NoError(t, err)
+		result, err := connector.ExecuteQueryWithColumns("SELECT COUNT(*) AS n FROM users", nil)
+		require.NoError(t, err)
+		return result.Data[0]["n"].(int64)
+	}
+	assert.Equal(t, int64(3), count())
+
+	require.NoError(t, os.WriteFile(file, []byte("id,name\n1,alice\n"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(file, later, later))
+	assert.Equal(t, int64(1), count(), "changed files are loaded again")
+}
+
+func TestResolveFilePath_Sandbox(t *testing.T) {
+	dir := setupFiles(t)
+
+	outside := filepath.Join(t.TempDir(), "other.csv")
+	require.NoError(t, os.WriteFile(outside, []byte("a\n1\n"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.csv")))
+
+	_, err := ResolveFilePath(outside)
+	assert.ErrorIs(t, err, ErrFilePathNotAllowed)
+
+	_, err = ResolveFilePath("link.csv")
+	assert.ErrorIs(t, err, ErrFilePathNotAllowed)
+
+	_, err = ResolveFilePath("sales/notes.txt")
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+
+	_, err = ResolveFilePath("missing.csv")
+	assert.Error(t, err)
+
+	ConfigureFiles(FileConfig{})
+	_, err = ResolveFilePath("sales")
+	assert.ErrorIs(t, err, ErrFilesDisabled)
+}
+
+func TestConnector_File_TooLarge(t *testing.T) {
+	dir := setupFiles(t)
+	ConfigureFiles(FileConfig{Dir: dir, MaxFileSize: 16})
+
+	err := NewConnector(&ConnectionConfig{Type: File, Database: "sales"}).Connect()
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+}
+
+func TestSaveFileUpload(t *testing.T) {
+	dir := setupFiles(t)
+
+	path, size, err := SaveFileUpload("../../report.csv", strings.NewReader("a,b\n1,2\n"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 8, size)
+	assert.True(t, strings.HasPrefix(path, "uploads"+string(filepath.Separator)))
+	assert.Equal(t, "report.csv", filepath.Base(path))
+
+	connector := connectFiles(t, path)
+	result, err := connector.ExecuteQueryWithColumns("SELECT a + b AS total FROM report", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, result.Data[0]["total"])
+
+	_, _, err = SaveFileUpload("report.exe", strings.NewReader("x"))
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+
+	ConfigureFiles(FileConfig{Dir: dir, MaxFileSize: 4})
+	_, _, err = SaveFileUpload("big.csv", strings.NewReader("a,b\n1,2\n"))
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+}
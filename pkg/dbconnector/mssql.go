@@ -0,0 +1,285 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+)
+
+func init() {
+	Register(mssqlDialect{})
+	RegisterAlias("mssql", MSSQL)
+}
+
+type mssqlDialect struct{}
+
+func (mssqlDialect) Name() DBType       { return MSSQL }
+func (mssqlDialect) DriverName() string { return "sqlserver" }
+
+// BuildDSN returns a go-mssqldb URL. Without an SSL mode only the login is
+// encrypted, as the driver does by default; the certificates of the other
+// modes are applied by NewDriverConnector.
+func (mssqlDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	dsn := fmt.Sprintf(
+		"sqlserver://%s@%s:%d?database=%s",
+		url.UserPassword(config.Username, config.Password), config.Host, config.Port, url.QueryEscape(config.Database),
+	)
+
+	switch config.SSLMode {
+	case "", SSLModeDisable:
+	case SSLModeRequire:
+		dsn += "&encrypt=true&TrustServerCertificate=true"
+	case SSLModeVerifyCA, SSLModeVerifyFull:
+		dsn += "&encrypt=true&hostNameInCertificate=" + url.QueryEscape(tlsServerName(config))
+	default:
+		return "", fmt.Errorf("unsupported ssl mode for sqlserver: %s", config.SSLMode)
+	}
+	for _, key := range sortedKeys(config.Options) {
+		dsn += "&" + url.QueryEscape(key) + "=" + url.QueryEscape(config.Options[key])
+	}
+	return dsn, nil
+}
+
+// mssqlDriverOptions are the connection parameters a datasource may set, in
+// the lower case go-mssqldb matches them in. Encryption is set by SSLMode.
+var mssqlDriverOptions = []string{"app name", "applicationintent", "connection timeout", "dial timeout", "keepalive", "packet size"}
+
+func (mssqlDialect) ValidateOptions(options map[string]string) error {
+	return checkOptionNames(MSSQL, options, mssqlDriverOptions...)
+}
+
+// mssqlSessionStatements are the session initialization statements a
+// datasource may run: SET options that change how queries run, not what they
+// may change. EXECUTE AS and sp_setapprole are left out.
+var mssqlSessionStatements = newSessionAllowlist(
+	`SET\s+LOCK_TIMEOUT\s+-?\d+`,
+	`SET\s+(?:LANGUAGE|DATEFORMAT)\s+`+sessionValue,
+	`SET\s+DATEFIRST\s+[1-7]`,
+	`SET\s+DEADLOCK_PRIORITY\s+(?:LOW|NORMAL|HIGH|-?\d+)`,
+	`SET\s+(?:QUERY_GOVERNOR_COST_LIMIT|TEXTSIZE|ROWCOUNT)\s+\d+`,
+	`SET\s+`+sessionSettings("ANSI_NULLS", "ANSI_PADDING", "ANSI_WARNINGS", "ARITHABORT", "CONCAT_NULL_YIELDS_NULL",
+		"NOCOUNT", "NUMERIC_ROUNDABORT", "QUOTED_IDENTIFIER", "XACT_ABORT")+`\s+(?:ON|OFF)`,
+	`SET\s+TRANSACTION\s+ISOLATION\s+LEVEL\s+(?:READ\s+UNCOMMITTED|READ\s+COMMITTED|REPEATABLE\s+READ|SNAPSHOT|SERIALIZABLE)`,
+)
+
+func (mssqlDialect) ValidateSessionStatement(stmt string) error {
+	return mssqlSessionStatements.validate(MSSQL, stmt)
+}
+
+// NewDriverConnector sets the driver's TLS configuration directly, as its
+// DSN only takes a CA certificate file and no client certificate
+func (d mssqlDialect) NewDriverConnector(config *ConnectionConfig) (driver.Connector, error) {
+	dsn, err := d.BuildDSN(config)
+	if err != nil {
+		return nil, err
+	}
+	params, err := msdsn.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		params.TLSConfig = tlsConfig
+	}
+	return mssql.NewConnectorConfig(params), nil
+}
+
+func (mssqlDialect) VersionQuery() string {
+	return "SELECT CAST(SERVERPROPERTY('ProductVersion') AS NVARCHAR(128)) + ' ' + CAST(SERVERPROPERTY('Edition') AS NVARCHAR(128))"
+}
+
+// IsUnknownDatabase reports error 4060, the login's database cannot be opened
+func (mssqlDialect) IsUnknownDatabase(err error) bool {
+	var value mssql.Error
+	if errors.As(err, &value) {
+		return value.Number == 4060
+	}
+	var pointer *mssql.Error
+	return errors.As(err, &pointer) && pointer.Number == 4060
+}
+
+// ReplicationLag reads the lag of the local Always On secondary replica of the
+// database; databases outside an availability group report none
+func (mssqlDialect) ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	return queryLagSeconds(ctx, db, `SELECT CAST(MAX(secondary_lag_seconds) AS float)
+FROM sys.dm_hadr_database_replica_states
+WHERE is_local = 1 AND database_id = DB_ID()`)
+}
+
+// UnreadableSchemas lists the schemas of the database the user cannot select
+// from, leaving out the schemas of the fixed database roles
+func (mssqlDialect) UnreadableSchemas(ctx context.Context, db *sql.DB) ([]string, error) {
+	return queryStrings(ctx, db, `
+		SELECT s.name
+		FROM sys.schemas s
+		WHERE s.schema_id < 16384
+		  AND s.name NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest')
+		  AND HAS_PERMS_BY_NAME(QUOTENAME(s.name), 'SCHEMA', 'SELECT') = 0
+		ORDER BY s.name`)
+}
+
+func (mssqlDialect) Bind(ctx context.Context, query string, params map[string]interface{}, _ map[string]string) (context.Context, string, []interface{}, error) {
+	converted, args := BindNumbered(query, params, func(n int) string { return fmt.Sprintf("@p%d", n) })
+	return ctx, converted, args, nil
+}
+
+func (mssqlDialect) QuoteIdentifier(name string) string { return quoteWith(name, "[", "]") }
+
+// LimitRows limits a SELECT with TOP, as SQL Server has no LIMIT clause
+func (mssqlDialect) LimitRows(n int) (string, string) { return fmt.Sprintf("TOP %d", n), "" }
+
+// ReadOnlySession returns nil: SQL Server has no read-only session setting,
+// access is limited through the login's permissions
+func (mssqlDialect) ReadOnlySession() []string { return nil }
+
+func (mssqlDialect) ParamType(columnType string) string {
+	if columnType == "bit" {
+		return ParamTypeBoolean
+	}
+	return genericParamType(columnType)
+}
+
+// mssqlObjects restricts catalog queries to user tables and views
+const mssqlObjects = `o.type IN ('U', 'V') AND o.is_ms_shipped = 0`
+
+func (mssqlDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	// Indexed views, which have a clustered index, are SQL Server's materialized views
+	var tables []TableInfo
+	err := forEachRow(ctx, db, `
+		SELECT s.name, o.name,
+			   CASE
+				   WHEN o.type = 'U' THEN 'table'
+				   WHEN EXISTS (SELECT 1 FROM sys.indexes i WHERE i.object_id = o.object_id AND i.index_id = 1) THEN 'materialized_view'
+				   ELSE 'view'
+			   END,
+			   COALESCE(CAST(ep.value AS nvarchar(max)), ''),
+			   (SELECT SUM(p.rows) FROM sys.partitions p WHERE p.object_id = o.object_id AND p.index_id IN (0, 1))
+		FROM sys.objects o
+		JOIN sys.schemas s ON s.schema_id = o.schema_id
+		LEFT JOIN sys.extended_properties ep
+			ON ep.class = 1 AND ep.major_id = o.object_id AND ep.minor_id = 0 AND ep.name = 'MS_Description'
+		WHERE `+mssqlObjects+`
+		ORDER BY s.name, o.name
+	`, nil, func(rows *sql.Rows) error {
+		var t TableInfo
+		var estimate sql.NullInt64
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Kind, &t.Comment, &estimate); err != nil {
+			return err
+		}
+		t.RowEstimate = rowEstimate(estimate)
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanMSSQLColumns(ctx, db, "", nil, catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT s.name, o.name, fk.name, rs.name, ro.name, c.name, rc.name
+		FROM sys.foreign_keys fk
+		JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+		JOIN sys.objects o ON o.object_id = fk.parent_object_id
+		JOIN sys.schemas s ON s.schema_id = o.schema_id
+		JOIN sys.objects ro ON ro.object_id = fk.referenced_object_id
+		JOIN sys.schemas rs ON rs.schema_id = ro.schema_id
+		JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+		JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+		WHERE `+mssqlObjects+`
+		ORDER BY s.name, o.name, fk.name, fkc.constraint_column_id
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, refSchema, refTable, column, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &refSchema, &refTable, &column, &refColumn); err != nil {
+			return err
+		}
+		catalog.addForeignKeyColumn(schema, table, name, refSchema, refTable, column, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT s.name, o.name, i.name, i.is_unique, i.is_primary_key, c.name
+		FROM sys.indexes i
+		JOIN sys.objects o ON o.object_id = i.object_id
+		JOIN sys.schemas s ON s.schema_id = o.schema_id
+		JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+		JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+		WHERE i.name IS NOT NULL AND ic.is_included_column = 0 AND `+mssqlObjects+`
+		ORDER BY s.name, o.name, i.name, ic.key_ordinal
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, column string
+		var unique, primary bool
+		if err := rows.Scan(&schema, &table, &name, &unique, &primary, &column); err != nil {
+			return err
+		}
+		catalog.addIndexColumn(schema, table, name, unique, primary, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func (mssqlDialect) GetColumns(db *sql.DB, _ *ConnectionConfig, schema, tableName string) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+	err := scanMSSQLColumns(context.Background(), db, "AND s.name = @p1 AND o.name = @p2",
+		[]interface{}{schema, tableName}, func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanMSSQLColumns reads the columns of the tables and views matching filter
+func scanMSSQLColumns(ctx context.Context, db *sql.DB, filter string, args []interface{}, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT s.name, o.name, c.name, TYPE_NAME(c.user_type_id), c.is_nullable,
+			   CASE WHEN EXISTS (
+				   SELECT 1 FROM sys.index_columns ic
+				   JOIN sys.indexes i ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+				   WHERE i.is_primary_key = 1 AND ic.object_id = c.object_id AND ic.column_id = c.column_id
+			   ) THEN 1 ELSE 0 END,
+			   OBJECT_DEFINITION(c.default_object_id),
+			   COALESCE(CAST(ep.value AS nvarchar(max)), '')
+		FROM sys.columns c
+		JOIN sys.objects o ON o.object_id = c.object_id
+		JOIN sys.schemas s ON s.schema_id = o.schema_id
+		LEFT JOIN sys.extended_properties ep
+			ON ep.class = 1 AND ep.major_id = c.object_id AND ep.minor_id = c.column_id AND ep.name = 'MS_Description'
+		WHERE `+mssqlObjects+` `+filter+`
+		ORDER BY s.name, o.name, c.column_id
+	`, args, func(rows *sql.Rows) error {
+		var schema, table string
+		var column ColumnInfo
+		var isPK int
+		var def sql.NullString
+		if err := rows.Scan(&schema, &table, &column.Name, &column.Type, &column.Nullable, &isPK,
+			&def, &column.Comment); err != nil {
+			return err
+		}
+		column.PrimaryKey = isPK == 1
+		column.Default = nullableString(def)
+		add(schema, table, column)
+		return nil
+	})
+}
@@ -0,0 +1,359 @@
+package dbconnector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	Register(mysqlDialect{})
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() DBType       { return MySQL }
+func (mysqlDialect) DriverName() string { return "mysql" }
+
+func (mysqlDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?parseTime=true",
+		config.Username, config.Password, config.Host, config.Port, config.Database,
+	)
+
+	tlsKey, err := registerMySQLTLS(config)
+	if err != nil {
+		return "", err
+	}
+	if tlsKey != "" {
+		dsn += "&tls=" + tlsKey
+	}
+	for _, key := range sortedKeys(config.Options) {
+		dsn += "&" + key + "=" + url.QueryEscape(config.Options[key])
+	}
+	return dsn, nil
+}
+
+// mysqlDriverOptions are the DSN parameters a datasource may set. Parameters
+// such as allowAllFiles or multiStatements are left out on purpose.
+var mysqlDriverOptions = []string{"charset", "collation", "loc", "timeout", "readTimeout", "writeTimeout"}
+
+func (mysqlDialect) ValidateOptions(options map[string]string) error {
+	return checkOptionNames(MySQL, options, mysqlDriverOptions...)
+}
+
+// mysqlSessionStatements are the session initialization statements a
+// datasource may run. Variables such as transaction_read_only are left out,
+// as they could undo the read-only session.
+var mysqlSessionStatements = newSessionAllowlist(
+	`SET\s+(?:SESSION\s+|@@SESSION\.|@@)?`+sessionSettings("time_zone", "sql_mode", "max_execution_time",
+		"lock_wait_timeout", "innodb_lock_wait_timeout", "group_concat_max_len", "sql_select_limit",
+		"div_precision_increment")+`\s*=\s*`+sessionValue,
+	`SET\s+NAMES\s+`+sessionValue+`(?:\s+COLLATE\s+`+sessionValue+`)?`,
+	`SET\s+ROLE\s+`+mysqlRole+`(?:\s*,\s*`+mysqlRole+`)*`,
+	`SET\s+SESSION\s+TRANSACTION\s+ISOLATION\s+LEVEL\s+(?:READ\s+UNCOMMITTED|READ\s+COMMITTED|REPEATABLE\s+READ|SERIALIZABLE)`,
+)
+
+// mysqlRole matches a role name with an optional host, or DEFAULT, NONE or ALL
+const mysqlRole = `(?:` + sessionIdent + `|` + sessionString + `)(?:@(?:` + sessionIdent + `|` + sessionString + `))?`
+
+func (mysqlDialect) ValidateSessionStatement(stmt string) error {
+	return mysqlSessionStatements.validate(MySQL, stmt)
+}
+
+// registerMySQLTLS registers the TLS configuration of a connection with the
+// driver, which looks it up by the DSN's tls parameter, and returns its key.
+// Keys are derived from the settings, so connections with the same settings
+// share one registration.
+func registerMySQLTLS(config *ConnectionConfig) (string, error) {
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil || tlsConfig == nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(config.SSLMode + "|" + tlsServerName(config) + "|" + config.TLS.fingerprint()))
+	key := "dataweaver-" + hex.EncodeToString(sum[:8])
+	if err := mysql.RegisterTLSConfig(key, tlsConfig); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// MySQL capability flags of the SSL request
+const (
+	mysqlClientLongPassword     = 0x00000001
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSSL              = 0x00000800
+	mysqlClientSecureConnection = 0x00008000
+)
+
+// StartTLS reads the server greeting and answers it with an SSL request
+// packet, as clients do before sending credentials, then runs the handshake
+func (mysqlDialect) StartTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	greeting, err := readMySQLPacket(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server greeting: %w", err)
+	}
+	if len(greeting) > 3 && greeting[0] == 0xff {
+		// Refused before the handshake, e.g. a host that may not connect
+		return nil, fmt.Errorf("server refused connection: error %d: %s",
+			binary.LittleEndian.Uint16(greeting[1:3]), strings.TrimLeft(string(greeting[3:]), "#"))
+	}
+
+	// protocol version, NUL terminated server version, connection id, auth data, filler
+	end := bytes.IndexByte(greeting, 0)
+	if len(greeting) < 1 || end < 0 || len(greeting) < end+1+4+8+1+2 {
+		return nil, errors.New("malformed server greeting")
+	}
+	capabilities := binary.LittleEndian.Uint16(greeting[end+1+4+8+1:])
+	if capabilities&mysqlClientSSL == 0 {
+		return nil, errors.New("server does not support TLS")
+	}
+
+	// capability flags, max packet size, character set, 23 reserved bytes
+	request := make([]byte, 4+32)
+	request[0] = 32
+	request[3] = 1 // sequence id, answering the greeting
+	binary.LittleEndian.PutUint32(request[4:8], mysqlClientLongPassword|mysqlClientProtocol41|mysqlClientSSL|mysqlClientSecureConnection)
+	binary.LittleEndian.PutUint32(request[8:12], 1<<24-1)
+	request[12] = 45 // utf8mb4_general_ci
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// readMySQLPacket reads the payload of one protocol packet
+func readMySQLPacket(conn net.Conn) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (mysqlDialect) VersionQuery() string { return "SELECT VERSION()" }
+
+// IsUnknownDatabase reports error 1049, ER_BAD_DB_ERROR
+func (mysqlDialect) IsUnknownDatabase(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1049
+}
+
+// ReplicationLag reads Seconds_Behind_Source from SHOW REPLICA STATUS, or
+// Seconds_Behind_Master on servers before 8.0.22. Servers that are not
+// replicas return no status row.
+func (mysqlDialect) ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, name := range columns {
+		if name != "Seconds_Behind_Source" && name != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected %s %q", name, values[i])
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("replica status has no Seconds_Behind_Source column")
+}
+
+// UnreadableSchemas lists the schemas the user holds SELECT on neither
+// globally nor for the whole schema
+func (mysqlDialect) UnreadableSchemas(ctx context.Context, db *sql.DB) ([]string, error) {
+	// Grantees are written 'user'@'host', CURRENT_USER() as user@host
+	return queryStrings(ctx, db, `
+		SELECT s.SCHEMA_NAME
+		FROM information_schema.SCHEMATA s
+		CROSS JOIN (
+		  SELECT CONCAT("'", SUBSTRING_INDEX(CURRENT_USER(), '@', 1), "'@'",
+		                SUBSTRING_INDEX(CURRENT_USER(), '@', -1), "'") AS grantee
+		) u
+		WHERE s.SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+		  AND NOT EXISTS (
+		    SELECT 1 FROM information_schema.USER_PRIVILEGES p
+		    WHERE p.GRANTEE = u.grantee AND p.PRIVILEGE_TYPE = 'SELECT')
+		  AND NOT EXISTS (
+		    SELECT 1 FROM information_schema.SCHEMA_PRIVILEGES p
+		    WHERE p.GRANTEE = u.grantee AND p.TABLE_SCHEMA = s.SCHEMA_NAME AND p.PRIVILEGE_TYPE = 'SELECT')
+		ORDER BY s.SCHEMA_NAME`)
+}
+
+func (mysqlDialect) Bind(ctx context.Context, query string, params map[string]interface{}, _ map[string]string) (context.Context, string, []interface{}, error) {
+	converted, args := BindPositional(query, params, questionMark)
+	return ctx, converted, args, nil
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string { return quoteWith(name, "`", "`") }
+
+func (mysqlDialect) ReadOnlySession() []string {
+	return []string{"SET SESSION TRANSACTION READ ONLY"}
+}
+
+func (mysqlDialect) ParamType(columnType string) string {
+	// MySQL has no boolean type; BOOL columns are TINYINT(1)
+	if strings.EqualFold(columnType, "tinyint(1)") {
+		return ParamTypeBoolean
+	}
+	return genericParamType(columnType)
+}
+
+func (mysqlDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	var tables []TableInfo
+	err := forEachRow(ctx, db, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE, COALESCE(TABLE_COMMENT, ''), TABLE_ROWS
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE()
+		ORDER BY TABLE_NAME
+	`, nil, func(rows *sql.Rows) error {
+		var t TableInfo
+		var tableType string
+		var estimate sql.NullInt64
+		if err := rows.Scan(&t.Schema, &t.Name, &tableType, &t.Comment, &estimate); err != nil {
+			return err
+		}
+		t.Kind = TableKindTable
+		if strings.HasSuffix(tableType, "VIEW") {
+			// Views report the comment "VIEW" and no row count
+			t.Kind = TableKindView
+			t.Comment = ""
+		} else {
+			t.RowEstimate = rowEstimate(estimate)
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanMySQLColumns(ctx, db, "", nil, catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME,
+			   COLUMN_NAME, REFERENCED_COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, refSchema, refTable, column, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &refSchema, &refTable, &column, &refColumn); err != nil {
+			return err
+		}
+		catalog.addForeignKeyColumn(schema, table, name, refSchema, refTable, column, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Functional index keys have no COLUMN_NAME
+	err = forEachRow(ctx, db, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, NON_UNIQUE, COALESCE(COLUMN_NAME, '')
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE()
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, column string
+		var nonUnique int
+		if err := rows.Scan(&schema, &table, &name, &nonUnique, &column); err != nil {
+			return err
+		}
+		catalog.addIndexColumn(schema, table, name, nonUnique == 0, name == "PRIMARY", column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func (mysqlDialect) GetColumns(db *sql.DB, _ *ConnectionConfig, _, tableName string) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+	err := scanMySQLColumns(context.Background(), db, "AND TABLE_NAME = ?", []interface{}{tableName},
+		func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanMySQLColumns reads the columns of the tables in the current database
+// matching filter. COLUMN_TYPE keeps the display width that tells TINYINT(1)
+// booleans apart, which DATA_TYPE drops.
+func scanMySQLColumns(ctx context.Context, db *sql.DB, filter string, args []interface{}, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY,
+			   COLUMN_DEFAULT, COALESCE(COLUMN_COMMENT, '')
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() `+filter+`
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, args, func(rows *sql.Rows) error {
+		var schema, table, nullable, columnKey string
+		var column ColumnInfo
+		var def sql.NullString
+		if err := rows.Scan(&schema, &table, &column.Name, &column.Type, &nullable, &columnKey,
+			&def, &column.Comment); err != nil {
+			return err
+		}
+		column.Nullable = nullable == "YES"
+		column.PrimaryKey = columnKey == "PRI"
+		column.Default = nullableString(def)
+		add(schema, table, column)
+		return nil
+	})
+}
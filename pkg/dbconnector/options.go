@@ -0,0 +1,46 @@
+package dbconnector
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidateOptions checks that options are supported by the database type.
+// Dialects that do not implement OptionValidator accept no options.
+func ValidateOptions(dbType DBType, options map[string]string) error {
+	d, ok := Lookup(dbType)
+	if !ok {
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	if v, ok := d.(OptionValidator); ok {
+		return v.ValidateOptions(options)
+	}
+	return checkOptionNames(dbType, options)
+}
+
+// checkOptionNames rejects options not named in supported
+func checkOptionNames(dbType DBType, options map[string]string, supported ...string) error {
+	for key := range options {
+		found := false
+		for _, name := range supported {
+			if key == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("option %q is not supported for %s", key, dbType)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of options in order, so DSNs built from them are stable
+func sortedKeys(options map[string]string) []string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,371 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v2"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// Options understood by Oracle connections, set in ConnectionConfig.Options
+const (
+	// OracleOptionConnectType selects how Database identifies the database:
+	// a service name (default), a SID or a full TNS connect descriptor
+	OracleOptionConnectType = "connect_type"
+	// OracleOptionWalletPath is the directory of an auto-login wallet (cwallet.sso)
+	// holding the certificates for TLS connections
+	OracleOptionWalletPath = "wallet_path"
+)
+
+// Values of OracleOptionConnectType
+const (
+	OracleConnectServiceName = "service_name"
+	OracleConnectSID         = "sid"
+	OracleConnectTNS         = "tns"
+)
+
+func init() {
+	Register(oracleDialect{})
+}
+
+type oracleDialect struct{}
+
+func (oracleDialect) Name() DBType       { return Oracle }
+func (oracleDialect) DriverName() string { return "oracle" }
+
+func (oracleDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	return buildOracleDSN(config)
+}
+
+// Bind replaces :name parameters with :1, :2, ... binds. Oracle binds
+// positional arguments per occurrence, so a parameter used twice gets two binds.
+func (oracleDialect) Bind(ctx context.Context, query string, params map[string]interface{}, _ map[string]string) (context.Context, string, []interface{}, error) {
+	converted, args := BindPositional(query, params, func(n int) string { return fmt.Sprintf(":%d", n) })
+	return ctx, converted, args, nil
+}
+
+func (oracleDialect) QuoteIdentifier(name string) string { return quoteWith(name, `"`, `"`) }
+
+// LimitRows limits a SELECT with the row limiting clause of Oracle 12c and later
+func (oracleDialect) LimitRows(n int) (string, string) {
+	return "", fmt.Sprintf("FETCH FIRST %d ROWS ONLY", n)
+}
+
+// ReadOnlySession returns nil: SET TRANSACTION READ ONLY only lasts one
+// transaction, so access is limited through the user's privileges
+func (oracleDialect) ReadOnlySession() []string { return nil }
+
+func (oracleDialect) ParamType(columnType string) string {
+	switch {
+	case strings.HasPrefix(columnType, "NUMBER"), columnType == "BINARY_FLOAT", columnType == "BINARY_DOUBLE":
+		return ParamTypeNumber
+	case columnType == "DATE", strings.HasPrefix(columnType, "TIMESTAMP"):
+		// Oracle DATE values carry a time of day
+		return ParamTypeDateTime
+	default:
+		return genericParamType(columnType)
+	}
+}
+
+func (oracleDialect) ValidateOptions(options map[string]string) error {
+	if err := checkOptionNames(Oracle, options, OracleOptionConnectType, OracleOptionWalletPath); err != nil {
+		return err
+	}
+	switch options[OracleOptionConnectType] {
+	case "", OracleConnectServiceName, OracleConnectSID, OracleConnectTNS:
+		return nil
+	default:
+		return fmt.Errorf("%s must be one of: %s, %s, %s", OracleOptionConnectType,
+			OracleConnectServiceName, OracleConnectSID, OracleConnectTNS)
+	}
+}
+
+// oracleSessionStatements are the session initialization statements a
+// datasource may run: NLS, time zone and schema settings, and enabling roles
+// that are not password protected
+var oracleSessionStatements = newSessionAllowlist(
+	`ALTER\s+SESSION\s+SET\s+`+sessionSettings("CURRENT_SCHEMA", "TIME_ZONE", "NLS_DATE_FORMAT",
+		"NLS_TIMESTAMP_FORMAT", "NLS_TIMESTAMP_TZ_FORMAT", "NLS_LANGUAGE", "NLS_TERRITORY",
+		"NLS_NUMERIC_CHARACTERS", "NLS_SORT", "NLS_COMP")+`\s*=\s*`+sessionValue,
+	`SET\s+ROLE\s+`+sessionIdent+`(?:\s*,\s*`+sessionIdent+`)*`,
+)
+
+func (oracleDialect) ValidateSessionStatement(stmt string) error {
+	return oracleSessionStatements.validate(Oracle, stmt)
+}
+
+// ValidateTLS rejects certificates, which go-ora only reads from a wallet
+func (oracleDialect) ValidateTLS(t *TLSConfig) error {
+	if t.CACert != "" || t.ClientCert != "" || t.ClientKey != "" {
+		return fmt.Errorf("oracle datasources read certificates from a wallet, set %s instead", OracleOptionWalletPath)
+	}
+	return nil
+}
+
+// NewDriverConnector verifies the server certificate against the TLS server
+// name when one is set: go-ora checks it against the host it connects to, so
+// that host becomes the server name and the dialer connects to the real one.
+func (oracleDialect) NewDriverConnector(config *ConnectionConfig) (driver.Connector, error) {
+	redirect := config.SSLMode != "" && config.SSLMode != SSLModeDisable &&
+		tlsServerName(config) != config.Host && config.Options[OracleOptionConnectType] != OracleConnectTNS
+
+	dsnConfig := config
+	if redirect {
+		named := *config
+		named.Host = tlsServerName(config)
+		dsnConfig = &named
+	}
+	dsn, err := buildOracleDSN(dsnConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	connector := go_ora.NewConnector(dsn)
+	if redirect {
+		if oc, ok := connector.(*go_ora.OracleConnector); ok {
+			oc.Dialer(redirectDialer{addr: net.JoinHostPort(config.Host, strconv.Itoa(config.Port))})
+		}
+	}
+	return connector, nil
+}
+
+func (oracleDialect) VersionQuery() string {
+	return "SELECT version FROM product_component_version WHERE product LIKE 'Oracle%' AND ROWNUM = 1"
+}
+
+// ReplicationLag reads the apply lag of a Data Guard standby, formatted as
+// +DD HH:MI:SS. Primaries report no apply lag.
+func (oracleDialect) ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var value sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT value FROM v$dataguard_stats WHERE name = 'apply lag'").Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !value.Valid) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return parseOracleInterval(value.String)
+}
+
+// parseOracleInterval parses a day to second interval such as +00 00:01:30
+func parseOracleInterval(v string) (time.Duration, error) {
+	var days, hours, minutes, seconds int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(v, "+"), "%d %d:%d:%d", &days, &hours, &minutes, &seconds); err != nil {
+		return 0, fmt.Errorf("unexpected apply lag %q", v)
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+}
+
+// IsUnknownDatabase reports the listener not knowing the service (ORA-12514)
+// or SID (ORA-12505)
+func (oracleDialect) IsUnknownDatabase(err error) bool {
+	var oraErr *network.OracleError
+	return errors.As(err, &oraErr) && (oraErr.ErrCode == 12514 || oraErr.ErrCode == 12505)
+}
+
+// buildOracleDSN returns a go-ora URL. SSLMode "require" enables TLS and the
+// verify modes also check the server certificate; a wallet implies TLS.
+func buildOracleDSN(config *ConnectionConfig) (string, error) {
+	urlOptions := make(map[string]string)
+
+	switch config.SSLMode {
+	case "", SSLModeDisable:
+	case SSLModeRequire:
+		urlOptions["SSL"] = "true"
+		urlOptions["SSL VERIFY"] = "false"
+	case SSLModeVerifyCA, SSLModeVerifyFull:
+		urlOptions["SSL"] = "true"
+		urlOptions["SSL VERIFY"] = "true"
+	default:
+		return "", fmt.Errorf("unsupported ssl mode for oracle: %s", config.SSLMode)
+	}
+
+	if wallet := config.Options[OracleOptionWalletPath]; wallet != "" {
+		urlOptions["WALLET"] = wallet
+		urlOptions["SSL"] = "true"
+		if _, ok := urlOptions["SSL VERIFY"]; !ok {
+			urlOptions["SSL VERIFY"] = "true"
+		}
+	}
+
+	if len(urlOptions) == 0 {
+		urlOptions = nil
+	}
+
+	switch config.Options[OracleOptionConnectType] {
+	case "", OracleConnectServiceName:
+		return go_ora.BuildUrl(config.Host, config.Port, config.Database, config.Username, config.Password, urlOptions), nil
+
+	case OracleConnectSID:
+		if urlOptions == nil {
+			urlOptions = make(map[string]string)
+		}
+		urlOptions["SID"] = config.Database
+		return go_ora.BuildUrl(config.Host, config.Port, "", config.Username, config.Password, urlOptions), nil
+
+	case OracleConnectTNS:
+		// The descriptor names the hosts itself, so Host and Port are not used
+		return go_ora.BuildJDBC(config.Username, config.Password, config.Database, urlOptions), nil
+
+	default:
+		return "", fmt.Errorf("unsupported oracle connect type: %s", config.Options[OracleOptionConnectType])
+	}
+}
+
+// oracleSystemOwners lists the schemas of Oracle's own components, left out of GetSchema
+const oracleSystemOwners = `('SYS', 'SYSTEM', 'CTXSYS', 'DBSNMP', 'MDSYS', 'OLAPSYS', 'ORDDATA', 'ORDSYS', 'OUTLN', 'WMSYS', 'XDB')`
+
+func (oracleDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	// A materialized view also shows up as the table holding its rows, which is
+	// left out; dropped tables in the recycle bin are named BIN$...
+	var tables []TableInfo
+	err := forEachRow(ctx, db, `
+		SELECT o.OWNER, o.OBJECT_NAME, o.OBJECT_TYPE, COALESCE(tc.COMMENTS, mc.COMMENTS), t.NUM_ROWS
+		FROM ALL_OBJECTS o
+		LEFT JOIN ALL_TAB_COMMENTS tc ON tc.OWNER = o.OWNER AND tc.TABLE_NAME = o.OBJECT_NAME
+		LEFT JOIN ALL_MVIEW_COMMENTS mc ON mc.OWNER = o.OWNER AND mc.MVIEW_NAME = o.OBJECT_NAME
+		LEFT JOIN ALL_TABLES t ON t.OWNER = o.OWNER AND t.TABLE_NAME = o.OBJECT_NAME
+		WHERE o.OBJECT_TYPE IN ('TABLE', 'VIEW', 'MATERIALIZED VIEW')
+			AND o.OWNER NOT IN `+oracleSystemOwners+`
+			AND o.OBJECT_NAME NOT LIKE 'BIN$%'
+			AND NOT (o.OBJECT_TYPE = 'TABLE' AND EXISTS (
+				SELECT 1 FROM ALL_MVIEWS m WHERE m.OWNER = o.OWNER AND m.MVIEW_NAME = o.OBJECT_NAME
+			))
+		ORDER BY o.OWNER, o.OBJECT_NAME
+	`, nil, func(rows *sql.Rows) error {
+		var t TableInfo
+		var objectType string
+		var comment sql.NullString
+		var estimate sql.NullInt64
+		if err := rows.Scan(&t.Schema, &t.Name, &objectType, &comment, &estimate); err != nil {
+			return err
+		}
+		switch objectType {
+		case "VIEW":
+			t.Kind = TableKindView
+		case "MATERIALIZED VIEW":
+			t.Kind = TableKindMaterializedView
+		default:
+			t.Kind = TableKindTable
+		}
+		t.Comment = comment.String
+		t.RowEstimate = rowEstimate(estimate)
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanOracleColumns(ctx, db, "", nil, catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT c.OWNER, c.TABLE_NAME, c.CONSTRAINT_NAME, r.OWNER, r.TABLE_NAME, cc.COLUMN_NAME, rc.COLUMN_NAME
+		FROM ALL_CONSTRAINTS c
+		JOIN ALL_CONSTRAINTS r ON r.OWNER = c.R_OWNER AND r.CONSTRAINT_NAME = c.R_CONSTRAINT_NAME
+		JOIN ALL_CONS_COLUMNS cc ON cc.OWNER = c.OWNER AND cc.CONSTRAINT_NAME = c.CONSTRAINT_NAME
+		JOIN ALL_CONS_COLUMNS rc
+			ON rc.OWNER = r.OWNER AND rc.CONSTRAINT_NAME = r.CONSTRAINT_NAME AND rc.POSITION = cc.POSITION
+		WHERE c.CONSTRAINT_TYPE = 'R' AND c.OWNER NOT IN `+oracleSystemOwners+`
+		ORDER BY c.OWNER, c.TABLE_NAME, c.CONSTRAINT_NAME, cc.POSITION
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, refSchema, refTable, column, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &refSchema, &refTable, &column, &refColumn); err != nil {
+			return err
+		}
+		catalog.addForeignKeyColumn(schema, table, name, refSchema, refTable, column, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Function-based index keys are reported under their hidden SYS_NC column name
+	err = forEachRow(ctx, db, `
+		SELECT i.TABLE_OWNER, i.TABLE_NAME, i.INDEX_NAME, i.UNIQUENESS,
+			   CASE WHEN EXISTS (
+				   SELECT 1 FROM ALL_CONSTRAINTS pk
+				   WHERE pk.CONSTRAINT_TYPE = 'P' AND pk.OWNER = i.TABLE_OWNER
+					   AND pk.TABLE_NAME = i.TABLE_NAME AND pk.INDEX_NAME = i.INDEX_NAME
+			   ) THEN 1 ELSE 0 END,
+			   ic.COLUMN_NAME
+		FROM ALL_INDEXES i
+		JOIN ALL_IND_COLUMNS ic ON ic.INDEX_OWNER = i.OWNER AND ic.INDEX_NAME = i.INDEX_NAME
+		WHERE i.INDEX_TYPE <> 'LOB' AND i.TABLE_OWNER NOT IN `+oracleSystemOwners+`
+		ORDER BY i.TABLE_OWNER, i.TABLE_NAME, i.INDEX_NAME, ic.COLUMN_POSITION
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, uniqueness, column string
+		var primary int
+		if err := rows.Scan(&schema, &table, &name, &uniqueness, &primary, &column); err != nil {
+			return err
+		}
+		catalog.addIndexColumn(schema, table, name, uniqueness == "UNIQUE", primary == 1, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func (oracleDialect) GetColumns(db *sql.DB, _ *ConnectionConfig, schema, tableName string) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+	err := scanOracleColumns(context.Background(), db, "AND c.OWNER = :1 AND c.TABLE_NAME = :2",
+		[]interface{}{strings.ToUpper(schema), strings.ToUpper(tableName)}, func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanOracleColumns reads the columns of the tables and views matching filter
+func scanOracleColumns(ctx context.Context, db *sql.DB, filter string, args []interface{}, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT c.OWNER, c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.NULLABLE,
+			   CASE WHEN EXISTS (
+				   SELECT 1 FROM ALL_CONSTRAINTS cons
+				   JOIN ALL_CONS_COLUMNS cols
+					   ON cols.OWNER = cons.OWNER AND cols.CONSTRAINT_NAME = cons.CONSTRAINT_NAME
+				   WHERE cons.CONSTRAINT_TYPE = 'P' AND cons.OWNER = c.OWNER
+					   AND cons.TABLE_NAME = c.TABLE_NAME AND cols.COLUMN_NAME = c.COLUMN_NAME
+			   ) THEN 1 ELSE 0 END,
+			   c.DATA_DEFAULT, cc.COMMENTS
+		FROM ALL_TAB_COLUMNS c
+		LEFT JOIN ALL_COL_COMMENTS cc
+			ON cc.OWNER = c.OWNER AND cc.TABLE_NAME = c.TABLE_NAME AND cc.COLUMN_NAME = c.COLUMN_NAME
+		WHERE c.OWNER NOT IN `+oracleSystemOwners+` `+filter+`
+		ORDER BY c.OWNER, c.TABLE_NAME, c.COLUMN_ID
+	`, args, func(rows *sql.Rows) error {
+		var schema, table, nullable string
+		var column ColumnInfo
+		var isPK int
+		var def, comment sql.NullString
+		if err := rows.Scan(&schema, &table, &column.Name, &column.Type, &nullable, &isPK, &def, &comment); err != nil {
+			return err
+		}
+		column.Nullable = nullable == "Y"
+		column.PrimaryKey = isPK == 1
+		// DATA_DEFAULT holds the default as written, often with a trailing newline
+		if def.Valid {
+			def.String = strings.TrimSpace(def.String)
+		}
+		column.Default = nullableString(def)
+		column.Comment = comment.String
+		add(schema, table, column)
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package dbconnector
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func oracleConfig(sslMode string, options map[string]string) *ConnectionConfig {
+	return &ConnectionConfig{
+		Type:     Oracle,
+		Host:     "db.example.com",
+		Port:     1522,
+		Username: "scott",
+		Password: "p@ss:word",
+		Database: "ORCLPDB1",
+		SSLMode:  sslMode,
+		Options:  options,
+	}
+}
+
+func parseOracleDSN(t *testing.T, config *ConnectionConfig) *url.URL {
+	t.Helper()
+	dsn, err := NewConnector(config).buildDSN()
+	require.NoError(t, err)
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	return u
+}
+
+func TestConnector_buildDSN_Oracle_ServiceName(t *testing.T) {
+	u := parseOracleDSN(t, oracleConfig("", nil))
+
+	assert.Equal(t, "oracle", u.Scheme)
+	assert.Equal(t, "db.example.com:1522", u.Host)
+	assert.Equal(t, "/ORCLPDB1", u.Path)
+	assert.Equal(t, "scott", u.User.Username())
+	password, _ := u.User.Password()
+	assert.Equal(t, "p@ss:word", password)
+	assert.Empty(t, u.RawQuery)
+}
+
+func TestConnector_buildDSN_Oracle_SID(t *testing.T) {
+	u := parseOracleDSN(t, oracleConfig("", map[string]string{OracleOptionConnectType: OracleConnectSID}))
+
+	assert.Equal(t, "db.example.com:1522", u.Host)
+	assert.Equal(t, "/", u.Path)
+	assert.Equal(t, "ORCLPDB1", u.Query().Get("SID"))
+}
+
+func TestConnector_buildDSN_Oracle_TNS(t *testing.T) {
+	config := oracleConfig("", map[string]string{OracleOptionConnectType: OracleConnectTNS})
+	config.Database = "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db1)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=sales)))"
+
+	u := parseOracleDSN(t, config)
+
+	assert.Equal(t, config.Database, u.Query().Get("connStr"))
+}
+
+func TestConnector_buildDSN_Oracle_TLS(t *testing.T) {
+	u := parseOracleDSN(t, oracleConfig("require", nil))
+	assert.Equal(t, "true", u.Query().Get("SSL"))
+	assert.Equal(t, "false", u.Query().Get("SSL VERIFY"))
+
+	u = parseOracleDSN(t, oracleConfig("verify-full", nil))
+	assert.Equal(t, "true", u.Query().Get("SSL VERIFY"))
+
+	u = parseOracleDSN(t, oracleConfig("", map[string]string{OracleOptionWalletPath: "/etc/oracle/wallet"}))
+	assert.Equal(t, "/etc/oracle/wallet", u.Query().Get("WALLET"))
+	assert.Equal(t, "true", u.Query().Get("SSL"))
+	assert.Equal(t, "true", u.Query().Get("SSL VERIFY"))
+
+	_, err := NewConnector(oracleConfig("prefer", nil)).buildDSN()
+	assert.Error(t, err)
+}
+
+func TestConnector_convertNamedParams_Oracle(t *testing.T) {
+	config := &ConnectionConfig{Type: Oracle}
+	connector := NewConnector(config)
+
+	query := "SELECT * FROM users WHERE id = :id OR parent_id = :id OR name = :name"
+	params := map[string]interface{}{
+		"id":   1,
+		"name": "test",
+	}
+
+	convertedQuery, args := connector.convertNamedParams(query, params)
+
+	// Oracle binds positional arguments per occurrence
+	assert.Equal(t, "SELECT * FROM users WHERE id = :1 OR parent_id = :2 OR name = :3", convertedQuery)
+	assert.Equal(t, []interface{}{1, 1, "test"}, args)
+}
+
+func TestValidateOptions(t *testing.T) {
+	assert.NoError(t, ValidateOptions(Oracle, nil))
+	assert.NoError(t, ValidateOptions(Oracle, map[string]string{
+		OracleOptionConnectType: OracleConnectTNS,
+		OracleOptionWalletPath:  "/etc/oracle/wallet",
+	}))
+	assert.Error(t, ValidateOptions(Oracle, map[string]string{OracleOptionConnectType: "ldap"}))
+	assert.Error(t, ValidateOptions(Oracle, map[string]string{"unknown": "x"}))
+	assert.Error(t, ValidateOptions(PostgreSQL, map[string]string{OracleOptionConnectType: OracleConnectSID}))
+}
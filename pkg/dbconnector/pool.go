@@ -0,0 +1,204 @@
+package dbconnector
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PoolOptions configures the connection pool kept for each data source
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolOptions returns conservative pool settings for target databases
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MaxOpenConns:    10,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+// pooledConnector is a connector together with the configuration it was opened with
+type pooledConnector struct {
+	connector   *Connector
+	fingerprint string
+}
+
+// pendingConnect is a connect in progress, which other reads of the same data
+// source wait for instead of connecting themselves
+type pendingConnect struct {
+	fingerprint string
+	done        chan struct{}
+	connector   *Connector
+	err         error
+}
+
+// errConnectorRemoved is returned to reads whose connect finished after the
+// data source was removed from the pool
+var errConnectorRemoved = errors.New("data source connection was removed while connecting")
+
+// Pool keeps one open connector per data source so tool executions reuse
+// connections instead of dialing the target database on every call
+type Pool struct {
+	opts       PoolOptions
+	mu         sync.Mutex
+	connectors map[string]*pooledConnector
+	connecting map[string]*pendingConnect
+}
+
+// NewPool creates a new data source connection pool
+func NewPool(opts PoolOptions) *Pool {
+	return &Pool{
+		opts:       opts,
+		connectors: make(map[string]*pooledConnector),
+		connecting: make(map[string]*pendingConnect),
+	}
+}
+
+// Get returns the connector for the given key, connecting on first use.
+// A connector opened with a different configuration is closed and replaced.
+// Connecting happens outside the pool's lock, so a slow or unreachable data
+// source only holds up the reads of that data source, which share one attempt.
+func (p *Pool) Get(key string, config *ConnectionConfig) (*Connector, error) {
+	fingerprint := configFingerprint(config)
+
+	for {
+		p.mu.Lock()
+		var stale *Connector
+		if pooled, ok := p.connectors[key]; ok {
+			if pooled.fingerprint == fingerprint {
+				p.mu.Unlock()
+				return pooled.connector, nil
+			}
+			stale = pooled.connector
+			delete(p.connectors, key)
+		}
+		if pending, ok := p.connecting[key]; ok {
+			p.mu.Unlock()
+			closeConnector(stale)
+			<-pending.done
+			if pending.fingerprint == fingerprint {
+				return pending.connector, pending.err
+			}
+			continue
+		}
+
+		pending := &pendingConnect{fingerprint: fingerprint, done: make(chan struct{})}
+		p.connecting[key] = pending
+		p.mu.Unlock()
+		closeConnector(stale)
+
+		pending.connector, pending.err = p.connect(config)
+
+		p.mu.Lock()
+		current := p.connecting[key] == pending
+		if current {
+			delete(p.connecting, key)
+			if pending.err == nil {
+				p.connectors[key] = &pooledConnector{connector: pending.connector, fingerprint: fingerprint}
+			}
+		}
+		p.mu.Unlock()
+		if !current && pending.err == nil {
+			closeConnector(pending.connector)
+			pending.connector, pending.err = nil, errConnectorRemoved
+		}
+		close(pending.done)
+		return pending.connector, pending.err
+	}
+}
+
+// connect opens a connector with the pool's settings
+func (p *Pool) connect(config *ConnectionConfig) (*Connector, error) {
+	connector := NewConnector(config)
+	if err := connector.Connect(); err != nil {
+		return nil, err
+	}
+
+	db := connector.DB()
+	db.SetMaxOpenConns(p.opts.MaxOpenConns)
+	db.SetMaxIdleConns(p.opts.MaxIdleConns)
+	db.SetConnMaxLifetime(p.opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.opts.ConnMaxIdleTime)
+	return connector, nil
+}
+
+func closeConnector(connector *Connector) {
+	if connector != nil {
+		_ = connector.Close()
+	}
+}
+
+// Remove closes and forgets the connectors for the given key and for the
+// replicas of the data source under it. A connect in progress for them is
+// closed once it finishes.
+func (p *Pool) Remove(key string) {
+	p.mu.Lock()
+	var removed []*Connector
+	for k, pooled := range p.connectors {
+		if k == key || strings.HasPrefix(k, replicaKey(key, "")) {
+			removed = append(removed, pooled.connector)
+			delete(p.connectors, k)
+		}
+	}
+	for k := range p.connecting {
+		if k == key || strings.HasPrefix(k, replicaKey(key, "")) {
+			delete(p.connecting, k)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, connector := range removed {
+		closeConnector(connector)
+	}
+}
+
+// Stats returns the connection pool statistics keyed by data source
+func (p *Pool) Stats() map[string]sql.DBStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]sql.DBStats, len(p.connectors))
+	for key, pooled := range p.connectors {
+		stats[key] = pooled.connector.DB().Stats()
+	}
+	return stats
+}
+
+// Close closes every pooled connector
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for key, pooled := range p.connectors {
+		if err := pooled.connector.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(p.connectors, key)
+	}
+	return firstErr
+}
+
+// configFingerprint identifies the settings a connector was opened with and,
+// for dialects loading their data on connect, the version of that data
+func configFingerprint(config *ConnectionConfig) string {
+	fingerprint := fmt.Sprintf("%s|%s|%d|%s|%s|%s|%s|%v|%q|%s|%s",
+		config.Type, config.Host, config.Port, config.Username, config.Password, config.Database,
+		config.SSLMode, config.Options, config.SessionInit, config.TLS.fingerprint(), config.SSHTunnel.fingerprint())
+	if d, ok := Lookup(config.Type); ok {
+		if versioner, ok := d.(DataVersioner); ok {
+			fingerprint += "|" + versioner.DataVersion(config)
+		}
+	}
+	return fingerprint
+}
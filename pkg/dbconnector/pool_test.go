@@ -0,0 +1,64 @@
+package dbconnector
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_ConcurrentGetsShareConnector(t *testing.T) {
+	setupSQLite(t)
+	pool := NewPool(DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	config := &ConnectionConfig{Type: SQLite, Database: "app.db"}
+
+	var wg sync.WaitGroup
+	connectors := make([]*Connector, 8)
+	for i := range connectors {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			connector, err := pool.Get("ds", config)
+			assert.NoError(t, err)
+			connectors[i] = connector
+		}(i)
+	}
+	wg.Wait()
+
+	for _, connector := range connectors {
+		assert.Same(t, connectors[0], connector)
+	}
+	assert.Len(t, pool.Stats(), 1)
+}
+
+func TestPool_RemoveClosesReplicas(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1")
+	pool := NewPool(DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+
+	primary, err := pool.Get("ds", set.Primary)
+	require.NoError(t, err)
+	_, err = pool.Get(replicaKey("ds", "r1"), set.Replicas[0].Config)
+	require.NoError(t, err)
+	_, err = pool.Get("ds2", set.Primary)
+	require.NoError(t, err)
+
+	pool.Remove("ds")
+
+	assert.Equal(t, []string{"ds2"}, keysOf(pool.Stats()))
+	assert.Error(t, primary.DB().Ping(), "removed connectors are closed")
+
+	again, err := pool.Get("ds", set.Primary)
+	require.NoError(t, err)
+	assert.NotSame(t, primary, again)
+}
+
+func keysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,360 @@
+package dbconnector
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func init() {
+	Register(postgresDialect{})
+	RegisterAlias("postgres", PostgreSQL)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() DBType       { return PostgreSQL }
+func (postgresDialect) DriverName() string { return "postgres" }
+
+func (postgresDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	sslMode := config.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quotePostgresValue(config.Host), config.Port, quotePostgresValue(config.Username),
+		quotePostgresValue(config.Password), quotePostgresValue(config.Database), sslMode,
+	)
+	for _, key := range sortedKeys(config.Options) {
+		dsn += " " + key + "=" + quotePostgresValue(config.Options[key])
+	}
+	return dsn, nil
+}
+
+// postgresDriverOptions are the connection parameters a datasource may set.
+// pq sends parameters it does not know to the server as session settings,
+// so anything else could undo the read-only session.
+var postgresDriverOptions = []string{"application_name", "connect_timeout"}
+
+func (postgresDialect) ValidateOptions(options map[string]string) error {
+	return checkOptionNames(PostgreSQL, options, postgresDriverOptions...)
+}
+
+// postgresSessionStatements are the session initialization statements a
+// datasource may run. Settings such as default_transaction_read_only or
+// session_authorization are left out, as they could undo the read-only session.
+var postgresSessionStatements = newSessionAllowlist(
+	`SET\s+(?:SESSION\s+)?`+sessionSettings("search_path", "timezone", "statement_timeout", "lock_timeout",
+		"idle_in_transaction_session_timeout", "application_name", "datestyle", "intervalstyle",
+		"extra_float_digits", "work_mem", "jit")+`\s*(?:=|\s+TO\s+)\s*`+sessionValueList,
+	`SET\s+(?:SESSION\s+)?TIME\s+ZONE\s+`+sessionValue,
+	`SET\s+(?:SESSION\s+)?ROLE\s+`+sessionIdent,
+)
+
+func (postgresDialect) ValidateSessionStatement(stmt string) error {
+	return postgresSessionStatements.validate(PostgreSQL, stmt)
+}
+
+// quotePostgresValue quotes a key=value connection string value when it is
+// empty or holds spaces, quotes or backslashes
+func quotePostgresValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// NewDriverConnector opens TLS connections through postgresTLSDialer, as pq
+// only reads certificates from files and cannot verify another server name
+func (d postgresDialect) NewDriverConnector(config *ConnectionConfig) (driver.Connector, error) {
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		dsn, err := d.BuildDSN(config)
+		if err != nil {
+			return nil, err
+		}
+		return pq.NewConnector(dsn)
+	}
+
+	// The dialer hands pq a connection that is already encrypted
+	plain := *config
+	plain.SSLMode = SSLModeDisable
+	dsn, err := d.BuildDSN(&plain)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	connector.Dialer(postgresTLSDialer{config: tlsConfig})
+	return connector, nil
+}
+
+// postgresSSLRequest is the code of the message asking a server to switch to TLS
+const postgresSSLRequest = 80877103
+
+// postgresTLSDialer negotiates TLS the way libpq does, sending an SSLRequest
+// before the handshake, and returns the encrypted connection
+type postgresTLSDialer struct {
+	config *tls.Config
+}
+
+func (d postgresTLSDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d postgresTLSDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d postgresTLSDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	tlsConn, err := postgresStartTLS(ctx, conn, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+func postgresStartTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequest)
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	answer := make([]byte, 1)
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, err
+	}
+	if answer[0] != 'S' {
+		return nil, fmt.Errorf("server does not support TLS")
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// StartTLS negotiates TLS on a connection to the server like postgresTLSDialer
+func (postgresDialect) StartTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	return postgresStartTLS(ctx, conn, config)
+}
+
+func (postgresDialect) VersionQuery() string { return "SHOW server_version" }
+
+// IsUnknownDatabase reports SQLSTATE 3D000, invalid_catalog_name
+func (postgresDialect) IsUnknownDatabase(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "3D000"
+}
+
+// postgresLagQuery measures how long ago a standby replayed the last
+// transaction it received. A standby that has replayed everything it received
+// is not lagging, however long ago the primary last wrote.
+const postgresLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+END`
+
+func (postgresDialect) ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	return queryLagSeconds(ctx, db, postgresLagQuery)
+}
+
+// UnreadableSchemas lists the schemas the user cannot use, or that hold a
+// table or view the user cannot select from
+func (postgresDialect) UnreadableSchemas(ctx context.Context, db *sql.DB) ([]string, error) {
+	return queryStrings(ctx, db, `
+		SELECT n.nspname
+		FROM pg_namespace n
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg\_%'
+		  AND (NOT has_schema_privilege(n.oid, 'USAGE')
+		    OR EXISTS (
+		      SELECT 1 FROM pg_class c
+		      WHERE c.relnamespace = n.oid
+		        AND c.relkind IN ('r', 'v', 'm', 'p', 'f')
+		        AND NOT has_table_privilege(c.oid, 'SELECT')))
+		ORDER BY n.nspname`)
+}
+
+func (postgresDialect) Bind(ctx context.Context, query string, params map[string]interface{}, _ map[string]string) (context.Context, string, []interface{}, error) {
+	converted, args := BindNumbered(query, params, func(n int) string { return fmt.Sprintf("$%d", n) })
+	return ctx, converted, args, nil
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string { return quoteWith(name, `"`, `"`) }
+
+func (postgresDialect) ReadOnlySession() []string {
+	return []string{"SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY"}
+}
+
+func (postgresDialect) ParamType(columnType string) string { return genericParamType(columnType) }
+
+// postgresRelations restricts catalog queries to user tables, partitioned and
+// foreign tables, views and materialized views outside the system schemas
+const postgresRelations = `c.relkind IN ('r', 'p', 'f', 'v', 'm')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp%'`
+
+func (postgresDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	var tables []TableInfo
+	err := forEachRow(ctx, db, `
+		SELECT n.nspname, c.relname, c.relkind, COALESCE(obj_description(c.oid, 'pg_class'), ''),
+			   CASE WHEN c.relkind IN ('r', 'm') THEN c.reltuples::bigint END
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE `+postgresRelations+`
+		ORDER BY n.nspname, c.relname
+	`, nil, func(rows *sql.Rows) error {
+		var t TableInfo
+		var kind string
+		var estimate sql.NullInt64
+		if err := rows.Scan(&t.Schema, &t.Name, &kind, &t.Comment, &estimate); err != nil {
+			return err
+		}
+		switch kind {
+		case "v":
+			t.Kind = TableKindView
+		case "m":
+			t.Kind = TableKindMaterializedView
+		default:
+			t.Kind = TableKindTable
+		}
+		t.RowEstimate = rowEstimate(estimate)
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanPostgresColumns(ctx, db, "", nil, catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	err = forEachRow(ctx, db, `
+		SELECT n.nspname, c.relname, con.conname, rn.nspname, rc.relname, a.attname, ra.attname
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+		WHERE con.contype = 'f' AND `+postgresRelations+`
+		ORDER BY n.nspname, c.relname, con.conname, k.ord
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, refSchema, refTable, column, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &refSchema, &refTable, &column, &refColumn); err != nil {
+			return err
+		}
+		catalog.addForeignKeyColumn(schema, table, name, refSchema, refTable, column, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Expression index keys have attnum 0 and are reported by their expression
+	err = forEachRow(ctx, db, `
+		SELECT n.nspname, c.relname, ic.relname, i.indisunique, i.indisprimary,
+			   COALESCE(a.attname, pg_get_indexdef(i.indexrelid, k.ord::int, true))
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+		LEFT JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum AND k.attnum > 0
+		WHERE k.ord <= i.indnkeyatts AND `+postgresRelations+`
+		ORDER BY n.nspname, c.relname, ic.relname, k.ord
+	`, nil, func(rows *sql.Rows) error {
+		var schema, table, name, column string
+		var unique, primary bool
+		if err := rows.Scan(&schema, &table, &name, &unique, &primary, &column); err != nil {
+			return err
+		}
+		catalog.addIndexColumn(schema, table, name, unique, primary, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func (postgresDialect) GetColumns(db *sql.DB, _ *ConnectionConfig, schema, table string) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+	err := scanPostgresColumns(context.Background(), db, "AND n.nspname = $1 AND c.relname = $2",
+		[]interface{}{schema, table}, func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanPostgresColumns reads the columns of the relations matching filter. It
+// reads pg_attribute rather than information_schema.columns, which leaves out
+// materialized views.
+func scanPostgresColumns(ctx context.Context, db *sql.DB, filter string, args []interface{}, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			   EXISTS (
+				   SELECT 1 FROM pg_index i
+				   WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+			   ),
+			   pg_get_expr(d.adbin, d.adrelid), COALESCE(col_description(c.oid, a.attnum), '')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attnum > 0 AND NOT a.attisdropped AND `+postgresRelations+`
+		`+filter+`
+		ORDER BY n.nspname, c.relname, a.attnum
+	`, args, func(rows *sql.Rows) error {
+		var schema, table string
+		var column ColumnInfo
+		var def sql.NullString
+		if err := rows.Scan(&schema, &table, &column.Name, &column.Type, &column.Nullable,
+			&column.PrimaryKey, &def, &column.Comment); err != nil {
+			return err
+		}
+		column.Default = nullableString(def)
+		add(schema, table, column)
+		return nil
+	})
+}
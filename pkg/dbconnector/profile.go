@@ -0,0 +1,458 @@
+package dbconnector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/dataweaver/pkg/analytics"
+)
+
+// ErrTableNotFound is returned when a previewed or profiled table does not exist
+var ErrTableNotFound = errors.New("table not found")
+
+// ProfileConfig bounds table previews and profiles and hides sensitive columns from them
+type ProfileConfig struct {
+	SensitiveColumns []string // case-insensitive patterns (path.Match syntax) of column names never previewed or profiled
+	PreviewMaxRows   int      // most rows a preview returns
+	SampleRows       int      // rows a profile reads; 0 profiles the whole table
+	TopValues        int      // most frequent values reported per column
+	HistogramBuckets int      // buckets of the histogram of numeric columns
+}
+
+// DefaultProfileConfig returns the limits used until ConfigureProfiling is called
+func DefaultProfileConfig() ProfileConfig {
+	return ProfileConfig{
+		PreviewMaxRows:   100,
+		SampleRows:       100000,
+		TopValues:        5,
+		HistogramBuckets: 10,
+	}
+}
+
+var (
+	profileMu     sync.RWMutex
+	profileConfig = DefaultProfileConfig()
+)
+
+// ConfigureProfiling sets the limits and sensitive columns applied to every
+// table preview and profile. Zero limits keep their defaults.
+func ConfigureProfiling(cfg ProfileConfig) {
+	defaults := DefaultProfileConfig()
+	if cfg.PreviewMaxRows <= 0 {
+		cfg.PreviewMaxRows = defaults.PreviewMaxRows
+	}
+	if cfg.SampleRows < 0 {
+		cfg.SampleRows = 0
+	}
+	if cfg.TopValues <= 0 {
+		cfg.TopValues = defaults.TopValues
+	}
+	if cfg.HistogramBuckets <= 0 {
+		cfg.HistogramBuckets = defaults.HistogramBuckets
+	}
+
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	profileConfig = cfg
+}
+
+func currentProfileConfig() ProfileConfig {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	return profileConfig
+}
+
+// IsSensitiveColumn reports whether a column matches one of the configured sensitive column patterns
+func IsSensitiveColumn(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range currentProfileConfig().SensitiveColumns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TablePreview holds a sample of the rows of a table
+type TablePreview struct {
+	QueryResult
+	ExcludedColumns []string // sensitive columns left out of the preview
+}
+
+// ColumnProfile summarizes the values of one column
+type ColumnProfile struct {
+	Name          string
+	Type          string
+	NullCount     int64
+	NullRatio     float64
+	DistinctCount int64
+	Min           interface{}       // absent for types without an ordering
+	Max           interface{}       // absent for types without an ordering
+	TopValues     []ValueCount      // most frequent non-null values
+	Histogram     []HistogramBucket // numeric columns only
+	Error         string            // the first aggregate the database rejected for this column
+}
+
+// ValueCount is a value and the number of rows holding it
+type ValueCount struct {
+	Value interface{}
+	Count int64
+}
+
+// HistogramBucket counts the values in [Lower, Upper), or [Lower, Upper] for the last bucket
+type HistogramBucket struct {
+	Lower float64
+	Upper float64
+	Count int64
+}
+
+// TableProfile summarizes the values of the columns of a table
+type TableProfile struct {
+	Schema          string
+	Table           string
+	RowCount        int64 // rows profiled
+	Sampled         bool  // RowCount reached the sample size, so the table may hold more rows
+	Columns         []ColumnProfile
+	ExcludedColumns []string // sensitive columns left out of the profile
+}
+
+// RowLimiter is implemented by dialects that do not limit a SELECT with a trailing LIMIT clause
+type RowLimiter interface {
+	// LimitRows returns the clauses that limit a SELECT to n rows: prefix
+	// directly follows SELECT and suffix ends the statement
+	LimitRows(n int) (prefix, suffix string)
+}
+
+// limitRows returns the clauses that limit a SELECT to n rows in dialect d
+func limitRows(d Dialect, n int) (prefix, suffix string) {
+	if limiter, ok := d.(RowLimiter); ok {
+		return limiter.LimitRows(n)
+	}
+	return "", fmt.Sprintf("LIMIT %d", n)
+}
+
+// selectLimited builds a SELECT of columns from source limited to n rows.
+// where, groupBy and orderBy are optional clauses without their keywords.
+func selectLimited(d Dialect, columns, source, where, groupBy, orderBy string, n int) string {
+	prefix, suffix := limitRows(d, n)
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if prefix != "" {
+		sb.WriteString(prefix + " ")
+	}
+	sb.WriteString(columns + " FROM " + source)
+	if where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+	if groupBy != "" {
+		sb.WriteString(" GROUP BY " + groupBy)
+	}
+	if orderBy != "" {
+		sb.WriteString(" ORDER BY " + orderBy)
+	}
+	if suffix != "" {
+		sb.WriteString(" " + suffix)
+	}
+	return sb.String()
+}
+
+// qualifiedTable quotes a table name, qualified with its schema when one is given
+func (c *Connector) qualifiedTable(schema, table string) string {
+	if schema == "" {
+		return c.dialect.QuoteIdentifier(table)
+	}
+	return c.dialect.QuoteIdentifier(schema) + "." + c.dialect.QuoteIdentifier(table)
+}
+
+// visibleColumns returns the columns of a table split into those that may be
+// read and the names of the sensitive ones
+func (c *Connector) visibleColumns(schema, table string) ([]ColumnInfo, []string, error) {
+	columns, err := c.GetTableSchema(schema, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+
+	var visible []ColumnInfo
+	var excluded []string
+	for _, col := range columns {
+		if IsSensitiveColumn(col.Name) {
+			excluded = append(excluded, col.Name)
+			continue
+		}
+		visible = append(visible, col)
+	}
+	return visible, excluded, nil
+}
+
+// queryContext runs a statement built by the connector itself, without binding parameters
+func (c *Connector) queryContext(ctx context.Context, query string) (*QueryResult, error) {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return c.rowsToQueryResult(rows)
+}
+
+// PreviewTable returns up to limit rows of a table, leaving out sensitive columns.
+// limit is capped at the configured preview size.
+func (c *Connector) PreviewTable(ctx context.Context, schema, table string, limit int) (*TablePreview, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	cfg := currentProfileConfig()
+	if limit <= 0 || limit > cfg.PreviewMaxRows {
+		limit = cfg.PreviewMaxRows
+	}
+
+	columns, excluded, err := c.visibleColumns(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TablePreview{ExcludedColumns: excluded}
+	if len(columns) == 0 {
+		preview.Columns = []string{}
+		return preview, nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = c.dialect.QuoteIdentifier(col.Name)
+	}
+	query := selectLimited(c.dialect, strings.Join(quoted, ", "), c.qualifiedTable(schema, table), "", "", "", limit)
+
+	result, err := c.queryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview table: %w", err)
+	}
+	preview.QueryResult = *result
+	return preview, nil
+}
+
+// ProfileTable computes the null ratio, distinct count, range, most frequent
+// values and, for numeric columns, a histogram of every column of a table
+// that is not sensitive. Only the configured number of sample rows is read.
+// A column whose aggregates the database rejects carries the error instead
+// of failing the whole profile.
+func (c *Connector) ProfileTable(ctx context.Context, schema, table string) (*TableProfile, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	cfg := currentProfileConfig()
+	columns, excluded, err := c.visibleColumns(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &TableProfile{
+		Schema:          schema,
+		Table:           table,
+		Columns:         []ColumnProfile{},
+		ExcludedColumns: excluded,
+	}
+	if len(columns) == 0 {
+		return profile, nil
+	}
+
+	// Every aggregate reads the same bounded sample of the table
+	source := c.qualifiedTable(schema, table)
+	if cfg.SampleRows > 0 {
+		quoted := make([]string, len(columns))
+		for i, col := range columns {
+			quoted[i] = c.dialect.QuoteIdentifier(col.Name)
+		}
+		source = "(" + selectLimited(c.dialect, strings.Join(quoted, ", "), source, "", "", "", cfg.SampleRows) + ") s"
+	}
+
+	result, err := c.queryContext(ctx, "SELECT COUNT(*) FROM "+source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to profile table: %w", err)
+	}
+	profile.RowCount = firstInt(result)
+	profile.Sampled = cfg.SampleRows > 0 && profile.RowCount >= int64(cfg.SampleRows)
+
+	for _, col := range columns {
+		profile.Columns = append(profile.Columns, c.profileColumn(ctx, cfg, source, col, profile.RowCount))
+	}
+
+	return profile, nil
+}
+
+// profileColumn runs the aggregates of one column against source
+func (c *Connector) profileColumn(ctx context.Context, cfg ProfileConfig, source string, col ColumnInfo, rowCount int64) ColumnProfile {
+	p := ColumnProfile{Name: col.Name, Type: col.Type}
+	quoted := c.dialect.QuoteIdentifier(col.Name)
+
+	fail := func(err error) {
+		if p.Error == "" {
+			p.Error = err.Error()
+		}
+	}
+
+	result, err := c.queryContext(ctx, fmt.Sprintf("SELECT COUNT(%s), COUNT(DISTINCT %s) FROM %s", quoted, quoted, source))
+	if err != nil {
+		fail(err)
+		return p
+	}
+	if row := firstRow(result); row != nil {
+		nonNull, _ := countValue(row[0])
+		p.NullCount = rowCount - nonNull
+		p.DistinctCount, _ = countValue(row[1])
+	}
+	p.NullRatio = analytics.CalculateRatio(p.NullCount, rowCount)
+
+	numeric := col.ParamType == ParamTypeInteger || col.ParamType == ParamTypeNumber
+	switch col.ParamType {
+	case ParamTypeInteger, ParamTypeNumber, ParamTypeDate, ParamTypeDateTime, ParamTypeString:
+		result, err = c.queryContext(ctx, fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", quoted, quoted, source))
+		if err != nil {
+			fail(err)
+			break
+		}
+		if row := firstRow(result); row != nil {
+			p.Min, p.Max = row[0], row[1]
+		}
+	}
+
+	if p.DistinctCount > 0 {
+		query := selectLimited(c.dialect, quoted+", COUNT(*)", source, quoted+" IS NOT NULL", quoted, "COUNT(*) DESC", cfg.TopValues)
+		if result, err = c.queryContext(ctx, query); err != nil {
+			fail(err)
+		} else {
+			for _, row := range result.Data {
+				count, _ := countValue(row[result.Columns[1]])
+				p.TopValues = append(p.TopValues, ValueCount{Value: row[result.Columns[0]], Count: count})
+			}
+		}
+	}
+
+	if numeric {
+		if histogram, err := c.histogram(ctx, cfg, source, quoted, col.ParamType, p.Min, p.Max); err != nil {
+			fail(err)
+		} else {
+			p.Histogram = histogram
+		}
+	}
+
+	return p
+}
+
+// histogram counts the values of a numeric column in buckets of equal width between min and max
+func (c *Connector) histogram(ctx context.Context, cfg ProfileConfig, source, quoted, paramType string, min, max interface{}) ([]HistogramBucket, error) {
+	lo, ok := numericValue(min)
+	if !ok {
+		return nil, nil
+	}
+	hi, ok := numericValue(max)
+	if !ok {
+		return nil, nil
+	}
+
+	buckets := cfg.HistogramBuckets
+	if paramType == ParamTypeInteger && hi-lo+1 < float64(buckets) {
+		buckets = int(hi-lo) + 1
+	}
+	bounds := analytics.HistogramBounds(lo, hi, buckets)
+	if bounds == nil {
+		return nil, nil
+	}
+
+	sums := make([]string, len(bounds)-1)
+	for i := range sums {
+		upper := "<"
+		if i == len(sums)-1 {
+			upper = "<="
+		}
+		sums[i] = fmt.Sprintf("SUM(CASE WHEN %s >= %s AND %s %s %s THEN 1 ELSE 0 END)",
+			quoted, formatBound(bounds[i]), quoted, upper, formatBound(bounds[i+1]))
+	}
+
+	result, err := c.queryContext(ctx, "SELECT "+strings.Join(sums, ", ")+" FROM "+source)
+	if err != nil {
+		return nil, err
+	}
+	row := firstRow(result)
+	if row == nil {
+		return nil, nil
+	}
+
+	histogram := make([]HistogramBucket, len(sums))
+	for i := range histogram {
+		count, _ := countValue(row[i])
+		histogram[i] = HistogramBucket{Lower: bounds[i], Upper: bounds[i+1], Count: count}
+	}
+	return histogram, nil
+}
+
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// firstRow returns the values of the first row of a result in column order
+func firstRow(result *QueryResult) []interface{} {
+	if len(result.Data) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(result.Columns))
+	for i, col := range result.Columns {
+		values[i] = result.Data[0][col]
+	}
+	return values
+}
+
+// firstInt returns the first value of a result as an integer
+func firstInt(result *QueryResult) int64 {
+	row := firstRow(result)
+	if len(row) == 0 {
+		return 0
+	}
+	n, _ := countValue(row[0])
+	return n
+}
+
+// numericValue converts a numeric value as returned by any of the drivers to a float
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	case float32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return 0, false
+	}
+}
+
+// countValue converts a count as returned by any of the drivers to an integer
+func countValue(v interface{}) (int64, bool) {
+	if n, ok := v.(int64); ok {
+		return n, true
+	}
+	f, ok := numericValue(v)
+	return int64(f), ok
+}
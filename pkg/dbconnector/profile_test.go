@@ -0,0 +1,104 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupProfiling creates a SQLite database with a table worth profiling
+func setupProfiling(t *testing.T, cfg ProfileConfig) *Connector {
+	t.Helper()
+
+	_, file := setupSQLite(t)
+	db, err := sql.Open("sqlite", file)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE payments (id INTEGER, status TEXT, amount REAL, api_token TEXT);
+		INSERT INTO payments VALUES
+			(1, 'paid', 10, 'a'), (2, 'paid', 20, 'b'), (3, 'open', 30, 'c'),
+			(4, 'paid', NULL, 'd'), (5, NULL, 100, 'e');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	ConfigureProfiling(cfg)
+	t.Cleanup(func() { ConfigureProfiling(DefaultProfileConfig()) })
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	t.Cleanup(func() { connector.Close() })
+	return connector
+}
+
+func TestConnector_PreviewTable(t *testing.T) {
+	connector := setupProfiling(t, ProfileConfig{SensitiveColumns: []string{"*TOKEN*"}, PreviewMaxRows: 3})
+
+	preview, err := connector.PreviewTable(context.Background(), "main", "payments", 50)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "status", "amount"}, preview.Columns)
+	assert.Equal(t, []string{"api_token"}, preview.ExcludedColumns)
+	assert.Len(t, preview.Data, 3)
+
+	preview, err = connector.PreviewTable(context.Background(), "main", "payments", 2)
+	require.NoError(t, err)
+	assert.Len(t, preview.Data, 2)
+
+	_, err = connector.PreviewTable(context.Background(), "main", "missing", 2)
+	assert.ErrorIs(t, err, ErrTableNotFound)
+}
+
+func TestConnector_ProfileTable(t *testing.T) {
+	connector := setupProfiling(t, ProfileConfig{SensitiveColumns: []string{"api_token"}, TopValues: 2, HistogramBuckets: 3})
+
+	profile, err := connector.ProfileTable(context.Background(), "main", "payments")
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, profile.RowCount)
+	assert.False(t, profile.Sampled)
+	assert.Equal(t, []string{"api_token"}, profile.ExcludedColumns)
+	require.Len(t, profile.Columns, 3)
+
+	status := profile.Columns[1]
+	assert.Empty(t, status.Error)
+	assert.EqualValues(t, 1, status.NullCount)
+	assert.InDelta(t, 0.2, status.NullRatio, 1e-9)
+	assert.EqualValues(t, 2, status.DistinctCount)
+	assert.Equal(t, "open", status.Min)
+	assert.Equal(t, "paid", status.Max)
+	assert.Equal(t, []ValueCount{{Value: "paid", Count: 3}, {Value: "open", Count: 1}}, status.TopValues)
+	assert.Nil(t, status.Histogram)
+
+	amount := profile.Columns[2]
+	assert.Empty(t, amount.Error)
+	assert.Equal(t, 10.0, amount.Min)
+	assert.Equal(t, 100.0, amount.Max)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 10, Upper: 40, Count: 3},
+		{Lower: 40, Upper: 70, Count: 0},
+		{Lower: 70, Upper: 100, Count: 1},
+	}, amount.Histogram)
+}
+
+func TestConnector_ProfileTable_Sampled(t *testing.T) {
+	connector := setupProfiling(t, ProfileConfig{SampleRows: 2})
+
+	profile, err := connector.ProfileTable(context.Background(), "main", "payments")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, profile.RowCount)
+	assert.True(t, profile.Sampled)
+	require.Len(t, profile.Columns, 4)
+	assert.EqualValues(t, 2, profile.Columns[0].DistinctCount)
+}
+
+func TestLimitRows(t *testing.T) {
+	postgres, _ := Lookup(PostgreSQL)
+	mssql, _ := Lookup(MSSQL)
+	oracle, _ := Lookup(Oracle)
+
+	assert.Equal(t, `SELECT "a" FROM "t" ORDER BY "a" LIMIT 5`, selectLimited(postgres, `"a"`, `"t"`, "", "", `"a"`, 5))
+	assert.Equal(t, `SELECT TOP 5 [a] FROM [t] WHERE [a] IS NOT NULL`, selectLimited(mssql, "[a]", "[t]", "[a] IS NOT NULL", "", "", 5))
+	assert.Equal(t, `SELECT "A" FROM "T" GROUP BY "A" FETCH FIRST 5 ROWS ONLY`, selectLimited(oracle, `"A"`, `"T"`, "", `"A"`, "", 5))
+}
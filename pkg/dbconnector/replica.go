@@ -0,0 +1,286 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyReplica is returned when every replica of a data source is down
+// or lags too far behind and falling back to the primary is not allowed
+var ErrNoHealthyReplica = errors.New("no healthy replica")
+
+// RoutePrimary is the Route target of reads that run on the primary
+const RoutePrimary = "primary"
+
+// ReplicaLagChecker is implemented by dialects that can tell how far a replica
+// lags behind its primary. Servers that are not replicas report no lag.
+type ReplicaLagChecker interface {
+	ReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error)
+}
+
+// Replica is a read replica of a data source
+type Replica struct {
+	Name   string
+	Weight int // share of the reads among healthy replicas; 0 takes none
+	Config *ConnectionConfig
+}
+
+// ReplicaSet is a primary together with its read replicas
+type ReplicaSet struct {
+	Primary  *ConnectionConfig
+	Replicas []Replica
+
+	MaxLag            time.Duration // replicas lagging further behind take no reads; 0 does not check lag
+	FallbackToPrimary bool          // read from the primary when no replica is healthy
+}
+
+// Route tells where a read was sent
+type Route struct {
+	Target   string        // RoutePrimary or the name of a replica
+	Lag      time.Duration // replication lag of the replica when last checked
+	Fallback bool          // the primary was used because no replica was healthy
+}
+
+// RouterOptions configures how often replicas are checked
+type RouterOptions struct {
+	CheckInterval time.Duration // how long a replica check is trusted
+	CheckTimeout  time.Duration
+}
+
+// DefaultRouterOptions returns the replica check settings used by the server
+func DefaultRouterOptions() RouterOptions {
+	return RouterOptions{
+		CheckInterval: 15 * time.Second,
+		CheckTimeout:  5 * time.Second,
+	}
+}
+
+// replicaState is the outcome of the last check of a replica
+type replicaState struct {
+	fingerprint string
+	checked     time.Time
+	lagChecked  bool
+	lag         time.Duration
+	err         error
+}
+
+// pendingCheck is a replica check in progress, which reads of the replica
+// wait for instead of checking it themselves
+type pendingCheck struct {
+	done  chan struct{}
+	state *replicaState
+}
+
+// Router sends reads to the healthy replicas of a data source, by weight,
+// through the connectors of a Pool. Replica checks are cached for
+// RouterOptions.CheckInterval and refreshed in the background once older, so
+// reads only wait for the first check of a replica, which they share.
+type Router struct {
+	pool *Pool
+	opts RouterOptions
+
+	mu       sync.Mutex
+	states   map[string]*replicaState
+	checking map[string]*pendingCheck
+	rand     *rand.Rand
+}
+
+// NewRouter creates a router over the connectors of pool
+func NewRouter(pool *Pool, opts RouterOptions) *Router {
+	return &Router{
+		pool:     pool,
+		opts:     opts,
+		states:   make(map[string]*replicaState),
+		checking: make(map[string]*pendingCheck),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Get returns the connector a read on the data source under key runs on.
+// Sets without replicas always read from the primary.
+func (r *Router) Get(ctx context.Context, key string, set *ReplicaSet) (*Connector, Route, error) {
+	if len(set.Replicas) == 0 {
+		connector, err := r.pool.Get(key, set.Primary)
+		return connector, Route{Target: RoutePrimary}, err
+	}
+
+	type candidate struct {
+		replica Replica
+		lag     time.Duration
+	}
+	var healthy []candidate
+	var reasons []string
+	totalWeight := 0
+	for _, replica := range set.Replicas {
+		if replica.Weight <= 0 {
+			continue
+		}
+		lag, err := r.check(ctx, key, replica, set.MaxLag > 0)
+		if err == nil && set.MaxLag > 0 && lag > set.MaxLag {
+			err = fmt.Errorf("lags %s behind, more than %s", lag.Round(time.Second), set.MaxLag)
+		}
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", replica.Name, err))
+			continue
+		}
+		healthy = append(healthy, candidate{replica: replica, lag: lag})
+		totalWeight += replica.Weight
+	}
+
+	if len(healthy) > 0 {
+		r.mu.Lock()
+		pick := r.rand.Intn(totalWeight)
+		r.mu.Unlock()
+
+		chosen := healthy[len(healthy)-1]
+		for _, c := range healthy {
+			if pick < c.replica.Weight {
+				chosen = c
+				break
+			}
+			pick -= c.replica.Weight
+		}
+		connector, err := r.pool.Get(replicaKey(key, chosen.replica.Name), chosen.replica.Config)
+		return connector, Route{Target: chosen.replica.Name, Lag: chosen.lag}, err
+	}
+
+	if !set.FallbackToPrimary {
+		if len(reasons) == 0 {
+			return nil, Route{}, fmt.Errorf("%w: every replica has weight 0", ErrNoHealthyReplica)
+		}
+		return nil, Route{}, fmt.Errorf("%w: %s", ErrNoHealthyReplica, strings.Join(reasons, "; "))
+	}
+	connector, err := r.pool.Get(key, set.Primary)
+	return connector, Route{Target: RoutePrimary, Fallback: true}, err
+}
+
+// check returns the replication lag of a replica, or why it cannot take
+// reads. The lag is only queried when withLag is set, as it may need
+// privileges reads do not.
+func (r *Router) check(ctx context.Context, key string, replica Replica, withLag bool) (time.Duration, error) {
+	rkey := replicaKey(key, replica.Name)
+	fingerprint := configFingerprint(replica.Config)
+
+	r.mu.Lock()
+	state, ok := r.states[rkey]
+	// A failed lag check says nothing about reads that do not check lag
+	reusable := ok && (state.lagChecked == withLag || (state.lagChecked && state.err == nil))
+	if reusable && state.fingerprint == fingerprint {
+		if time.Since(state.checked) >= r.opts.CheckInterval {
+			r.startCheckLocked(rkey, fingerprint, replica.Config, state.lagChecked)
+		}
+		r.mu.Unlock()
+		return state.lag, state.err
+	}
+	pending := r.startCheckLocked(rkey, fingerprint, replica.Config, withLag)
+	r.mu.Unlock()
+
+	select {
+	case <-pending.done:
+		return pending.state.lag, pending.state.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// startCheckLocked checks a replica in the background unless the same check
+// of it is already running, and returns the check. r.mu must be held.
+func (r *Router) startCheckLocked(rkey, fingerprint string, config *ConnectionConfig, withLag bool) *pendingCheck {
+	checkKey := fmt.Sprintf("%s|%s|%t", rkey, fingerprint, withLag)
+	if pending, ok := r.checking[checkKey]; ok {
+		return pending
+	}
+	pending := &pendingCheck{done: make(chan struct{})}
+	r.checking[checkKey] = pending
+
+	go func() {
+		state := &replicaState{fingerprint: fingerprint, checked: time.Now(), lagChecked: withLag}
+		state.lag, state.err = r.measure(context.Background(), rkey, config, withLag)
+		pending.state = state
+
+		r.mu.Lock()
+		// A check outlived by Remove must not bring back the replica's state
+		if r.checking[checkKey] == pending {
+			delete(r.checking, checkKey)
+			r.states[rkey] = state
+		}
+		r.mu.Unlock()
+		close(pending.done)
+	}()
+	return pending
+}
+
+// measure connects to a replica and pings it or reads its replication lag
+func (r *Router) measure(ctx context.Context, rkey string, config *ConnectionConfig, withLag bool) (time.Duration, error) {
+	connector, err := r.pool.Get(rkey, config)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.CheckTimeout)
+	defer cancel()
+
+	if !withLag {
+		return 0, connector.DB().PingContext(ctx)
+	}
+	checker, ok := connector.dialect.(ReplicaLagChecker)
+	if !ok {
+		return 0, fmt.Errorf("replication lag cannot be checked for %s", config.Type)
+	}
+	lag, err := checker.ReplicationLag(ctx, connector.DB())
+	if err != nil {
+		return 0, fmt.Errorf("failed to check replication lag: %w", err)
+	}
+	return lag, nil
+}
+
+// Remove closes the connectors of the data source under key and of its
+// replicas, and forgets their checks. Call it when the data source is changed
+// or deleted.
+func (r *Router) Remove(key string) {
+	r.pool.Remove(key)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for rkey := range r.states {
+		if strings.HasPrefix(rkey, replicaKey(key, "")) {
+			delete(r.states, rkey)
+		}
+	}
+	for checkKey := range r.checking {
+		if strings.HasPrefix(checkKey, replicaKey(key, "")) {
+			delete(r.checking, checkKey)
+		}
+	}
+}
+
+// replicaKey is the pool key of a replica of the data source under key
+func replicaKey(key, name string) string {
+	return key + "#replica:" + name
+}
+
+// SupportsReplicationLag reports whether the lag of replicas of a database
+// type can be checked
+func SupportsReplicationLag(dbType DBType) bool {
+	d, ok := Lookup(dbType)
+	if !ok {
+		return false
+	}
+	_, ok = d.(ReplicaLagChecker)
+	return ok
+}
+
+// queryLagSeconds runs a query returning a replication lag in seconds
+func queryLagSeconds(ctx context.Context, db *sql.DB, query string) (time.Duration, error) {
+	var seconds sql.NullFloat64
+	if err := db.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
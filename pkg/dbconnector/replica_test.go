@@ -0,0 +1,144 @@
+package dbconnector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteReplicaSet returns a set whose primary and replicas are copies of the
+// test database, so reads show where they were routed
+func sqliteReplicaSet(t *testing.T, names ...string) *ReplicaSet {
+	dir, file := setupSQLite(t)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	set := &ReplicaSet{Primary: &ConnectionConfig{Type: SQLite, Database: "app.db"}}
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".db"), data, 0o600))
+		set.Replicas = append(set.Replicas, Replica{Name: name, Weight: 1, Config: &ConnectionConfig{Type: SQLite, Database: name + ".db"}})
+	}
+	return set
+}
+
+func newTestRouter(t *testing.T) *Router {
+	pool := NewPool(DefaultPoolOptions())
+	t.Cleanup(func() { pool.Close() })
+	return NewRouter(pool, DefaultRouterOptions())
+}
+
+func TestRouter_WithoutReplicasReadsPrimary(t *testing.T) {
+	set := sqliteReplicaSet(t)
+
+	connector, route, err := newTestRouter(t).Get(context.Background(), "ds", set)
+	require.NoError(t, err)
+	assert.Equal(t, Route{Target: RoutePrimary}, route)
+	assert.Same(t, set.Primary, connector.config)
+}
+
+func TestRouter_RoutesByWeight(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1", "r2")
+	set.Replicas[0].Weight = 0
+	router := newTestRouter(t)
+
+	for i := 0; i < 20; i++ {
+		connector, route, err := router.Get(context.Background(), "ds", set)
+		require.NoError(t, err)
+		assert.Equal(t, "r2", route.Target, "replicas with weight 0 take no reads")
+		assert.Same(t, set.Replicas[1].Config, connector.config)
+	}
+}
+
+func TestRouter_UnhealthyReplicas(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1")
+	set.Replicas[0].Config.Database = "missing.db"
+	router := newTestRouter(t)
+
+	_, _, err := router.Get(context.Background(), "ds", set)
+	assert.ErrorIs(t, err, ErrNoHealthyReplica)
+	assert.Contains(t, err.Error(), "r1:")
+
+	set.FallbackToPrimary = true
+	connector, route, err := router.Get(context.Background(), "ds", set)
+	require.NoError(t, err)
+	assert.Equal(t, Route{Target: RoutePrimary, Fallback: true}, route)
+	assert.Same(t, set.Primary, connector.config)
+}
+
+func TestRouter_LagThreshold(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1")
+	set.MaxLag = time.Second
+	router := newTestRouter(t)
+
+	// SQLite cannot report replication lag, so a replica that must be checked is not trusted
+	_, _, err := router.Get(context.Background(), "ds", set)
+	assert.ErrorIs(t, err, ErrNoHealthyReplica)
+	assert.Contains(t, err.Error(), "replication lag cannot be checked")
+
+	set.MaxLag = 0
+	_, route, err := router.Get(context.Background(), "ds", set)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", route.Target, "a failed lag check does not matter once lag is not checked")
+}
+
+func TestRouter_ConcurrentReadsShareCheck(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1")
+	router := newTestRouter(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, route, err := router.Get(context.Background(), "ds", set)
+			assert.NoError(t, err)
+			assert.Equal(t, "r1", route.Target)
+		}()
+	}
+	wg.Wait()
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	assert.Empty(t, router.checking)
+	assert.Len(t, router.states, 1)
+}
+
+func TestRouter_RefreshesExpiredChecksInBackground(t *testing.T) {
+	set := sqliteReplicaSet(t, "r1")
+	router := newTestRouter(t)
+	rkey := replicaKey("ds", "r1")
+
+	_, _, err := router.Get(context.Background(), "ds", set)
+	require.NoError(t, err)
+
+	router.mu.Lock()
+	expired := *router.states[rkey]
+	expired.checked = time.Now().Add(-time.Hour)
+	router.states[rkey] = &expired
+	router.mu.Unlock()
+
+	_, route, err := router.Get(context.Background(), "ds", set)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", route.Target, "the expired check is used while it is refreshed")
+
+	assert.Eventually(t, func() bool {
+		router.mu.Lock()
+		defer router.mu.Unlock()
+		return time.Since(router.states[rkey].checked) < time.Minute
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestParseOracleInterval(t *testing.T) {
+	lag, err := parseOracleInterval("+01 02:03:04")
+	require.NoError(t, err)
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, lag)
+
+	_, err = parseOracleInterval("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxSessionStatements caps ConnectionConfig.SessionInit, as every statement
+// costs a round trip on each new connection
+const maxSessionStatements = 10
+
+// ValidateSessionInit checks that statements only change session settings the
+// database type allows. Dialects that do not implement SessionValidator
+// accept no statements.
+func ValidateSessionInit(dbType DBType, statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+	d, ok := Lookup(dbType)
+	if !ok {
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	v, ok := d.(SessionValidator)
+	if !ok {
+		return fmt.Errorf("session initialization statements are not supported for %s", dbType)
+	}
+	if len(statements) > maxSessionStatements {
+		return fmt.Errorf("at most %d session initialization statements are allowed", maxSessionStatements)
+	}
+	for _, stmt := range statements {
+		if err := v.ValidateSessionStatement(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Patterns for the values of allowed session statements: identifiers,
+// quoted identifiers, string literals and numbers
+const (
+	sessionIdent     = `(?:[A-Za-z_][A-Za-z0-9_$#]*|"[^"]+"|\[[^\]]+\]|` + "`[^`]+`" + `)`
+	sessionString    = `'(?:[^']|'')*'`
+	sessionNumber    = `-?\d+(?:\.\d+)?`
+	sessionValue     = `(?:` + sessionIdent + `|` + sessionString + `|` + sessionNumber + `)`
+	sessionValueList = sessionValue + `(?:\s*,\s*` + sessionValue + `)*`
+)
+
+// sessionAllowlist holds the patterns a session statement must match in full
+type sessionAllowlist []*regexp.Regexp
+
+// newSessionAllowlist compiles case-insensitive statement patterns
+func newSessionAllowlist(patterns ...string) sessionAllowlist {
+	list := make(sessionAllowlist, len(patterns))
+	for i, p := range patterns {
+		list[i] = regexp.MustCompile(`(?i)^` + p + `$`)
+	}
+	return list
+}
+
+// sessionSettings returns a pattern matching any of names
+func sessionSettings(names ...string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	return `(?:` + strings.Join(quoted, "|") + `)`
+}
+
+// validate checks that stmt is a single statement matching one of the patterns
+func (a sessionAllowlist) validate(dbType DBType, stmt string) error {
+	trimmed := strings.TrimSpace(stmt)
+	trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, ";"))
+	if trimmed == "" {
+		return fmt.Errorf("session initialization statement is empty")
+	}
+	if strings.Contains(trimmed, ";") || strings.Contains(trimmed, "--") || strings.Contains(trimmed, "/*") {
+		return fmt.Errorf("session initialization statement %q must be a single statement without comments", stmt)
+	}
+	for _, pattern := range a {
+		if pattern.MatchString(trimmed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("session initialization statement %q is not allowed for %s", stmt, dbType)
+}
+
+// SessionError reports a session statement the server rejected on a new connection
+type SessionError struct {
+	Statement string
+	Err       error
+}
+
+func (e *SessionError) Error() string {
+	return fmt.Sprintf("session setup %q failed: %v", e.Statement, e.Err)
+}
+
+func (e *SessionError) Unwrap() error {
+	return e.Err
+}
+
+// openDB opens a database through driverName. Every new connection runs the
+// session statements before it is handed out, so settings survive the pool
+// replacing connections.
+func openDB(driverName, dsn string, session []string) (*sql.DB, error) {
+	if len(session) == 0 {
+		return sql.Open(driverName, dsn)
+	}
+
+	// sql.Open only looks the driver up, it does not connect
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	_ = probe.Close()
+
+	var base driver.Connector
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if base, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		base = &dsnConnector{dsn: dsn, driver: drv}
+	}
+
+	return openConnector(base, session), nil
+}
+
+// openConnector opens a database through a driver connector, running the
+// session statements on every new connection like openDB
+func openConnector(base driver.Connector, session []string) *sql.DB {
+	if len(session) == 0 {
+		return sql.OpenDB(base)
+	}
+	return sql.OpenDB(&sessionConnector{Connector: base, statements: session})
+}
+
+// dsnConnector adapts a driver without driver.DriverContext to driver.Connector
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sessionConnector runs statements on every connection it opens
+type sessionConnector struct {
+	driver.Connector
+	statements []string
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range c.statements {
+		if err := execSession(ctx, conn, stmt); err != nil {
+			conn.Close()
+			return nil, &SessionError{Statement: stmt, Err: err}
+		}
+	}
+	return conn, nil
+}
+
+// execSession executes a statement without arguments on a driver connection
+func execSession(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+		return err
+	}
+	_, err = stmt.Exec(nil)
+	return err
+}
@@ -0,0 +1,83 @@
+package dbconnector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSessionInit(t *testing.T) {
+	allowed := map[DBType][]string{
+		PostgreSQL: {
+			"SET search_path TO analytics, public",
+			"set search_path = \"Sales\", public;",
+			"SET TIME ZONE 'UTC'",
+			"SET SESSION statement_timeout = '30s'",
+			"SET ROLE analyst_ro",
+		},
+		MySQL: {
+			"SET time_zone = '+00:00'",
+			"SET @@session.max_execution_time = 30000",
+			"SET NAMES utf8mb4 COLLATE utf8mb4_unicode_ci",
+			"SET ROLE 'analyst'@'%'",
+		},
+		MSSQL: {
+			"SET LOCK_TIMEOUT 5000",
+			"SET DATEFORMAT dmy",
+			"SET TRANSACTION ISOLATION LEVEL READ UNCOMMITTED",
+			"SET ARITHABORT ON",
+		},
+		Oracle: {
+			"ALTER SESSION SET CURRENT_SCHEMA = sales",
+			"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY-MM-DD'",
+			"SET ROLE analyst_ro",
+		},
+	}
+	for dbType, statements := range allowed {
+		for _, stmt := range statements {
+			assert.NoError(t, ValidateSessionInit(dbType, []string{stmt}), stmt)
+		}
+	}
+
+	rejected := map[DBType][]string{
+		PostgreSQL: {
+			"SET default_transaction_read_only = off",
+			"SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE",
+			"SET SESSION AUTHORIZATION postgres",
+			"SET search_path TO public; DELETE FROM orders",
+			"SET search_path TO public -- comment",
+			"DELETE FROM orders",
+			"",
+		},
+		MySQL: {
+			"SET SESSION TRANSACTION READ WRITE",
+			"SET transaction_read_only = 0",
+			"SET GLOBAL time_zone = '+00:00'",
+		},
+		MSSQL: {
+			"EXECUTE AS USER = 'dbo'",
+			"SET IDENTITY_INSERT orders ON",
+		},
+		Oracle: {
+			"ALTER SESSION SET CURRENT_SCHEMA = sales /* x */",
+			"SET ROLE dba IDENTIFIED BY secret",
+		},
+		SQLite:     {"PRAGMA query_only = 0"},
+		ClickHouse: {"SET readonly = 0"},
+	}
+	for dbType, statements := range rejected {
+		for _, stmt := range statements {
+			assert.Error(t, ValidateSessionInit(dbType, []string{stmt}), stmt)
+		}
+	}
+
+	assert.NoError(t, ValidateSessionInit(SQLite, nil))
+	assert.Error(t, ValidateSessionInit(PostgreSQL, make([]string, maxSessionStatements+1)))
+}
+
+func TestConnector_SessionRunsReadOnlyLast(t *testing.T) {
+	config := &ConnectionConfig{Type: MySQL, SessionInit: []string{"SET time_zone = '+00:00'"}}
+
+	assert.Equal(t, []string{"SET time_zone = '+00:00'", "SET SESSION TRANSACTION READ ONLY"}, NewConnector(config).session(config))
+	assert.Equal(t, []string{"SET time_zone = '+00:00'"}, config.SessionInit, "the config is left unchanged")
+}
@@ -0,0 +1,445 @@
+package dbconnector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteDriverName is the driver SQLite and file datasources are opened with:
+// the modernc driver with ATTACH disabled on every connection, so a query
+// cannot open database files outside the sandbox
+const sqliteDriverName = "sqlite_sandboxed"
+
+func init() {
+	Register(sqliteDialect{})
+
+	drv := &sqlite.Driver{}
+	drv.RegisterConnectionHook(disableAttach)
+	sql.Register(sqliteDriverName, drv)
+}
+
+// sqliteLimitAttached is SQLITE_LIMIT_ATTACHED, the most databases a connection may attach
+const sqliteLimitAttached = 7
+
+// sqliteDriverModule and sqliteDriverVersion pin the modernc driver that
+// sqliteConnLimit links into: the method is unexported, so its receiver and
+// signature are only known for this release. Upgrading the driver means
+// re-checking (*conn).limit and bumping the version here
+const (
+	sqliteDriverModule  = "modernc.org/sqlite"
+	sqliteDriverVersion = "v1.29.0"
+)
+
+// sqliteConnLimit is sqlite3_limit on a connection of the modernc driver,
+// which does not export it for the driver.Conn its connection hooks receive
+//
+//go:linkname sqliteConnLimit modernc.org/sqlite.(*conn).limit
+func sqliteConnLimit(conn unsafe.Pointer, id int, newVal int) int
+
+// disableAttach allows no attached databases on a new connection, so ATTACH
+// fails whatever file it names
+func disableAttach(conn sqlite.ExecQuerierContext, _ string) error {
+	if v := linkedSQLiteVersion(); v != sqliteDriverVersion {
+		return fmt.Errorf("cannot disable attached databases: %s is %s, not the pinned %s",
+			sqliteDriverModule, v, sqliteDriverVersion)
+	}
+	c := reflect.ValueOf(conn)
+	if c.Kind() != reflect.Pointer {
+		return fmt.Errorf("unexpected sqlite connection type %T", conn)
+	}
+	sqliteConnLimit(c.UnsafePointer(), sqliteLimitAttached, 0)
+	if n := sqliteConnLimit(c.UnsafePointer(), sqliteLimitAttached, -1); n != 0 {
+		return fmt.Errorf("failed to disable attached databases: limit is %d", n)
+	}
+	return nil
+}
+
+// linkedSQLiteVersion is the version of the modernc driver built into the
+// binary, or "" when the build carries no module information
+var linkedSQLiteVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != sqliteDriverModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+})
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() DBType       { return SQLite }
+func (sqliteDialect) DriverName() string { return sqliteDriverName }
+
+// BuildDSN opens the database file Database names
+func (sqliteDialect) BuildDSN(config *ConnectionConfig) (string, error) {
+	return buildSQLiteDSN(config.Database)
+}
+
+func (sqliteDialect) ResolvePath(path string) (string, error) {
+	return ResolveSQLitePath(path)
+}
+
+func (sqliteDialect) VersionQuery() string { return "SELECT 'SQLite ' || sqlite_version()" }
+
+// IsUnknownDatabase returns false: opening a missing file fails in ResolvePath
+func (sqliteDialect) IsUnknownDatabase(error) bool { return false }
+
+// Bind numbers placeholders so a parameter used twice binds the same argument.
+// The driver runs every statement of a query, so queries of more than one
+// statement are rejected.
+func (sqliteDialect) Bind(ctx context.Context, query string, params map[string]interface{}, _ map[string]string) (context.Context, string, []interface{}, error) {
+	if !isSingleStatement(query) {
+		return ctx, "", nil, errors.New("sqlite queries must be a single statement")
+	}
+	converted, args := bindSQLite(query, params)
+	return ctx, converted, args, nil
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string { return quoteWith(name, `"`, `"`) }
+
+// ReadOnlySession returns nil: the DSN opens files read-only with query_only
+func (sqliteDialect) ReadOnlySession() []string { return nil }
+
+func (sqliteDialect) ParamType(columnType string) string { return genericParamType(columnType) }
+
+func (sqliteDialect) GetSchema(db *sql.DB, _ *ConnectionConfig) ([]TableInfo, error) {
+	return getSQLiteSchema(db)
+}
+
+func (sqliteDialect) GetColumns(db *sql.DB, _ *ConnectionConfig, _, table string) ([]ColumnInfo, error) {
+	return getSQLiteColumns(db, table)
+}
+
+func bindSQLite(query string, params map[string]interface{}) (string, []interface{}) {
+	return BindNumbered(query, params, func(n int) string { return fmt.Sprintf("?%d", n) })
+}
+
+// isSingleStatement reports whether query holds one statement, optionally
+// followed by a semicolon. Semicolons in string literals, quoted identifiers
+// and comments do not end a statement.
+func isSingleStatement(query string) bool {
+	ended := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`' || ch == '[':
+			closing := ch
+			if ch == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				return !ended
+			}
+			if ended {
+				return false
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return true
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return true
+			}
+			i += end + 3
+		case ch == ';':
+			ended = true
+		case ended && !isSpace(ch):
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == '\v'
+}
+
+var (
+	// ErrSQLiteDisabled is returned when no directory is allowed for SQLite database files
+	ErrSQLiteDisabled = errors.New("sqlite datasources are disabled: no allowed directory is configured")
+	// ErrSQLitePathNotAllowed is returned when a database file resolves outside the allowed directory
+	ErrSQLitePathNotAllowed = errors.New("sqlite database path is outside the allowed directory")
+)
+
+// SQLiteConfig restricts where SQLite database files may be opened from
+type SQLiteConfig struct {
+	AllowedDir  string // database files must resolve inside this directory; empty disables SQLite
+	AllowWrites bool   // open database files read-write instead of read-only
+}
+
+var (
+	sqliteMu     sync.RWMutex
+	sqliteConfig SQLiteConfig
+)
+
+// ConfigureSQLite sets the sandbox applied to every SQLite connection
+func ConfigureSQLite(cfg SQLiteConfig) {
+	sqliteMu.Lock()
+	defer sqliteMu.Unlock()
+	sqliteConfig = cfg
+}
+
+func currentSQLiteConfig() SQLiteConfig {
+	sqliteMu.RLock()
+	defer sqliteMu.RUnlock()
+	return sqliteConfig
+}
+
+// ResolveSQLitePath resolves a database file path against the allowed directory.
+// Relative paths are taken relative to that directory. Symlinks are followed
+// before the check so they cannot point outside of it.
+func ResolveSQLitePath(path string) (string, error) {
+	cfg := currentSQLiteConfig()
+	if cfg.AllowedDir == "" {
+		return "", ErrSQLiteDisabled
+	}
+
+	// The path ends up in a file: URI, where these would change its meaning
+	if path == "" || strings.ContainsAny(path, "?#%") || strings.HasPrefix(path, "file:") || path == ":memory:" {
+		return "", fmt.Errorf("invalid sqlite database path %q", path)
+	}
+
+	resolved, err := resolveInDir(cfg.AllowedDir, path)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOutsideDir):
+			return "", ErrSQLitePathNotAllowed
+		case errors.Is(err, os.ErrNotExist):
+			return "", fmt.Errorf("sqlite database file not found: %s", path)
+		default:
+			return "", fmt.Errorf("failed to resolve sqlite database path: %w", err)
+		}
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat sqlite database file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("sqlite database path is a directory: %s", path)
+	}
+
+	return resolved, nil
+}
+
+// errOutsideDir is returned by resolveInDir for paths that resolve outside the directory
+var errOutsideDir = errors.New("path is outside the directory")
+
+// resolveInDir resolves path against dir. Relative paths are taken relative to
+// dir, and symlinks are followed before checking that the result is inside it.
+func resolveInDir(dir, path string) (string, error) {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid allowed directory: %w", err)
+	}
+	if base, err = filepath.EvalSymlinks(base); err != nil {
+		return "", fmt.Errorf("invalid allowed directory: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errOutsideDir
+	}
+	return resolved, nil
+}
+
+// buildSQLiteDSN returns a file: URI opening the database file, read-only unless writes are allowed
+func buildSQLiteDSN(path string) (string, error) {
+	resolved, err := ResolveSQLitePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if currentSQLiteConfig().AllowWrites {
+		return fmt.Sprintf("file:%s?mode=rw&_pragma=busy_timeout(5000)", resolved), nil
+	}
+	return fmt.Sprintf("file:%s?mode=ro&_pragma=query_only(1)&_pragma=busy_timeout(5000)", resolved), nil
+}
+
+// sqliteTables restricts catalog queries to user tables and views
+const sqliteTables = `m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'`
+
+func getSQLiteSchema(db *sql.DB) ([]TableInfo, error) {
+	ctx := context.Background()
+
+	var tables []TableInfo
+	err := forEachRow(ctx, db, `
+		SELECT m.name, m.type
+		FROM sqlite_master m
+		WHERE `+sqliteTables+`
+		ORDER BY m.name
+	`, nil, func(rows *sql.Rows) error {
+		t := TableInfo{Schema: "main"}
+		var kind string
+		if err := rows.Scan(&t.Name, &kind); err != nil {
+			return err
+		}
+		t.Kind = TableKindTable
+		if kind == "view" {
+			t.Kind = TableKindView
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog := newSchemaCatalog(tables)
+
+	if err := scanSQLiteColumns(ctx, db, "", nil, catalog.addColumn); err != nil {
+		return nil, err
+	}
+
+	// Row counts are only known once ANALYZE has filled sqlite_stat1, whose
+	// stat column starts with the number of rows
+	var analyzed bool
+	if err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_stat1')",
+	).Scan(&analyzed); err != nil {
+		return nil, err
+	}
+	if analyzed {
+		err = forEachRow(ctx, db, `
+			SELECT tbl, MAX(CAST(stat AS INTEGER))
+			FROM sqlite_stat1
+			GROUP BY tbl
+		`, nil, func(rows *sql.Rows) error {
+			var table string
+			var estimate sql.NullInt64
+			if err := rows.Scan(&table, &estimate); err != nil {
+				return err
+			}
+			if t := catalog.table("main", table); t != nil {
+				t.RowEstimate = rowEstimate(estimate)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// SQLite does not name foreign keys, so rows are grouped by their id. A
+	// reference without columns points at the primary key of the parent table.
+	var lastTable string
+	lastID := -1
+	err = forEachRow(ctx, db, `
+		SELECT m.name, f.id, f."table", f."from",
+			   COALESCE(f."to", (SELECT p.name FROM pragma_table_info(f."table") p WHERE p.pk = f.seq + 1), '')
+		FROM sqlite_master m
+		JOIN pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, f.id, f.seq
+	`, nil, func(rows *sql.Rows) error {
+		var table, refTable, column, refColumn string
+		var id int
+		if err := rows.Scan(&table, &id, &refTable, &column, &refColumn); err != nil {
+			return err
+		}
+		t := catalog.table("main", table)
+		if t == nil {
+			return nil
+		}
+		if table != lastTable || id != lastID {
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKeyInfo{ReferencedSchema: "main", ReferencedTable: refTable})
+			lastTable, lastID = table, id
+		}
+		fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys of expression indexes have no name; INTEGER PRIMARY KEY columns are
+	// the rowid and have no index
+	err = forEachRow(ctx, db, `
+		SELECT m.name, il.name, il."unique", il.origin, COALESCE(ii.name, '')
+		FROM sqlite_master m
+		JOIN pragma_index_list(m.name) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, il.name, ii.seqno
+	`, nil, func(rows *sql.Rows) error {
+		var table, name, origin, column string
+		var unique int
+		if err := rows.Scan(&table, &name, &unique, &origin, &column); err != nil {
+			return err
+		}
+		catalog.addIndexColumn("main", table, name, unique == 1, origin == "pk", column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.tables, nil
+}
+
+func getSQLiteColumns(db *sql.DB, tableName string) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+	err := scanSQLiteColumns(context.Background(), db, "AND m.name = ?", []interface{}{tableName},
+		func(_, _ string, column ColumnInfo) {
+			columns = append(columns, column)
+		})
+	return columns, err
+}
+
+// scanSQLiteColumns reads the columns of the tables and views matching filter
+func scanSQLiteColumns(ctx context.Context, db *sql.DB, filter string, args []interface{}, add func(schema, table string, column ColumnInfo)) error {
+	return forEachRow(ctx, db, `
+		SELECT m.name, p.name, p.type, p."notnull", p.pk, p.dflt_value
+		FROM sqlite_master m
+		JOIN pragma_table_info(m.name) p
+		WHERE `+sqliteTables+` `+filter+`
+		ORDER BY m.name, p.cid
+	`, args, func(rows *sql.Rows) error {
+		var table string
+		var column ColumnInfo
+		var notNull, pk int
+		var def sql.NullString
+		if err := rows.Scan(&table, &column.Name, &column.Type, &notNull, &pk, &def); err != nil {
+			return err
+		}
+		column.Nullable = notNull == 0
+		column.PrimaryKey = pk > 0
+		column.Default = nullableString(def)
+		add("main", table, column)
+		return nil
+	})
+}
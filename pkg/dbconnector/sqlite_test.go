@@ -0,0 +1,258 @@
+package dbconnector
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSQLite creates a database file with a users table inside a fresh allowed directory
+func setupSQLite(t *testing.T) (dir, file string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	file = filepath.Join(dir, "app.db")
+
+	db, err := sql.Open("sqlite", file)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT);
+		INSERT INTO users (id, name, email) VALUES (1, 'alice', 'alice@example.com'), (2, 'bob', NULL);
+		CREATE VIEW named_users AS SELECT id, name FROM users;
+	`)
+	require.NoError(t, err)
+
+	ConfigureSQLite(SQLiteConfig{AllowedDir: dir})
+	t.Cleanup(func() { ConfigureSQLite(SQLiteConfig{}) })
+	return dir, file
+}
+
+func TestResolveSQLitePath(t *testing.T) {
+	dir, file := setupSQLite(t)
+
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	want := filepath.Join(resolvedDir, "app.db")
+
+	got, err := ResolveSQLitePath("app.db")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = ResolveSQLitePath(file)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestResolveSQLitePath_Sandbox(t *testing.T) {
+	dir, _ := setupSQLite(t)
+
+	outside := filepath.Join(t.TempDir(), "other.db")
+	require.NoError(t, os.WriteFile(outside, nil, 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.db")))
+
+	_, err := ResolveSQLitePath(outside)
+	assert.ErrorIs(t, err, ErrSQLitePathNotAllowed)
+
+	_, err = ResolveSQLitePath("../" + filepath.Base(filepath.Dir(outside)) + "/other.db")
+	assert.Error(t, err)
+
+	_, err = ResolveSQLitePath("link.db")
+	assert.ErrorIs(t, err, ErrSQLitePathNotAllowed)
+
+	for _, path := range []string{"", ":memory:", "file:app.db", "app.db?mode=rwc", "missing.db", "."} {
+		_, err = ResolveSQLitePath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestResolveSQLitePath_Disabled(t *testing.T) {
+	ConfigureSQLite(SQLiteConfig{})
+
+	_, err := ResolveSQLitePath("app.db")
+	assert.ErrorIs(t, err, ErrSQLiteDisabled)
+}
+
+func TestConnector_convertNamedParams_SQLite(t *testing.T) {
+	config := &ConnectionConfig{Type: SQLite}
+	connector := NewConnector(config)
+
+	query := "SELECT * FROM users WHERE id = :id OR parent_id = :id OR name = :name"
+	params := map[string]interface{}{
+		"id":   1,
+		"name": "test",
+	}
+
+	convertedQuery, args := connector.convertNamedParams(query, params)
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?1 OR parent_id = ?1 OR name = ?2", convertedQuery)
+	assert.Equal(t, []interface{}{1, "test"}, args)
+}
+
+func TestConnector_SQLite_Query(t *testing.T) {
+	setupSQLite(t)
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	defer connector.Close()
+
+	result, err := connector.ExecuteQueryWithColumns(
+		"SELECT id, name FROM users WHERE id = :id OR name = :name ORDER BY id",
+		map[string]interface{}{"id": 1, "name": "bob"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name"}, result.Columns)
+	require.Len(t, result.Data, 2)
+	assert.Equal(t, "alice", result.Data[0]["name"])
+	assert.Equal(t, "bob", result.Data[1]["name"])
+}
+
+func TestConnector_SQLite_ReadOnly(t *testing.T) {
+	setupSQLite(t)
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	defer connector.Close()
+
+	_, err := connector.Exec("DELETE FROM users")
+	assert.Error(t, err)
+}
+
+func TestConnector_SQLite_NoAttach(t *testing.T) {
+	dir, _ := setupSQLite(t)
+
+	// A database outside the allowed directory
+	outside := filepath.Join(t.TempDir(), "secret.db")
+	db, err := sql.Open("sqlite", outside)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE s (x TEXT); INSERT INTO s VALUES ('secret')")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	defer connector.Close()
+	connector.DB().SetMaxOpenConns(1)
+
+	_, err = connector.ExecuteQueryWithColumns("SELECT 1; ATTACH DATABASE '"+outside+"' AS s2", nil)
+	assert.ErrorContains(t, err, "single statement")
+
+	// Even a query reaching the driver directly cannot attach
+	_, err = connector.Exec("ATTACH DATABASE '" + outside + "' AS s2")
+	assert.Error(t, err)
+	_, err = connector.Exec("ATTACH DATABASE '" + filepath.Join(dir, "app.db") + "' AS s3")
+	assert.Error(t, err, "not even files inside the allowed directory")
+
+	_, err = connector.ExecuteQueryWithColumns("SELECT x FROM s2.s", nil)
+	assert.Error(t, err)
+}
+
+func TestSQLiteDriverVersionPinned(t *testing.T) {
+	// disableAttach links into an unexported method of this exact release
+	assert.Equal(t, sqliteDriverVersion, linkedSQLiteVersion(),
+		"re-check (*conn).limit in the new driver before bumping sqliteDriverVersion")
+}
+
+func TestIsSingleStatement(t *testing.T) {
+	tests := []struct {
+		query  string
+		single bool
+	}{
+		{"SELECT 1", true},
+		{"SELECT 1;", true},
+		{"SELECT 1 ;\n  -- done\n", true},
+		{"SELECT ';' AS a, \"x;y\", [z;] FROM t", true},
+		{"SELECT 1 /* ; */ FROM t", true},
+		{"SELECT 1; ATTACH DATABASE 'x' AS s2", false},
+		{"SELECT 1; SELECT 2", false},
+		{"SELECT 1;/* c */SELECT 2", false},
+		{"SELECT 1; 'x'", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.single, isSingleStatement(tt.query), tt.query)
+	}
+}
+
+func TestConnector_SQLite_Schema(t *testing.T) {
+	setupSQLite(t)
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	defer connector.Close()
+
+	tables, err := connector.GetSchema()
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+	assert.Equal(t, "named_users", tables[0].Name)
+	assert.Equal(t, "users", tables[1].Name)
+	assert.Equal(t, "main", tables[1].Schema)
+
+	columns, err := connector.GetTableSchema("main", "users")
+	require.NoError(t, err)
+	assert.Equal(t, []ColumnInfo{
+		{Name: "id", Type: "INTEGER", Nullable: true, PrimaryKey: true, ParamType: ParamTypeInteger},
+		{Name: "name", Type: "TEXT", Nullable: false, ParamType: ParamTypeString},
+		{Name: "email", Type: "TEXT", Nullable: true, ParamType: ParamTypeString},
+	}, columns)
+}
+
+func TestConnector_SQLite_SchemaDetails(t *testing.T) {
+	_, file := setupSQLite(t)
+
+	db, err := sql.Open("sqlite", file)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE regions (code TEXT, country TEXT, PRIMARY KEY (code, country));
+		CREATE TABLE orders (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users,
+			region TEXT, country TEXT,
+			status TEXT DEFAULT 'new',
+			FOREIGN KEY (region, country) REFERENCES regions (code, country)
+		);
+		CREATE UNIQUE INDEX orders_user_status ON orders (user_id, status);
+		ANALYZE;
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	connector := NewConnector(&ConnectionConfig{Type: SQLite, Database: "app.db"})
+	require.NoError(t, connector.Connect())
+	defer connector.Close()
+
+	tables, err := connector.GetSchema()
+	require.NoError(t, err)
+	byName := make(map[string]TableInfo)
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	assert.Equal(t, TableKindView, byName["named_users"].Kind)
+	assert.Nil(t, byName["named_users"].RowEstimate)
+	require.NotNil(t, byName["users"].RowEstimate)
+	assert.Equal(t, int64(2), *byName["users"].RowEstimate)
+
+	orders := byName["orders"]
+	assert.Equal(t, TableKindTable, orders.Kind)
+	assert.Equal(t, []ForeignKeyInfo{
+		{Columns: []string{"region", "country"}, ReferencedSchema: "main", ReferencedTable: "regions", ReferencedColumns: []string{"code", "country"}},
+		{Columns: []string{"user_id"}, ReferencedSchema: "main", ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+	}, orders.ForeignKeys)
+	assert.Equal(t, []IndexInfo{
+		{Name: "orders_user_status", Columns: []string{"user_id", "status"}, Unique: true},
+	}, orders.Indexes)
+	assert.Equal(t, []IndexInfo{
+		{Name: "sqlite_autoindex_regions_1", Columns: []string{"code", "country"}, Unique: true, Primary: true},
+	}, byName["regions"].Indexes)
+
+	status := orders.Columns[4]
+	assert.Equal(t, "status", status.Name)
+	require.NotNil(t, status.Default)
+	assert.Equal(t, "'new'", *status.Default)
+	assert.Nil(t, orders.Columns[0].Default)
+}
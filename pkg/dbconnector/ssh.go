@@ -0,0 +1,475 @@
+package dbconnector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshDialTimeout       = 10 * time.Second
+	sshKeepAliveInterval = 30 * time.Second
+)
+
+// SSHTunnelConfig describes the SSH bastion a database is reached through
+type SSHTunnelConfig struct {
+	Host       string
+	Port       int
+	User       string
+	Password   string
+	PrivateKey string // PEM encoded
+	Passphrase string // of an encrypted private key
+	KnownHosts string // known_hosts lines the bastion's host key must match
+}
+
+// Addr returns the host:port of the bastion
+func (c *SSHTunnelConfig) Addr() string {
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// fingerprint identifies the tunnel settings without keeping the secrets around
+func (c *SSHTunnelConfig) fingerprint() string {
+	if c == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+		c.Addr(), c.User, c.Password, c.PrivateKey, c.Passphrase, c.KnownHosts)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateSSHTunnel checks that a tunnel names a bastion, can authenticate and
+// can verify the bastion's host key
+func ValidateSSHTunnel(c *SSHTunnelConfig) error {
+	if c.Host == "" {
+		return errors.New("ssh host is required")
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return errors.New("ssh port must be between 0 and 65535")
+	}
+	if c.User == "" {
+		return errors.New("ssh user is required")
+	}
+	if c.Password == "" && c.PrivateKey == "" {
+		return errors.New("ssh password or private key is required")
+	}
+	if c.KnownHosts == "" {
+		return errors.New("ssh known_hosts is required to verify the bastion")
+	}
+	_, err := c.clientConfig()
+	return err
+}
+
+// clientConfig builds the SSH client configuration of the tunnel
+func (c *SSHTunnelConfig) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if c.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if c.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(c.PrivateKey), []byte(c.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(c.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if c.Password != "" {
+		auth = append(auth, ssh.Password(c.Password))
+	}
+
+	hostKeyCallback, err := knownHostsCallback(c.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+// knownHostsCallback verifies host keys against known_hosts content. The
+// knownhosts package only reads files, so the content goes through a
+// temporary one that is removed once parsed.
+func knownHostsCallback(content string) (ssh.HostKeyCallback, error) {
+	f, err := os.CreateTemp("", "dataweaver-known-hosts-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create known_hosts file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.WriteString(f, content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write known_hosts file: %w", err)
+	}
+
+	callback, err := knownhosts.New(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// Hop names a leg of the path from the server to a tunneled database
+type Hop string
+
+const (
+	HopBastion  Hop = "bastion"  // reaching and authenticating to the SSH server
+	HopTarget   Hop = "target"   // the SSH server reaching the database host
+	HopDatabase Hop = "database" // the database accepting the connection
+)
+
+// HopError reports which hop of a tunneled connection failed
+type HopError struct {
+	Hop  Hop
+	Addr string
+	Err  error
+}
+
+func (e *HopError) Error() string {
+	return fmt.Sprintf("%s hop %s failed: %v", e.Hop, e.Addr, e.Err)
+}
+
+func (e *HopError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	tunnelsMu sync.Mutex
+	tunnels   = make(map[string]*sshTunnel)
+	opening   = make(map[string]*openingTunnel) // guarded by tunnelsMu
+)
+
+// openingTunnel is a tunnel being opened, which connectors asking for the
+// same tunnel meanwhile wait for instead of opening their own
+type openingTunnel struct {
+	done    chan struct{}
+	waiters int // guarded by tunnelsMu
+	tunnel  *sshTunnel
+	err     error
+}
+
+// sshTunnel forwards connections accepted on a local port to a database
+// through an SSH bastion. Connectors to the same database through the same
+// bastion share one tunnel, which is closed when the last of them releases it.
+type sshTunnel struct {
+	key          string
+	refs         int // guarded by tunnelsMu
+	addr         string
+	target       string
+	clientConfig *ssh.ClientConfig
+	listener     net.Listener
+	done         chan struct{}
+
+	mu     sync.Mutex
+	client *ssh.Client // nil after the bastion connection broke, redialed on next use
+}
+
+// acquireTunnel returns the tunnel to target through the bastion, opening it
+// if no connector holds one. Failures are reported as a *HopError. The tunnel
+// is opened outside tunnelsMu, so a slow bastion or database only holds up
+// the connectors waiting for the same tunnel.
+func acquireTunnel(config *SSHTunnelConfig, target string) (*sshTunnel, error) {
+	key := config.fingerprint() + "|" + target
+
+	tunnelsMu.Lock()
+	if t, ok := tunnels[key]; ok {
+		t.refs++
+		tunnelsMu.Unlock()
+		return t, nil
+	}
+	if o, ok := opening[key]; ok {
+		// The opener takes a reference for every waiter
+		o.waiters++
+		tunnelsMu.Unlock()
+		<-o.done
+		return o.tunnel, o.err
+	}
+	o := &openingTunnel{done: make(chan struct{})}
+	opening[key] = o
+	tunnelsMu.Unlock()
+
+	t, err := openTunnel(config, target)
+
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+	delete(opening, key)
+	if err == nil {
+		t.key = key
+		t.refs = 1 + o.waiters
+		tunnels[key] = t
+	}
+	o.tunnel, o.err = t, err
+	close(o.done)
+	return t, err
+}
+
+// openTunnel connects to the bastion, checks that it can reach target and
+// starts forwarding from a local port
+func openTunnel(config *SSHTunnelConfig, target string) (*sshTunnel, error) {
+	addr := config.Addr()
+	clientConfig, err := config.clientConfig()
+	if err != nil {
+		return nil, &HopError{Hop: HopBastion, Addr: addr, Err: err}
+	}
+
+	client, err := dialSSH(addr, clientConfig)
+	if err != nil {
+		return nil, &HopError{Hop: HopBastion, Addr: addr, Err: err}
+	}
+
+	// Surface an unreachable database as its own hop instead of as a driver timeout
+	probe, err := dialTarget(client, target, clientConfig.Timeout)
+	if err != nil {
+		client.Close()
+		return nil, &HopError{Hop: HopTarget, Addr: target, Err: err}
+	}
+	probe.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to listen for ssh tunnel: %w", err)
+	}
+
+	t := &sshTunnel{
+		addr:         addr,
+		target:       target,
+		clientConfig: clientConfig,
+		listener:     listener,
+		done:         make(chan struct{}),
+		client:       client,
+	}
+	go t.serve()
+	go t.keepAlive()
+	return t, nil
+}
+
+// dialTarget opens a connection to target through the bastion, giving up
+// after timeout if the bastion neither connects nor refuses
+func dialTarget(client *ssh.Client, target string, timeout time.Duration) (net.Conn, error) {
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+	result := make(chan dialed, 1)
+	go func() {
+		conn, err := client.Dial("tcp", target)
+		result <- dialed{conn, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.conn, r.err
+	case <-timer.C:
+		// Close the connection should the bastion still open it
+		go func() {
+			if r := <-result; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("dial %s through bastion: %w", target, os.ErrDeadlineExceeded)
+	}
+}
+
+// dialSSH connects to a bastion. Unlike ssh.Dial, the timeout also covers the
+// handshake, so a server that accepts but never answers does not hang callers.
+func dialSSH(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(config.Timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// LocalAddr returns the host and port database drivers connect to
+func (t *sshTunnel) LocalAddr() (string, int) {
+	addr := t.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// release drops a connector's hold on the tunnel, closing it with the last one
+func (t *sshTunnel) release() {
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+
+	t.refs--
+	if t.refs > 0 {
+		return
+	}
+	delete(tunnels, t.key)
+	t.close()
+}
+
+func (t *sshTunnel) close() {
+	close(t.done)
+	t.listener.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+// serve forwards every accepted connection until the tunnel is closed
+func (t *sshTunnel) serve() {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+func (t *sshTunnel) forward(local net.Conn) {
+	remote, err := t.dial()
+	if err != nil {
+		local.Close()
+		return
+	}
+
+	copied := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		copied <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		copied <- struct{}{}
+	}()
+
+	// Either side hanging up ends the forwarded connection
+	<-copied
+	local.Close()
+	remote.Close()
+}
+
+// dial opens a connection to the database through the bastion, reconnecting
+// to the bastion once if its connection broke
+func (t *sshTunnel) dial() (net.Conn, error) {
+	client, err := t.sshClient()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.Dial("tcp", t.target)
+	if err == nil {
+		return conn, nil
+	}
+	var openErr *ssh.OpenChannelError
+	if errors.As(err, &openErr) {
+		// The bastion answered, it just cannot reach the database
+		return nil, err
+	}
+
+	t.dropClient(client)
+	if client, err = t.sshClient(); err != nil {
+		return nil, err
+	}
+	return client.Dial("tcp", t.target)
+}
+
+// sshClient returns the bastion connection, dialing it if it broke
+func (t *sshTunnel) sshClient() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil, errors.New("ssh tunnel is closed")
+	default:
+	}
+
+	if t.client == nil {
+		client, err := dialSSH(t.addr, t.clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		t.client = client
+	}
+	return t.client, nil
+}
+
+// dropClient closes a broken bastion connection unless it was already replaced
+func (t *sshTunnel) dropClient(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == client {
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+// keepAlive sends keepalive requests so idle tunnels survive NAT and firewall
+// timeouts, and drops the bastion connection once it stops answering
+func (t *sshTunnel) keepAlive() {
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+
+		t.mu.Lock()
+		client := t.client
+		t.mu.Unlock()
+		if client == nil {
+			continue
+		}
+
+		answered := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			answered <- err
+		}()
+
+		select {
+		case err := <-answered:
+			if err != nil {
+				t.dropClient(client)
+			}
+		case <-time.After(sshDialTimeout):
+			t.dropClient(client)
+		case <-t.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package dbconnector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startSSHServer runs a bastion that accepts password "secret" for user
+// "tunnel" and forwards direct-tcpip channels
+func startSSHServer(t *testing.T) (addr string, hostKey ssh.PublicKey) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, config)
+		}
+	}()
+
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func serveSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		// RFC 4254 7.2: host to connect, port to connect, originator address, originator port
+		payload := newChannel.ExtraData()
+		hostLen := binary.BigEndian.Uint32(payload)
+		host := string(payload[4 : 4+hostLen])
+		port := binary.BigEndian.Uint32(payload[4+hostLen:])
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			io.Copy(channel, target)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(target, channel)
+			target.Close()
+		}()
+	}
+}
+
+// startEchoServer runs a TCP server that writes back what it reads
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func tunnelConfig(t *testing.T, addr string, hostKey ssh.PublicKey) *SSHTunnelConfig {
+	host, portStr, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	return &SSHTunnelConfig{
+		Host:       host,
+		Port:       port,
+		User:       "tunnel",
+		Password:   "secret",
+		KnownHosts: knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey) + "\n",
+	}
+}
+
+func TestSSHTunnel_ForwardsAndIsShared(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+	target := startEchoServer(t)
+	config := tunnelConfig(t, sshAddr, hostKey)
+
+	first, err := acquireTunnel(config, target)
+	require.NoError(t, err)
+	second, err := acquireTunnel(config, target)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	host, port := first.LocalAddr()
+	conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+	conn.Close()
+
+	first.release()
+	_, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	assert.NoError(t, err, "tunnel closed while still held")
+
+	second.release()
+	tunnelsMu.Lock()
+	assert.Empty(t, tunnels)
+	tunnelsMu.Unlock()
+}
+
+func TestSSHTunnel_ReportsFailedHop(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+	target := startEchoServer(t)
+
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherPriv)
+	require.NoError(t, err)
+
+	// A port nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		config func() *SSHTunnelConfig
+		target string
+		hop    Hop
+	}{
+		{
+			name: "wrong password",
+			config: func() *SSHTunnelConfig {
+				c := tunnelConfig(t, sshAddr, hostKey)
+				c.Password = "wrong"
+				return c
+			},
+			target: target,
+			hop:    HopBastion,
+		},
+		{
+			name: "unknown host key",
+			config: func() *SSHTunnelConfig {
+				return tunnelConfig(t, sshAddr, otherSigner.PublicKey())
+			},
+			target: target,
+			hop:    HopBastion,
+		},
+		{
+			name: "bastion down",
+			config: func() *SSHTunnelConfig {
+				return tunnelConfig(t, unreachable, hostKey)
+			},
+			target: target,
+			hop:    HopBastion,
+		},
+		{
+			name: "database unreachable from bastion",
+			config: func() *SSHTunnelConfig {
+				return tunnelConfig(t, sshAddr, hostKey)
+			},
+			target: unreachable,
+			hop:    HopTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := acquireTunnel(tt.config(), tt.target)
+			var hopErr *HopError
+			require.ErrorAs(t, err, &hopErr)
+			assert.Equal(t, tt.hop, hopErr.Hop)
+		})
+	}
+}
+
+// startSilentServer runs a TCP server that accepts connections and never answers
+func startSilentServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSSHTunnel_SlowBastionDoesNotBlockOthers(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+	target := startEchoServer(t)
+	// Registered first, so it runs after the silent bastion hangs up
+	stuck := make(chan error, 1)
+	t.Cleanup(func() { <-stuck })
+	silent := startSilentServer(t)
+
+	// The handshake with the silent bastion only ends with the test
+	go func() {
+		_, err := acquireTunnel(tunnelConfig(t, silent, hostKey), target)
+		stuck <- err
+	}()
+	require.Eventually(t, func() bool {
+		tunnelsMu.Lock()
+		defer tunnelsMu.Unlock()
+		return len(opening) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	opened := make(chan error, 1)
+	go func() {
+		tunnel, err := acquireTunnel(tunnelConfig(t, sshAddr, hostKey), target)
+		if err == nil {
+			tunnel.release()
+		}
+		opened <- err
+	}()
+	select {
+	case err := <-opened:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("opening a tunnel waited for another bastion")
+	}
+}
+
+func TestSSHTunnel_ConcurrentAcquiresShareOpen(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+	target := startEchoServer(t)
+	config := tunnelConfig(t, sshAddr, hostKey)
+
+	const n = 8
+	acquired := make([]*sshTunnel, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tunnel, err := acquireTunnel(config, target)
+			assert.NoError(t, err)
+			acquired[i] = tunnel
+		}(i)
+	}
+	wg.Wait()
+
+	for _, tunnel := range acquired {
+		assert.Same(t, acquired[0], tunnel)
+	}
+	for _, tunnel := range acquired {
+		tunnel.release()
+	}
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+	assert.Empty(t, tunnels, "every acquire held a reference")
+	assert.Empty(t, opening)
+}
+
+func TestValidateSSHTunnel(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+
+	valid := tunnelConfig(t, sshAddr, hostKey)
+	assert.NoError(t, ValidateSSHTunnel(valid))
+
+	noAuth := *valid
+	noAuth.Password = ""
+	assert.Error(t, ValidateSSHTunnel(&noAuth))
+
+	noKnownHosts := *valid
+	noKnownHosts.KnownHosts = ""
+	assert.Error(t, ValidateSSHTunnel(&noKnownHosts))
+
+	badKey := *valid
+	badKey.PrivateKey = "not a key"
+	assert.Error(t, ValidateSSHTunnel(&badKey))
+}
+
+func TestConnector_SSHTunnelReportsDatabaseHop(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t)
+
+	// Accepts connections like a database host would, then hangs up
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	connector := NewConnector(&ConnectionConfig{
+		Type:      PostgreSQL,
+		Host:      "127.0.0.1",
+		Port:      port,
+		Username:  "user",
+		Password:  "pass",
+		Database:  "db",
+		SSHTunnel: tunnelConfig(t, sshAddr, hostKey),
+	})
+	err = connector.Connect()
+
+	var hopErr *HopError
+	require.ErrorAs(t, err, &hopErr)
+	assert.Equal(t, HopDatabase, hopErr.Hop)
+
+	tunnelsMu.Lock()
+	assert.Empty(t, tunnels, "failed connect must release its tunnel")
+	tunnelsMu.Unlock()
+}
@@ -0,0 +1,190 @@
+package dbconnector
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// SSL modes of ConnectionConfig.SSLMode, named as PostgreSQL names them
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"     // encrypt without verifying the server certificate
+	SSLModeVerifyCA   = "verify-ca"   // verify the server certificate chains to a trusted CA
+	SSLModeVerifyFull = "verify-full" // also verify it is valid for the server name
+)
+
+// TLSConfig holds the certificates of TLS connections to a database. Whether
+// and how the server is verified is set by ConnectionConfig.SSLMode.
+type TLSConfig struct {
+	CACert     string // PEM encoded CA bundle; the system roots are trusted when empty
+	ClientCert string // PEM encoded client certificate for mutual TLS
+	ClientKey  string // PEM encoded key of ClientCert
+	ServerName string // name the server certificate is verified against when it differs from Host
+}
+
+// fingerprint identifies the TLS settings without keeping the key around
+func (t *TLSConfig) fingerprint() string {
+	if t == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(t.CACert + "|" + t.ClientCert + "|" + t.ClientKey + "|" + t.ServerName))
+	return hex.EncodeToString(sum[:])
+}
+
+// TLSValidator is implemented by dialects whose driver supports only part of TLSConfig
+type TLSValidator interface {
+	ValidateTLS(t *TLSConfig) error
+}
+
+// ValidateTLS checks that a datasource type supports the SSL mode and TLS
+// settings and that the certificates parse
+func ValidateTLS(dbType DBType, sslMode string, t *TLSConfig) error {
+	switch sslMode {
+	case "", SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+	default:
+		return fmt.Errorf("ssl mode must be one of: %s, %s, %s, %s",
+			SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull)
+	}
+
+	if IsFileBased(dbType) {
+		if (sslMode != "" && sslMode != SSLModeDisable) || t != nil {
+			return fmt.Errorf("%s datasources do not connect over the network", dbType)
+		}
+		return nil
+	}
+	if t == nil {
+		return nil
+	}
+
+	if d, ok := Lookup(dbType); ok {
+		if v, ok := d.(TLSValidator); ok {
+			if err := v.ValidateTLS(t); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := t.certificates()
+	return err
+}
+
+// tlsCertificates are the parsed certificates of a TLSConfig
+type tlsCertificates struct {
+	roots  *x509.CertPool // nil trusts the system roots
+	client []tls.Certificate
+}
+
+func (t *TLSConfig) certificates() (*tlsCertificates, error) {
+	certs := &tlsCertificates{}
+	if t.CACert != "" {
+		certs.roots = x509.NewCertPool()
+		if !certs.roots.AppendCertsFromPEM([]byte(t.CACert)) {
+			return nil, errors.New("ca certificate contains no PEM encoded certificate")
+		}
+	}
+
+	if t.ClientCert != "" || t.ClientKey != "" {
+		if t.ClientCert == "" || t.ClientKey == "" {
+			return nil, errors.New("client certificate and client key must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(t.ClientCert), []byte(t.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		certs.client = []tls.Certificate{cert}
+	}
+	return certs, nil
+}
+
+// tlsServerName returns the name the server certificate is verified against
+func tlsServerName(config *ConnectionConfig) string {
+	if config.TLS != nil && config.TLS.ServerName != "" {
+		return config.TLS.ServerName
+	}
+	return config.Host
+}
+
+// buildTLSConfig returns the TLS configuration of connections to the
+// database, nil when SSLMode disables TLS
+func buildTLSConfig(config *ConnectionConfig) (*tls.Config, error) {
+	if config.SSLMode == "" || config.SSLMode == SSLModeDisable {
+		return nil, nil
+	}
+
+	certs := &tlsCertificates{}
+	if config.TLS != nil {
+		var err error
+		if certs, err = config.TLS.certificates(); err != nil {
+			return nil, err
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:   tlsServerName(config),
+		RootCAs:      certs.roots,
+		Certificates: certs.client,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch config.SSLMode {
+	case SSLModeRequire:
+		tlsConfig.InsecureSkipVerify = true
+	case SSLModeVerifyCA:
+		// crypto/tls cannot check the chain without the name, so it is checked here
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, certs.roots, "")
+		}
+	case SSLModeVerifyFull:
+	default:
+		return nil, fmt.Errorf("unsupported ssl mode: %s", config.SSLMode)
+	}
+	return tlsConfig, nil
+}
+
+// verifyChain checks that the server certificate chains to roots, or to the
+// system roots when roots is nil, and that it is valid for serverName unless
+// serverName is empty
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool, serverName string) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server sent no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		DNSName:       serverName,
+	}
+	var leaf *x509.Certificate
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("invalid server certificate: %w", err)
+		}
+		if i == 0 {
+			leaf = cert
+		} else {
+			opts.Intermediates.AddCert(cert)
+		}
+	}
+
+	_, err := leaf.Verify(opts)
+	return err
+}
+
+// redirectDialer dials addr whatever address the driver asks for. Drivers
+// that verify the server certificate against the host they connect to are
+// given the server name as host, and connect to the real address through it.
+type redirectDialer struct {
+	addr string
+}
+
+func (d redirectDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, d.addr)
+}
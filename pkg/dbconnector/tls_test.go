@@ -0,0 +1,253 @@
+package dbconnector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a certificate with its key, PEM encoded
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCert issues a certificate for dnsName signed by parent, or a
+// self-signed CA when parent is nil
+func newTestCert(t *testing.T, dnsName string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.DNSNames = []string{dnsName}
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair([]byte(c.certPEM), []byte(c.keyPEM))
+	require.NoError(t, err)
+	return cert
+}
+
+// handshake runs a TLS handshake between a client using config and a server
+// presenting server, and returns the client's error
+func handshake(t *testing.T, config *tls.Config, server tls.Certificate) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{server}}).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	return tls.Client(conn, config).Handshake()
+}
+
+func TestBuildTLSConfig_VerifyModes(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	otherCA := newTestCert(t, "Other CA", nil)
+	server := newTestCert(t, "db.internal", ca).tlsCertificate(t)
+
+	tests := []struct {
+		name    string
+		mode    string
+		host    string
+		tls     *TLSConfig
+		wantErr bool
+	}{
+		{"require accepts any certificate", SSLModeRequire, "10.0.0.5", &TLSConfig{CACert: otherCA.certPEM}, false},
+		{"verify-ca ignores the name", SSLModeVerifyCA, "10.0.0.5", &TLSConfig{CACert: ca.certPEM}, false},
+		{"verify-ca rejects another CA", SSLModeVerifyCA, "db.internal", &TLSConfig{CACert: otherCA.certPEM}, true},
+		{"verify-full checks the name", SSLModeVerifyFull, "10.0.0.5", &TLSConfig{CACert: ca.certPEM}, true},
+		{"verify-full uses the server name", SSLModeVerifyFull, "10.0.0.5", &TLSConfig{CACert: ca.certPEM, ServerName: "db.internal"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := buildTLSConfig(&ConnectionConfig{Host: tt.host, SSLMode: tt.mode, TLS: tt.tls})
+			require.NoError(t, err)
+
+			err = handshake(t, config, server)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildTLSConfig_Disabled(t *testing.T) {
+	config, err := buildTLSConfig(&ConnectionConfig{SSLMode: SSLModeDisable, TLS: &TLSConfig{ServerName: "db"}})
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestValidateTLS(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	client := newTestCert(t, "client", ca)
+	other := newTestCert(t, "other", ca)
+
+	assert.NoError(t, ValidateTLS(PostgreSQL, SSLModeVerifyFull, &TLSConfig{
+		CACert: ca.certPEM, ClientCert: client.certPEM, ClientKey: client.keyPEM,
+	}))
+	assert.NoError(t, ValidateTLS(MySQL, "", nil))
+
+	assert.Error(t, ValidateTLS(PostgreSQL, "prefer", nil))
+	assert.Error(t, ValidateTLS(PostgreSQL, SSLModeRequire, &TLSConfig{CACert: "not a certificate"}))
+	assert.Error(t, ValidateTLS(PostgreSQL, SSLModeRequire, &TLSConfig{ClientCert: client.certPEM}))
+	assert.Error(t, ValidateTLS(PostgreSQL, SSLModeRequire, &TLSConfig{ClientCert: client.certPEM, ClientKey: other.keyPEM}))
+	assert.Error(t, ValidateTLS(Oracle, SSLModeVerifyFull, &TLSConfig{CACert: ca.certPEM}))
+	assert.NoError(t, ValidateTLS(Oracle, SSLModeVerifyFull, &TLSConfig{ServerName: "db.internal"}))
+	assert.Error(t, ValidateTLS(SQLite, SSLModeRequire, nil))
+}
+
+func TestPostgresTLSDialer(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	server := newTestCert(t, "db.internal", ca).tlsCertificate(t)
+	client := newTestCert(t, "client", ca)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	clientSubject := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err != nil || binary.BigEndian.Uint32(request[4:]) != postgresSSLRequest {
+			return
+		}
+		conn.Write([]byte("S"))
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		tlsConn := tls.Server(conn, &tls.Config{
+			Certificates: []tls.Certificate{server},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    roots,
+		})
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		clientSubject <- tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}()
+
+	config, err := buildTLSConfig(&ConnectionConfig{
+		Host:    "127.0.0.1",
+		SSLMode: SSLModeVerifyFull,
+		TLS: &TLSConfig{
+			CACert:     ca.certPEM,
+			ClientCert: client.certPEM,
+			ClientKey:  client.keyPEM,
+			ServerName: "db.internal",
+		},
+	})
+	require.NoError(t, err)
+
+	conn, err := postgresTLSDialer{config: config}.DialTimeout("tcp", listener.Addr().String(), 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "client", <-clientSubject)
+}
+
+func TestMySQLDSN_RegistersTLSConfig(t *testing.T) {
+	ca := newTestCert(t, "Test CA", nil)
+	config := &ConnectionConfig{
+		Type:     MySQL,
+		Host:     "localhost",
+		Port:     3306,
+		Username: "user",
+		Password: "password",
+		Database: "testdb",
+		SSLMode:  SSLModeVerifyCA,
+		TLS:      &TLSConfig{CACert: ca.certPEM},
+	}
+
+	dsn, err := NewConnector(config).buildDSN()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dsn, "user:password@tcp(localhost:3306)/testdb?parseTime=true&tls=dataweaver-"), dsn)
+
+	again, err := NewConnector(config).buildDSN()
+	require.NoError(t, err)
+	assert.Equal(t, dsn, again, "same settings must reuse the registration")
+}
+
+func TestMSSQLDSN_SSLModes(t *testing.T) {
+	config := &ConnectionConfig{
+		Type:     MSSQL,
+		Host:     "10.0.0.5",
+		Port:     1433,
+		Username: "sa",
+		Password: "password",
+		Database: "master",
+		SSLMode:  SSLModeRequire,
+	}
+	dsn, err := NewConnector(config).buildDSN()
+	require.NoError(t, err)
+	assert.Contains(t, dsn, "encrypt=true&TrustServerCertificate=true")
+
+	config.SSLMode = SSLModeVerifyFull
+	config.TLS = &TLSConfig{ServerName: "db.internal"}
+	dsn, err = NewConnector(config).buildDSN()
+	require.NoError(t, err)
+	assert.Contains(t, dsn, "encrypt=true&hostNameInCertificate=db.internal")
+}
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "dataweaver"
+
+var (
+	// HTTP API
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// MCP runtime
+	toolCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "tool_calls_total",
+		Help:      "Total number of MCP tool calls by server, tool and status.",
+	}, []string{"server_id", "tool", "status"})
+
+	toolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "tool_call_duration_seconds",
+		Help:      "MCP tool call latency by server and tool.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"server_id", "tool"})
+
+	rateLimitRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "rate_limit_rejections_total",
+		Help:      "Total number of MCP requests rejected by the per-server rate limit.",
+	}, []string{"server_id"})
+
+	queueWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "queue_wait_seconds",
+		Help:      "Time MCP tool calls waited for an execution slot by limit scope.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"scope"})
+
+	busyRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "busy_rejections_total",
+		Help:      "Total number of MCP tool calls rejected by a concurrency limit by limit scope.",
+	}, []string{"scope"})
+
+	logQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "log_queue_depth",
+		Help:      "Number of MCP call logs waiting to be written.",
+	})
+
+	logsDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "logs_dropped_total",
+		Help:      "Total number of MCP call logs that could not be persisted.",
+	}, []string{"reason"})
+
+	logsWrittenTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "logs_written_total",
+		Help:      "Total number of MCP call logs persisted to the database.",
+	})
+
+	logsSpilledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "logs_spilled_total",
+		Help:      "Total number of MCP call logs written to the local spill file.",
+	})
+
+	logBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mcp",
+		Name:      "log_batch_size",
+		Help:      "Number of MCP call logs inserted per batch.",
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500},
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpRequestsTotal,
+		httpRequestDuration,
+		toolCallsTotal,
+		toolCallDuration,
+		rateLimitRejectionsTotal,
+		queueWaitDuration,
+		busyRejectionsTotal,
+		logQueueDepth,
+		logsDroppedTotal,
+		logsWrittenTotal,
+		logsSpilledTotal,
+		logBatchSize,
+	)
+}
+
+// Handler returns the HTTP handler serving metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records a completed HTTP request
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+	httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveToolCall records a completed MCP tool call
+func ObserveToolCall(serverID, tool, status string, duration time.Duration) {
+	toolCallsTotal.WithLabelValues(serverID, tool, status).Inc()
+	toolCallDuration.WithLabelValues(serverID, tool).Observe(duration.Seconds())
+}
+
+// IncRateLimitRejections records a request rejected by the rate limiter
+func IncRateLimitRejections(serverID string) {
+	rateLimitRejectionsTotal.WithLabelValues(serverID).Inc()
+}
+
+// ObserveQueueWait records the time a tool call waited for an execution slot.
+// scope is "server" or "datasource".
+func ObserveQueueWait(scope string, wait time.Duration) {
+	queueWaitDuration.WithLabelValues(scope).Observe(wait.Seconds())
+}
+
+// IncBusyRejections records a tool call rejected by a concurrency limit
+func IncBusyRejections(scope string) {
+	busyRejectionsTotal.WithLabelValues(scope).Inc()
+}
+
+// SetLogQueueDepth records the current number of queued MCP call logs
+func SetLogQueueDepth(depth int) {
+	logQueueDepth.Set(float64(depth))
+}
+
+// AddLogsDropped records MCP call logs that were lost
+func AddLogsDropped(reason string, n int) {
+	logsDroppedTotal.WithLabelValues(reason).Add(float64(n))
+}
+
+// ObserveLogBatch records a batch of MCP call logs persisted to the database
+func ObserveLogBatch(n int) {
+	logsWrittenTotal.Add(float64(n))
+	logBatchSize.Observe(float64(n))
+}
+
+// AddLogsSpilled records MCP call logs written to the local spill file
+func AddLogsSpilled(n int) {
+	logsSpilledTotal.Add(float64(n))
+}
+
+// PoolStatsFunc returns connection pool statistics keyed by data source ID
+type PoolStatsFunc func() map[string]sql.DBStats
+
+// poolCollector exports data source connection pool statistics
+type poolCollector struct {
+	statsFunc    PoolStatsFunc
+	openDesc     *prometheus.Desc
+	inUseDesc    *prometheus.Desc
+	idleDesc     *prometheus.Desc
+	maxOpenDesc  *prometheus.Desc
+	waitDesc     *prometheus.Desc
+	waitTimeDesc *prometheus.Desc
+}
+
+// currentPoolCollector is the collector registered by RegisterPoolStats
+var currentPoolCollector prometheus.Collector
+
+// RegisterPoolStats exports the connection pools returned by statsFunc as gauges.
+// Registering again replaces the previous source.
+func RegisterPoolStats(statsFunc PoolStatsFunc) error {
+	labels := []string{"datasource_id"}
+	collector := &poolCollector{
+		statsFunc:    statsFunc,
+		openDesc:     prometheus.NewDesc(namespace+"_datasource_pool_open_connections", "Number of established connections, both in use and idle.", labels, nil),
+		inUseDesc:    prometheus.NewDesc(namespace+"_datasource_pool_in_use_connections", "Number of connections currently in use.", labels, nil),
+		idleDesc:     prometheus.NewDesc(namespace+"_datasource_pool_idle_connections", "Number of idle connections.", labels, nil),
+		maxOpenDesc:  prometheus.NewDesc(namespace+"_datasource_pool_max_open_connections", "Maximum number of open connections.", labels, nil),
+		waitDesc:     prometheus.NewDesc(namespace+"_datasource_pool_wait_count_total", "Total number of connections waited for.", labels, nil),
+		waitTimeDesc: prometheus.NewDesc(namespace+"_datasource_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", labels, nil),
+	}
+
+	if currentPoolCollector != nil {
+		prometheus.Unregister(currentPoolCollector)
+	}
+	if err := prometheus.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			return err
+		}
+	}
+	currentPoolCollector = collector
+	return nil
+}
+
+// Describe implements prometheus.Collector
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.openDesc
+	ch <- p.inUseDesc
+	ch <- p.idleDesc
+	ch <- p.maxOpenDesc
+	ch <- p.waitDesc
+	ch <- p.waitTimeDesc
+}
+
+// Collect implements prometheus.Collector
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for id, stats := range p.statsFunc() {
+		ch <- prometheus.MustNewConstMetric(p.openDesc, prometheus.GaugeValue, float64(stats.OpenConnections), id)
+		ch <- prometheus.MustNewConstMetric(p.inUseDesc, prometheus.GaugeValue, float64(stats.InUse), id)
+		ch <- prometheus.MustNewConstMetric(p.idleDesc, prometheus.GaugeValue, float64(stats.Idle), id)
+		ch <- prometheus.MustNewConstMetric(p.maxOpenDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections), id)
+		ch <- prometheus.MustNewConstMetric(p.waitDesc, prometheus.CounterValue, float64(stats.WaitCount), id)
+		ch <- prometheus.MustNewConstMetric(p.waitTimeDesc, prometheus.CounterValue, stats.WaitDuration.Seconds(), id)
+	}
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape returns the metrics Handler serves
+func scrape(t *testing.T) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHandler_ExposesCollectors(t *testing.T) {
+	ObserveHTTPRequest("GET", "/api/v1/queries/:id", "200", 20*time.Millisecond)
+	ObserveToolCall("server-1", "list_orders", "success", 150*time.Millisecond)
+	IncRateLimitRejections("server-1")
+	ObserveQueueWait("datasource", 5*time.Millisecond)
+	IncBusyRejections("server")
+	SetLogQueueDepth(3)
+	AddLogsDropped("queue_full", 2)
+	ObserveLogBatch(10)
+	AddLogsSpilled(4)
+
+	body := scrape(t)
+
+	for _, line := range []string{
+		`dataweaver_http_requests_total{method="GET",route="/api/v1/queries/:id",status="200"} 1`,
+		`dataweaver_http_request_duration_seconds_count{method="GET",route="/api/v1/queries/:id"} 1`,
+		`dataweaver_mcp_tool_calls_total{server_id="server-1",status="success",tool="list_orders"} 1`,
+		`dataweaver_mcp_tool_call_duration_seconds_count{server_id="server-1",tool="list_orders"} 1`,
+		`dataweaver_mcp_rate_limit_rejections_total{server_id="server-1"} 1`,
+		`dataweaver_mcp_queue_wait_seconds_count{scope="datasource"} 1`,
+		`dataweaver_mcp_busy_rejections_total{scope="server"} 1`,
+		`dataweaver_mcp_log_queue_depth 3`,
+		`dataweaver_mcp_logs_dropped_total{reason="queue_full"} 2`,
+		`dataweaver_mcp_logs_written_total 10`,
+		`dataweaver_mcp_log_batch_size_count 1`,
+		`dataweaver_mcp_logs_spilled_total 4`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestRegisterPoolStats_ReplacesSource(t *testing.T) {
+	require.NoError(t, RegisterPoolStats(func() map[string]sql.DBStats {
+		return map[string]sql.DBStats{"ds-old": {OpenConnections: 1}}
+	}))
+	require.NoError(t, RegisterPoolStats(func() map[string]sql.DBStats {
+		return map[string]sql.DBStats{"ds-1": {
+			MaxOpenConnections: 10,
+			OpenConnections:    4,
+			InUse:              3,
+			Idle:               1,
+			WaitCount:          7,
+			WaitDuration:       1500 * time.Millisecond,
+		}}
+	}))
+
+	body := scrape(t)
+
+	for _, line := range []string{
+		`dataweaver_datasource_pool_open_connections{datasource_id="ds-1"} 4`,
+		`dataweaver_datasource_pool_in_use_connections{datasource_id="ds-1"} 3`,
+		`dataweaver_datasource_pool_idle_connections{datasource_id="ds-1"} 1`,
+		`dataweaver_datasource_pool_max_open_connections{datasource_id="ds-1"} 10`,
+		`dataweaver_datasource_pool_wait_count_total{datasource_id="ds-1"} 7`,
+		`dataweaver_datasource_pool_wait_duration_seconds_total{datasource_id="ds-1"} 1.5`,
+	} {
+		assert.Contains(t, body, line)
+	}
+	assert.NotContains(t, body, "ds-old", "the previous source is no longer scraped")
+}
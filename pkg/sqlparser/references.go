@@ -0,0 +1,251 @@
+package sqlparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// References lists the database objects a SQL template refers to
+type References struct {
+	// Tables are the tables read after FROM and JOIN, lowercased and
+	// schema-qualified where the template qualifies them, e.g. "public.orders"
+	Tables []string `json:"tables"`
+	// Identifiers are all other names in the template, lowercased, such as
+	// column names and aliases
+	Identifiers []string `json:"identifiers"`
+	// SelectsAll is set when the template selects * or table.*, so it reads
+	// columns it does not name
+	SelectsAll bool `json:"selects_all"`
+}
+
+// HasTable reports whether the template reads table. schema is matched only
+// when the template qualifies the table with one.
+func (r References) HasTable(schema, table string) bool {
+	schema, table = strings.ToLower(schema), strings.ToLower(table)
+	for _, ref := range r.Tables {
+		name, qualifier := ref, ""
+		if i := strings.LastIndexByte(ref, '.'); i >= 0 {
+			name, qualifier = ref[i+1:], ref[:i]
+			if j := strings.LastIndexByte(qualifier, '.'); j >= 0 {
+				qualifier = qualifier[j+1:]
+			}
+		}
+		if name == table && (qualifier == "" || qualifier == schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasIdentifier reports whether name appears in the template outside of table references
+func (r References) HasIdentifier(name string) bool {
+	name = strings.ToLower(name)
+	for _, ident := range r.Identifiers {
+		if ident == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tableListEnd lists the keywords that end the table list of a FROM clause
+var tableListEnd = map[string]bool{
+	"where": true, "join": true, "inner": true, "left": true, "right": true, "full": true,
+	"cross": true, "natural": true, "outer": true, "on": true, "using": true, "group": true,
+	"order": true, "having": true, "limit": true, "offset": true, "fetch": true, "union": true,
+	"intersect": true, "except": true, "window": true, "for": true, "lateral": true,
+}
+
+// ExtractReferences returns the tables and identifiers a SQL template refers
+// to. It tokenizes rather than parses the template, so it errs on the side of
+// reporting too much: an identifier is reported wherever it appears.
+func ExtractReferences(sql string) References {
+	tokens := tokenize(sql)
+
+	var refs References
+	seenTables := make(map[string]bool)
+	seenIdents := make(map[string]bool)
+	inTable := make([]bool, len(tokens))
+
+	// readName reads a dotted name starting at tokens[i] and returns it with
+	// the index after it, or -1 when tokens[i] does not start a name
+	readName := func(i int) (string, int) {
+		if i >= len(tokens) || tokens[i].kind != tokenIdent {
+			return "", -1
+		}
+		parts := []string{tokens[i].text}
+		inTable[i] = true
+		i++
+		for i+1 < len(tokens) && tokens[i].kind == tokenDot && tokens[i+1].kind == tokenIdent {
+			parts = append(parts, tokens[i+1].text)
+			inTable[i+1] = true
+			i += 2
+		}
+		return strings.Join(parts, "."), i
+	}
+
+	addTable := func(name string) {
+		if !seenTables[name] {
+			seenTables[name] = true
+			refs.Tables = append(refs.Tables, name)
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.kind != tokenIdent || tok.quoted || (tok.text != "from" && tok.text != "join") {
+			continue
+		}
+
+		name, next := readName(i + 1)
+		if next < 0 {
+			continue
+		}
+		addTable(name)
+		if tok.text == "join" {
+			continue
+		}
+
+		// FROM a [AS] x, b [AS] y, ...
+		for next >= 0 && next < len(tokens) {
+			if tokens[next].kind == tokenIdent && !tokens[next].quoted && tokens[next].text == "as" {
+				next++
+			}
+			if next < len(tokens) && tokens[next].kind == tokenIdent &&
+				(tokens[next].quoted || !tableListEnd[tokens[next].text]) {
+				next++ // alias
+			}
+			if next >= len(tokens) || tokens[next].kind != tokenComma {
+				break
+			}
+			name, next = readName(next + 1)
+			if next >= 0 {
+				addTable(name)
+			}
+		}
+	}
+
+	for i, tok := range tokens {
+		if tok.kind == tokenStar && i > 0 && startsSelectItem(tokens[i-1]) {
+			refs.SelectsAll = true
+		}
+		if tok.kind == tokenIdent && !inTable[i] && !seenIdents[tok.text] {
+			seenIdents[tok.text] = true
+			refs.Identifiers = append(refs.Identifiers, tok.text)
+		}
+	}
+
+	return refs
+}
+
+// startsSelectItem reports whether a * after prev is a select list star,
+// rather than a multiplication or the argument of count(*)
+func startsSelectItem(prev token) bool {
+	switch prev.kind {
+	case tokenComma, tokenDot:
+		return true
+	case tokenIdent:
+		return !prev.quoted && (prev.text == "select" || prev.text == "distinct" || prev.text == "all")
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenDot
+	tokenComma
+	tokenStar
+	tokenOther
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // lowercased identifier
+	quoted bool   // the identifier was quoted, so it cannot be a keyword
+}
+
+// tokenize splits sql into identifiers and punctuation, skipping comments,
+// string literals and :name parameters
+func tokenize(sql string) []token {
+	var tokens []token
+	runes := []rune(sql)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+
+		case r == '\'':
+			// '' inside a literal is an escaped quote, which this reads as two literals
+			i++
+			for i < len(runes) && runes[i] != '\'' {
+				i++
+			}
+			i++
+
+		case r == '"' || r == '`' || r == '[':
+			closing := map[rune]rune{'"': '"', '`': '`', '[': ']'}[r]
+			start := i + 1
+			i = start
+			for i < len(runes) && runes[i] != closing {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(string(runes[start:min(i, len(runes))])), quoted: true})
+			i++
+
+		case r == ':' && i+1 < len(runes) && isWordRune(runes[i+1]):
+			// :name parameter; the type of a PostgreSQL ::type cast is skipped the same way
+			i++
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			if !unicode.IsDigit(runes[start]) {
+				tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(word)})
+			}
+
+		case r == '.':
+			tokens = append(tokens, token{kind: tokenDot})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma})
+			i++
+
+		case r == '*':
+			tokens = append(tokens, token{kind: tokenStar})
+			i++
+
+		default:
+			tokens = append(tokens, token{kind: tokenOther})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,100 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractReferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		tables      []string
+		identifiers []string
+	}{
+		{
+			name:        "Simple select",
+			sql:         "SELECT id, name FROM users WHERE id = :id",
+			tables:      []string{"users"},
+			identifiers: []string{"select", "id", "name", "from", "where"},
+		},
+		{
+			name:        "Qualified and quoted tables with aliases",
+			sql:         `SELECT o.total FROM "Sales".orders AS o JOIN [dbo].[Customers] c ON c.id = o.customer_id`,
+			tables:      []string{"sales.orders", "dbo.customers"},
+			identifiers: []string{"select", "o", "total", "from", "as", "join", "c", "on", "id", "customer_id"},
+		},
+		{
+			name:        "Comma separated table list",
+			sql:         "SELECT * FROM a x, b AS y, `c` WHERE x.id = y.id",
+			tables:      []string{"a", "b", "c"},
+			identifiers: []string{"select", "from", "x", "as", "y", "where", "id"},
+		},
+		{
+			name:        "Comments, literals and casts are skipped",
+			sql:         "SELECT amount::numeric FROM payments -- FROM ignored\n/* JOIN hidden */ WHERE note = 'FROM secret'",
+			tables:      []string{"payments"},
+			identifiers: []string{"select", "amount", "from", "where", "note"},
+		},
+		{
+			name:        "Subquery",
+			sql:         "SELECT * FROM (SELECT user_id FROM orders) t",
+			tables:      []string{"orders"},
+			identifiers: []string{"select", "from", "user_id", "t"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := ExtractReferences(tt.sql)
+			if !reflect.DeepEqual(refs.Tables, tt.tables) {
+				t.Errorf("Tables = %v, want %v", refs.Tables, tt.tables)
+			}
+			if !reflect.DeepEqual(refs.Identifiers, tt.identifiers) {
+				t.Errorf("Identifiers = %v, want %v", refs.Identifiers, tt.identifiers)
+			}
+		})
+	}
+}
+
+func TestExtractReferences_SelectsAll(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT * FROM orders", true},
+		{"SELECT DISTINCT * FROM orders", true},
+		{"SELECT o.*, c.name FROM orders o JOIN customers c ON c.id = o.customer_id", true},
+		{"SELECT id, * FROM orders", true},
+		{"SELECT count(*) FROM orders", false},
+		{"SELECT price * quantity FROM order_items", false},
+		{"SELECT id FROM orders /* * */", false},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractReferences(tt.sql).SelectsAll; got != tt.expected {
+			t.Errorf("SelectsAll(%q) = %v, want %v", tt.sql, got, tt.expected)
+		}
+	}
+}
+
+func TestReferences_HasTable(t *testing.T) {
+	refs := ExtractReferences("SELECT * FROM public.orders JOIN customers ON true")
+
+	tests := []struct {
+		schema, table string
+		expected      bool
+	}{
+		{"public", "orders", true},
+		{"PUBLIC", "Orders", true},
+		{"sales", "orders", false},
+		{"sales", "customers", true},
+		{"public", "products", false},
+	}
+
+	for _, tt := range tests {
+		if got := refs.HasTable(tt.schema, tt.table); got != tt.expected {
+			t.Errorf("HasTable(%q, %q) = %v, want %v", tt.schema, tt.table, got, tt.expected)
+		}
+	}
+}
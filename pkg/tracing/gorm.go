@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormSpanKey        = "tracing:span"
+	gormCallbackName   = "tracing"
+	metadataDBSystem   = "postgresql"
+	attrDBSystem       = attribute.Key("db.system")
+	attrDBStatement    = attribute.Key("db.statement")
+	attrDBTable        = attribute.Key("db.sql.table")
+	attrDBRowsAffected = attribute.Key("db.rows_affected")
+)
+
+// GormPlugin creates a span for every metadata database operation.
+// Statements are recorded with placeholders only; bound values are never exported.
+type GormPlugin struct{}
+
+// NewGormPlugin creates a new GORM tracing plugin
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		name   string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"gorm.create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"gorm.query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"gorm.update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"gorm.delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"gorm.row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"gorm.raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, hook := range hooks {
+		if err := hook.before(gormCallbackName+":before_"+hook.name, p.before(hook.name)); err != nil {
+			return err
+		}
+		if err := hook.after(gormCallbackName+":after_"+hook.name, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := StartSpan(db.Statement.Context, operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrDBSystem.String(metadataDBSystem)),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attrDBStatement.String(db.Statement.SQL.String()),
+		attrDBTable.String(db.Statement.Table),
+		attrDBRowsAffected.Int64(db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type credential struct {
+	ID     uint
+	ApiKey string
+}
+
+// newTracedDB returns a dry run database traced by GormPlugin, and the
+// exporter its spans end up in
+func newTracedDB(t *testing.T) (*gorm.DB, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewGormPlugin()))
+	return db, exporter
+}
+
+func TestGormPlugin_OmitsBoundValues(t *testing.T) {
+	db, exporter := newTracedDB(t)
+	const secret = "dw_live_0123456789abcdef"
+
+	ctx, parent := StartSpan(context.Background(), "request")
+	var found []credential
+	require.NoError(t, db.WithContext(ctx).Where("api_key = ?", secret).Find(&found).Error)
+	require.NoError(t, db.WithContext(ctx).Create(&credential{ApiKey: secret}).Error)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	names := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		names[span.Name] = span
+	}
+	for _, name := range []string{"gorm.query", "gorm.create"} {
+		span, ok := names[name]
+		require.True(t, ok, name)
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID(), "%s is a child of the request span", name)
+
+		attrs := make(map[string]string)
+		for _, attr := range span.Attributes {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+			assert.NotContains(t, attr.Value.Emit(), secret, "%s exports a bound value in %s", name, attr.Key)
+		}
+		assert.Equal(t, "postgresql", attrs["db.system"])
+		assert.Equal(t, "credentials", attrs["db.sql.table"])
+		assert.Contains(t, attrs["db.statement"], "$1", "the statement keeps its placeholders")
+	}
+}
@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/yourusername/dataweaver/pkg/dbconnector"
 )
 
 var validate *validator.Validate
@@ -15,20 +19,44 @@ func Init() error {
 		if err := v.RegisterValidation("dbtype", validateDBType); err != nil {
 			return err
 		}
+		if err := v.RegisterValidation("required_for_server", validateRequiredForServer); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// validateDBType validates database type
+// validateDBType validates database type against the registered dialects
 func validateDBType(fl validator.FieldLevel) bool {
-	dbType := fl.Field().String()
-	validTypes := []string{"postgresql", "mysql", "mssql", "sqlite"}
-	for _, t := range validTypes {
-		if t == dbType {
+	_, ok := dbconnector.Lookup(dbconnector.DBType(fl.Field().String()))
+	return ok
+}
+
+// validateRequiredForServer requires a connection field, such as the host, unless
+// the Type field of the struct names a file-based database or a ConnectionURL
+// field is set, which the service fills the connection fields from
+func validateRequiredForServer(fl validator.FieldLevel) bool {
+	parent := reflect.Indirect(fl.Parent())
+	if parent.Kind() == reflect.Struct {
+		if url := parent.FieldByName("ConnectionURL"); url.IsValid() && url.Kind() == reflect.String && url.String() != "" {
+			return true
+		}
+		if dbType := parent.FieldByName("Type"); dbType.IsValid() && dbType.Kind() == reflect.String &&
+			dbconnector.IsFileBased(dbconnector.DBType(dbType.String())) {
 			return true
 		}
 	}
-	return false
+	return !fl.Field().IsZero()
+}
+
+// dbTypeNames lists the registered database types for error messages
+func dbTypeNames() string {
+	types := dbconnector.Types()
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
 }
 
 // Validate validates a struct
@@ -53,7 +81,9 @@ func GetErrorMessage(err error) string {
 			case "max":
 				return e.Field() + " must be at most " + e.Param() + " characters"
 			case "dbtype":
-				return e.Field() + " must be one of: postgresql, mysql, mssql, sqlite"
+				return e.Field() + " must be one of: " + dbTypeNames()
+			case "required_for_server", "required_without":
+				return e.Field() + " is required"
 			default:
 				return e.Field() + " is invalid"
 			}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// HeaderEvent carries the event name of the delivery
+	HeaderEvent = "X-DataWeaver-Event"
+	// HeaderTimestamp carries the Unix time the payload was signed at
+	HeaderTimestamp = "X-DataWeaver-Timestamp"
+	// HeaderSignature carries "sha256=" followed by the hex HMAC of "<timestamp>.<body>"
+	HeaderSignature = "X-DataWeaver-Signature"
+)
+
+// ErrForbiddenTarget is returned for webhook URLs that point at the server's
+// own network: loopback, link-local, private and unspecified addresses
+var ErrForbiddenTarget = errors.New("webhook target is not allowed")
+
+// Sender delivers signed JSON payloads to webhook endpoints with retries
+type Sender struct {
+	client       *http.Client
+	maxAttempts  int
+	backoff      time.Duration
+	allowPrivate bool // deliver to forbidden targets too; only set by tests
+}
+
+// Result describes the outcome of a delivery
+type Result struct {
+	Attempts   int
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// NewSender creates a new Sender. Failed attempts are retried up to maxAttempts
+// times, waiting backoff before the first retry and doubling it after each one.
+func NewSender(timeout time.Duration, maxAttempts int, backoff time.Duration) *Sender {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	// Host names are checked again once resolved, as they may point anywhere,
+	// including after a redirect. Deliveries do not go through a proxy, which
+	// would hide the address connected to.
+	s := &Sender{maxAttempts: maxAttempts, backoff: backoff}
+	dialer := &net.Dialer{Timeout: timeout, Control: s.guardTarget}
+	s.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return s
+}
+
+// ValidateURL checks that raw is an http or https URL whose host is not a
+// forbidden address. Host names are only rejected when they name the local
+// host; where they resolve to is checked when delivering.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook url must be http or https, not %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("webhook url has no host")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && forbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	return nil
+}
+
+// guardTarget refuses connections to forbidden addresses. It runs for every
+// address a host name resolves to, right before connecting.
+func (s *Sender) guardTarget(network, address string, _ syscall.RawConn) error {
+	if s.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	return nil
+}
+
+// forbiddenIP reports whether ip belongs to the server's own network
+func forbiddenIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// Sign computes the signature of a payload signed at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches the payload signed at timestamp
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Send posts body to url. The payload is signed when secret is not empty.
+// Network errors and 5xx or 429 responses are retried; other responses and
+// forbidden targets are final.
+func (s *Sender) Send(ctx context.Context, url, secret, event string, body []byte) Result {
+	start := time.Now()
+	result := Result{}
+	if !s.allowPrivate {
+		if err := ValidateURL(url); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	backoff := s.backoff
+
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		result.Attempts = attempt
+
+		statusCode, err := s.post(ctx, url, secret, event, body)
+		result.StatusCode = statusCode
+		result.Err = err
+		if err == nil || !retryable(statusCode, err) || attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			result.Duration = time.Since(start)
+			return result
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (s *Sender) post(ctx context.Context, url, secret, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DataWeaver-Webhook/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt should be retried. A target
+// refused once resolved stays refused, so it is not tried again.
+func retryable(statusCode int, err error) bool {
+	if errors.Is(err, ErrForbiddenTarget) {
+		return false
+	}
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
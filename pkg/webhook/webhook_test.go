@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSender returns a sender that may deliver to test servers on the
+// loopback interface
+func newTestSender() *Sender {
+	s := NewSender(time.Second, 3, time.Millisecond)
+	s.allowPrivate = true
+	return s
+}
+
+func TestSign_Verify(t *testing.T) {
+	body := []byte(`{"event":"firing"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	assert.Contains(t, signature, "sha256=")
+	assert.True(t, Verify("secret", 1700000000, body, signature))
+	assert.False(t, Verify("other", 1700000000, body, signature))
+	assert.False(t, Verify("secret", 1700000001, body, signature))
+}
+
+func TestSender_Send_SignsPayload(t *testing.T) {
+	body := []byte(`{"event":"firing"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+
+		assert.Equal(t, "firing", r.Header.Get(HeaderEvent))
+		assert.True(t, Verify("secret", timestamp, received, r.Header.Get(HeaderSignature)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result := newTestSender().Send(context.Background(), server.URL, "secret", "firing", body)
+
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+}
+
+func TestSender_Send_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result := newTestSender().Send(context.Background(), server.URL, "", "firing", []byte(`{}`))
+
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 3, result.Attempts)
+}
+
+func TestSender_Send_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	result := newTestSender().Send(context.Background(), server.URL, "", "firing", []byte(`{}`))
+
+	assert.Error(t, result.Err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestValidateURL(t *testing.T) {
+	for _, allowed := range []string{
+		"https://hooks.example.com/alerts",
+		"http://203.0.113.10:8080/hook",
+	} {
+		assert.NoError(t, ValidateURL(allowed), allowed)
+	}
+
+	for _, forbidden := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://172.16.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		assert.ErrorIs(t, ValidateURL(forbidden), ErrForbiddenTarget, forbidden)
+	}
+
+	assert.Error(t, ValidateURL("ftp://hooks.example.com/alerts"))
+	assert.Error(t, ValidateURL("https:///alerts"))
+}
+
+func TestSender_Send_RefusesPrivateTargets(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second, 1, time.Millisecond)
+	result := sender.Send(context.Background(), server.URL, "", "firing", []byte(`{}`))
+	assert.ErrorIs(t, result.Err, ErrForbiddenTarget)
+
+	// Addresses a host name resolves to, or a redirect leads to, are refused when connecting
+	_, err := sender.client.Get(server.URL)
+	assert.ErrorIs(t, err, ErrForbiddenTarget)
+
+	assert.Zero(t, atomic.LoadInt32(&calls))
+}
+
+func TestSender_Send_DoesNotRetryForbiddenTargets(t *testing.T) {
+	sender := NewSender(time.Second, 3, time.Millisecond)
+
+	// A host name that passes ValidateURL but resolves to loopback
+	transport := sender.client.Transport.(*http.Transport)
+	dial := transport.DialContext
+	var dials int32
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		_, port, _ := net.SplitHostPort(addr)
+		return dial(ctx, network, net.JoinHostPort("127.0.0.1", port))
+	}
+
+	result := sender.Send(context.Background(), "http://hooks.example.com/alert", "", "firing", []byte(`{}`))
+	assert.ErrorIs(t, result.Err, ErrForbiddenTarget)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+}